@@ -9,6 +9,7 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -24,7 +25,7 @@ import (
 )
 
 // sendRequest builds the URL for the given horizon request and sends the url to a horizon server
-func (c *Client) sendRequest(hr HorizonRequest, resp interface{}) (err error) {
+func (c *Client) sendRequest(ctx context.Context, hr HorizonRequest, resp interface{}) (err error) {
 	endpoint, err := hr.BuildURL()
 	if err != nil {
 		return
@@ -33,15 +34,15 @@ func (c *Client) sendRequest(hr HorizonRequest, resp interface{}) (err error) {
 	c.HorizonURL = c.fixHorizonURL()
 	_, ok := hr.(submitRequest)
 	if ok {
-		return c.sendRequestURL(c.HorizonURL+endpoint, "post", resp)
+		return c.sendRequestURL(ctx, c.HorizonURL+endpoint, "post", resp)
 	}
 
-	return c.sendRequestURL(c.HorizonURL+endpoint, "get", resp)
+	return c.sendRequestURL(ctx, c.HorizonURL+endpoint, "get", resp)
 }
 
 // checkMemoRequired implements a memo required check as defined in
 // https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0029.md
-func (c *Client) checkMemoRequired(transaction *txnbuild.Transaction) error {
+func (c *Client) checkMemoRequired(ctx context.Context, transaction *txnbuild.Transaction) error {
 	destinations := map[string]bool{}
 
 	for i, op := range transaction.Operations() {
@@ -77,7 +78,7 @@ func (c *Client) checkMemoRequired(transaction *txnbuild.Transaction) error {
 			DataKey:   "config.memo_required",
 		}
 
-		data, err := c.AccountData(request)
+		data, err := c.AccountDataContext(ctx, request)
 		if err != nil {
 			horizonError := GetError(err)
 
@@ -101,34 +102,64 @@ func (c *Client) checkMemoRequired(transaction *txnbuild.Transaction) error {
 
 // sendRequestURL sends a url to a horizon server.
 // It can be used for requests that do not implement the HorizonRequest interface.
-func (c *Client) sendRequestURL(requestURL string, method string, a interface{}) (err error) {
-	var req *http.Request
+// Failed requests are retried according to the RetryPolicy of the client, on
+// the healthiest of its horizon servers.
+func (c *Client) sendRequestURL(ctx context.Context, requestURL string, method string, a interface{}) (err error) {
+	method = strings.ToUpper(method)
+	c.setDefaultClient()
+	if c.horizonTimeout == 0 {
+		c.horizonTimeout = HorizonTimeout
+	}
 
-	if method == "post" || method == "POST" {
-		req, err = http.NewRequest("POST", requestURL, nil)
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
-	} else {
-		req, err = http.NewRequest("GET", requestURL, nil)
+	for attempt := 0; ; attempt++ {
+		server, attemptURL := c.route(requestURL)
+		resp, cancel, err := c.doRequest(ctx, attemptURL, method)
+		if ctx.Err() == nil {
+			c.updateServerHealth(server, resp, err)
+		}
+
+		if attempt < c.RetryPolicy.MaxRetries && ctx.Err() == nil {
+			if delay, ok := c.RetryPolicy.retryDelay(attempt, method, resp, err); ok {
+				if resp != nil {
+					resp.Body.Close()
+				}
+				cancel()
+				if err = sleep(ctx, delay); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if err != nil {
+			cancel()
+			return err
+		}
+		err = decodeResponse(resp, &a, c)
+		cancel()
+		return err
 	}
+}
 
+// doRequest sends a single request to a horizon server. The returned cancel
+// function must be called once the response is read.
+func (c *Client) doRequest(ctx context.Context, requestURL string, method string) (*http.Response, context.CancelFunc, error) {
+	req, err := http.NewRequest(method, requestURL, nil)
 	if err != nil {
-		return errors.Wrap(err, "error creating HTTP request")
+		return nil, func() {}, errors.Wrap(err, "error creating HTTP request")
 	}
-	c.setClientAppHeaders(req)
-	c.setDefaultClient()
-	if c.horizonTimeout == 0 {
-		c.horizonTimeout = HorizonTimeout
+	if method == "POST" {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded; param=value")
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second*c.horizonTimeout)
+	c.setClientAppHeaders(req)
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*c.horizonTimeout)
 	resp, err := c.HTTP.Do(req.WithContext(ctx))
 	if err != nil {
 		cancel()
-		return
+		return nil, func() {}, err
 	}
-
-	err = decodeResponse(resp, &a, c)
-	cancel()
-	return
+	return resp, cancel, nil
 }
 
 // stream handles connections to endpoints that support streaming on a horizon server
@@ -150,7 +181,8 @@ func (c *Client) stream(
 	for {
 		// updates the url with new cursor
 		su.RawQuery = query.Encode()
-		req, err := http.NewRequest("GET", su.String(), nil)
+		server, requestURL := c.route(su.String())
+		req, err := http.NewRequest("GET", requestURL, nil)
 		if err != nil {
 			return errors.Wrap(err, "error creating HTTP request")
 		}
@@ -160,6 +192,9 @@ func (c *Client) stream(
 
 		// We can use c.HTTP here because we set Timeout per request not on the client. See sendRequest()
 		resp, err := c.HTTP.Do(req)
+		if ctx.Err() == nil {
+			c.updateServerHealth(server, resp, err)
+		}
 		if err != nil {
 			return errors.Wrap(err, "error sending HTTP request")
 		}
@@ -293,13 +328,25 @@ func (c *Client) HorizonTimeout() time.Duration {
 // have a trustline to an asset.
 // See https://www.stellar.org/developers/horizon/reference/endpoints/accounts.html
 func (c *Client) Accounts(request AccountsRequest) (accounts hProtocol.AccountsPage, err error) {
-	err = c.sendRequest(request, &accounts)
+	return c.AccountsContext(context.Background(), request)
+}
+
+// AccountsContext is the same as Accounts, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) AccountsContext(ctx context.Context, request AccountsRequest) (accounts hProtocol.AccountsPage, err error) {
+	err = c.sendRequest(ctx, request, &accounts)
 	return
 }
 
 // AccountDetail returns information for a single account.
 // See https://www.stellar.org/developers/horizon/reference/endpoints/accounts-single.html
 func (c *Client) AccountDetail(request AccountRequest) (account hProtocol.Account, err error) {
+	return c.AccountDetailContext(context.Background(), request)
+}
+
+// AccountDetailContext is the same as AccountDetail, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) AccountDetailContext(ctx context.Context, request AccountRequest) (account hProtocol.Account, err error) {
 	if request.AccountID == "" {
 		err = errors.New("no account ID provided")
 	}
@@ -308,13 +355,19 @@ func (c *Client) AccountDetail(request AccountRequest) (account hProtocol.Accoun
 		return
 	}
 
-	err = c.sendRequest(request, &account)
+	err = c.sendRequest(ctx, request, &account)
 	return
 }
 
 // AccountData returns a single data associated with a given account
 // See https://www.stellar.org/developers/horizon/reference/endpoints/data-for-account.html
 func (c *Client) AccountData(request AccountRequest) (accountData hProtocol.AccountData, err error) {
+	return c.AccountDataContext(context.Background(), request)
+}
+
+// AccountDataContext is the same as AccountData, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) AccountDataContext(ctx context.Context, request AccountRequest) (accountData hProtocol.AccountData, err error) {
 	if request.AccountID == "" || request.DataKey == "" {
 		err = errors.New("too few parameters")
 	}
@@ -323,34 +376,58 @@ func (c *Client) AccountData(request AccountRequest) (accountData hProtocol.Acco
 		return
 	}
 
-	err = c.sendRequest(request, &accountData)
+	err = c.sendRequest(ctx, request, &accountData)
 	return
 }
 
 // Effects returns effects(https://www.stellar.org/developers/horizon/reference/resources/effect.html)
 // It can be used to return effects for an account, a ledger, an operation, a transaction and all effects on the network.
 func (c *Client) Effects(request EffectRequest) (effects effects.EffectsPage, err error) {
-	err = c.sendRequest(request, &effects)
+	return c.EffectsContext(context.Background(), request)
+}
+
+// EffectsContext is the same as Effects, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) EffectsContext(ctx context.Context, request EffectRequest) (effects effects.EffectsPage, err error) {
+	err = c.sendRequest(ctx, request, &effects)
 	return
 }
 
 // Assets returns asset information.
 // See https://www.stellar.org/developers/horizon/reference/endpoints/assets-all.html
 func (c *Client) Assets(request AssetRequest) (assets hProtocol.AssetsPage, err error) {
-	err = c.sendRequest(request, &assets)
+	return c.AssetsContext(context.Background(), request)
+}
+
+// AssetsContext is the same as Assets, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) AssetsContext(ctx context.Context, request AssetRequest) (assets hProtocol.AssetsPage, err error) {
+	err = c.sendRequest(ctx, request, &assets)
 	return
 }
 
 // Ledgers returns information about all ledgers.
 // See https://www.stellar.org/developers/horizon/reference/endpoints/ledgers-all.html
 func (c *Client) Ledgers(request LedgerRequest) (ledgers hProtocol.LedgersPage, err error) {
-	err = c.sendRequest(request, &ledgers)
+	return c.LedgersContext(context.Background(), request)
+}
+
+// LedgersContext is the same as Ledgers, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) LedgersContext(ctx context.Context, request LedgerRequest) (ledgers hProtocol.LedgersPage, err error) {
+	err = c.sendRequest(ctx, request, &ledgers)
 	return
 }
 
 // LedgerDetail returns information about a particular ledger for a given sequence number
 // See https://www.stellar.org/developers/horizon/reference/endpoints/ledgers-single.html
 func (c *Client) LedgerDetail(sequence uint32) (ledger hProtocol.Ledger, err error) {
+	return c.LedgerDetailContext(context.Background(), sequence)
+}
+
+// LedgerDetailContext is the same as LedgerDetail, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) LedgerDetailContext(ctx context.Context, sequence uint32) (ledger hProtocol.Ledger, err error) {
 	if sequence == 0 {
 		err = errors.New("invalid sequence number provided")
 	}
@@ -360,28 +437,46 @@ func (c *Client) LedgerDetail(sequence uint32) (ledger hProtocol.Ledger, err err
 	}
 
 	request := LedgerRequest{forSequence: sequence}
-	err = c.sendRequest(request, &ledger)
+	err = c.sendRequest(ctx, request, &ledger)
 	return
 }
 
 // FeeStats returns information about fees in the last 5 ledgers.
 // See https://www.stellar.org/developers/horizon/reference/endpoints/fee-stats.html
 func (c *Client) FeeStats() (feestats hProtocol.FeeStats, err error) {
+	return c.FeeStatsContext(context.Background())
+}
+
+// FeeStatsContext is the same as FeeStats, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) FeeStatsContext(ctx context.Context) (feestats hProtocol.FeeStats, err error) {
 	request := feeStatsRequest{endpoint: "fee_stats"}
-	err = c.sendRequest(request, &feestats)
+	err = c.sendRequest(ctx, request, &feestats)
 	return
 }
 
 // Offers returns information about offers made on the SDEX.
 // See https://www.stellar.org/developers/horizon/reference/endpoints/offers-for-account.html
 func (c *Client) Offers(request OfferRequest) (offers hProtocol.OffersPage, err error) {
-	err = c.sendRequest(request, &offers)
+	return c.OffersContext(context.Background(), request)
+}
+
+// OffersContext is the same as Offers, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) OffersContext(ctx context.Context, request OfferRequest) (offers hProtocol.OffersPage, err error) {
+	err = c.sendRequest(ctx, request, &offers)
 	return
 }
 
 // OfferDetails returns information for a single offer.
 // See https://www.stellar.org/developers/horizon/reference/endpoints/offer-details.html
 func (c *Client) OfferDetails(offerID string) (offer hProtocol.Offer, err error) {
+	return c.OfferDetailsContext(context.Background(), offerID)
+}
+
+// OfferDetailsContext is the same as OfferDetails, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) OfferDetailsContext(ctx context.Context, offerID string) (offer hProtocol.Offer, err error) {
 	if len(offerID) == 0 {
 		err = errors.New("no offer ID provided")
 		return
@@ -392,20 +487,32 @@ func (c *Client) OfferDetails(offerID string) (offer hProtocol.Offer, err error)
 		return
 	}
 
-	err = c.sendRequest(OfferRequest{OfferID: offerID}, &offer)
+	err = c.sendRequest(ctx, OfferRequest{OfferID: offerID}, &offer)
 	return
 }
 
 // Operations returns stellar operations (https://www.stellar.org/developers/horizon/reference/resources/operation.html)
 // It can be used to return operations for an account, a ledger, a transaction and all operations on the network.
 func (c *Client) Operations(request OperationRequest) (ops operations.OperationsPage, err error) {
-	err = c.sendRequest(request.SetOperationsEndpoint(), &ops)
+	return c.OperationsContext(context.Background(), request)
+}
+
+// OperationsContext is the same as Operations, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) OperationsContext(ctx context.Context, request OperationRequest) (ops operations.OperationsPage, err error) {
+	err = c.sendRequest(ctx, request.SetOperationsEndpoint(), &ops)
 	return
 }
 
 // OperationDetail returns a single stellar operation for a given operation id
 // See https://www.stellar.org/developers/horizon/reference/resources/operation.html
 func (c *Client) OperationDetail(id string) (ops operations.Operation, err error) {
+	return c.OperationDetailContext(context.Background(), id)
+}
+
+// OperationDetailContext is the same as OperationDetail, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) OperationDetailContext(ctx context.Context, id string) (ops operations.Operation, err error) {
 	if id == "" {
 		return ops, errors.New("invalid operation id provided")
 	}
@@ -414,7 +521,7 @@ func (c *Client) OperationDetail(id string) (ops operations.Operation, err error
 
 	var record interface{}
 
-	err = c.sendRequest(request, &record)
+	err = c.sendRequest(ctx, request, &record)
 	if err != nil {
 		return ops, errors.Wrap(err, "sending request to horizon")
 	}
@@ -438,9 +545,16 @@ func (c *Client) OperationDetail(id string) (ops operations.Operation, err error
 // SubmitTransactionXDR submits a transaction represented as a base64 XDR string to the network. err can be either error object or horizon.Error object.
 // See https://www.stellar.org/developers/horizon/reference/endpoints/transactions-create.html
 func (c *Client) SubmitTransactionXDR(transactionXdr string) (tx hProtocol.Transaction,
+	err error) {
+	return c.SubmitTransactionXDRContext(context.Background(), transactionXdr)
+}
+
+// SubmitTransactionXDRContext is the same as SubmitTransactionXDR, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) SubmitTransactionXDRContext(ctx context.Context, transactionXdr string) (tx hProtocol.Transaction,
 	err error) {
 	request := submitRequest{endpoint: "transactions", transactionXdr: transactionXdr}
-	err = c.sendRequest(request, &tx)
+	err = c.sendRequest(ctx, request, &tx)
 	return
 }
 
@@ -454,7 +568,13 @@ func (c *Client) SubmitTransactionXDR(transactionXdr string) (tx hProtocol.Trans
 //
 // See https://www.stellar.org/developers/horizon/reference/endpoints/transactions-create.html
 func (c *Client) SubmitFeeBumpTransaction(transaction *txnbuild.FeeBumpTransaction) (tx hProtocol.Transaction, err error) {
-	return c.SubmitFeeBumpTransactionWithOptions(transaction, SubmitTxOpts{})
+	return c.SubmitFeeBumpTransactionContext(context.Background(), transaction)
+}
+
+// SubmitFeeBumpTransactionContext is the same as SubmitFeeBumpTransaction, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) SubmitFeeBumpTransactionContext(ctx context.Context, transaction *txnbuild.FeeBumpTransaction) (tx hProtocol.Transaction, err error) {
+	return c.SubmitFeeBumpTransactionWithOptionsContext(ctx, transaction, SubmitTxOpts{})
 }
 
 // SubmitFeeBumpTransactionWithOptions submits a fee bump transaction to the network, allowing
@@ -462,10 +582,16 @@ func (c *Client) SubmitFeeBumpTransaction(transaction *txnbuild.FeeBumpTransacti
 //
 // See https://www.stellar.org/developers/horizon/reference/endpoints/transactions-create.html
 func (c *Client) SubmitFeeBumpTransactionWithOptions(transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (tx hProtocol.Transaction, err error) {
+	return c.SubmitFeeBumpTransactionWithOptionsContext(context.Background(), transaction, opts)
+}
+
+// SubmitFeeBumpTransactionWithOptionsContext is the same as SubmitFeeBumpTransactionWithOptions, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) SubmitFeeBumpTransactionWithOptionsContext(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (tx hProtocol.Transaction, err error) {
 	// only check if memo is required if skip is false and the inner transaction
 	// doesn't have a memo.
 	if inner := transaction.InnerTransaction(); !opts.SkipMemoRequiredCheck && inner.Memo() == nil {
-		err = c.checkMemoRequired(inner)
+		err = c.checkMemoRequired(ctx, inner)
 		if err != nil {
 			return
 		}
@@ -477,7 +603,7 @@ func (c *Client) SubmitFeeBumpTransactionWithOptions(transaction *txnbuild.FeeBu
 		return
 	}
 
-	return c.SubmitTransactionXDR(txeBase64)
+	return c.SubmitTransactionXDRContext(ctx, txeBase64)
 }
 
 // SubmitTransaction submits a transaction to the network. err can be either an
@@ -490,7 +616,13 @@ func (c *Client) SubmitFeeBumpTransactionWithOptions(transaction *txnbuild.FeeBu
 //
 // See https://www.stellar.org/developers/horizon/reference/endpoints/transactions-create.html
 func (c *Client) SubmitTransaction(transaction *txnbuild.Transaction) (tx hProtocol.Transaction, err error) {
-	return c.SubmitTransactionWithOptions(transaction, SubmitTxOpts{})
+	return c.SubmitTransactionContext(context.Background(), transaction)
+}
+
+// SubmitTransactionContext is the same as SubmitTransaction, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) SubmitTransactionContext(ctx context.Context, transaction *txnbuild.Transaction) (tx hProtocol.Transaction, err error) {
+	return c.SubmitTransactionWithOptionsContext(ctx, transaction, SubmitTxOpts{})
 }
 
 // SubmitTransactionWithOptions submits a transaction to the network, allowing
@@ -498,10 +630,16 @@ func (c *Client) SubmitTransaction(transaction *txnbuild.Transaction) (tx hProto
 //
 // See https://www.stellar.org/developers/horizon/reference/endpoints/transactions-create.html
 func (c *Client) SubmitTransactionWithOptions(transaction *txnbuild.Transaction, opts SubmitTxOpts) (tx hProtocol.Transaction, err error) {
+	return c.SubmitTransactionWithOptionsContext(context.Background(), transaction, opts)
+}
+
+// SubmitTransactionWithOptionsContext is the same as SubmitTransactionWithOptions, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) SubmitTransactionWithOptionsContext(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (tx hProtocol.Transaction, err error) {
 	// only check if memo is required if skip is false and the transaction
 	// doesn't have a memo.
 	if !opts.SkipMemoRequiredCheck && transaction.Memo() == nil {
-		err = c.checkMemoRequired(transaction)
+		err = c.checkMemoRequired(ctx, transaction)
 		if err != nil {
 			return
 		}
@@ -513,75 +651,205 @@ func (c *Client) SubmitTransactionWithOptions(transaction *txnbuild.Transaction,
 		return
 	}
 
-	return c.SubmitTransactionXDR(txeBase64)
+	return c.SubmitTransactionXDRContext(ctx, txeBase64)
+}
+
+// AccountSigners returns the signers and thresholds of the given accounts,
+// which are used by txnbuild to evaluate the signatures of transactions.
+func (c *Client) AccountSigners(accountIDs ...string) (map[string]txnbuild.AccountSigners, error) {
+	return c.AccountSignersContext(context.Background(), accountIDs...)
+}
+
+// AccountSignersContext is the same as AccountSigners, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) AccountSignersContext(ctx context.Context, accountIDs ...string) (map[string]txnbuild.AccountSigners, error) {
+	accounts := make(map[string]txnbuild.AccountSigners, len(accountIDs))
+	for _, accountID := range accountIDs {
+		if _, ok := accounts[accountID]; ok {
+			continue
+		}
+		account, err := c.AccountDetailContext(ctx, AccountRequest{AccountID: accountID})
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to load account %s", accountID)
+		}
+		accounts[accountID] = txnbuild.AccountSigners{
+			Signers:         account.SignerSummary(),
+			LowThreshold:    txnbuild.Threshold(account.Thresholds.LowThreshold),
+			MediumThreshold: txnbuild.Threshold(account.Thresholds.MedThreshold),
+			HighThreshold:   txnbuild.Threshold(account.Thresholds.HighThreshold),
+		}
+	}
+	return accounts, nil
+}
+
+// TransactionSignatureStatus loads the signers of the source accounts of a
+// transaction and returns which of its signatures are valid, missing and
+// extra for the given network passphrase.
+func (c *Client) TransactionSignatureStatus(transaction *txnbuild.Transaction, network string) (txnbuild.SignatureStatus, error) {
+	return c.TransactionSignatureStatusContext(context.Background(), transaction, network)
+}
+
+// TransactionSignatureStatusContext is the same as TransactionSignatureStatus, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) TransactionSignatureStatusContext(ctx context.Context, transaction *txnbuild.Transaction, network string) (txnbuild.SignatureStatus, error) {
+	accounts, err := c.AccountSignersContext(ctx, requiredAccounts(transaction.RequiredThresholds())...)
+	if err != nil {
+		return txnbuild.SignatureStatus{}, err
+	}
+	return transaction.SignatureStatus(network, accounts)
+}
+
+// FeeBumpTransactionSignatureStatus loads the signers of the fee account and
+// of the source accounts of the inner transaction of a fee bump transaction
+// and returns which of its signatures are valid, missing and extra for the
+// given network passphrase.
+func (c *Client) FeeBumpTransactionSignatureStatus(transaction *txnbuild.FeeBumpTransaction, network string) (txnbuild.SignatureStatus, error) {
+	return c.FeeBumpTransactionSignatureStatusContext(context.Background(), transaction, network)
+}
+
+// FeeBumpTransactionSignatureStatusContext is the same as FeeBumpTransactionSignatureStatus, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) FeeBumpTransactionSignatureStatusContext(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, network string) (txnbuild.SignatureStatus, error) {
+	accountIDs := append(
+		requiredAccounts(transaction.RequiredThresholds()),
+		requiredAccounts(transaction.InnerTransaction().RequiredThresholds())...,
+	)
+	accounts, err := c.AccountSignersContext(ctx, accountIDs...)
+	if err != nil {
+		return txnbuild.SignatureStatus{}, err
+	}
+	return transaction.SignatureStatus(network, accounts)
+}
+
+func requiredAccounts(required map[string]txnbuild.ThresholdCategory) []string {
+	accountIDs := make([]string, 0, len(required))
+	for accountID := range required {
+		accountIDs = append(accountIDs, accountID)
+	}
+	sort.Strings(accountIDs)
+	return accountIDs
 }
 
 // Transactions returns stellar transactions (https://www.stellar.org/developers/horizon/reference/resources/transaction.html)
 // It can be used to return transactions for an account, a ledger,and all transactions on the network.
 func (c *Client) Transactions(request TransactionRequest) (txs hProtocol.TransactionsPage, err error) {
-	err = c.sendRequest(request, &txs)
+	return c.TransactionsContext(context.Background(), request)
+}
+
+// TransactionsContext is the same as Transactions, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) TransactionsContext(ctx context.Context, request TransactionRequest) (txs hProtocol.TransactionsPage, err error) {
+	err = c.sendRequest(ctx, request, &txs)
 	return
 }
 
 // TransactionDetail returns information about a particular transaction for a given transaction hash
 // See https://www.stellar.org/developers/horizon/reference/endpoints/transactions-single.html
 func (c *Client) TransactionDetail(txHash string) (tx hProtocol.Transaction, err error) {
+	return c.TransactionDetailContext(context.Background(), txHash)
+}
+
+// TransactionDetailContext is the same as TransactionDetail, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) TransactionDetailContext(ctx context.Context, txHash string) (tx hProtocol.Transaction, err error) {
 	if txHash == "" {
 		return tx, errors.New("no transaction hash provided")
 	}
 
 	request := TransactionRequest{forTransactionHash: txHash}
-	err = c.sendRequest(request, &tx)
+	err = c.sendRequest(ctx, request, &tx)
 	return
 }
 
 // OrderBook returns the orderbook for an asset pair (https://www.stellar.org/developers/horizon/reference/resources/orderbook.html)
 func (c *Client) OrderBook(request OrderBookRequest) (obs hProtocol.OrderBookSummary, err error) {
-	err = c.sendRequest(request, &obs)
+	return c.OrderBookContext(context.Background(), request)
+}
+
+// OrderBookContext is the same as OrderBook, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) OrderBookContext(ctx context.Context, request OrderBookRequest) (obs hProtocol.OrderBookSummary, err error) {
+	err = c.sendRequest(ctx, request, &obs)
 	return
 }
 
 // Paths returns the available paths to make a strict receive path payment. See https://www.stellar.org/developers/horizon/reference/endpoints/path-finding-strict-receive.html
 // This function is an alias for `client.StrictReceivePaths` and will be deprecated, use `client.StrictReceivePaths` instead.
 func (c *Client) Paths(request PathsRequest) (paths hProtocol.PathsPage, err error) {
-	paths, err = c.StrictReceivePaths(request)
+	return c.PathsContext(context.Background(), request)
+}
+
+// PathsContext is the same as Paths, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PathsContext(ctx context.Context, request PathsRequest) (paths hProtocol.PathsPage, err error) {
+	paths, err = c.StrictReceivePathsContext(ctx, request)
 	return
 }
 
 // StrictReceivePaths returns the available paths to make a strict receive path payment. See https://www.stellar.org/developers/horizon/reference/endpoints/path-finding-strict-receive.html
 func (c *Client) StrictReceivePaths(request PathsRequest) (paths hProtocol.PathsPage, err error) {
-	err = c.sendRequest(request, &paths)
+	return c.StrictReceivePathsContext(context.Background(), request)
+}
+
+// StrictReceivePathsContext is the same as StrictReceivePaths, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) StrictReceivePathsContext(ctx context.Context, request PathsRequest) (paths hProtocol.PathsPage, err error) {
+	err = c.sendRequest(ctx, request, &paths)
 	return
 }
 
 // StrictSendPaths returns the available paths to make a strict send path payment. See https://www.stellar.org/developers/horizon/reference/endpoints/path-finding-strict-send.html
 func (c *Client) StrictSendPaths(request StrictSendPathsRequest) (paths hProtocol.PathsPage, err error) {
-	err = c.sendRequest(request, &paths)
+	return c.StrictSendPathsContext(context.Background(), request)
+}
+
+// StrictSendPathsContext is the same as StrictSendPaths, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) StrictSendPathsContext(ctx context.Context, request StrictSendPathsRequest) (paths hProtocol.PathsPage, err error) {
+	err = c.sendRequest(ctx, request, &paths)
 	return
 }
 
 // Payments returns stellar account_merge, create_account, path payment and payment operations.
 // It can be used to return payments for an account, a ledger, a transaction and all payments on the network.
 func (c *Client) Payments(request OperationRequest) (ops operations.OperationsPage, err error) {
-	err = c.sendRequest(request.SetPaymentsEndpoint(), &ops)
+	return c.PaymentsContext(context.Background(), request)
+}
+
+// PaymentsContext is the same as Payments, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PaymentsContext(ctx context.Context, request OperationRequest) (ops operations.OperationsPage, err error) {
+	err = c.sendRequest(ctx, request.SetPaymentsEndpoint(), &ops)
 	return
 }
 
 // Trades returns stellar trades (https://www.stellar.org/developers/horizon/reference/resources/trade.html)
 // It can be used to return trades for an account, an offer and all trades on the network.
 func (c *Client) Trades(request TradeRequest) (tds hProtocol.TradesPage, err error) {
-	err = c.sendRequest(request, &tds)
+	return c.TradesContext(context.Background(), request)
+}
+
+// TradesContext is the same as Trades, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) TradesContext(ctx context.Context, request TradeRequest) (tds hProtocol.TradesPage, err error) {
+	err = c.sendRequest(ctx, request, &tds)
 	return
 }
 
 // Fund creates a new account funded from friendbot. It only works on test networks. See
 // https://www.stellar.org/developers/guides/get-started/create-account.html for more information.
 func (c *Client) Fund(addr string) (tx hProtocol.Transaction, err error) {
+	return c.FundContext(context.Background(), addr)
+}
+
+// FundContext is the same as Fund, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) FundContext(ctx context.Context, addr string) (tx hProtocol.Transaction, err error) {
 	if !c.isTestNet {
 		return tx, errors.New("can't fund account from friendbot on production network")
 	}
 	friendbotURL := fmt.Sprintf("%sfriendbot?addr=%s", c.fixHorizonURL(), addr)
-	err = c.sendRequestURL(friendbotURL, "get", &tx)
+	err = c.sendRequestURL(ctx, friendbotURL, "get", &tx)
 	return
 }
 
@@ -595,7 +863,13 @@ func (c *Client) StreamTrades(ctx context.Context, request TradeRequest, handler
 
 // TradeAggregations returns stellar trade aggregations (https://www.stellar.org/developers/horizon/reference/resources/trade_aggregation.html)
 func (c *Client) TradeAggregations(request TradeAggregationRequest) (tds hProtocol.TradeAggregationsPage, err error) {
-	err = c.sendRequest(request, &tds)
+	return c.TradeAggregationsContext(context.Background(), request)
+}
+
+// TradeAggregationsContext is the same as TradeAggregations, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) TradeAggregationsContext(ctx context.Context, request TradeAggregationRequest) (tds hProtocol.TradeAggregationsPage, err error) {
+	err = c.sendRequest(ctx, request, &tds)
 	return
 }
 
@@ -672,7 +946,13 @@ func (c *Client) FetchTimebounds(seconds int64) (txnbuild.Timebounds, error) {
 
 // Root loads the root endpoint of horizon
 func (c *Client) Root() (root hProtocol.Root, err error) {
-	err = c.sendRequestURL(c.fixHorizonURL(), "get", &root)
+	return c.RootContext(context.Background())
+}
+
+// RootContext is the same as Root, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) RootContext(ctx context.Context) (root hProtocol.Root, err error) {
+	err = c.sendRequestURL(ctx, c.fixHorizonURL(), "get", &root)
 	return
 }
 
@@ -683,111 +963,219 @@ func (c *Client) Version() string {
 
 // NextAccountsPage returns the next page of accounts.
 func (c *Client) NextAccountsPage(page hProtocol.AccountsPage) (accounts hProtocol.AccountsPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &accounts)
+	return c.NextAccountsPageContext(context.Background(), page)
+}
+
+// NextAccountsPageContext is the same as NextAccountsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextAccountsPageContext(ctx context.Context, page hProtocol.AccountsPage) (accounts hProtocol.AccountsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &accounts)
 	return
 }
 
 // NextAssetsPage returns the next page of assets.
 func (c *Client) NextAssetsPage(page hProtocol.AssetsPage) (assets hProtocol.AssetsPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &assets)
+	return c.NextAssetsPageContext(context.Background(), page)
+}
+
+// NextAssetsPageContext is the same as NextAssetsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextAssetsPageContext(ctx context.Context, page hProtocol.AssetsPage) (assets hProtocol.AssetsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &assets)
 	return
 }
 
 // PrevAssetsPage returns the previous page of assets.
 func (c *Client) PrevAssetsPage(page hProtocol.AssetsPage) (assets hProtocol.AssetsPage, err error) {
-	err = c.sendRequestURL(page.Links.Prev.Href, "get", &assets)
+	return c.PrevAssetsPageContext(context.Background(), page)
+}
+
+// PrevAssetsPageContext is the same as PrevAssetsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevAssetsPageContext(ctx context.Context, page hProtocol.AssetsPage) (assets hProtocol.AssetsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Prev.Href, "get", &assets)
 	return
 }
 
 // NextLedgersPage returns the next page of ledgers.
 func (c *Client) NextLedgersPage(page hProtocol.LedgersPage) (ledgers hProtocol.LedgersPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &ledgers)
+	return c.NextLedgersPageContext(context.Background(), page)
+}
+
+// NextLedgersPageContext is the same as NextLedgersPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextLedgersPageContext(ctx context.Context, page hProtocol.LedgersPage) (ledgers hProtocol.LedgersPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &ledgers)
 	return
 }
 
 // PrevLedgersPage returns the previous page of ledgers.
 func (c *Client) PrevLedgersPage(page hProtocol.LedgersPage) (ledgers hProtocol.LedgersPage, err error) {
-	err = c.sendRequestURL(page.Links.Prev.Href, "get", &ledgers)
+	return c.PrevLedgersPageContext(context.Background(), page)
+}
+
+// PrevLedgersPageContext is the same as PrevLedgersPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevLedgersPageContext(ctx context.Context, page hProtocol.LedgersPage) (ledgers hProtocol.LedgersPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Prev.Href, "get", &ledgers)
 	return
 }
 
 // NextEffectsPage returns the next page of effects.
 func (c *Client) NextEffectsPage(page effects.EffectsPage) (efp effects.EffectsPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &efp)
+	return c.NextEffectsPageContext(context.Background(), page)
+}
+
+// NextEffectsPageContext is the same as NextEffectsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextEffectsPageContext(ctx context.Context, page effects.EffectsPage) (efp effects.EffectsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &efp)
 	return
 }
 
 // PrevEffectsPage returns the previous page of effects.
 func (c *Client) PrevEffectsPage(page effects.EffectsPage) (efp effects.EffectsPage, err error) {
-	err = c.sendRequestURL(page.Links.Prev.Href, "get", &efp)
+	return c.PrevEffectsPageContext(context.Background(), page)
+}
+
+// PrevEffectsPageContext is the same as PrevEffectsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevEffectsPageContext(ctx context.Context, page effects.EffectsPage) (efp effects.EffectsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Prev.Href, "get", &efp)
 	return
 }
 
 // NextTransactionsPage returns the next page of transactions.
 func (c *Client) NextTransactionsPage(page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &transactions)
+	return c.NextTransactionsPageContext(context.Background(), page)
+}
+
+// NextTransactionsPageContext is the same as NextTransactionsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextTransactionsPageContext(ctx context.Context, page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &transactions)
 	return
 }
 
 // PrevTransactionsPage returns the previous page of transactions.
 func (c *Client) PrevTransactionsPage(page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
-	err = c.sendRequestURL(page.Links.Prev.Href, "get", &transactions)
+	return c.PrevTransactionsPageContext(context.Background(), page)
+}
+
+// PrevTransactionsPageContext is the same as PrevTransactionsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevTransactionsPageContext(ctx context.Context, page hProtocol.TransactionsPage) (transactions hProtocol.TransactionsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Prev.Href, "get", &transactions)
 	return
 }
 
 // NextOperationsPage returns the next page of operations.
 func (c *Client) NextOperationsPage(page operations.OperationsPage) (operations operations.OperationsPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &operations)
+	return c.NextOperationsPageContext(context.Background(), page)
+}
+
+// NextOperationsPageContext is the same as NextOperationsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextOperationsPageContext(ctx context.Context, page operations.OperationsPage) (operations operations.OperationsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &operations)
 	return
 }
 
 // PrevOperationsPage returns the previous page of operations.
 func (c *Client) PrevOperationsPage(page operations.OperationsPage) (operations operations.OperationsPage, err error) {
-	err = c.sendRequestURL(page.Links.Prev.Href, "get", &operations)
+	return c.PrevOperationsPageContext(context.Background(), page)
+}
+
+// PrevOperationsPageContext is the same as PrevOperationsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevOperationsPageContext(ctx context.Context, page operations.OperationsPage) (operations operations.OperationsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Prev.Href, "get", &operations)
 	return
 }
 
 // NextPaymentsPage returns the next page of payments.
 func (c *Client) NextPaymentsPage(page operations.OperationsPage) (operations.OperationsPage, error) {
-	return c.NextOperationsPage(page)
+	return c.NextPaymentsPageContext(context.Background(), page)
+}
+
+// NextPaymentsPageContext is the same as NextPaymentsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextPaymentsPageContext(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error) {
+	return c.NextOperationsPageContext(ctx, page)
 }
 
 // PrevPaymentsPage returns the previous page of payments.
 func (c *Client) PrevPaymentsPage(page operations.OperationsPage) (operations.OperationsPage, error) {
-	return c.PrevOperationsPage(page)
+	return c.PrevPaymentsPageContext(context.Background(), page)
+}
+
+// PrevPaymentsPageContext is the same as PrevPaymentsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevPaymentsPageContext(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error) {
+	return c.PrevOperationsPageContext(ctx, page)
 }
 
 // NextOffersPage returns the next page of offers.
 func (c *Client) NextOffersPage(page hProtocol.OffersPage) (offers hProtocol.OffersPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &offers)
+	return c.NextOffersPageContext(context.Background(), page)
+}
+
+// NextOffersPageContext is the same as NextOffersPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextOffersPageContext(ctx context.Context, page hProtocol.OffersPage) (offers hProtocol.OffersPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &offers)
 	return
 }
 
 // PrevOffersPage returns the previous page of offers.
 func (c *Client) PrevOffersPage(page hProtocol.OffersPage) (offers hProtocol.OffersPage, err error) {
-	err = c.sendRequestURL(page.Links.Prev.Href, "get", &offers)
+	return c.PrevOffersPageContext(context.Background(), page)
+}
+
+// PrevOffersPageContext is the same as PrevOffersPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevOffersPageContext(ctx context.Context, page hProtocol.OffersPage) (offers hProtocol.OffersPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Prev.Href, "get", &offers)
 	return
 }
 
 // NextTradesPage returns the next page of trades.
 func (c *Client) NextTradesPage(page hProtocol.TradesPage) (trades hProtocol.TradesPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &trades)
+	return c.NextTradesPageContext(context.Background(), page)
+}
+
+// NextTradesPageContext is the same as NextTradesPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextTradesPageContext(ctx context.Context, page hProtocol.TradesPage) (trades hProtocol.TradesPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &trades)
 	return
 }
 
 // PrevTradesPage returns the previous page of trades.
 func (c *Client) PrevTradesPage(page hProtocol.TradesPage) (trades hProtocol.TradesPage, err error) {
-	err = c.sendRequestURL(page.Links.Prev.Href, "get", &trades)
+	return c.PrevTradesPageContext(context.Background(), page)
+}
+
+// PrevTradesPageContext is the same as PrevTradesPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevTradesPageContext(ctx context.Context, page hProtocol.TradesPage) (trades hProtocol.TradesPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Prev.Href, "get", &trades)
 	return
 }
 
 // HomeDomainForAccount returns the home domain for a single account.
 func (c *Client) HomeDomainForAccount(aid string) (string, error) {
+	return c.HomeDomainForAccountContext(context.Background(), aid)
+}
+
+// HomeDomainForAccountContext is the same as HomeDomainForAccount, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) HomeDomainForAccountContext(ctx context.Context, aid string) (string, error) {
 	if aid == "" {
 		return "", errors.New("no account ID provided")
 	}
 
-	accountDetail, err := c.AccountDetail(AccountRequest{AccountID: aid})
+	accountDetail, err := c.AccountDetailContext(ctx, AccountRequest{AccountID: aid})
 	if err != nil {
 		return "", errors.Wrap(err, "get account detail failed")
 	}
@@ -798,28 +1186,52 @@ func (c *Client) HomeDomainForAccount(aid string) (string, error) {
 // NextTradeAggregationsPage returns the next page of trade aggregations from the current
 // trade aggregations response.
 func (c *Client) NextTradeAggregationsPage(page hProtocol.TradeAggregationsPage) (ta hProtocol.TradeAggregationsPage, err error) {
-	err = c.sendRequestURL(page.Links.Next.Href, "get", &ta)
+	return c.NextTradeAggregationsPageContext(context.Background(), page)
+}
+
+// NextTradeAggregationsPageContext is the same as NextTradeAggregationsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) NextTradeAggregationsPageContext(ctx context.Context, page hProtocol.TradeAggregationsPage) (ta hProtocol.TradeAggregationsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Next.Href, "get", &ta)
 	return
 }
 
 // PrevTradeAggregationsPage returns the previous page of trade aggregations from the current
 // trade aggregations response.
 func (c *Client) PrevTradeAggregationsPage(page hProtocol.TradeAggregationsPage) (ta hProtocol.TradeAggregationsPage, err error) {
-	err = c.sendRequestURL(page.Links.Prev.Href, "get", &ta)
+	return c.PrevTradeAggregationsPageContext(context.Background(), page)
+}
+
+// PrevTradeAggregationsPageContext is the same as PrevTradeAggregationsPage, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) PrevTradeAggregationsPageContext(ctx context.Context, page hProtocol.TradeAggregationsPage) (ta hProtocol.TradeAggregationsPage, err error) {
+	err = c.sendRequestURL(ctx, page.Links.Prev.Href, "get", &ta)
 	return
 }
 
 // ClaimableBalances returns details about available claimable balances,
 // possibly filtered to a specific sponsor or other parameters.
 func (c *Client) ClaimableBalances(cbr ClaimableBalanceRequest) (cb hProtocol.ClaimableBalances, err error) {
-	err = c.sendRequest(cbr, &cb)
+	return c.ClaimableBalancesContext(context.Background(), cbr)
+}
+
+// ClaimableBalancesContext is the same as ClaimableBalances, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) ClaimableBalancesContext(ctx context.Context, cbr ClaimableBalanceRequest) (cb hProtocol.ClaimableBalances, err error) {
+	err = c.sendRequest(ctx, cbr, &cb)
 	return
 }
 
 // ClaimableBalance returns details about a *specific*, unique claimable balance.
 func (c *Client) ClaimableBalance(id string) (cb hProtocol.ClaimableBalance, err error) {
+	return c.ClaimableBalanceContext(context.Background(), id)
+}
+
+// ClaimableBalanceContext is the same as ClaimableBalance, but takes a context.Context which can be
+// used to cancel the request.
+func (c *Client) ClaimableBalanceContext(ctx context.Context, id string) (cb hProtocol.ClaimableBalance, err error) {
 	cbr := ClaimableBalanceRequest{ID: id}
-	err = c.sendRequest(cbr, &cb)
+	err = c.sendRequest(ctx, cbr, &cb)
 	return
 }
 
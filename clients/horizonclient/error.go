@@ -5,6 +5,7 @@ import (
 
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
 )
 
@@ -57,6 +58,23 @@ func (herr *Error) ResultString() (string, error) {
 	return b64, nil
 }
 
+// TransactionError decodes the transaction result of the error into a
+// readable form, which describes the failed operations and whether the
+// failure can be retried. It returns nil if the result is not a failure.
+func (herr *Error) TransactionError() (*txnbuild.TransactionError, error) {
+	result, err := herr.ResultString()
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, err := herr.EnvelopeXDR()
+	if err != nil && err != ErrEnvelopeNotPopulated {
+		return nil, err
+	}
+
+	return txnbuild.TransactionErrorFromXDR(result, envelope)
+}
+
 // ResultCodes extracts a result code summary from the error, if possible.
 func (herr *Error) ResultCodes() (*hProtocol.TransactionResultCodes, error) {
 
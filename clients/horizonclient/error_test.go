@@ -3,6 +3,7 @@ package horizonclient
 import (
 	"testing"
 
+	"github.com/stellar/go/txnbuild"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -92,3 +93,35 @@ func TestError_Envelope(t *testing.T) {
 		assert.Contains(t, err.Error(), "xdr decode")
 	}
 }
+
+func TestError_TransactionError(t *testing.T) {
+	var herr Error
+
+	// happy path: transaction_failed with the appropriate extra fields
+	herr.Problem.Type = "transaction_failed"
+	herr.Problem.Extras = make(map[string]interface{})
+	herr.Problem.Extras["result_xdr"] = "AAAAAAAAAMj/////AAAAAgAAAAAAAAAA/////wAAAAAAAAAAAAAAAAAAAAA="
+
+	txErr, err := herr.TransactionError()
+	if assert.NoError(t, err) {
+		assert.Equal(t, "tx_failed", txErr.Result.Code)
+		assert.Equal(t, int64(200), txErr.FeeCharged)
+		if assert.Len(t, txErr.Operations, 1) {
+			assert.Equal(t, 0, txErr.Operations[0].Index)
+			assert.Equal(t, "create_account", txErr.Operations[0].Type)
+			assert.Equal(t, "op_malformed", txErr.Operations[0].Result.Code)
+		}
+		assert.Equal(t, txnbuild.Permanent, txErr.Class())
+	}
+
+	// sad path: missing result_xdr extra
+	herr.Problem.Extras = make(map[string]interface{})
+	_, err = herr.TransactionError()
+	assert.Equal(t, ErrResultNotPopulated, err)
+
+	// sad path: unparseable envelope_xdr extra
+	herr.Problem.Extras["result_xdr"] = "AAAAAAAAAMj/////AAAAAgAAAAAAAAAA/////wAAAAAAAAAAAAAAAAAAAAA="
+	herr.Problem.Extras["envelope_xdr"] = "kaboom"
+	_, err = herr.TransactionError()
+	assert.Error(t, err)
+}
@@ -0,0 +1,478 @@
+package horizontest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/stellar/go/amount"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/support/render/hal"
+	"github.com/stellar/go/support/render/problem"
+)
+
+const (
+	// defaultLimit and maxLimit are the default and maximum number of records
+	// of a page.
+	defaultLimit = 10
+	maxLimit     = 200
+)
+
+var notFound = problem.P{
+	Type:   "https://stellar.org/horizon-errors/not_found",
+	Title:  "Resource Missing",
+	Status: http.StatusNotFound,
+	Detail: "The resource at the url requested was not found.  This usually " +
+		"occurs for one of two reasons:  The url requested is not valid, or no " +
+		"data in our database could be found with the parameters provided.",
+}
+
+// invalidField returns the problem Horizon responds with when a parameter of
+// a request is invalid.
+func invalidField(name, reason string) problem.P {
+	return problem.P{
+		Type:   "https://stellar.org/horizon-errors/bad_request",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: "The request you sent was invalid in some way.",
+		Extras: map[string]interface{}{
+			"invalid_field": name,
+			"reason":        reason,
+		},
+	}
+}
+
+func renderJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/hal+json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func renderProblem(w http.ResponseWriter, p problem.P) {
+	w.Header().Set("Content-Type", "application/problem+json; charset=utf-8")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// filter selects the records of a collection for a request.
+type filter func(r *http.Request, rec *record) bool
+
+func forAccount(r *http.Request, rec *record) bool {
+	return rec.participants[chi.URLParam(r, "account_id")]
+}
+
+func forTransaction(r *http.Request, rec *record) bool {
+	hash := chi.URLParam(r, "tx_id")
+	for _, h := range rec.hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+func payments(r *http.Request, rec *record) bool {
+	return rec.payment
+}
+
+func (s *Server) router() http.Handler {
+	transactions := func() []*record { return s.transactions }
+	ops := func() []*record { return s.operations }
+
+	mux := chi.NewRouter()
+	mux.Get("/", s.getRoot)
+	mux.Get("/accounts/{account_id}", s.getAccount)
+	mux.Get("/accounts/{account_id}/data/{key}", s.getAccountData)
+	mux.Get("/accounts/{account_id}/transactions", s.collection(transactions, forAccount))
+	mux.Get("/accounts/{account_id}/operations", s.collection(ops, forAccount))
+	mux.Get("/accounts/{account_id}/payments", s.collection(ops, forAccount, payments))
+	mux.Get("/transactions", s.collection(transactions))
+	mux.Post("/transactions", s.postTransaction)
+	mux.Get("/transactions/{tx_id}", s.getTransaction)
+	mux.Get("/transactions/{tx_id}/operations", s.collection(ops, forTransaction))
+	mux.Get("/transactions/{tx_id}/payments", s.collection(ops, forTransaction, payments))
+	mux.Get("/operations", s.collection(ops))
+	mux.Get("/operations/{id}", s.getOperation)
+	mux.Get("/payments", s.collection(ops, payments))
+	mux.Get("/fee_stats", s.getFeeStats)
+	mux.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		renderProblem(w, notFound)
+	})
+	return mux
+}
+
+func (s *Server) getRoot(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	ledger := s.ledger
+	s.mutex.Unlock()
+
+	var root hProtocol.Root
+	root.Links.Self = hal.NewLink(s.URL + "/")
+	root.Links.Account = hal.NewLink(s.URL + "/accounts/{account_id}")
+	root.Links.AccountTransactions = hal.NewLink(s.URL + "/accounts/{account_id}/transactions{?cursor,limit,order}")
+	root.Links.FeeStats = hal.NewLink(s.URL + "/fee_stats")
+	root.Links.Operation = hal.NewLink(s.URL + "/operations/{id}")
+	root.Links.Operations = hal.NewLink(s.URL + "/operations{?cursor,limit,order,include_failed}")
+	root.Links.Payments = hal.NewLink(s.URL + "/payments{?cursor,limit,order,include_failed}")
+	root.Links.Transaction = hal.NewLink(s.URL + "/transactions/{hash}")
+	root.Links.Transactions = hal.NewLink(s.URL + "/transactions{?cursor,limit,order}")
+	root.HorizonVersion = "horizontest"
+	root.StellarCoreVersion = "horizontest"
+	root.IngestSequence = ledger
+	root.HorizonSequence = int32(ledger)
+	root.HistoryElderSequence = 1
+	root.CoreSequence = int32(ledger)
+	root.NetworkPassphrase = s.NetworkPassphrase
+	root.CurrentProtocolVersion = 14
+	root.CoreSupportedProtocolVersion = 14
+	renderJSON(w, http.StatusOK, root)
+}
+
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "account_id")
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a, ok := s.accounts[id]
+	if !ok {
+		renderProblem(w, notFound)
+		return
+	}
+	resource := hProtocol.Account{
+		ID:                 id,
+		AccountID:          id,
+		Sequence:           strconv.FormatInt(a.sequence, 10),
+		SubentryCount:      int32(len(a.data)),
+		LastModifiedLedger: a.lastModified,
+		Balances: []hProtocol.Balance{{
+			Balance:            amount.StringFromInt64(a.balance),
+			BuyingLiabilities:  "0.0000000",
+			SellingLiabilities: "0.0000000",
+			Asset:              base.Asset{Type: "native"},
+		}},
+		Signers: []hProtocol.Signer{{
+			Weight: 1,
+			Key:    id,
+			Type:   "ed25519_public_key",
+		}},
+		Data: map[string]string{},
+		PT:   id,
+	}
+	for name, value := range a.data {
+		resource.Data[name] = base64.StdEncoding.EncodeToString(value)
+	}
+	resource.Links.Self = hal.NewLink(s.URL + "/accounts/" + id)
+	resource.Links.Transactions = hal.NewLink(s.URL + "/accounts/" + id + "/transactions{?cursor,limit,order}")
+	resource.Links.Operations = hal.NewLink(s.URL + "/accounts/" + id + "/operations{?cursor,limit,order}")
+	resource.Links.Payments = hal.NewLink(s.URL + "/accounts/" + id + "/payments{?cursor,limit,order}")
+	resource.Links.Data = hal.NewLink(s.URL + "/accounts/" + id + "/data/{key}")
+	renderJSON(w, http.StatusOK, resource)
+}
+
+func (s *Server) getAccountData(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	a, ok := s.accounts[chi.URLParam(r, "account_id")]
+	if !ok {
+		renderProblem(w, notFound)
+		return
+	}
+	value, ok := a.data[chi.URLParam(r, "key")]
+	if !ok {
+		renderProblem(w, notFound)
+		return
+	}
+	renderJSON(w, http.StatusOK, hProtocol.AccountData{
+		Value: base64.StdEncoding.EncodeToString(value),
+	})
+}
+
+func (s *Server) postTransaction(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		renderProblem(w, invalidField("tx", err.Error()))
+		return
+	}
+	envelope := r.Form.Get("tx")
+	if envelope == "" {
+		renderProblem(w, invalidField("tx", "Transaction envelope is required"))
+		return
+	}
+
+	s.mutex.Lock()
+	resp, p := s.submit(envelope, true)
+	s.mutex.Unlock()
+	if p != nil {
+		renderProblem(w, *p)
+		return
+	}
+	renderJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) getTransaction(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, rec := range s.transactions {
+		if forTransaction(r, rec) {
+			renderJSON(w, http.StatusOK, rec.resource)
+			return
+		}
+	}
+	renderProblem(w, notFound)
+}
+
+func (s *Server) getOperation(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	id := chi.URLParam(r, "id")
+	for _, rec := range s.operations {
+		if strconv.FormatInt(rec.id, 10) == id {
+			renderJSON(w, http.StatusOK, rec.resource)
+			return
+		}
+	}
+	renderProblem(w, notFound)
+}
+
+func (s *Server) getFeeStats(w http.ResponseWriter, r *http.Request) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	stats := hProtocol.FeeStats{
+		LastLedger:        s.ledger - 1,
+		LastLedgerBaseFee: s.baseFee,
+	}
+	var charged, max []int64
+	operations := 0
+	for _, f := range s.lastFees {
+		charged = append(charged, f.charged)
+		max = append(max, f.max)
+		operations += f.operations
+	}
+	stats.LedgerCapacityUsage = float64(operations) / ledgerCapacity
+	stats.FeeCharged = distribution(charged, s.baseFee)
+	stats.MaxFee = distribution(max, s.baseFee)
+	renderJSON(w, http.StatusOK, stats)
+}
+
+// distribution returns the distribution of fees, which is the base fee if
+// there are none.
+func distribution(fees []int64, baseFee int64) hProtocol.FeeDistribution {
+	if len(fees) == 0 {
+		fees = []int64{baseFee}
+	}
+	sort.Slice(fees, func(i, j int) bool { return fees[i] < fees[j] })
+	percentile := func(p int) int64 {
+		i := (len(fees)*p+99)/100 - 1
+		if i < 0 {
+			i = 0
+		}
+		return fees[i]
+	}
+
+	mode, count := fees[0], 0
+	for i := 0; i < len(fees); {
+		j := i
+		for j < len(fees) && fees[j] == fees[i] {
+			j++
+		}
+		if j-i > count {
+			mode, count = fees[i], j-i
+		}
+		i = j
+	}
+
+	return hProtocol.FeeDistribution{
+		Max:  fees[len(fees)-1],
+		Min:  fees[0],
+		Mode: mode,
+		P10:  percentile(10),
+		P20:  percentile(20),
+		P30:  percentile(30),
+		P40:  percentile(40),
+		P50:  percentile(50),
+		P60:  percentile(60),
+		P70:  percentile(70),
+		P80:  percentile(80),
+		P90:  percentile(90),
+		P95:  percentile(95),
+		P99:  percentile(99),
+	}
+}
+
+// pageQuery holds the paging parameters of a request.
+type pageQuery struct {
+	cursor        int64
+	order         string
+	limit         int
+	includeFailed bool
+}
+
+// parsePageQuery parses the paging parameters of a request. The "now" cursor
+// points after the last record of the ledger.
+func (s *Server) parsePageQuery(r *http.Request) (pageQuery, *problem.P) {
+	query := r.URL.Query()
+	q := pageQuery{order: "asc", limit: defaultLimit}
+
+	switch order := query.Get("order"); order {
+	case "":
+	case "asc", "desc":
+		q.order = order
+	default:
+		p := invalidField("order", "order: invalid value")
+		return q, &p
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		var err error
+		q.limit, err = strconv.Atoi(limit)
+		if err != nil || q.limit <= 0 || q.limit > maxLimit {
+			p := invalidField("limit", fmt.Sprintf("limit: must be between 1 and %d", maxLimit))
+			return q, &p
+		}
+	}
+
+	switch cursor := query.Get("cursor"); cursor {
+	case "":
+		if q.order == "desc" {
+			q.cursor = math.MaxInt64
+		}
+	case "now":
+		s.mutex.Lock()
+		q.cursor = toid(s.ledger, s.txCount+1, 0) - 1
+		s.mutex.Unlock()
+	default:
+		var err error
+		q.cursor, err = strconv.ParseInt(cursor, 10, 64)
+		if err != nil || q.cursor < 0 {
+			p := invalidField("cursor", "cursor: must be a positive integer or now")
+			return q, &p
+		}
+	}
+
+	switch includeFailed := query.Get("include_failed"); includeFailed {
+	case "", "false":
+	case "true":
+		q.includeFailed = true
+	default:
+		p := invalidField("include_failed", "include_failed: must be true or false")
+		return q, &p
+	}
+	return q, nil
+}
+
+// selectRecords returns the page of records for the query.
+func selectRecords(records []*record, q pageQuery, keep func(*record) bool) []*record {
+	var page []*record
+	if q.order == "desc" {
+		for i := len(records) - 1; i >= 0 && len(page) < q.limit; i-- {
+			if records[i].id < q.cursor && keep(records[i]) {
+				page = append(page, records[i])
+			}
+		}
+		return page
+	}
+	for i := 0; i < len(records) && len(page) < q.limit; i++ {
+		if records[i].id > q.cursor && keep(records[i]) {
+			page = append(page, records[i])
+		}
+	}
+	return page
+}
+
+// collection returns a handler serving the records selected by the filters,
+// as a page or as a stream of server-sent events if the request accepts
+// them.
+func (s *Server) collection(records func() []*record, filters ...filter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, p := s.parsePageQuery(r)
+		if p != nil {
+			renderProblem(w, *p)
+			return
+		}
+		keep := func(rec *record) bool {
+			if !rec.successful && !q.includeFailed {
+				return false
+			}
+			for _, f := range filters {
+				if !f(r, rec) {
+					return false
+				}
+			}
+			return true
+		}
+
+		if r.Header.Get("Accept") == "text/event-stream" {
+			s.stream(w, r, records, q, keep)
+			return
+		}
+
+		s.mutex.Lock()
+		selected := selectRecords(records(), q, keep)
+		s.mutex.Unlock()
+
+		page := hal.Page{
+			Order:  q.order,
+			Limit:  uint64(q.limit),
+			Cursor: r.URL.Query().Get("cursor"),
+		}
+		fullURL := *r.URL
+		fullURL.Scheme = "http"
+		fullURL.Host = r.Host
+		page.FullURL = &fullURL
+		for _, rec := range selected {
+			page.Add(rec.resource)
+		}
+		page.PopulateLinks()
+		renderJSON(w, http.StatusOK, page)
+	}
+}
+
+// stream sends the records selected by the query as server-sent events, and
+// then the new ones as they are added to the ledger, until the request or
+// the server is closed.
+func (s *Server) stream(w http.ResponseWriter, r *http.Request, records func() []*record, q pageQuery, keep func(*record) bool) {
+	w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, "retry: 1000\nevent: open\ndata: \"hello\"\n\n")
+	q.order = "asc"
+
+	for {
+		s.mutex.Lock()
+		selected := selectRecords(records(), q, keep)
+		updated, done := s.updated, s.done
+		s.mutex.Unlock()
+
+		for _, rec := range selected {
+			data, err := json.Marshal(rec.resource)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Fprintf(w, "id: %s\ndata: %s\n\n", rec.resource.PagingToken(), data)
+			q.cursor = rec.id
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if len(selected) == q.limit {
+			continue
+		}
+
+		select {
+		case <-updated:
+		case <-done:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
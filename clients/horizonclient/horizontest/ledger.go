@@ -0,0 +1,655 @@
+package horizontest
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/base"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// rootBalance is RootBalance in stroops.
+	rootBalance = 100000000000 * 10000000
+	// baseReserve is the reserve of each ledger entry, in stroops.
+	baseReserve = 5000000
+	// ledgerCapacity is the number of operations that fit in a ledger.
+	ledgerCapacity = 1000
+)
+
+// account is an account of the ledger.
+type account struct {
+	sequence     int64
+	balance      int64
+	data         map[string][]byte
+	lastModified uint32
+}
+
+func (a *account) minBalance() int64 {
+	return (2 + int64(len(a.data))) * baseReserve
+}
+
+func (a *account) clone() *account {
+	c := *a
+	c.data = make(map[string][]byte, len(a.data))
+	for name, value := range a.data {
+		c.data[name] = value
+	}
+	return &c
+}
+
+// record is a transaction or an operation of the ledger, with what is needed
+// to filter and page through them.
+type record struct {
+	id           int64
+	successful   bool
+	payment      bool
+	hashes       []string
+	participants map[string]bool
+	resource     interface{ PagingToken() string }
+}
+
+// fee is the fee charged and the maximum fee of a transaction, per
+// operation.
+type fee struct {
+	charged    int64
+	max        int64
+	operations int
+}
+
+// toid returns the id of an operation, or of a transaction when op is 0, the
+// same way Horizon does.
+func toid(ledger uint32, tx, op int) int64 {
+	return int64(ledger)<<32 | int64(tx)<<12 | int64(op)
+}
+
+var (
+	transactionMalformed = problem.P{
+		Type:   "https://stellar.org/horizon-errors/transaction_malformed",
+		Title:  "Transaction Malformed",
+		Status: http.StatusBadRequest,
+		Detail: "Horizon could not decode the transaction envelope in this " +
+			"request. A transaction should be an XDR TransactionEnvelope " +
+			"struct encoded using base64.",
+	}
+	transactionFailed = problem.P{
+		Type:   "https://stellar.org/horizon-errors/transaction_failed",
+		Title:  "Transaction Failed",
+		Status: http.StatusBadRequest,
+		Detail: "The transaction failed when submitted to the stellar network. " +
+			"The `extras.result_codes` field on this response contains further " +
+			"details.",
+	}
+)
+
+// transactionCodes maps transaction result codes to the strings used by
+// Horizon.
+var transactionCodes = map[xdr.TransactionResultCode]string{
+	xdr.TransactionResultCodeTxFeeBumpInnerSuccess: "tx_fee_bump_inner_success",
+	xdr.TransactionResultCodeTxFeeBumpInnerFailed:  "tx_fee_bump_inner_failed",
+	xdr.TransactionResultCodeTxSuccess:             "tx_success",
+	xdr.TransactionResultCodeTxFailed:              "tx_failed",
+	xdr.TransactionResultCodeTxTooEarly:            "tx_too_early",
+	xdr.TransactionResultCodeTxTooLate:             "tx_too_late",
+	xdr.TransactionResultCodeTxMissingOperation:    "tx_missing_operation",
+	xdr.TransactionResultCodeTxBadSeq:              "tx_bad_seq",
+	xdr.TransactionResultCodeTxBadAuth:             "tx_bad_auth",
+	xdr.TransactionResultCodeTxInsufficientBalance: "tx_insufficient_balance",
+	xdr.TransactionResultCodeTxNoAccount:           "tx_no_source_account",
+	xdr.TransactionResultCodeTxInsufficientFee:     "tx_insufficient_fee",
+}
+
+// transactionResult returns the result of a transaction. If the transaction
+// is wrapped in a fee bump transaction, innerHash is the hash of the inner
+// transaction and the result is wrapped in the one of the fee bump
+// transaction.
+func transactionResult(feeCharged int64, code xdr.TransactionResultCode, results []xdr.OperationResult, innerHash *xdr.Hash) xdr.TransactionResult {
+	var opResults *[]xdr.OperationResult
+	if code == xdr.TransactionResultCodeTxSuccess || code == xdr.TransactionResultCodeTxFailed {
+		opResults = &results
+	}
+	if innerHash == nil {
+		return xdr.TransactionResult{
+			FeeCharged: xdr.Int64(feeCharged),
+			Result:     xdr.TransactionResultResult{Code: code, Results: opResults},
+		}
+	}
+
+	outerCode := xdr.TransactionResultCodeTxFeeBumpInnerFailed
+	if code == xdr.TransactionResultCodeTxSuccess {
+		outerCode = xdr.TransactionResultCodeTxFeeBumpInnerSuccess
+	}
+	return xdr.TransactionResult{
+		FeeCharged: xdr.Int64(feeCharged),
+		Result: xdr.TransactionResultResult{
+			Code: outerCode,
+			InnerResultPair: &xdr.InnerTransactionResultPair{
+				TransactionHash: *innerHash,
+				Result: xdr.InnerTransactionResult{
+					Result: xdr.InnerTransactionResultResult{Code: code, Results: opResults},
+				},
+			},
+		},
+	}
+}
+
+// failure returns the problem Horizon responds with when a submitted
+// transaction fails.
+func failure(envelopeXDR string, result xdr.TransactionResult, opCodes []string) *problem.P {
+	resultXDR, err := xdr.MarshalBase64(result)
+	if err != nil {
+		panic(err)
+	}
+	p := transactionFailed
+	p.Extras = map[string]interface{}{
+		"envelope_xdr": envelopeXDR,
+		"result_xdr":   resultXDR,
+		"result_codes": hProtocol.TransactionResultCodes{
+			TransactionCode: transactionCodes[result.Result.Code],
+			OperationCodes:  opCodes,
+		},
+	}
+	return &p
+}
+
+// signedBy reports whether the master key of the account signed the
+// transaction with the given hash.
+func signedBy(accountID string, hash [32]byte, signatures []xdr.DecoratedSignature) bool {
+	kp, err := keypair.ParseAddress(accountID)
+	if err != nil {
+		return false
+	}
+	hint := kp.Hint()
+	for _, signature := range signatures {
+		if signature.Hint == xdr.SignatureHint(hint) && kp.Verify(hash[:], signature.Signature) == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// checkTransaction makes the checks stellar-core makes before applying a
+// transaction. The fee is not checked when the transaction is wrapped in a
+// fee bump transaction, which pays it instead.
+func (s *Server) checkTransaction(tx *txnbuild.Transaction, hash [32]byte, checkAuth, checkFee bool) xdr.TransactionResultCode {
+	now := time.Now().Unix()
+	timebounds := tx.Timebounds()
+	source := tx.SourceAccount()
+	a := s.accounts[source.AccountID]
+	fee := s.baseFee * int64(len(tx.Operations()))
+
+	switch {
+	case len(tx.Operations()) == 0:
+		return xdr.TransactionResultCodeTxMissingOperation
+	case timebounds.MinTime > now:
+		return xdr.TransactionResultCodeTxTooEarly
+	case timebounds.MaxTime != 0 && timebounds.MaxTime < now:
+		return xdr.TransactionResultCodeTxTooLate
+	case checkFee && tx.MaxFee() < fee:
+		return xdr.TransactionResultCodeTxInsufficientFee
+	case a == nil:
+		return xdr.TransactionResultCodeTxNoAccount
+	case source.Sequence != a.sequence+1:
+		return xdr.TransactionResultCodeTxBadSeq
+	case checkAuth && !signedBy(source.AccountID, hash, tx.Signatures()):
+		return xdr.TransactionResultCodeTxBadAuth
+	case checkFee && a.balance-fee < a.minBalance():
+		return xdr.TransactionResultCodeTxInsufficientBalance
+	}
+	return xdr.TransactionResultCodeTxSuccess
+}
+
+// checkFeeBump makes the checks stellar-core makes on the fee account of a fee
+// bump transaction.
+func (s *Server) checkFeeBump(tx *txnbuild.FeeBumpTransaction, hash [32]byte, checkAuth bool) xdr.TransactionResultCode {
+	a := s.accounts[tx.FeeAccount()]
+	fee := s.baseFee * int64(len(tx.InnerTransaction().Operations())+1)
+
+	switch {
+	case tx.MaxFee() < fee:
+		return xdr.TransactionResultCodeTxInsufficientFee
+	case a == nil:
+		return xdr.TransactionResultCodeTxNoAccount
+	case checkAuth && !signedBy(tx.FeeAccount(), hash, tx.Signatures()):
+		return xdr.TransactionResultCodeTxBadAuth
+	case a.balance-fee < a.minBalance():
+		return xdr.TransactionResultCodeTxInsufficientBalance
+	}
+	return xdr.TransactionResultCodeTxSuccess
+}
+
+// submit applies a transaction envelope to the ledger and returns its
+// resource, or the problem Horizon responds with if it fails. Signatures are
+// only checked if checkAuth is true. The caller must hold the mutex.
+func (s *Server) submit(envelopeXDR string, checkAuth bool) (hProtocol.Transaction, *problem.P) {
+	malformed := transactionMalformed
+	malformed.Extras = map[string]interface{}{"envelope_xdr": envelopeXDR}
+
+	generic, err := txnbuild.TransactionFromXDR(envelopeXDR)
+	if err != nil {
+		return hProtocol.Transaction{}, &malformed
+	}
+	tx, ok := generic.Transaction()
+	feeBump, isFeeBump := generic.FeeBump()
+	if isFeeBump {
+		tx = feeBump.InnerTransaction()
+	} else if !ok {
+		return hProtocol.Transaction{}, &malformed
+	}
+	hash, err := tx.Hash(s.NetworkPassphrase)
+	if err != nil {
+		return hProtocol.Transaction{}, &malformed
+	}
+
+	ops := tx.Operations()
+	source := tx.SourceAccount().AccountID
+	feeAccount := source
+	feeCharged := s.baseFee * int64(len(ops))
+	maxFee := tx.MaxFee()
+	outerHash := hash
+	var innerHash *xdr.Hash
+	if isFeeBump {
+		outerHash, err = feeBump.Hash(s.NetworkPassphrase)
+		if err != nil {
+			return hProtocol.Transaction{}, &malformed
+		}
+		innerHash = (*xdr.Hash)(&hash)
+		feeAccount = feeBump.FeeAccount()
+		feeCharged = s.baseFee * int64(len(ops)+1)
+		maxFee = feeBump.MaxFee()
+
+		if code := s.checkFeeBump(feeBump, outerHash, checkAuth); code != xdr.TransactionResultCodeTxSuccess {
+			return hProtocol.Transaction{}, failure(envelopeXDR, transactionResult(0, code, nil, nil), nil)
+		}
+	}
+	if code := s.checkTransaction(tx, hash, checkAuth, !isFeeBump); code != xdr.TransactionResultCodeTxSuccess {
+		return hProtocol.Transaction{}, failure(envelopeXDR, transactionResult(0, code, nil, innerHash), nil)
+	}
+
+	// The fee and the sequence number are used even if an operation fails.
+	s.accounts[feeAccount].balance -= feeCharged
+	s.accounts[feeAccount].lastModified = s.ledger
+	s.accounts[source].sequence = tx.SourceAccount().Sequence
+	s.accounts[source].lastModified = s.ledger
+
+	accounts := make(map[string]*account, len(s.accounts))
+	for id, a := range s.accounts {
+		accounts[id] = a.clone()
+	}
+	results := make([]xdr.OperationResult, len(ops))
+	opCodes := make([]string, len(ops))
+	successful := true
+	for i, op := range ops {
+		opSource := source
+		if op.GetSourceAccount() != nil {
+			opSource = op.GetSourceAccount().GetAccountID()
+		}
+		switch {
+		case accounts[opSource] == nil:
+			results[i], opCodes[i] = xdr.OperationResult{Code: xdr.OperationResultCodeOpNoAccount}, "op_no_source_account"
+		case checkAuth && opSource != source && !signedBy(opSource, hash, tx.Signatures()):
+			results[i], opCodes[i] = xdr.OperationResult{Code: xdr.OperationResultCodeOpBadAuth}, "op_bad_auth"
+		default:
+			results[i], opCodes[i] = s.applyOperation(accounts, opSource, op)
+		}
+		successful = successful && opCodes[i] == "op_success"
+	}
+	code := xdr.TransactionResultCodeTxSuccess
+	if successful {
+		s.accounts = accounts
+	} else {
+		code = xdr.TransactionResultCodeTxFailed
+	}
+	result := transactionResult(feeCharged, code, results, innerHash)
+
+	resource := s.recordTransaction(tx, feeBump, envelopeXDR, result, outerHash, hash)
+	for i, op := range ops {
+		s.recordOperation(resource, i, op)
+	}
+	n := int64(len(ops))
+	if isFeeBump {
+		n++
+	}
+	s.fees = append(s.fees, fee{charged: feeCharged / n, max: maxFee / n, operations: len(ops)})
+
+	close(s.updated)
+	s.updated = make(chan struct{})
+
+	if !successful {
+		return resource, failure(envelopeXDR, result, opCodes)
+	}
+	return resource, nil
+}
+
+// applyOperation applies an operation to the accounts and returns its result
+// and result code.
+func (s *Server) applyOperation(accounts map[string]*account, source string, op txnbuild.Operation) (xdr.OperationResult, string) {
+	src := accounts[source]
+	src.lastModified = s.ledger
+
+	switch op := op.(type) {
+	case *txnbuild.CreateAccount:
+		startingBalance, err := amount.ParseInt64(op.Amount)
+		code := xdr.CreateAccountResultCodeCreateAccountSuccess
+		result := "op_success"
+		switch {
+		case err != nil || startingBalance <= 0:
+			code, result = xdr.CreateAccountResultCodeCreateAccountMalformed, "op_malformed"
+		case accounts[op.Destination] != nil:
+			code, result = xdr.CreateAccountResultCodeCreateAccountAlreadyExist, "op_already_exists"
+		case startingBalance < 2*baseReserve:
+			code, result = xdr.CreateAccountResultCodeCreateAccountLowReserve, "op_low_reserve"
+		case src.balance-startingBalance < src.minBalance():
+			code, result = xdr.CreateAccountResultCodeCreateAccountUnderfunded, "op_underfunded"
+		default:
+			src.balance -= startingBalance
+			accounts[op.Destination] = &account{
+				sequence:     int64(s.ledger) << 32,
+				balance:      startingBalance,
+				data:         map[string][]byte{},
+				lastModified: s.ledger,
+			}
+		}
+		return opResult(xdr.OperationTypeCreateAccount, xdr.CreateAccountResult{Code: code}), result
+
+	case *txnbuild.Payment:
+		paid, err := amount.ParseInt64(op.Amount)
+		dest := accounts[op.Destination]
+		code := xdr.PaymentResultCodePaymentSuccess
+		result := "op_success"
+		switch {
+		case op.Asset == nil || !op.Asset.IsNative():
+			return xdr.OperationResult{Code: xdr.OperationResultCodeOpNotSupported}, "op_not_supported"
+		case err != nil || paid <= 0:
+			code, result = xdr.PaymentResultCodePaymentMalformed, "op_malformed"
+		case dest == nil:
+			code, result = xdr.PaymentResultCodePaymentNoDestination, "op_no_destination"
+		case src.balance-paid < src.minBalance():
+			code, result = xdr.PaymentResultCodePaymentUnderfunded, "op_underfunded"
+		default:
+			src.balance -= paid
+			dest.balance += paid
+			dest.lastModified = s.ledger
+		}
+		return opResult(xdr.OperationTypePayment, xdr.PaymentResult{Code: code}), result
+
+	case *txnbuild.AccountMerge:
+		dest := accounts[op.Destination]
+		mergeResult := xdr.AccountMergeResult{Code: xdr.AccountMergeResultCodeAccountMergeSuccess}
+		result := "op_success"
+		switch {
+		case op.Destination == source:
+			mergeResult.Code, result = xdr.AccountMergeResultCodeAccountMergeMalformed, "op_malformed"
+		case dest == nil:
+			mergeResult.Code, result = xdr.AccountMergeResultCodeAccountMergeNoAccount, "op_no_account"
+		case len(src.data) > 0:
+			mergeResult.Code, result = xdr.AccountMergeResultCodeAccountMergeHasSubEntries, "op_has_sub_entries"
+		default:
+			balance := xdr.Int64(src.balance)
+			mergeResult.SourceAccountBalance = &balance
+			dest.balance += src.balance
+			dest.lastModified = s.ledger
+			delete(accounts, source)
+		}
+		return opResult(xdr.OperationTypeAccountMerge, mergeResult), result
+
+	case *txnbuild.ManageData:
+		_, exists := src.data[op.Name]
+		code := xdr.ManageDataResultCodeManageDataSuccess
+		result := "op_success"
+		switch {
+		case op.Name == "" || len(op.Name) > 64:
+			code, result = xdr.ManageDataResultCodeManageDataInvalidName, "op_data_invalid_name"
+		case op.Value == nil && !exists:
+			code, result = xdr.ManageDataResultCodeManageDataNameNotFound, "op_data_name_not_found"
+		case op.Value != nil && !exists && src.balance < src.minBalance()+baseReserve:
+			code, result = xdr.ManageDataResultCodeManageDataLowReserve, "op_low_reserve"
+		case op.Value == nil:
+			delete(src.data, op.Name)
+		default:
+			src.data[op.Name] = op.Value
+		}
+		return opResult(xdr.OperationTypeManageData, xdr.ManageDataResult{Code: code}), result
+
+	case *txnbuild.BumpSequence:
+		code := xdr.BumpSequenceResultCodeBumpSequenceSuccess
+		result := "op_success"
+		switch {
+		case op.BumpTo < 0:
+			code, result = xdr.BumpSequenceResultCodeBumpSequenceBadSeq, "op_bad_seq"
+		case op.BumpTo > src.sequence:
+			src.sequence = op.BumpTo
+		}
+		return opResult(xdr.OperationTypeBumpSequence, xdr.BumpSequenceResult{Code: code}), result
+	}
+
+	return xdr.OperationResult{Code: xdr.OperationResultCodeOpNotSupported}, "op_not_supported"
+}
+
+// opResult wraps the result of an operation of the given type.
+func opResult(opType xdr.OperationType, result interface{}) xdr.OperationResult {
+	tr, err := xdr.NewOperationResultTr(opType, result)
+	if err != nil {
+		panic(err)
+	}
+	return xdr.OperationResult{Code: xdr.OperationResultCodeOpInner, Tr: &tr}
+}
+
+// recordTransaction adds an applied transaction to the ledger history and
+// returns its resource.
+func (s *Server) recordTransaction(tx *txnbuild.Transaction, feeBump *txnbuild.FeeBumpTransaction, envelopeXDR string, result xdr.TransactionResult, hash, innerHash [32]byte) hProtocol.Transaction {
+	s.txCount++
+	id := toid(s.ledger, s.txCount, 0)
+	resultXDR, err := xdr.MarshalBase64(result)
+	if err != nil {
+		panic(err)
+	}
+	successful := result.Successful()
+
+	resource := hProtocol.Transaction{
+		ID:              hex.EncodeToString(hash[:]),
+		PT:              strconv.FormatInt(id, 10),
+		Successful:      successful,
+		Hash:            hex.EncodeToString(hash[:]),
+		Ledger:          int32(s.ledger),
+		LedgerCloseTime: time.Now().UTC().Truncate(time.Second),
+		Account:         tx.SourceAccount().AccountID,
+		AccountSequence: strconv.FormatInt(tx.SourceAccount().Sequence, 10),
+		FeeAccount:      tx.SourceAccount().AccountID,
+		FeeCharged:      int64(result.FeeCharged),
+		MaxFee:          tx.MaxFee(),
+		OperationCount:  int32(len(tx.Operations())),
+		EnvelopeXdr:     envelopeXDR,
+		ResultXdr:       resultXDR,
+		Signatures:      signatures(tx.Signatures()),
+	}
+	resource.Links.Self.Href = s.URL + "/transactions/" + resource.Hash
+	resource.Links.Account.Href = s.URL + "/accounts/" + resource.Account
+	resource.Links.Operations.Href = s.URL + "/transactions/" + resource.Hash + "/operations"
+	resource.Links.Transaction = resource.Links.Self
+	if timebounds := tx.Timebounds(); timebounds.MinTime != 0 || timebounds.MaxTime != 0 {
+		resource.ValidAfter = time.Unix(timebounds.MinTime, 0).UTC().Format(time.RFC3339)
+		if timebounds.MaxTime != 0 {
+			resource.ValidBefore = time.Unix(timebounds.MaxTime, 0).UTC().Format(time.RFC3339)
+		}
+	}
+	setMemo(&resource, tx.Memo())
+
+	hashes := []string{resource.Hash}
+	if feeBump != nil {
+		resource.FeeAccount = feeBump.FeeAccount()
+		resource.MaxFee = feeBump.MaxFee()
+		resource.Signatures = signatures(feeBump.Signatures())
+		resource.FeeBumpTransaction = &hProtocol.FeeBumpTransaction{
+			Hash:       resource.Hash,
+			Signatures: resource.Signatures,
+		}
+		resource.InnerTransaction = &hProtocol.InnerTransaction{
+			Hash:       hex.EncodeToString(innerHash[:]),
+			Signatures: signatures(tx.Signatures()),
+			MaxFee:     tx.MaxFee(),
+		}
+		hashes = append(hashes, resource.InnerTransaction.Hash)
+	}
+
+	participants := map[string]bool{resource.Account: true, resource.FeeAccount: true}
+	for _, op := range tx.Operations() {
+		for _, participant := range opParticipants(resource.Account, op) {
+			participants[participant] = true
+		}
+	}
+	s.transactions = append(s.transactions, &record{
+		id:           id,
+		successful:   successful,
+		hashes:       hashes,
+		participants: participants,
+		resource:     resource,
+	})
+	return resource
+}
+
+// recordOperation adds the operation with the given index in an applied
+// transaction to the ledger history.
+func (s *Server) recordOperation(tx hProtocol.Transaction, index int, op txnbuild.Operation) {
+	participants := opParticipants(tx.Account, op)
+	xdrOp, err := op.BuildXDR()
+	if err != nil {
+		panic(err)
+	}
+	opType := xdrOp.Body.Type
+	id := toid(s.ledger, s.txCount, index+1)
+
+	b := operations.Base{
+		ID:                    strconv.FormatInt(id, 10),
+		PT:                    strconv.FormatInt(id, 10),
+		TransactionSuccessful: tx.Successful,
+		SourceAccount:         participants[0],
+		Type:                  operations.TypeNames[opType],
+		TypeI:                 int32(opType),
+		LedgerCloseTime:       tx.LedgerCloseTime,
+		TransactionHash:       tx.Hash,
+	}
+	b.Links.Self.Href = s.URL + "/operations/" + b.ID
+	b.Links.Transaction.Href = tx.Links.Self.Href
+
+	var resource operations.Operation = b
+	payment := false
+	switch op := op.(type) {
+	case *txnbuild.CreateAccount:
+		resource = operations.CreateAccount{
+			Base:            b,
+			StartingBalance: op.Amount,
+			Funder:          b.SourceAccount,
+			Account:         op.Destination,
+		}
+		payment = true
+	case *txnbuild.Payment:
+		p := operations.Payment{
+			Base:   b,
+			From:   b.SourceAccount,
+			To:     op.Destination,
+			Amount: op.Amount,
+		}
+		if op.Asset != nil {
+			p.Asset = base.Asset{Type: "native"}
+			if !op.Asset.IsNative() {
+				p.Asset = base.Asset{Code: op.Asset.GetCode(), Issuer: op.Asset.GetIssuer(), Type: "credit_alphanum4"}
+				if len(p.Asset.Code) > 4 {
+					p.Asset.Type = "credit_alphanum12"
+				}
+			}
+		}
+		resource = p
+		payment = true
+	case *txnbuild.AccountMerge:
+		resource = operations.AccountMerge{
+			Base:    b,
+			Account: b.SourceAccount,
+			Into:    op.Destination,
+		}
+		payment = true
+	case *txnbuild.ManageData:
+		resource = operations.ManageData{
+			Base:  b,
+			Name:  op.Name,
+			Value: base64.StdEncoding.EncodeToString(op.Value),
+		}
+	case *txnbuild.BumpSequence:
+		resource = operations.BumpSequence{
+			Base:   b,
+			BumpTo: strconv.FormatInt(op.BumpTo, 10),
+		}
+	}
+
+	opRecord := &record{
+		id:           id,
+		successful:   tx.Successful,
+		payment:      payment,
+		hashes:       []string{tx.Hash},
+		participants: map[string]bool{},
+		resource:     resource,
+	}
+	if tx.InnerTransaction != nil {
+		opRecord.hashes = append(opRecord.hashes, tx.InnerTransaction.Hash)
+	}
+	for _, participant := range participants {
+		opRecord.participants[participant] = true
+	}
+	s.operations = append(s.operations, opRecord)
+}
+
+// opParticipants returns the accounts taking part in an operation, its source
+// account first.
+func opParticipants(txSource string, op txnbuild.Operation) []string {
+	participants := []string{txSource}
+	if op.GetSourceAccount() != nil {
+		participants[0] = op.GetSourceAccount().GetAccountID()
+	}
+	switch op := op.(type) {
+	case *txnbuild.CreateAccount:
+		participants = append(participants, op.Destination)
+	case *txnbuild.Payment:
+		participants = append(participants, op.Destination)
+	case *txnbuild.AccountMerge:
+		participants = append(participants, op.Destination)
+	}
+	return participants
+}
+
+func signatures(decorated []xdr.DecoratedSignature) []string {
+	encoded := make([]string, len(decorated))
+	for i, signature := range decorated {
+		encoded[i] = base64.StdEncoding.EncodeToString(signature.Signature)
+	}
+	return encoded
+}
+
+// setMemo sets the memo of a transaction resource the way Horizon renders it.
+func setMemo(resource *hProtocol.Transaction, memo txnbuild.Memo) {
+	switch memo := memo.(type) {
+	case txnbuild.MemoText:
+		resource.MemoType = "text"
+		resource.Memo = string(memo)
+		resource.MemoBytes = base64.StdEncoding.EncodeToString([]byte(memo))
+	case txnbuild.MemoID:
+		resource.MemoType = "id"
+		resource.Memo = strconv.FormatUint(uint64(memo), 10)
+	case txnbuild.MemoHash:
+		resource.MemoType = "hash"
+		resource.Memo = base64.StdEncoding.EncodeToString(memo[:])
+	case txnbuild.MemoReturn:
+		resource.MemoType = "return"
+		resource.Memo = base64.StdEncoding.EncodeToString(memo[:])
+	default:
+		resource.MemoType = "none"
+	}
+}
@@ -0,0 +1,189 @@
+// Package horizontest provides a fake Horizon server for testing code which
+// uses horizonclient end to end, without a network.
+//
+// A Server runs an httptest.Server which implements the main Horizon routes:
+// the root resource, accounts and their data entries, transaction submission
+// and lookup, operations, payments and fee stats. The transactions,
+// operations and payments collections can be paged through and streamed
+// like the real ones.
+//
+// The routes are backed by an in-memory ledger which can be scripted with
+// CreateAccount, Pay and Apply, and advanced with CloseLedger. Transactions
+// submitted over HTTP are checked and applied like stellar-core would, with
+// these simplifications:
+//
+//   - Only lumen balances and data entries are modelled, and the master key
+//     of each account is its only signer.
+//   - Only the create account, payment, account merge, manage data and bump
+//     sequence operations are supported, other operations fail with
+//     op_not_supported.
+//   - Transactions are applied as soon as they are submitted, and belong to
+//     the current ledger until CloseLedger is called.
+package horizontest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
+)
+
+// RootBalance is the balance of the root account of a new Server, in lumens.
+const RootBalance = "100000000000"
+
+// Server is a fake Horizon server backed by an in-memory ledger. It is safe
+// for concurrent use.
+type Server struct {
+	*httptest.Server
+	NetworkPassphrase string
+
+	mutex        sync.Mutex
+	root         *keypair.Full
+	ledger       uint32
+	baseFee      int64
+	accounts     map[string]*account
+	transactions []*record
+	operations   []*record
+	txCount      int
+	fees         []fee
+	lastFees     []fee
+	updated      chan struct{}
+	done         chan struct{}
+}
+
+// NewServer starts a Server for the network with the given passphrase. Its
+// ledger holds the root account of the network, funded with RootBalance
+// lumens, and a base fee of txnbuild.MinBaseFee.
+func NewServer(networkPassphrase string) *Server {
+	root := keypair.Master(networkPassphrase).(*keypair.Full)
+	s := &Server{
+		NetworkPassphrase: networkPassphrase,
+		root:              root,
+		ledger:            2,
+		baseFee:           txnbuild.MinBaseFee,
+		accounts: map[string]*account{
+			root.Address(): {
+				balance: rootBalance,
+				data:    map[string][]byte{},
+			},
+		},
+		updated: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	s.Server = httptest.NewServer(s.router())
+	return s
+}
+
+// Close closes the streams which are open and shuts down the server.
+func (s *Server) Close() {
+	s.mutex.Lock()
+	select {
+	case <-s.done:
+	default:
+		close(s.done)
+	}
+	s.mutex.Unlock()
+	s.Server.Close()
+}
+
+// Client returns a horizonclient.Client for the server.
+func (s *Server) Client() *horizonclient.Client {
+	return &horizonclient.Client{HorizonURL: s.URL}
+}
+
+// Root returns the keypair of the root account of the network.
+func (s *Server) Root() *keypair.Full {
+	return s.root
+}
+
+// Ledger returns the sequence number of the current ledger, which the
+// transactions applied until the next call to CloseLedger belong to.
+func (s *Server) Ledger() uint32 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.ledger
+}
+
+// CloseLedger closes the current ledger and returns the sequence number of
+// the next one. The fee stats are computed from the transactions of the last
+// closed ledger.
+func (s *Server) CloseLedger() uint32 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.lastFees = s.fees
+	s.fees = nil
+	s.txCount = 0
+	s.ledger++
+	return s.ledger
+}
+
+// SetBaseFee sets the base fee of the network, in stroops. It must be at
+// least txnbuild.MinBaseFee.
+func (s *Server) SetBaseFee(baseFee int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.baseFee = baseFee
+}
+
+// CreateAccount creates an account funded by the root account with the given
+// starting balance, in lumens.
+func (s *Server) CreateAccount(accountID, startingBalance string) (hProtocol.Transaction, error) {
+	return s.Apply(s.root.Address(), &txnbuild.CreateAccount{
+		Destination: accountID,
+		Amount:      startingBalance,
+	})
+}
+
+// Pay applies a payment of the given amount of lumens between two accounts.
+func (s *Server) Pay(from, to, amount string) (hProtocol.Transaction, error) {
+	return s.Apply(from, &txnbuild.Payment{
+		Destination: to,
+		Amount:      amount,
+		Asset:       txnbuild.NativeAsset{},
+	})
+}
+
+// Apply applies a transaction with the given operations to the ledger,
+// without checking its signatures. The transaction uses the next sequence
+// number of the source account and pays the base fee. If the transaction
+// fails the error is a *horizonclient.Error, as if it had been submitted.
+func (s *Server) Apply(sourceAccount string, ops ...txnbuild.Operation) (hProtocol.Transaction, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var sequence int64
+	if a, ok := s.accounts[sourceAccount]; ok {
+		sequence = a.sequence
+	}
+	source := txnbuild.NewSimpleAccount(sourceAccount, sequence)
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &source,
+			IncrementSequenceNum: true,
+			Operations:           ops,
+			BaseFee:              s.baseFee,
+			Timebounds:           txnbuild.NewInfiniteTimeout(),
+		},
+	)
+	if err != nil {
+		return hProtocol.Transaction{}, errors.Wrap(err, "failed to build transaction")
+	}
+	envelope, err := tx.Base64()
+	if err != nil {
+		return hProtocol.Transaction{}, errors.Wrap(err, "failed to encode transaction")
+	}
+
+	resp, p := s.submit(envelope, false)
+	if p != nil {
+		return resp, &horizonclient.Error{
+			Response: &http.Response{StatusCode: p.Status},
+			Problem:  *p,
+		}
+	}
+	return resp, nil
+}
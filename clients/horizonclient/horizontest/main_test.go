@@ -0,0 +1,323 @@
+package horizontest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/clients/horizonclient/txbuilder"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAccount(t *testing.T, server *Server, balance string) *keypair.Full {
+	kp := keypair.MustRandom()
+	_, err := server.CreateAccount(kp.Address(), balance)
+	require.NoError(t, err)
+	return kp
+}
+
+func payment(t *testing.T, client *horizonclient.Client, from *keypair.Full, to, amount string) *txnbuild.Transaction {
+	account, err := client.AccountDetail(horizonclient.AccountRequest{AccountID: from.Address()})
+	require.NoError(t, err)
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &account,
+			IncrementSequenceNum: true,
+			Operations: []txnbuild.Operation{
+				&txnbuild.Payment{Destination: to, Amount: amount, Asset: txnbuild.NativeAsset{}},
+			},
+			BaseFee:    txnbuild.MinBaseFee,
+			Timebounds: txnbuild.NewTimeout(300),
+		},
+	)
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, from)
+	require.NoError(t, err)
+	return tx
+}
+
+func resultCodes(t *testing.T, err error) (string, []string) {
+	hErr := horizonclient.GetError(err)
+	require.NotNil(t, hErr, "not a horizon error: %v", err)
+	codes, err := hErr.ResultCodes()
+	require.NoError(t, err)
+	return codes.TransactionCode, codes.OperationCodes
+}
+
+func mustSequence(t *testing.T, account interface{ GetSequenceNumber() (int64, error) }) int64 {
+	sequence, err := account.GetSequenceNumber()
+	require.NoError(t, err)
+	return sequence
+}
+
+func TestAccounts(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+
+	root, err := client.AccountDetail(horizonclient.AccountRequest{AccountID: server.Root().Address()})
+	require.NoError(t, err)
+	balance, err := root.GetNativeBalance()
+	require.NoError(t, err)
+	assert.Equal(t, "100000000000.0000000", balance)
+
+	kp := newAccount(t, server, "100")
+	account, err := client.AccountDetail(horizonclient.AccountRequest{AccountID: kp.Address()})
+	require.NoError(t, err)
+	balance, err = account.GetNativeBalance()
+	require.NoError(t, err)
+	assert.Equal(t, "100.0000000", balance)
+	assert.Equal(t, strconv.FormatInt(int64(server.Ledger())<<32, 10), account.Sequence)
+	assert.Equal(t, kp.Address(), account.Signers[0].Key)
+
+	_, err = server.Apply(kp.Address(), &txnbuild.ManageData{Name: "config.memo_required", Value: []byte("1")})
+	require.NoError(t, err)
+	data, err := client.AccountData(horizonclient.AccountRequest{AccountID: kp.Address(), DataKey: "config.memo_required"})
+	require.NoError(t, err)
+	assert.Equal(t, "MQ==", data.Value)
+
+	_, err = client.AccountDetail(horizonclient.AccountRequest{AccountID: keypair.MustRandom().Address()})
+	assert.True(t, horizonclient.IsNotFoundError(err))
+
+	_, err = server.CreateAccount(kp.Address(), "100")
+	code, opCodes := resultCodes(t, err)
+	assert.Equal(t, "tx_failed", code)
+	assert.Equal(t, []string{"op_already_exists"}, opCodes)
+}
+
+func TestSubmit(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice := newAccount(t, server, "100")
+	bob := newAccount(t, server, "100")
+
+	tx := payment(t, client, alice, bob.Address(), "10")
+	resp, err := client.SubmitTransaction(tx)
+	require.NoError(t, err)
+	assert.True(t, resp.Successful)
+	assert.Equal(t, int64(100), resp.FeeCharged)
+	hash, err := tx.HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	assert.Equal(t, hash, resp.Hash)
+
+	account, err := client.AccountDetail(horizonclient.AccountRequest{AccountID: alice.Address()})
+	require.NoError(t, err)
+	balance, err := account.GetNativeBalance()
+	require.NoError(t, err)
+	assert.Equal(t, "89.9999900", balance)
+	sequence := mustSequence(t, account)
+
+	// The same transaction cannot be submitted twice.
+	_, err = client.SubmitTransaction(tx)
+	code, _ := resultCodes(t, err)
+	assert.Equal(t, "tx_bad_seq", code)
+
+	// Transactions must be signed by their source account.
+	unsigned, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &account,
+			IncrementSequenceNum: true,
+			Operations:           []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 1}},
+			BaseFee:              txnbuild.MinBaseFee,
+			Timebounds:           txnbuild.NewTimeout(300),
+		},
+	)
+	require.NoError(t, err)
+	_, err = client.SubmitTransaction(unsigned)
+	code, _ = resultCodes(t, err)
+	assert.Equal(t, "tx_bad_auth", code)
+
+	// A failed transaction uses its sequence number and pays its fee.
+	_, err = client.SubmitTransaction(payment(t, client, alice, bob.Address(), "1000"))
+	code, opCodes := resultCodes(t, err)
+	assert.Equal(t, "tx_failed", code)
+	assert.Equal(t, []string{"op_underfunded"}, opCodes)
+	failed, err := client.AccountDetail(horizonclient.AccountRequest{AccountID: alice.Address()})
+	require.NoError(t, err)
+	assert.Equal(t, sequence+1, mustSequence(t, failed))
+	balance, err = failed.GetNativeBalance()
+	require.NoError(t, err)
+	assert.Equal(t, "89.9999800", balance)
+}
+
+func TestSubmitFeeBump(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice := newAccount(t, server, "100")
+	sponsor := newAccount(t, server, "100")
+
+	tx := payment(t, client, alice, server.Root().Address(), "10")
+	feeBump, err := txnbuild.NewFeeBumpTransaction(
+		txnbuild.FeeBumpTransactionParams{
+			Inner:      tx,
+			FeeAccount: sponsor.Address(),
+			BaseFee:    txnbuild.MinBaseFee,
+		},
+	)
+	require.NoError(t, err)
+	feeBump, err = feeBump.Sign(network.TestNetworkPassphrase, sponsor)
+	require.NoError(t, err)
+
+	resp, err := client.SubmitFeeBumpTransaction(feeBump)
+	require.NoError(t, err)
+	assert.Equal(t, sponsor.Address(), resp.FeeAccount)
+	assert.Equal(t, int64(200), resp.FeeCharged)
+	innerHash, err := tx.HashHex(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	assert.Equal(t, innerHash, resp.InnerTransaction.Hash)
+
+	// The transaction can be found by its inner hash.
+	found, err := client.TransactionDetail(innerHash)
+	require.NoError(t, err)
+	assert.Equal(t, resp.Hash, found.Hash)
+
+	// Failures of the inner transaction are reported as such.
+	_, err = client.SubmitFeeBumpTransaction(feeBump)
+	code, _ := resultCodes(t, err)
+	assert.Equal(t, "tx_fee_bump_inner_failed", code)
+}
+
+func TestCollections(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice := newAccount(t, server, "100")
+	bob := newAccount(t, server, "100")
+	server.CloseLedger()
+	for i := 0; i < 3; i++ {
+		_, err := server.Pay(alice.Address(), bob.Address(), "1")
+		require.NoError(t, err)
+	}
+	_, err := server.Pay(alice.Address(), bob.Address(), "1000")
+	require.Error(t, err)
+	_, err = server.Apply(bob.Address(), &txnbuild.BumpSequence{BumpTo: 1})
+	require.NoError(t, err)
+
+	page, err := client.Payments(horizonclient.OperationRequest{ForAccount: alice.Address(), Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Embedded.Records, 2)
+	assert.IsType(t, operations.CreateAccount{}, page.Embedded.Records[0])
+	assert.IsType(t, operations.Payment{}, page.Embedded.Records[1])
+	page, err = client.NextPaymentsPage(page)
+	require.NoError(t, err)
+	require.Len(t, page.Embedded.Records, 2)
+	payment := page.Embedded.Records[1].(operations.Payment)
+	assert.Equal(t, bob.Address(), payment.To)
+	assert.Equal(t, "1.0000000", payment.Amount)
+	page, err = client.NextPaymentsPage(page)
+	require.NoError(t, err)
+	assert.Empty(t, page.Embedded.Records)
+
+	ops, err := client.Operations(horizonclient.OperationRequest{ForAccount: bob.Address(), Order: horizonclient.OrderDesc})
+	require.NoError(t, err)
+	require.Len(t, ops.Embedded.Records, 5)
+	assert.Equal(t, "bump_sequence", ops.Embedded.Records[0].GetType())
+
+	txs, err := client.Transactions(horizonclient.TransactionRequest{ForAccount: alice.Address()})
+	require.NoError(t, err)
+	assert.Len(t, txs.Embedded.Records, 4)
+	txs, err = client.Transactions(horizonclient.TransactionRequest{ForAccount: alice.Address(), IncludeFailed: true})
+	require.NoError(t, err)
+	require.Len(t, txs.Embedded.Records, 5)
+	assert.False(t, txs.Embedded.Records[4].Successful)
+	assert.Equal(t, int32(server.Ledger()), txs.Embedded.Records[4].Ledger)
+
+	ops, err = client.Operations(horizonclient.OperationRequest{ForTransaction: txs.Embedded.Records[1].Hash})
+	require.NoError(t, err)
+	require.Len(t, ops.Embedded.Records, 1)
+	assert.Equal(t, txs.Embedded.Records[1].Hash, ops.Embedded.Records[0].GetTransactionHash())
+
+	_, err = client.Operations(horizonclient.OperationRequest{Cursor: "abc"})
+	if assert.Error(t, err) {
+		assert.Equal(t, 400, horizonclient.GetError(err).Response.StatusCode)
+	}
+}
+
+func TestStreamPayments(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+	alice := newAccount(t, server, "100")
+	bob := newAccount(t, server, "100")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var received []operations.Operation
+	done := make(chan error)
+	go func() {
+		done <- client.StreamPayments(ctx, horizonclient.OperationRequest{ForAccount: bob.Address(), Cursor: "0"}, func(op operations.Operation) {
+			received = append(received, op)
+			if len(received) == 3 {
+				cancel()
+			}
+		})
+	}()
+
+	_, err := server.Pay(alice.Address(), bob.Address(), "1")
+	require.NoError(t, err)
+	server.CloseLedger()
+	_, err = server.Pay(bob.Address(), alice.Address(), "2")
+	require.NoError(t, err)
+
+	require.NoError(t, <-done)
+	require.Len(t, received, 3)
+	assert.Equal(t, "create_account", received[0].GetType())
+	assert.Equal(t, "1.0000000", received[1].(operations.Payment).Amount)
+	assert.Equal(t, "2.0000000", received[2].(operations.Payment).Amount)
+}
+
+func TestFeeStats(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	client := server.Client()
+
+	stats, err := client.FeeStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), stats.LastLedgerBaseFee)
+	assert.Equal(t, int64(100), stats.MaxFee.P99)
+
+	server.SetBaseFee(200)
+	for i := 0; i < 4; i++ {
+		newAccount(t, server, "10")
+	}
+	server.CloseLedger()
+	stats, err = client.FeeStats()
+	require.NoError(t, err)
+	assert.Equal(t, server.Ledger()-1, stats.LastLedger)
+	assert.Equal(t, int64(200), stats.LastLedgerBaseFee)
+	assert.Equal(t, int64(200), stats.FeeCharged.P50)
+	assert.Equal(t, 0.004, stats.LedgerCapacityUsage)
+}
+
+func TestTxBuilder(t *testing.T) {
+	server := NewServer(network.TestNetworkPassphrase)
+	defer server.Close()
+	alice := newAccount(t, server, "100")
+	builder := &txbuilder.Builder{
+		Client:            server.Client(),
+		NetworkPassphrase: network.TestNetworkPassphrase,
+	}
+	params := txbuilder.Params{
+		SourceAccount: alice.Address(),
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 0}},
+		Signers:       []*keypair.Full{alice},
+	}
+
+	_, err := builder.Submit(params)
+	require.NoError(t, err)
+
+	// The cached sequence number is refreshed when the account is used
+	// elsewhere.
+	_, err = server.Pay(alice.Address(), server.Root().Address(), "1")
+	require.NoError(t, err)
+	resp, err := builder.Submit(params)
+	require.NoError(t, err)
+	assert.True(t, resp.Successful)
+}
@@ -0,0 +1,727 @@
+package horizonclient
+
+import (
+	"context"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/protocols/horizon/effects"
+	"github.com/stellar/go/protocols/horizon/operations"
+)
+
+// pageIterator walks the records of a paged resource, loading the next page
+// once the records of the current one are used up. It is embedded in the
+// iterators of each resource, which convert its records to their type.
+type pageIterator struct {
+	ctx        context.Context
+	maxRecords int
+	cursor     string
+	// load loads the page at nextURL, or the first page if nextURL is empty,
+	// and returns its records and the URL of the next page.
+	load func(ctx context.Context, nextURL string) ([]interface{}, string, error)
+}
+
+// Cursor returns the paging token of the last record the iteration reached,
+// which can be set as the Cursor of a request to resume from there.
+func (it *pageIterator) Cursor() string {
+	return it.cursor
+}
+
+func (it *pageIterator) each(fn func(record interface{}) error) error {
+	count := 0
+	nextURL := ""
+	for {
+		if err := it.ctx.Err(); err != nil {
+			return err
+		}
+		records, next, err := it.load(it.ctx, nextURL)
+		if err != nil {
+			return err
+		}
+		if len(records) == 0 {
+			return nil
+		}
+
+		for _, record := range records {
+			if it.maxRecords > 0 && count >= it.maxRecords {
+				return nil
+			}
+			count++
+			if pt, ok := record.(interface{ PagingToken() string }); ok {
+				it.cursor = pt.PagingToken()
+			}
+			if err := fn(record); err != nil {
+				return err
+			}
+		}
+
+		if next == "" || next == nextURL {
+			return nil
+		}
+		nextURL = next
+	}
+}
+
+// AccountIterator iterates over accounts, following the links to the next pages.
+type AccountIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n accounts. Zero means no limit.
+func (it *AccountIterator) MaxRecords(n int) *AccountIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every account in order, until there are no more
+// accounts or fn returns an error, which is returned.
+func (it *AccountIterator) Each(fn func(hProtocol.Account) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(hProtocol.Account))
+	})
+}
+
+// Chan sends every account to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *AccountIterator) Chan() (<-chan hProtocol.Account, <-chan error) {
+	records := make(chan hProtocol.Account)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record hProtocol.Account) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateAccounts returns an iterator over the accounts matching the
+// request.
+func (c *Client) IterateAccounts(ctx context.Context, request AccountsRequest) *AccountIterator {
+	return &AccountIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page hProtocol.AccountsPage
+			var err error
+			if nextURL == "" {
+				page, err = c.AccountsContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// AssetIterator iterates over assets, following the links to the next pages.
+type AssetIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n assets. Zero means no limit.
+func (it *AssetIterator) MaxRecords(n int) *AssetIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every asset in order, until there are no more
+// assets or fn returns an error, which is returned.
+func (it *AssetIterator) Each(fn func(hProtocol.AssetStat) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(hProtocol.AssetStat))
+	})
+}
+
+// Chan sends every asset to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *AssetIterator) Chan() (<-chan hProtocol.AssetStat, <-chan error) {
+	records := make(chan hProtocol.AssetStat)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record hProtocol.AssetStat) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateAssets returns an iterator over the assets matching the
+// request.
+func (c *Client) IterateAssets(ctx context.Context, request AssetRequest) *AssetIterator {
+	return &AssetIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page hProtocol.AssetsPage
+			var err error
+			if nextURL == "" {
+				page, err = c.AssetsContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// ClaimableBalanceIterator iterates over claimable balances, following the links to the next pages.
+type ClaimableBalanceIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n claimable balances. Zero means no limit.
+func (it *ClaimableBalanceIterator) MaxRecords(n int) *ClaimableBalanceIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every claimable balance in order, until there are no more
+// claimable balances or fn returns an error, which is returned.
+func (it *ClaimableBalanceIterator) Each(fn func(hProtocol.ClaimableBalance) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(hProtocol.ClaimableBalance))
+	})
+}
+
+// Chan sends every claimable balance to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *ClaimableBalanceIterator) Chan() (<-chan hProtocol.ClaimableBalance, <-chan error) {
+	records := make(chan hProtocol.ClaimableBalance)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record hProtocol.ClaimableBalance) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateClaimableBalances returns an iterator over the claimable balances matching the
+// request.
+func (c *Client) IterateClaimableBalances(ctx context.Context, request ClaimableBalanceRequest) *ClaimableBalanceIterator {
+	return &ClaimableBalanceIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page hProtocol.ClaimableBalances
+			var err error
+			if nextURL == "" {
+				page, err = c.ClaimableBalancesContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// EffectIterator iterates over effects, following the links to the next pages.
+type EffectIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n effects. Zero means no limit.
+func (it *EffectIterator) MaxRecords(n int) *EffectIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every effect in order, until there are no more
+// effects or fn returns an error, which is returned.
+func (it *EffectIterator) Each(fn func(effects.Effect) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(effects.Effect))
+	})
+}
+
+// Chan sends every effect to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *EffectIterator) Chan() (<-chan effects.Effect, <-chan error) {
+	records := make(chan effects.Effect)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record effects.Effect) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateEffects returns an iterator over the effects matching the
+// request.
+func (c *Client) IterateEffects(ctx context.Context, request EffectRequest) *EffectIterator {
+	return &EffectIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page effects.EffectsPage
+			var err error
+			if nextURL == "" {
+				page, err = c.EffectsContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// LedgerIterator iterates over ledgers, following the links to the next pages.
+type LedgerIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n ledgers. Zero means no limit.
+func (it *LedgerIterator) MaxRecords(n int) *LedgerIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every ledger in order, until there are no more
+// ledgers or fn returns an error, which is returned.
+func (it *LedgerIterator) Each(fn func(hProtocol.Ledger) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(hProtocol.Ledger))
+	})
+}
+
+// Chan sends every ledger to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *LedgerIterator) Chan() (<-chan hProtocol.Ledger, <-chan error) {
+	records := make(chan hProtocol.Ledger)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record hProtocol.Ledger) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateLedgers returns an iterator over the ledgers matching the
+// request.
+func (c *Client) IterateLedgers(ctx context.Context, request LedgerRequest) *LedgerIterator {
+	return &LedgerIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page hProtocol.LedgersPage
+			var err error
+			if nextURL == "" {
+				page, err = c.LedgersContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// OfferIterator iterates over offers, following the links to the next pages.
+type OfferIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n offers. Zero means no limit.
+func (it *OfferIterator) MaxRecords(n int) *OfferIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every offer in order, until there are no more
+// offers or fn returns an error, which is returned.
+func (it *OfferIterator) Each(fn func(hProtocol.Offer) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(hProtocol.Offer))
+	})
+}
+
+// Chan sends every offer to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *OfferIterator) Chan() (<-chan hProtocol.Offer, <-chan error) {
+	records := make(chan hProtocol.Offer)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record hProtocol.Offer) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateOffers returns an iterator over the offers matching the
+// request.
+func (c *Client) IterateOffers(ctx context.Context, request OfferRequest) *OfferIterator {
+	return &OfferIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page hProtocol.OffersPage
+			var err error
+			if nextURL == "" {
+				page, err = c.OffersContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// OperationIterator iterates over operations, following the links to the next pages.
+type OperationIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n operations. Zero means no limit.
+func (it *OperationIterator) MaxRecords(n int) *OperationIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every operation in order, until there are no more
+// operations or fn returns an error, which is returned.
+func (it *OperationIterator) Each(fn func(operations.Operation) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(operations.Operation))
+	})
+}
+
+// Chan sends every operation to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *OperationIterator) Chan() (<-chan operations.Operation, <-chan error) {
+	records := make(chan operations.Operation)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record operations.Operation) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateOperations returns an iterator over the operations matching the
+// request.
+func (c *Client) IterateOperations(ctx context.Context, request OperationRequest) *OperationIterator {
+	return &OperationIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page operations.OperationsPage
+			var err error
+			if nextURL == "" {
+				page, err = c.OperationsContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// IteratePayments returns an iterator over the payments matching the
+// request.
+func (c *Client) IteratePayments(ctx context.Context, request OperationRequest) *OperationIterator {
+	return &OperationIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page operations.OperationsPage
+			var err error
+			if nextURL == "" {
+				page, err = c.PaymentsContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// TradeAggregationIterator iterates over trade aggregations, following the links to the next pages.
+type TradeAggregationIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n trade aggregations. Zero means no limit.
+func (it *TradeAggregationIterator) MaxRecords(n int) *TradeAggregationIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every trade aggregation in order, until there are no more
+// trade aggregations or fn returns an error, which is returned.
+func (it *TradeAggregationIterator) Each(fn func(hProtocol.TradeAggregation) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(hProtocol.TradeAggregation))
+	})
+}
+
+// Chan sends every trade aggregation to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *TradeAggregationIterator) Chan() (<-chan hProtocol.TradeAggregation, <-chan error) {
+	records := make(chan hProtocol.TradeAggregation)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record hProtocol.TradeAggregation) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateTradeAggregations returns an iterator over the trade aggregations matching the
+// request.
+func (c *Client) IterateTradeAggregations(ctx context.Context, request TradeAggregationRequest) *TradeAggregationIterator {
+	return &TradeAggregationIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page hProtocol.TradeAggregationsPage
+			var err error
+			if nextURL == "" {
+				page, err = c.TradeAggregationsContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// TradeIterator iterates over trades, following the links to the next pages.
+type TradeIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n trades. Zero means no limit.
+func (it *TradeIterator) MaxRecords(n int) *TradeIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every trade in order, until there are no more
+// trades or fn returns an error, which is returned.
+func (it *TradeIterator) Each(fn func(hProtocol.Trade) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(hProtocol.Trade))
+	})
+}
+
+// Chan sends every trade to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *TradeIterator) Chan() (<-chan hProtocol.Trade, <-chan error) {
+	records := make(chan hProtocol.Trade)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record hProtocol.Trade) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateTrades returns an iterator over the trades matching the
+// request.
+func (c *Client) IterateTrades(ctx context.Context, request TradeRequest) *TradeIterator {
+	return &TradeIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page hProtocol.TradesPage
+			var err error
+			if nextURL == "" {
+				page, err = c.TradesContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
+
+// TransactionIterator iterates over transactions, following the links to the next pages.
+type TransactionIterator struct {
+	pageIterator
+}
+
+// MaxRecords stops the iteration after n transactions. Zero means no limit.
+func (it *TransactionIterator) MaxRecords(n int) *TransactionIterator {
+	it.maxRecords = n
+	return it
+}
+
+// Each calls fn with every transaction in order, until there are no more
+// transactions or fn returns an error, which is returned.
+func (it *TransactionIterator) Each(fn func(hProtocol.Transaction) error) error {
+	return it.each(func(record interface{}) error {
+		return fn(record.(hProtocol.Transaction))
+	})
+}
+
+// Chan sends every transaction to the returned channel, which is closed when
+// the iteration ends. The error which ended the iteration, or nil, is then
+// sent to the error channel. Cancel the context to stop early.
+func (it *TransactionIterator) Chan() (<-chan hProtocol.Transaction, <-chan error) {
+	records := make(chan hProtocol.Transaction)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(records)
+		errs <- it.Each(func(record hProtocol.Transaction) error {
+			select {
+			case records <- record:
+				return nil
+			case <-it.ctx.Done():
+				return it.ctx.Err()
+			}
+		})
+	}()
+	return records, errs
+}
+
+// IterateTransactions returns an iterator over the transactions matching the
+// request.
+func (c *Client) IterateTransactions(ctx context.Context, request TransactionRequest) *TransactionIterator {
+	return &TransactionIterator{pageIterator{
+		ctx: ctx,
+		load: func(ctx context.Context, nextURL string) ([]interface{}, string, error) {
+			var page hProtocol.TransactionsPage
+			var err error
+			if nextURL == "" {
+				page, err = c.TransactionsContext(ctx, request)
+			} else {
+				err = c.sendRequestURL(ctx, nextURL, "get", &page)
+			}
+			if err != nil {
+				return nil, "", err
+			}
+			records := make([]interface{}, len(page.Embedded.Records))
+			for i, record := range page.Embedded.Records {
+				records[i] = record
+			}
+			return records, page.Links.Next.Href, nil
+		},
+	}}
+}
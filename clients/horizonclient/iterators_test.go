@@ -0,0 +1,123 @@
+package horizonclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// pagedServer serves the given number of transactions, two per page, with
+// paging tokens "1", "2", ...
+func pagedServer(total int) *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := 0
+		if cursor := r.URL.Query().Get("cursor"); cursor != "" {
+			start, _ = strconv.Atoi(cursor)
+		}
+		var page hProtocol.TransactionsPage
+		page.Embedded.Records = []hProtocol.Transaction{}
+		for i := start + 1; i <= total && i <= start+2; i++ {
+			page.Embedded.Records = append(page.Embedded.Records, hProtocol.Transaction{
+				Hash: fmt.Sprintf("tx%d", i),
+				PT:   strconv.Itoa(i),
+			})
+		}
+		next := start + len(page.Embedded.Records)
+		page.Links.Next.Href = fmt.Sprintf("%s%s?cursor=%d", server.URL, r.URL.Path, next)
+		json.NewEncoder(w).Encode(page)
+	}))
+	return server
+}
+
+func TestIteratorEach(t *testing.T) {
+	server := pagedServer(5)
+	defer server.Close()
+	client := &Client{HorizonURL: server.URL}
+
+	var hashes []string
+	it := client.IterateTransactions(context.Background(), TransactionRequest{})
+	err := it.Each(func(tx hProtocol.Transaction) error {
+		hashes = append(hashes, tx.Hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx1", "tx2", "tx3", "tx4", "tx5"}, hashes)
+	assert.Equal(t, "5", it.Cursor())
+
+	// Iterations can be limited and resumed from their cursor.
+	hashes = nil
+	it = client.IterateTransactions(context.Background(), TransactionRequest{}).MaxRecords(3)
+	err = it.Each(func(tx hProtocol.Transaction) error {
+		hashes = append(hashes, tx.Hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx1", "tx2", "tx3"}, hashes)
+
+	hashes = nil
+	it = client.IterateTransactions(context.Background(), TransactionRequest{Cursor: it.Cursor()})
+	err = it.Each(func(tx hProtocol.Transaction) error {
+		hashes = append(hashes, tx.Hash)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tx4", "tx5"}, hashes)
+
+	// An error of the callback ends the iteration.
+	stop := errors.New("stop")
+	count := 0
+	err = client.IterateTransactions(context.Background(), TransactionRequest{}).
+		Each(func(tx hProtocol.Transaction) error {
+			count++
+			return stop
+		})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestIteratorChan(t *testing.T) {
+	server := pagedServer(3)
+	defer server.Close()
+	client := &Client{HorizonURL: server.URL}
+
+	records, errs := client.IterateTransactions(context.Background(), TransactionRequest{}).Chan()
+	var hashes []string
+	for tx := range records {
+		hashes = append(hashes, tx.Hash)
+	}
+	assert.NoError(t, <-errs)
+	assert.Equal(t, []string{"tx1", "tx2", "tx3"}, hashes)
+
+	// Cancelling the context stops the iteration.
+	ctx, cancel := context.WithCancel(context.Background())
+	records, errs = client.IterateTransactions(ctx, TransactionRequest{}).Chan()
+	tx := <-records
+	assert.Equal(t, "tx1", tx.Hash)
+	cancel()
+	for range records {
+	}
+	assert.Equal(t, context.Canceled, <-errs)
+}
+
+func TestIteratorErrors(t *testing.T) {
+	client := &Client{HorizonURL: "https://localhost/"}
+	err := client.IterateTrades(context.Background(), TradeRequest{ForOfferID: "1", ForAccount: "G"}).
+		Each(func(hProtocol.Trade) error { return nil })
+	assert.EqualError(t, err, "invalid request: too many parameters")
+}
+
+func TestClaimableBalanceRequestPaging(t *testing.T) {
+	endpoint, err := ClaimableBalanceRequest{Sponsor: "GABC", Cursor: "12", Limit: 5, Order: OrderDesc}.BuildURL()
+	require.NoError(t, err)
+	assert.Equal(t, "claimable_balances?cursor=12&limit=5&order=desc&sponsor=GABC", endpoint)
+}
@@ -138,12 +138,25 @@ type Client struct {
 	AppName string
 
 	// AppVersion is the version of the application using the horizonclient package
-	AppVersion     string
+	AppVersion string
+
+	// FailoverURLs are other Horizon servers, used in order when HorizonURL
+	// is failing requests
+	FailoverURLs []string
+
+	// RetryPolicy configures how failed requests are retried
+	RetryPolicy RetryPolicy
+
 	horizonTimeout time.Duration
 	isTestNet      bool
 
 	// clock is a Clock returning the current time.
 	clock *clock.Clock
+
+	// unhealthyServers maps the servers of the client which recently failed
+	// a request to the time until which they are avoided.
+	unhealthyServers      map[string]time.Time
+	unhealthyServersMutex sync.Mutex
 }
 
 // SubmitTxOpts represents the submit transaction options
@@ -154,30 +167,61 @@ type SubmitTxOpts struct {
 // ClientInterface contains methods implemented by the horizon client
 type ClientInterface interface {
 	Accounts(request AccountsRequest) (hProtocol.AccountsPage, error)
+	AccountsContext(ctx context.Context, request AccountsRequest) (hProtocol.AccountsPage, error)
 	AccountDetail(request AccountRequest) (hProtocol.Account, error)
+	AccountDetailContext(ctx context.Context, request AccountRequest) (hProtocol.Account, error)
 	AccountData(request AccountRequest) (hProtocol.AccountData, error)
+	AccountDataContext(ctx context.Context, request AccountRequest) (hProtocol.AccountData, error)
+	AccountSigners(accountIDs ...string) (map[string]txnbuild.AccountSigners, error)
+	AccountSignersContext(ctx context.Context, accountIDs ...string) (map[string]txnbuild.AccountSigners, error)
 	Effects(request EffectRequest) (effects.EffectsPage, error)
+	EffectsContext(ctx context.Context, request EffectRequest) (effects.EffectsPage, error)
 	Assets(request AssetRequest) (hProtocol.AssetsPage, error)
+	AssetsContext(ctx context.Context, request AssetRequest) (hProtocol.AssetsPage, error)
 	Ledgers(request LedgerRequest) (hProtocol.LedgersPage, error)
+	LedgersContext(ctx context.Context, request LedgerRequest) (hProtocol.LedgersPage, error)
 	LedgerDetail(sequence uint32) (hProtocol.Ledger, error)
+	LedgerDetailContext(ctx context.Context, sequence uint32) (hProtocol.Ledger, error)
 	FeeStats() (hProtocol.FeeStats, error)
+	FeeStatsContext(ctx context.Context) (hProtocol.FeeStats, error)
 	Offers(request OfferRequest) (hProtocol.OffersPage, error)
+	OffersContext(ctx context.Context, request OfferRequest) (hProtocol.OffersPage, error)
 	OfferDetails(offerID string) (offer hProtocol.Offer, err error)
+	OfferDetailsContext(ctx context.Context, offerID string) (offer hProtocol.Offer, err error)
 	Operations(request OperationRequest) (operations.OperationsPage, error)
+	OperationsContext(ctx context.Context, request OperationRequest) (operations.OperationsPage, error)
 	OperationDetail(id string) (operations.Operation, error)
+	OperationDetailContext(ctx context.Context, id string) (operations.Operation, error)
 	SubmitTransactionXDR(transactionXdr string) (hProtocol.Transaction, error)
+	SubmitTransactionXDRContext(ctx context.Context, transactionXdr string) (hProtocol.Transaction, error)
 	SubmitFeeBumpTransactionWithOptions(transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (hProtocol.Transaction, error)
+	SubmitFeeBumpTransactionWithOptionsContext(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, opts SubmitTxOpts) (hProtocol.Transaction, error)
 	SubmitTransactionWithOptions(transaction *txnbuild.Transaction, opts SubmitTxOpts) (hProtocol.Transaction, error)
+	SubmitTransactionWithOptionsContext(ctx context.Context, transaction *txnbuild.Transaction, opts SubmitTxOpts) (hProtocol.Transaction, error)
 	SubmitFeeBumpTransaction(transaction *txnbuild.FeeBumpTransaction) (hProtocol.Transaction, error)
+	SubmitFeeBumpTransactionContext(ctx context.Context, transaction *txnbuild.FeeBumpTransaction) (hProtocol.Transaction, error)
 	SubmitTransaction(transaction *txnbuild.Transaction) (hProtocol.Transaction, error)
+	SubmitTransactionContext(ctx context.Context, transaction *txnbuild.Transaction) (hProtocol.Transaction, error)
+	TransactionSignatureStatus(transaction *txnbuild.Transaction, network string) (txnbuild.SignatureStatus, error)
+	TransactionSignatureStatusContext(ctx context.Context, transaction *txnbuild.Transaction, network string) (txnbuild.SignatureStatus, error)
+	FeeBumpTransactionSignatureStatus(transaction *txnbuild.FeeBumpTransaction, network string) (txnbuild.SignatureStatus, error)
+	FeeBumpTransactionSignatureStatusContext(ctx context.Context, transaction *txnbuild.FeeBumpTransaction, network string) (txnbuild.SignatureStatus, error)
 	Transactions(request TransactionRequest) (hProtocol.TransactionsPage, error)
+	TransactionsContext(ctx context.Context, request TransactionRequest) (hProtocol.TransactionsPage, error)
 	TransactionDetail(txHash string) (hProtocol.Transaction, error)
+	TransactionDetailContext(ctx context.Context, txHash string) (hProtocol.Transaction, error)
 	OrderBook(request OrderBookRequest) (hProtocol.OrderBookSummary, error)
+	OrderBookContext(ctx context.Context, request OrderBookRequest) (hProtocol.OrderBookSummary, error)
 	Paths(request PathsRequest) (hProtocol.PathsPage, error)
+	PathsContext(ctx context.Context, request PathsRequest) (hProtocol.PathsPage, error)
 	Payments(request OperationRequest) (operations.OperationsPage, error)
+	PaymentsContext(ctx context.Context, request OperationRequest) (operations.OperationsPage, error)
 	TradeAggregations(request TradeAggregationRequest) (hProtocol.TradeAggregationsPage, error)
+	TradeAggregationsContext(ctx context.Context, request TradeAggregationRequest) (hProtocol.TradeAggregationsPage, error)
 	Trades(request TradeRequest) (hProtocol.TradesPage, error)
+	TradesContext(ctx context.Context, request TradeRequest) (hProtocol.TradesPage, error)
 	Fund(addr string) (hProtocol.Transaction, error)
+	FundContext(ctx context.Context, addr string) (hProtocol.Transaction, error)
 	StreamTransactions(ctx context.Context, request TransactionRequest, handler TransactionHandler) error
 	StreamTrades(ctx context.Context, request TradeRequest, handler TradeHandler) error
 	StreamEffects(ctx context.Context, request EffectRequest, handler EffectHandler) error
@@ -187,26 +231,47 @@ type ClientInterface interface {
 	StreamLedgers(ctx context.Context, request LedgerRequest, handler LedgerHandler) error
 	StreamOrderBooks(ctx context.Context, request OrderBookRequest, handler OrderBookHandler) error
 	Root() (hProtocol.Root, error)
+	RootContext(ctx context.Context) (hProtocol.Root, error)
 	NextAccountsPage(hProtocol.AccountsPage) (hProtocol.AccountsPage, error)
+	NextAccountsPageContext(ctx context.Context, page hProtocol.AccountsPage) (hProtocol.AccountsPage, error)
 	NextAssetsPage(hProtocol.AssetsPage) (hProtocol.AssetsPage, error)
+	NextAssetsPageContext(ctx context.Context, page hProtocol.AssetsPage) (hProtocol.AssetsPage, error)
 	PrevAssetsPage(hProtocol.AssetsPage) (hProtocol.AssetsPage, error)
+	PrevAssetsPageContext(ctx context.Context, page hProtocol.AssetsPage) (hProtocol.AssetsPage, error)
 	NextLedgersPage(hProtocol.LedgersPage) (hProtocol.LedgersPage, error)
+	NextLedgersPageContext(ctx context.Context, page hProtocol.LedgersPage) (hProtocol.LedgersPage, error)
 	PrevLedgersPage(hProtocol.LedgersPage) (hProtocol.LedgersPage, error)
+	PrevLedgersPageContext(ctx context.Context, page hProtocol.LedgersPage) (hProtocol.LedgersPage, error)
 	NextEffectsPage(effects.EffectsPage) (effects.EffectsPage, error)
+	NextEffectsPageContext(ctx context.Context, page effects.EffectsPage) (effects.EffectsPage, error)
 	PrevEffectsPage(effects.EffectsPage) (effects.EffectsPage, error)
+	PrevEffectsPageContext(ctx context.Context, page effects.EffectsPage) (effects.EffectsPage, error)
 	NextTransactionsPage(hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error)
+	NextTransactionsPageContext(ctx context.Context, page hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error)
 	PrevTransactionsPage(hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error)
+	PrevTransactionsPageContext(ctx context.Context, page hProtocol.TransactionsPage) (hProtocol.TransactionsPage, error)
 	NextOperationsPage(operations.OperationsPage) (operations.OperationsPage, error)
+	NextOperationsPageContext(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error)
 	PrevOperationsPage(operations.OperationsPage) (operations.OperationsPage, error)
+	PrevOperationsPageContext(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error)
 	NextPaymentsPage(operations.OperationsPage) (operations.OperationsPage, error)
+	NextPaymentsPageContext(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error)
 	PrevPaymentsPage(operations.OperationsPage) (operations.OperationsPage, error)
+	PrevPaymentsPageContext(ctx context.Context, page operations.OperationsPage) (operations.OperationsPage, error)
 	NextOffersPage(hProtocol.OffersPage) (hProtocol.OffersPage, error)
+	NextOffersPageContext(ctx context.Context, page hProtocol.OffersPage) (hProtocol.OffersPage, error)
 	PrevOffersPage(hProtocol.OffersPage) (hProtocol.OffersPage, error)
+	PrevOffersPageContext(ctx context.Context, page hProtocol.OffersPage) (hProtocol.OffersPage, error)
 	NextTradesPage(hProtocol.TradesPage) (hProtocol.TradesPage, error)
+	NextTradesPageContext(ctx context.Context, page hProtocol.TradesPage) (hProtocol.TradesPage, error)
 	PrevTradesPage(hProtocol.TradesPage) (hProtocol.TradesPage, error)
+	PrevTradesPageContext(ctx context.Context, page hProtocol.TradesPage) (hProtocol.TradesPage, error)
 	HomeDomainForAccount(aid string) (string, error)
+	HomeDomainForAccountContext(ctx context.Context, aid string) (string, error)
 	NextTradeAggregationsPage(hProtocol.TradeAggregationsPage) (hProtocol.TradeAggregationsPage, error)
+	NextTradeAggregationsPageContext(ctx context.Context, page hProtocol.TradeAggregationsPage) (hProtocol.TradeAggregationsPage, error)
 	PrevTradeAggregationsPage(hProtocol.TradeAggregationsPage) (hProtocol.TradeAggregationsPage, error)
+	PrevTradeAggregationsPageContext(ctx context.Context, page hProtocol.TradeAggregationsPage) (hProtocol.TradeAggregationsPage, error)
 }
 
 // DefaultTestNetClient is a default client to connect to test network.
@@ -411,12 +476,16 @@ type TradeAggregationRequest struct {
 }
 
 // ClaimableBalanceRequest contains data about claimable balances.
-// The filters are optional (all added except Asset)
+// The filters are optional (all added except Asset), as are the query
+// parameters (Order, Cursor and Limit).
 type ClaimableBalanceRequest struct {
 	ID       string
 	Asset    string
 	Sponsor  string
 	Claimant string
+	Order    Order
+	Cursor   string
+	Limit    uint
 }
 
 // ServerTimeRecord contains data for the current unix time of a horizon server instance, and the local time when it was recorded.
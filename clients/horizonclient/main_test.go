@@ -1,6 +1,8 @@
 package horizonclient
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
@@ -162,7 +164,7 @@ func TestCheckMemoRequired(t *testing.T) {
 				).ReturnString(404, notFoundResponse)
 			}
 
-			err = client.checkMemoRequired(tx)
+			err = client.checkMemoRequired(context.Background(), tx)
 
 			if len(tc.expected) > 0 {
 				tt.Error(err)
@@ -339,6 +341,72 @@ func TestAccountDetail(t *testing.T) {
 	}
 }
 
+func TestTransactionSignatureStatus(t *testing.T) {
+	hmock := httptest.NewClient()
+	client := &Client{
+		HorizonURL: "https://localhost/",
+		HTTP:       hmock,
+	}
+
+	source := keypair.MustRandom()
+	cosigner := keypair.MustRandom()
+	other := keypair.MustRandom()
+	account, err := json.Marshal(hProtocol.Account{
+		AccountID:  source.Address(),
+		Thresholds: hProtocol.AccountThresholds{LowThreshold: 1, MedThreshold: 2, HighThreshold: 3},
+		Signers: []hProtocol.Signer{
+			{Key: source.Address(), Weight: 1},
+			{Key: cosigner.Address(), Weight: 1},
+		},
+	})
+	assert.NoError(t, err)
+	hmock.On(
+		"GET",
+		"https://localhost/accounts/"+source.Address(),
+	).ReturnString(200, string(account))
+	hmock.On(
+		"GET",
+		"https://localhost/accounts/"+other.Address(),
+	).ReturnString(404, notFoundResponse)
+
+	sourceAccount := txnbuild.NewSimpleAccount(source.Address(), 1)
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations:    []txnbuild.Operation{&txnbuild.Payment{Destination: other.Address(), Amount: "1", Asset: txnbuild.NativeAsset{}}},
+			BaseFee:       txnbuild.MinBaseFee,
+			Timebounds:    txnbuild.NewInfiniteTimeout(),
+		},
+	)
+	assert.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, source)
+	assert.NoError(t, err)
+
+	status, err := client.TransactionSignatureStatus(tx, network.TestNetworkPassphrase)
+	if assert.NoError(t, err) {
+		assert.False(t, status.Ready())
+		assert.Equal(t, []string{source.Address()}, status.Accounts[0].Signed)
+		assert.Equal(t, []string{cosigner.Address()}, status.Accounts[0].Missing)
+		assert.Equal(t, txnbuild.Threshold(2), status.Accounts[0].Threshold)
+	}
+
+	tx, err = tx.Sign(network.TestNetworkPassphrase, cosigner)
+	assert.NoError(t, err)
+	hmock.On(
+		"GET",
+		"https://localhost/accounts/"+source.Address(),
+	).ReturnString(200, string(account))
+	status, err = client.TransactionSignatureStatus(tx, network.TestNetworkPassphrase)
+	if assert.NoError(t, err) {
+		assert.True(t, status.Ready())
+	}
+
+	_, err = client.AccountSigners(other.Address())
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to load account "+other.Address()+": horizon error")
+	}
+}
+
 func TestAccountData(t *testing.T) {
 	hmock := httptest.NewClient()
 	client := &Client{
@@ -0,0 +1,177 @@
+package horizonclient
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// DefaultMinBackoff is the delay before the first retry of a request when
+	// RetryPolicy.MinBackoff is not set.
+	DefaultMinBackoff = 500 * time.Millisecond
+	// DefaultMaxBackoff is the longest delay between retries of a request when
+	// RetryPolicy.MaxBackoff is not set.
+	DefaultMaxBackoff = 10 * time.Second
+	// FailoverCooldown is how long a horizon server that failed a request is
+	// avoided when the client has other servers to fail over to.
+	FailoverCooldown = 30 * time.Second
+)
+
+// RetryPolicy configures how a Client retries failed requests. The zero value
+// does not retry.
+//
+// GET requests are retried when they fail to reach the server or get a 5xx
+// response. Other requests, such as transaction submissions, are only retried
+// on 429 Too Many Requests, 503 Service Unavailable and 504 Gateway Timeout
+// responses. The delay between retries grows exponentially, and is at least
+// as long as the Retry-After header of the response, up to MaxBackoff.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a request is retried.
+	MaxRetries int
+	// MinBackoff is the delay before the first retry, doubled for each
+	// following one. Defaults to DefaultMinBackoff.
+	MinBackoff time.Duration
+	// MaxBackoff caps the delay between retries, including the delays asked
+	// for by Retry-After headers. Defaults to DefaultMaxBackoff.
+	MaxBackoff time.Duration
+}
+
+// retryDelay returns how long to wait before retrying a request, and false if
+// the request must not be retried.
+func (p RetryPolicy) retryDelay(attempt int, method string, resp *http.Response, err error) (time.Duration, bool) {
+	if err != nil {
+		if method != "GET" {
+			return 0, false
+		}
+	} else {
+		switch {
+		case resp.StatusCode == http.StatusTooManyRequests,
+			resp.StatusCode == http.StatusServiceUnavailable,
+			resp.StatusCode == http.StatusGatewayTimeout:
+		case resp.StatusCode >= 500 && method == "GET":
+		default:
+			return 0, false
+		}
+	}
+
+	minBackoff, maxBackoff := p.MinBackoff, p.MaxBackoff
+	if minBackoff == 0 {
+		minBackoff = DefaultMinBackoff
+	}
+	if maxBackoff == 0 {
+		maxBackoff = DefaultMaxBackoff
+	}
+	delay := minBackoff
+	for i := 0; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	if retryAfter := retryAfter(resp); retryAfter > delay {
+		delay = retryAfter
+	}
+	if delay > maxBackoff {
+		delay = maxBackoff
+	}
+	return delay, true
+}
+
+// retryAfter parses the Retry-After header of a response, which is either a
+// number of seconds or an HTTP date.
+func retryAfter(resp *http.Response) time.Duration {
+	if resp == nil {
+		return 0
+	}
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+// sleep waits for the given duration or until the context is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// servers returns the base URLs of the horizon servers of the client, the
+// preferred one first.
+func (c *Client) servers() []string {
+	servers := []string{c.fixHorizonURL()}
+	for _, failoverURL := range c.FailoverURLs {
+		server := strings.TrimRight(failoverURL, "/") + "/"
+		if server != servers[0] {
+			servers = append(servers, server)
+		}
+	}
+	return servers
+}
+
+// route returns the URL to send a request for the given URL to. If the client
+// has failover URLs and the URL is on one of its servers, it is moved to the
+// first healthy server, which is also returned.
+func (c *Client) route(requestURL string) (string, string) {
+	if len(c.FailoverURLs) == 0 {
+		return "", requestURL
+	}
+	servers := c.servers()
+	for _, server := range servers {
+		if strings.HasPrefix(requestURL, server) {
+			healthy := c.healthiestServer(servers)
+			return healthy, healthy + strings.TrimPrefix(requestURL, server)
+		}
+	}
+	return "", requestURL
+}
+
+// healthiestServer returns the first of the servers that has not recently
+// failed, or the one that failed the longest time ago if they all did.
+func (c *Client) healthiestServer(servers []string) string {
+	c.unhealthyServersMutex.Lock()
+	defer c.unhealthyServersMutex.Unlock()
+
+	now := c.clock.Now()
+	best := servers[0]
+	for _, server := range servers {
+		until, ok := c.unhealthyServers[server]
+		if !ok || !now.Before(until) {
+			return server
+		}
+		if until.Before(c.unhealthyServers[best]) {
+			best = server
+		}
+	}
+	return best
+}
+
+// updateServerHealth records the outcome of a request to a server.
+func (c *Client) updateServerHealth(server string, resp *http.Response, err error) {
+	if server == "" {
+		return
+	}
+	c.unhealthyServersMutex.Lock()
+	defer c.unhealthyServersMutex.Unlock()
+
+	if err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		if c.unhealthyServers == nil {
+			c.unhealthyServers = map[string]time.Time{}
+		}
+		c.unhealthyServers[server] = c.clock.Now().Add(FailoverCooldown)
+	} else {
+		delete(c.unhealthyServers, server)
+	}
+}
@@ -0,0 +1,196 @@
+package horizonclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stellar/go/support/clock"
+	"github.com/stellar/go/support/clock/clocktest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingServer returns a server which responds with the given statuses in
+// order, and with 200 OK once they are used up.
+func failingServer(statuses ...int) (*httptest.Server, *int32) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		i := atomic.AddInt32(&requests, 1) - 1
+		if int(i) < len(statuses) {
+			w.WriteHeader(statuses[i])
+			w.Write([]byte(`{"status": 503}`))
+			return
+		}
+		w.Write([]byte(`{"core_version": "` + r.Method + `"}`))
+	}))
+	return server, &requests
+}
+
+func TestRetryGet(t *testing.T) {
+	server, requests := failingServer(500, 503, 429)
+	defer server.Close()
+	client := &Client{
+		HorizonURL:  server.URL,
+		RetryPolicy: RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond},
+	}
+
+	root, err := client.Root()
+	require.NoError(t, err)
+	assert.Equal(t, "GET", root.StellarCoreVersion)
+	assert.Equal(t, int32(4), atomic.LoadInt32(requests))
+
+	// Without retries the error is returned.
+	server, requests = failingServer(503)
+	defer server.Close()
+	client = &Client{HorizonURL: server.URL}
+	_, err = client.Root()
+	if assert.Error(t, err) {
+		assert.Equal(t, 503, GetError(err).Response.StatusCode)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+}
+
+func TestRetrySubmit(t *testing.T) {
+	server, requests := failingServer(504, 500)
+	defer server.Close()
+	client := &Client{
+		HorizonURL:  server.URL,
+		RetryPolicy: RetryPolicy{MaxRetries: 3, MinBackoff: time.Millisecond},
+	}
+
+	// Submissions are retried on 504 but not on 500.
+	_, err := client.SubmitTransactionXDR("AAAA")
+	if assert.Error(t, err) {
+		assert.Equal(t, 500, GetError(err).Response.StatusCode)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(requests))
+}
+
+func TestRetryDelay(t *testing.T) {
+	policy := RetryPolicy{MinBackoff: time.Second, MaxBackoff: 5 * time.Second}
+	unavailable := &http.Response{StatusCode: 503, Header: http.Header{}}
+
+	for attempt, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		delay, ok := policy.retryDelay(attempt, "GET", unavailable, nil)
+		assert.True(t, ok)
+		assert.Equal(t, expected, delay)
+	}
+
+	unavailable.Header.Set("Retry-After", "3")
+	delay, ok := policy.retryDelay(0, "POST", unavailable, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 3*time.Second, delay)
+
+	// Retry-After is capped at MaxBackoff.
+	unavailable.Header.Set("Retry-After", "30")
+	delay, ok = policy.retryDelay(0, "POST", unavailable, nil)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, delay)
+	delay, ok = RetryPolicy{}.retryDelay(0, "POST", unavailable, nil)
+	assert.True(t, ok)
+	assert.Equal(t, DefaultMaxBackoff, delay)
+
+	unavailable.Header.Set("Retry-After", time.Now().Add(4*time.Second).UTC().Format(http.TimeFormat))
+	delay, ok = policy.retryDelay(0, "GET", unavailable, nil)
+	assert.True(t, ok)
+	assert.InDelta(t, float64(4*time.Second), float64(delay), float64(2*time.Second))
+
+	_, ok = policy.retryDelay(0, "POST", nil, context.DeadlineExceeded)
+	assert.False(t, ok)
+	_, ok = policy.retryDelay(0, "GET", nil, context.DeadlineExceeded)
+	assert.True(t, ok)
+	_, ok = policy.retryDelay(0, "GET", &http.Response{StatusCode: 404}, nil)
+	assert.False(t, ok)
+}
+
+func TestRequestContext(t *testing.T) {
+	server, requests := failingServer(503, 503, 503)
+	defer server.Close()
+	client := &Client{
+		HorizonURL:  server.URL,
+		RetryPolicy: RetryPolicy{MaxRetries: 3, MinBackoff: time.Hour},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := client.RootContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(requests))
+
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = client.FeeStatsContext(ctx)
+	assert.Error(t, err)
+}
+
+func TestFailover(t *testing.T) {
+	primary, primaryRequests := failingServer(503)
+	defer primary.Close()
+	secondary, secondaryRequests := failingServer()
+	defer secondary.Close()
+	now := time.Unix(1600000000, 0)
+	client := &Client{
+		HorizonURL:   primary.URL,
+		FailoverURLs: []string{secondary.URL + "/"},
+		RetryPolicy:  RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond},
+		clock:        &clock.Clock{Source: clocktest.FixedSource(now)},
+	}
+
+	_, err := client.Root()
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(primaryRequests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(secondaryRequests))
+
+	// The failing server is avoided until the cooldown is over.
+	_, err = client.Root()
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(primaryRequests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(secondaryRequests))
+
+	client.clock.Source = clocktest.FixedSource(now.Add(FailoverCooldown))
+	_, err = client.Root()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(primaryRequests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(secondaryRequests))
+
+	// URLs which are not on one of the servers are left alone.
+	_, requestURL := client.route("https://friendbot.stellar.org/?addr=G")
+	assert.Equal(t, "https://friendbot.stellar.org/?addr=G", requestURL)
+}
+
+func TestFailoverHealthPerClient(t *testing.T) {
+	primary, primaryRequests := failingServer(503)
+	defer primary.Close()
+	secondary, secondaryRequests := failingServer()
+	defer secondary.Close()
+	now := time.Unix(1600000000, 0)
+	newClient := func() *Client {
+		return &Client{
+			HorizonURL:   primary.URL,
+			FailoverURLs: []string{secondary.URL + "/"},
+			RetryPolicy:  RetryPolicy{MaxRetries: 1, MinBackoff: time.Millisecond},
+			clock:        &clock.Clock{Source: clocktest.FixedSource(now)},
+		}
+	}
+
+	client := newClient()
+	_, err := client.Root()
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(primaryRequests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(secondaryRequests))
+
+	// Another client still prefers the server which failed the first one.
+	_, err = newClient().Root()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(primaryRequests))
+	assert.Equal(t, int32(1), atomic.LoadInt32(secondaryRequests))
+
+	_, err = client.Root()
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(primaryRequests))
+	assert.Equal(t, int32(2), atomic.LoadInt32(secondaryRequests))
+}
@@ -0,0 +1,345 @@
+package txbuilder
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// DefaultStartingBalance is the balance of channel accounts when
+	// ChannelPool.StartingBalance is not set.
+	DefaultStartingBalance = "2"
+
+	// maxOperations is the maximum number of operations in a transaction.
+	maxOperations = 100
+)
+
+// ErrPoolClosed is returned by ChannelPool.Submit once the pool is closed.
+var ErrPoolClosed = errors.New("channel pool is closed")
+
+// ErrNoChannels is returned by ChannelPool.Submit when the pool has no
+// channels, because Start could not create any or none of them could be
+// recovered.
+var ErrNoChannels = errors.New("channel pool has no channels left")
+
+// ChannelPool submits transactions on behalf of a main account through a
+// pool of channel accounts, so that many transactions can be submitted in the
+// same ledger without contending for the sequence number of the main account.
+//
+// Each transaction leases a channel, which is the source account of the
+// transaction and pays its fee, while its operations act on the main account.
+// Channels are created and funded by Start and merged back into the main
+// account by Close.
+type ChannelPool struct {
+	// Builder builds and submits the transactions of the pool and tracks the
+	// sequence numbers of the channels.
+	Builder *Builder
+	// Account is the main account, which funds the channels and is the
+	// source of the operations submitted through the pool.
+	Account *keypair.Full
+	// Size is the number of channels.
+	Size int
+	// StartingBalance is the balance each channel is funded with. Defaults to
+	// DefaultStartingBalance.
+	StartingBalance string
+	// Sponsored makes the main account sponsor the reserves of the channels,
+	// in which case StartingBalance only has to cover fees. It can be "0" if
+	// the Builder has a FeeAccount.
+	Sponsored bool
+
+	mutex    sync.Mutex
+	channels []*keypair.Full
+	free     chan *keypair.Full
+	closed   bool
+	// leases counts the Submit calls which hold or wait for a channel, so
+	// that Close can wait for them.
+	leases sync.WaitGroup
+}
+
+// Channels returns the keys of the channel accounts created by Start, except
+// the ones which could not be recovered by Submit. After Close, it returns
+// the channels which could not be merged back.
+func (p *ChannelPool) Channels() []*keypair.Full {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]*keypair.Full(nil), p.channels...)
+}
+
+// Start creates and funds the channel accounts.
+//
+// The channels are created in batches, one transaction per batch. If a batch
+// fails, the channels of the batches which succeeded are kept: Channels
+// returns them, Submit uses them and Close merges them back into the main
+// account.
+func (p *ChannelPool) Start() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.free != nil {
+		return errors.New("channel pool is already started")
+	}
+	if p.Size <= 0 {
+		return errors.New("channel pool size must be positive")
+	}
+
+	channels := make([]*keypair.Full, p.Size)
+	for i := range channels {
+		kp, err := keypair.Random()
+		if err != nil {
+			return errors.Wrap(err, "failed to generate channel key")
+		}
+		channels[i] = kp
+	}
+	created, err := p.create(channels)
+
+	p.channels = channels[:created]
+	p.free = make(chan *keypair.Full, len(channels))
+	for _, channel := range p.channels {
+		p.free <- channel
+	}
+	if len(p.channels) == 0 {
+		close(p.free)
+	}
+	return err
+}
+
+func (p *ChannelPool) startingBalance() string {
+	if p.StartingBalance == "" {
+		return DefaultStartingBalance
+	}
+	return p.StartingBalance
+}
+
+// create creates and funds the given channel accounts, in as few
+// transactions as possible. It returns the number of channels created, which
+// are the first ones of the slice, even if it fails.
+func (p *ChannelPool) create(channels []*keypair.Full) (int, error) {
+	opsPerChannel := 1
+	if p.Sponsored {
+		opsPerChannel = 3
+	}
+	batch := maxOperations / opsPerChannel
+
+	for start := 0; start < len(channels); start += batch {
+		end := start + batch
+		if end > len(channels) {
+			end = len(channels)
+		}
+
+		var ops []txnbuild.Operation
+		signers := []*keypair.Full{p.Account}
+		for _, channel := range channels[start:end] {
+			createAccount := &txnbuild.CreateAccount{
+				Destination: channel.Address(),
+				Amount:      p.startingBalance(),
+			}
+			if !p.Sponsored {
+				ops = append(ops, createAccount)
+				continue
+			}
+			ops = append(ops,
+				&txnbuild.BeginSponsoringFutureReserves{SponsoredID: channel.Address()},
+				createAccount,
+				&txnbuild.EndSponsoringFutureReserves{
+					SourceAccount: &txnbuild.SimpleAccount{AccountID: channel.Address()},
+				},
+			)
+			signers = append(signers, channel)
+		}
+
+		_, err := p.Builder.Submit(Params{
+			SourceAccount: p.Account.Address(),
+			Operations:    ops,
+			Signers:       signers,
+		})
+		if err != nil {
+			return start, errors.Wrap(err, "failed to create channel accounts")
+		}
+	}
+	return len(channels), nil
+}
+
+// Submit leases a channel and submits a transaction with the given
+// operations from it, blocking until a channel is available. Operations
+// without a source account act on the main account. The transaction is
+// signed by the channel, the main account and the given signers.
+//
+// If the channel account no longer exists it is created again and the
+// transaction is submitted once more. If it cannot be created, the channel
+// is removed from the pool.
+func (p *ChannelPool) Submit(operations []txnbuild.Operation, memo txnbuild.Memo, signers ...*keypair.Full) (hProtocol.Transaction, error) {
+	p.mutex.Lock()
+	free, closed := p.free, p.closed
+	if free != nil && !closed {
+		p.leases.Add(1)
+	}
+	p.mutex.Unlock()
+	if free == nil {
+		return hProtocol.Transaction{}, errors.New("channel pool is not started")
+	}
+	if closed {
+		return hProtocol.Transaction{}, ErrPoolClosed
+	}
+	defer p.leases.Done()
+
+	ops := make([]txnbuild.Operation, len(operations))
+	account := &txnbuild.SimpleAccount{AccountID: p.Account.Address()}
+	for i, op := range operations {
+		var err error
+		if ops[i], err = withSourceAccount(op, account); err != nil {
+			return hProtocol.Transaction{}, errors.Wrapf(err, "invalid operation %d", i)
+		}
+	}
+
+	channel, ok := <-free
+	if !ok {
+		return hProtocol.Transaction{}, ErrNoChannels
+	}
+
+	params := Params{
+		SourceAccount: channel.Address(),
+		Operations:    ops,
+		Memo:          memo,
+		Signers:       append([]*keypair.Full{channel, p.Account}, signers...),
+	}
+	resp, err := p.Builder.Submit(params)
+	if err != nil && p.missing(err) {
+		p.Builder.Forget(channel.Address())
+		if _, err = p.create([]*keypair.Full{channel}); err != nil {
+			p.drop(channel)
+			return hProtocol.Transaction{}, errors.Wrapf(err, "failed to recover channel %s", channel.Address())
+		}
+		resp, err = p.Builder.Submit(params)
+	}
+	free <- channel
+	return resp, err
+}
+
+// drop removes a channel which could not be recovered from the pool. When
+// no channel is left, the Submit calls waiting for one return ErrNoChannels.
+func (p *ChannelPool) drop(channel *keypair.Full) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for i, c := range p.channels {
+		if c == channel {
+			p.channels = append(p.channels[:i:i], p.channels[i+1:]...)
+			break
+		}
+	}
+	if len(p.channels) == 0 {
+		close(p.free)
+	}
+}
+
+// missing returns true if the error shows that the source account of the
+// transaction does not exist.
+func (p *ChannelPool) missing(err error) bool {
+	if horizonclient.IsNotFoundError(err) {
+		return true
+	}
+	code, ok := resultCode(err)
+	return ok && code == xdr.TransactionResultCodeTxNoAccount
+}
+
+// Close waits for the leased channels to be returned and merges all the
+// channel accounts back into the main account. The channels are merged in
+// batches, one transaction per batch. A batch which fails does not stop the
+// others; its channels are left in Channels and the errors of all the failed
+// batches are returned.
+func (p *ChannelPool) Close() error {
+	p.mutex.Lock()
+	if p.free == nil || p.closed {
+		p.mutex.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mutex.Unlock()
+
+	p.leases.Wait()
+
+	p.mutex.Lock()
+	channels := p.channels
+	if len(channels) > 0 {
+		// Otherwise the last channel was dropped, which closed free.
+		close(p.free)
+	}
+	p.mutex.Unlock()
+
+	var unmerged []*keypair.Full
+	var failures []string
+	for start := 0; start < len(channels); start += maxOperations {
+		end := start + maxOperations
+		if end > len(channels) {
+			end = len(channels)
+		}
+
+		var ops []txnbuild.Operation
+		signers := []*keypair.Full{p.Account}
+		for _, channel := range channels[start:end] {
+			ops = append(ops, &txnbuild.AccountMerge{
+				Destination:   p.Account.Address(),
+				SourceAccount: &txnbuild.SimpleAccount{AccountID: channel.Address()},
+			})
+			signers = append(signers, channel)
+		}
+
+		_, err := p.Builder.Submit(Params{
+			SourceAccount: p.Account.Address(),
+			Operations:    ops,
+			Signers:       signers,
+		})
+		if err != nil {
+			unmerged = append(unmerged, channels[start:end]...)
+			failures = append(failures, err.Error())
+			continue
+		}
+		for _, channel := range channels[start:end] {
+			p.Builder.Forget(channel.Address())
+		}
+	}
+
+	p.mutex.Lock()
+	p.channels = unmerged
+	p.mutex.Unlock()
+
+	if len(failures) > 0 {
+		return errors.Errorf(
+			"failed to merge %d of %d channel accounts: %s",
+			len(unmerged), len(channels), strings.Join(failures, "; "),
+		)
+	}
+	return nil
+}
+
+// withSourceAccount returns a copy of the operation with the given source
+// account, or the operation itself if it already has a source account.
+func withSourceAccount(op txnbuild.Operation, account txnbuild.Account) (txnbuild.Operation, error) {
+	if op.GetSourceAccount() != nil {
+		return op, nil
+	}
+	opType := reflect.TypeOf(op)
+	if opType.Kind() != reflect.Ptr {
+		return nil, errors.Errorf("unsupported operation %T", op)
+	}
+	copied, ok := reflect.New(opType.Elem()).Interface().(txnbuild.Operation)
+	if !ok {
+		return nil, errors.Errorf("unsupported operation %T", op)
+	}
+
+	xdrOp, err := op.BuildXDR()
+	if err != nil {
+		return nil, err
+	}
+	txnbuild.SetOpSourceAccount(&xdrOp, account)
+	if err := copied.FromXDR(xdrOp); err != nil {
+		return nil, err
+	}
+	return copied, nil
+}
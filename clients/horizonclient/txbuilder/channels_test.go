@@ -0,0 +1,305 @@
+package txbuilder
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type submissions struct {
+	mutex sync.Mutex
+	txs   []*txnbuild.Transaction
+}
+
+func (s *submissions) record(args mock.Arguments) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.txs = append(s.txs, args.Get(0).(*txnbuild.Transaction))
+}
+
+func newTestPool(t *testing.T, sponsored bool) (*ChannelPool, *horizonclient.MockClient, *submissions) {
+	main := keypair.MustRandom()
+	client := &horizonclient.MockClient{}
+	client.On("FeeStats").Return(feeStats, nil)
+	client.On("AccountDetail", mock.Anything).Return(hProtocol.Account{Sequence: "100"}, nil)
+	submitted := &submissions{}
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, nil).Once()
+
+	pool := &ChannelPool{
+		Builder:   &Builder{Client: client, NetworkPassphrase: network.TestNetworkPassphrase},
+		Account:   main,
+		Size:      2,
+		Sponsored: sponsored,
+	}
+	require.NoError(t, pool.Start())
+	return pool, client, submitted
+}
+
+func TestChannelPoolStart(t *testing.T) {
+	pool, _, submitted := newTestPool(t, false)
+	channels := pool.Channels()
+	require.Len(t, channels, 2)
+	require.Len(t, submitted.txs, 1)
+	create := submitted.txs[0]
+	assert.Equal(t, pool.Account.Address(), create.SourceAccount().AccountID)
+	assert.Len(t, create.Signatures(), 1)
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.CreateAccount{Destination: channels[0].Address(), Amount: DefaultStartingBalance},
+		&txnbuild.CreateAccount{Destination: channels[1].Address(), Amount: DefaultStartingBalance},
+	}, create.Operations())
+
+	assert.EqualError(t, pool.Start(), "channel pool is already started")
+}
+
+func TestChannelPoolStartSponsored(t *testing.T) {
+	pool, _, submitted := newTestPool(t, true)
+	channels := pool.Channels()
+	require.Len(t, submitted.txs, 1)
+	create := submitted.txs[0]
+	assert.Len(t, create.Signatures(), 3)
+	require.Len(t, create.Operations(), 6)
+	assert.Equal(t, &txnbuild.BeginSponsoringFutureReserves{SponsoredID: channels[0].Address()}, create.Operations()[0])
+	assert.Equal(t, channels[0].Address(), create.Operations()[2].GetSourceAccount().GetAccountID())
+}
+
+func TestChannelPoolStartPartialFailure(t *testing.T) {
+	client := &horizonclient.MockClient{}
+	client.On("FeeStats").Return(feeStats, nil)
+	client.On("AccountDetail", mock.Anything).Return(hProtocol.Account{Sequence: "100"}, nil)
+	submitted := &submissions{}
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, nil).Once()
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxFailed, false)).Once()
+
+	pool := &ChannelPool{
+		Builder: &Builder{Client: client, NetworkPassphrase: network.TestNetworkPassphrase},
+		Account: keypair.MustRandom(),
+		Size:    150,
+	}
+	err := pool.Start()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to create channel accounts")
+	}
+	require.Len(t, submitted.txs, 2)
+	assert.Len(t, submitted.txs[0].Operations(), 100)
+	assert.Len(t, submitted.txs[1].Operations(), 50)
+
+	// The channels of the first batch are kept, and merged back by Close.
+	channels := pool.Channels()
+	require.Len(t, channels, 100)
+	assert.Equal(t, channels[99].Address(), submitted.txs[0].Operations()[99].(*txnbuild.CreateAccount).Destination)
+
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, nil).Once()
+	require.NoError(t, pool.Close())
+	require.Len(t, submitted.txs, 3)
+	assert.Len(t, submitted.txs[2].Operations(), 100)
+	client.AssertExpectations(t)
+}
+
+func TestChannelPoolSubmit(t *testing.T) {
+	pool, client, submitted := newTestPool(t, false)
+	channels := pool.Channels()
+	destination := keypair.MustRandom().Address()
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, nil)
+
+	payment := &txnbuild.Payment{Destination: destination, Amount: "10", Asset: txnbuild.NativeAsset{}}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := pool.Submit([]txnbuild.Operation{payment}, txnbuild.MemoText("pay"))
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	// The operations of the caller are left unchanged.
+	assert.Nil(t, payment.SourceAccount)
+
+	require.Len(t, submitted.txs, 5)
+	sequences := map[string][]int64{}
+	for _, tx := range submitted.txs[1:] {
+		source := tx.SourceAccount()
+		sequences[source.AccountID] = append(sequences[source.AccountID], source.Sequence)
+		assert.Len(t, tx.Signatures(), 2)
+		require.Len(t, tx.Operations(), 1)
+		assert.Equal(t, pool.Account.Address(), tx.Operations()[0].GetSourceAccount().GetAccountID())
+	}
+	// Each channel uses consecutive sequence numbers.
+	assert.Equal(t, 4, len(sequences[channels[0].Address()])+len(sequences[channels[1].Address()]))
+	for _, channel := range channels {
+		used := sequences[channel.Address()]
+		sort.Slice(used, func(i, j int) bool { return used[i] < used[j] })
+		for i, sequence := range used {
+			assert.Equal(t, int64(101+i), sequence)
+		}
+	}
+}
+
+func TestChannelPoolRecoversMissingChannel(t *testing.T) {
+	pool, client, submitted := newTestPool(t, false)
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxNoAccount, false)).Once()
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{Hash: "abc"}, nil).Twice()
+
+	resp, err := pool.Submit([]txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 1}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "abc", resp.Hash)
+
+	require.Len(t, submitted.txs, 4)
+	channel := submitted.txs[1].SourceAccount().AccountID
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.CreateAccount{Destination: channel, Amount: DefaultStartingBalance},
+	}, submitted.txs[2].Operations())
+	assert.Equal(t, channel, submitted.txs[3].SourceAccount().AccountID)
+	client.AssertExpectations(t)
+}
+
+func TestChannelPoolDropsUnrecoverableChannel(t *testing.T) {
+	pool, client, submitted := newTestPool(t, false)
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxNoAccount, false)).Once()
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxFailed, false)).Once()
+
+	_, err := pool.Submit([]txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 1}}, nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to recover channel")
+	require.Len(t, submitted.txs, 3)
+	dropped := submitted.txs[1].SourceAccount().AccountID
+	channels := pool.Channels()
+	require.Len(t, channels, 1)
+	assert.NotEqual(t, dropped, channels[0].Address())
+
+	// The dropped channel is neither leased again nor merged back.
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, nil).Times(3)
+	for i := 0; i < 2; i++ {
+		_, err = pool.Submit([]txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 1}}, nil)
+		require.NoError(t, err)
+	}
+	require.NoError(t, pool.Close())
+	require.Len(t, submitted.txs, 6)
+	assert.Equal(t, channels[0].Address(), submitted.txs[3].SourceAccount().AccountID)
+	assert.Equal(t, channels[0].Address(), submitted.txs[4].SourceAccount().AccountID)
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.AccountMerge{
+			Destination:   pool.Account.Address(),
+			SourceAccount: &txnbuild.SimpleAccount{AccountID: channels[0].Address()},
+		},
+	}, submitted.txs[5].Operations())
+	client.AssertExpectations(t)
+}
+
+func TestChannelPoolNoChannelsLeft(t *testing.T) {
+	pool, client, submitted := newTestPool(t, false)
+	for i := 0; i < 2; i++ {
+		client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+			Run(submitted.record).
+			Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxNoAccount, false)).Once()
+		client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+			Run(submitted.record).
+			Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxFailed, false)).Once()
+
+		_, err := pool.Submit([]txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 1}}, nil)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "failed to recover channel")
+	}
+	assert.Empty(t, pool.Channels())
+
+	_, err := pool.Submit([]txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 1}}, nil)
+	assert.Equal(t, ErrNoChannels, err)
+	assert.NoError(t, pool.Close())
+	assert.Len(t, submitted.txs, 5)
+	client.AssertExpectations(t)
+}
+
+func TestChannelPoolClosePartialFailure(t *testing.T) {
+	client := &horizonclient.MockClient{}
+	client.On("FeeStats").Return(feeStats, nil)
+	client.On("AccountDetail", mock.Anything).Return(hProtocol.Account{Sequence: "100"}, nil)
+	submitted := &submissions{}
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, nil).Twice()
+
+	pool := &ChannelPool{
+		Builder: &Builder{Client: client, NetworkPassphrase: network.TestNetworkPassphrase},
+		Account: keypair.MustRandom(),
+		Size:    150,
+	}
+	require.NoError(t, pool.Start())
+	channels := pool.Channels()
+
+	// The first batch fails, the second one is merged anyway.
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxFailed, false)).Once()
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, nil).Once()
+	err := pool.Close()
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "failed to merge 100 of 150 channel accounts")
+	}
+	require.Len(t, submitted.txs, 4)
+	assert.Len(t, submitted.txs[2].Operations(), 100)
+	assert.Len(t, submitted.txs[3].Operations(), 50)
+	assert.Equal(t, channels[:100], pool.Channels())
+	client.AssertExpectations(t)
+}
+
+func TestChannelPoolClose(t *testing.T) {
+	pool, client, submitted := newTestPool(t, false)
+	channels := pool.Channels()
+	client.On("SubmitTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(submitted.record).
+		Return(hProtocol.Transaction{}, nil).Once()
+
+	require.NoError(t, pool.Close())
+	require.Len(t, submitted.txs, 2)
+	merge := submitted.txs[1]
+	assert.Equal(t, pool.Account.Address(), merge.SourceAccount().AccountID)
+	assert.Len(t, merge.Signatures(), 3)
+	assert.Equal(t, []txnbuild.Operation{
+		&txnbuild.AccountMerge{
+			Destination:   pool.Account.Address(),
+			SourceAccount: &txnbuild.SimpleAccount{AccountID: channels[0].Address()},
+		},
+		&txnbuild.AccountMerge{
+			Destination:   pool.Account.Address(),
+			SourceAccount: &txnbuild.SimpleAccount{AccountID: channels[1].Address()},
+		},
+	}, merge.Operations())
+
+	_, err := pool.Submit([]txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 1}}, nil)
+	assert.Equal(t, ErrPoolClosed, err)
+	assert.NoError(t, pool.Close())
+	client.AssertExpectations(t)
+}
@@ -0,0 +1,356 @@
+// Package txbuilder builds, signs and submits transactions using Horizon to
+// fill in what txnbuild leaves to the caller: the sequence number of the
+// source account, the fee and the timebounds.
+//
+// A Builder caches the sequence number of each source account it has seen, so
+// that several transactions from the same account can be built without a
+// round trip to Horizon each time. When a submission fails with tx_bad_seq
+// the sequence number is reloaded and the transaction is rebuilt, re-signed
+// and submitted again.
+//
+// A ChannelPool uses a Builder to submit transactions for one account through
+// a pool of channel accounts, for throughput beyond one transaction per
+// ledger.
+package txbuilder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	// DefaultFeePercentile is the percentile of the max_fee distribution
+	// used when Builder.FeePercentile is not set.
+	DefaultFeePercentile = 50
+	// DefaultTimeout is the validity of transactions built when
+	// Builder.Timeout is not set.
+	DefaultTimeout = 5 * time.Minute
+	// DefaultMaxAttempts is the number of submissions made by Submit when
+	// Builder.MaxAttempts is not set.
+	DefaultMaxAttempts = 3
+
+	// feeStatsMaxAge is how long fee stats are reused, about one ledger.
+	feeStatsMaxAge = 5 * time.Second
+)
+
+// Params are the parts of a transaction that are not filled in by the
+// Builder.
+type Params struct {
+	// SourceAccount is the address of the source account of the transaction.
+	SourceAccount string
+	Operations    []txnbuild.Operation
+	Memo          txnbuild.Memo
+	// Signers sign the transaction. If no signers are given the transaction
+	// is built but left unsigned.
+	Signers []*keypair.Full
+}
+
+// Builder builds transactions with sequence numbers and fees loaded from
+// Horizon. A Builder is safe for concurrent use, provided its fields are not
+// changed after the first call.
+type Builder struct {
+	Client            horizonclient.ClientInterface
+	NetworkPassphrase string
+
+	// FeePercentile selects the base fee from the max_fee distribution of the
+	// Horizon fee stats. It must be one of 10, 20, 30, 40, 50, 60, 70, 80,
+	// 90, 95 or 99. Defaults to DefaultFeePercentile.
+	FeePercentile int
+	// MaxBaseFee caps the base fee, in stroops. Zero means no cap.
+	MaxBaseFee int64
+
+	// FeeAccount, if set, pays the fees: every transaction is wrapped in a
+	// fee bump transaction signed by it, and the inner transaction pays the
+	// minimum base fee.
+	FeeAccount *keypair.Full
+
+	// Timeout is how long built transactions are valid for. Defaults to
+	// DefaultTimeout.
+	Timeout time.Duration
+	// MaxAttempts is the number of times Submit submits a transaction that
+	// fails with tx_bad_seq. Defaults to DefaultMaxAttempts.
+	MaxAttempts int
+	// SubmitOptions are passed to Horizon on submission.
+	SubmitOptions horizonclient.SubmitTxOpts
+
+	mutex        sync.Mutex
+	sequences    map[string]int64
+	feeStats     hProtocol.FeeStats
+	feeStatsTime time.Time
+}
+
+// Sequence returns the last sequence number used by the account, loading it
+// from Horizon if it is not cached.
+func (b *Builder) Sequence(accountID string) (int64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.sequence(accountID)
+}
+
+func (b *Builder) sequence(accountID string) (int64, error) {
+	if sequence, ok := b.sequences[accountID]; ok {
+		return sequence, nil
+	}
+	account, err := b.Client.AccountDetail(horizonclient.AccountRequest{AccountID: accountID})
+	if err != nil {
+		return 0, errors.Wrapf(err, "failed to load account %s", accountID)
+	}
+	sequence, err := account.GetSequenceNumber()
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid sequence number of account %s", accountID)
+	}
+	if b.sequences == nil {
+		b.sequences = map[string]int64{}
+	}
+	b.sequences[accountID] = sequence
+	return sequence, nil
+}
+
+// nextSequence reserves the next sequence number of the account.
+func (b *Builder) nextSequence(accountID string) (int64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	sequence, err := b.sequence(accountID)
+	if err != nil {
+		return 0, err
+	}
+	sequence++
+	b.sequences[accountID] = sequence
+	return sequence, nil
+}
+
+// Forget drops the cached sequence number of the account, which is loaded
+// again from Horizon when the account is next used.
+func (b *Builder) Forget(accountID string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	delete(b.sequences, accountID)
+}
+
+// BaseFee returns the base fee, in stroops, chosen from the Horizon fee
+// stats. It is at least the base fee of the last ledger and at most
+// MaxBaseFee.
+func (b *Builder) BaseFee() (int64, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.feeStatsTime.IsZero() || time.Since(b.feeStatsTime) > feeStatsMaxAge {
+		feeStats, err := b.Client.FeeStats()
+		if err != nil {
+			return 0, errors.Wrap(err, "failed to load fee stats")
+		}
+		b.feeStats = feeStats
+		b.feeStatsTime = time.Now()
+	}
+
+	percentile := b.FeePercentile
+	if percentile == 0 {
+		percentile = DefaultFeePercentile
+	}
+	fee, err := feePercentile(b.feeStats.MaxFee, percentile)
+	if err != nil {
+		return 0, err
+	}
+	if fee < b.feeStats.LastLedgerBaseFee {
+		fee = b.feeStats.LastLedgerBaseFee
+	}
+	if fee < txnbuild.MinBaseFee {
+		fee = txnbuild.MinBaseFee
+	}
+	if b.MaxBaseFee > 0 && fee > b.MaxBaseFee {
+		fee = b.MaxBaseFee
+	}
+	return fee, nil
+}
+
+func feePercentile(distribution hProtocol.FeeDistribution, percentile int) (int64, error) {
+	switch percentile {
+	case 10:
+		return distribution.P10, nil
+	case 20:
+		return distribution.P20, nil
+	case 30:
+		return distribution.P30, nil
+	case 40:
+		return distribution.P40, nil
+	case 50:
+		return distribution.P50, nil
+	case 60:
+		return distribution.P60, nil
+	case 70:
+		return distribution.P70, nil
+	case 80:
+		return distribution.P80, nil
+	case 90:
+		return distribution.P90, nil
+	case 95:
+		return distribution.P95, nil
+	case 99:
+		return distribution.P99, nil
+	}
+	return 0, errors.Errorf("invalid fee percentile %d", percentile)
+}
+
+func (b *Builder) timeout() int64 {
+	if b.Timeout == 0 {
+		return int64(DefaultTimeout / time.Second)
+	}
+	return int64(b.Timeout / time.Second)
+}
+
+// Build builds and signs a transaction using the next sequence number of its
+// source account. If a FeeAccount is configured the transaction pays the
+// minimum base fee, and should be wrapped with FeeBump before submission.
+//
+// Build consumes a sequence number: if the transaction is not submitted, call
+// Forget so that the next transaction does not fail with tx_bad_seq.
+func (b *Builder) Build(params Params) (*txnbuild.Transaction, error) {
+	baseFee := int64(txnbuild.MinBaseFee)
+	if b.FeeAccount == nil {
+		var err error
+		if baseFee, err = b.BaseFee(); err != nil {
+			return nil, err
+		}
+	}
+
+	sequence, err := b.nextSequence(params.SourceAccount)
+	if err != nil {
+		return nil, err
+	}
+	sourceAccount := txnbuild.NewSimpleAccount(params.SourceAccount, sequence-1)
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount:        &sourceAccount,
+			IncrementSequenceNum: true,
+			Operations:           params.Operations,
+			Memo:                 params.Memo,
+			BaseFee:              baseFee,
+			Timebounds:           txnbuild.NewTimeout(b.timeout()),
+		},
+	)
+	if err != nil {
+		b.Forget(params.SourceAccount)
+		return nil, errors.Wrap(err, "failed to build transaction")
+	}
+	if len(params.Signers) > 0 {
+		tx, err = tx.Sign(b.NetworkPassphrase, params.Signers...)
+		if err != nil {
+			b.Forget(params.SourceAccount)
+			return nil, errors.Wrap(err, "failed to sign transaction")
+		}
+	}
+	return tx, nil
+}
+
+// FeeBump wraps the transaction in a fee bump transaction paid for and
+// signed by the FeeAccount.
+func (b *Builder) FeeBump(tx *txnbuild.Transaction) (*txnbuild.FeeBumpTransaction, error) {
+	if b.FeeAccount == nil {
+		return nil, errors.New("no fee account is configured")
+	}
+	baseFee, err := b.BaseFee()
+	if err != nil {
+		return nil, err
+	}
+	if baseFee < tx.BaseFee() {
+		baseFee = tx.BaseFee()
+	}
+	feeBump, err := txnbuild.NewFeeBumpTransaction(
+		txnbuild.FeeBumpTransactionParams{
+			Inner:      tx,
+			FeeAccount: b.FeeAccount.Address(),
+			BaseFee:    baseFee,
+		},
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to build fee bump transaction")
+	}
+	feeBump, err = feeBump.Sign(b.NetworkPassphrase, b.FeeAccount)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to sign fee bump transaction")
+	}
+	return feeBump, nil
+}
+
+// Submit builds, signs and submits a transaction, wrapping it in a fee bump
+// transaction if a FeeAccount is configured. If the submission fails with
+// tx_bad_seq, the sequence number of the source account is reloaded and the
+// transaction is rebuilt and submitted again, up to MaxAttempts times.
+func (b *Builder) Submit(params Params) (hProtocol.Transaction, error) {
+	maxAttempts := b.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < maxAttempts; attempt++ {
+		var resp hProtocol.Transaction
+		resp, err = b.submit(params)
+		if err == nil {
+			return resp, nil
+		}
+		code, ok := resultCode(err)
+		if !ok || code != xdr.TransactionResultCodeTxBadSeq {
+			return resp, err
+		}
+	}
+	return hProtocol.Transaction{}, errors.Wrapf(err, "transaction failed after %d attempts", maxAttempts)
+}
+
+func (b *Builder) submit(params Params) (hProtocol.Transaction, error) {
+	tx, err := b.Build(params)
+	if err != nil {
+		return hProtocol.Transaction{}, err
+	}
+
+	var resp hProtocol.Transaction
+	if b.FeeAccount != nil {
+		var feeBump *txnbuild.FeeBumpTransaction
+		feeBump, err = b.FeeBump(tx)
+		if err != nil {
+			b.Forget(params.SourceAccount)
+			return hProtocol.Transaction{}, err
+		}
+		resp, err = b.Client.SubmitFeeBumpTransactionWithOptions(feeBump, b.SubmitOptions)
+	} else {
+		resp, err = b.Client.SubmitTransactionWithOptions(tx, b.SubmitOptions)
+	}
+	if err != nil {
+		// A transaction that failed in the ledger used its sequence number,
+		// otherwise the cached sequence number is ahead of the account.
+		if code, ok := resultCode(err); !ok || code != xdr.TransactionResultCodeTxFailed {
+			b.Forget(params.SourceAccount)
+		}
+		return resp, errors.Wrap(err, "failed to submit transaction")
+	}
+	return resp, nil
+}
+
+// resultCode returns the result code of a failed submission. For fee bump
+// transactions whose inner transaction failed it is the code of the inner
+// transaction.
+func resultCode(err error) (xdr.TransactionResultCode, bool) {
+	hErr := horizonclient.GetError(err)
+	if hErr == nil {
+		return 0, false
+	}
+	resultXDR, err := hErr.ResultString()
+	if err != nil {
+		return 0, false
+	}
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(resultXDR, &result); err != nil {
+		return 0, false
+	}
+	if inner, ok := result.Result.GetInnerResultPair(); ok {
+		return inner.Result.Result.Code, true
+	}
+	return result.Result.Code, true
+}
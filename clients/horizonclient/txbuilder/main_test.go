@@ -0,0 +1,251 @@
+package txbuilder
+
+import (
+	"testing"
+
+	"github.com/stellar/go/clients/horizonclient"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	hProtocol "github.com/stellar/go/protocols/horizon"
+	"github.com/stellar/go/support/render/problem"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+var feeStats = hProtocol.FeeStats{
+	LastLedgerBaseFee: 100,
+	MaxFee: hProtocol.FeeDistribution{
+		P10: 100,
+		P50: 200,
+		P90: 1000,
+		P99: 5000,
+	},
+}
+
+func submissionError(t *testing.T, code xdr.TransactionResultCode, feeBump bool) error {
+	var result xdr.TransactionResult
+	result.Result.Code = code
+	if code == xdr.TransactionResultCodeTxFailed {
+		result.Result.Results = &[]xdr.OperationResult{}
+	}
+	if feeBump {
+		result.Result.Code = xdr.TransactionResultCodeTxFeeBumpInnerFailed
+		var inner xdr.InnerTransactionResult
+		inner.Result.Code = code
+		result.Result.InnerResultPair = &xdr.InnerTransactionResultPair{Result: inner}
+	}
+	resultXDR, err := xdr.MarshalBase64(result)
+	require.NoError(t, err)
+	return &horizonclient.Error{
+		Problem: problem.P{
+			Type:   "https://stellar.org/horizon-errors/transaction_failed",
+			Status: 400,
+			Extras: map[string]interface{}{"result_xdr": resultXDR},
+		},
+	}
+}
+
+func withSequence(sequence int64) interface{} {
+	return mock.MatchedBy(func(tx *txnbuild.Transaction) bool {
+		return tx.SourceAccount().Sequence == sequence
+	})
+}
+
+func TestBaseFee(t *testing.T) {
+	client := &horizonclient.MockClient{}
+	client.On("FeeStats").Return(feeStats, nil).Once()
+	b := &Builder{Client: client}
+
+	fee, err := b.BaseFee()
+	require.NoError(t, err)
+	assert.Equal(t, int64(200), fee)
+
+	b.FeePercentile = 99
+	fee, err = b.BaseFee()
+	require.NoError(t, err)
+	assert.Equal(t, int64(5000), fee)
+
+	b.MaxBaseFee = 2000
+	fee, err = b.BaseFee()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2000), fee)
+
+	b.FeePercentile = 75
+	_, err = b.BaseFee()
+	assert.EqualError(t, err, "invalid fee percentile 75")
+
+	// The fee stats are loaded once and reused.
+	client.AssertExpectations(t)
+}
+
+func TestBaseFeeLastLedger(t *testing.T) {
+	client := &horizonclient.MockClient{}
+	stats := feeStats
+	stats.LastLedgerBaseFee = 300
+	client.On("FeeStats").Return(stats, nil).Once()
+	b := &Builder{Client: client, FeePercentile: 10}
+
+	fee, err := b.BaseFee()
+	require.NoError(t, err)
+	assert.Equal(t, int64(300), fee)
+}
+
+func TestBuild(t *testing.T) {
+	kp := keypair.MustRandom()
+	client := &horizonclient.MockClient{}
+	client.On("FeeStats").Return(feeStats, nil).Once()
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "41"}, nil).Once()
+	b := &Builder{Client: client, NetworkPassphrase: network.TestNetworkPassphrase}
+
+	params := Params{
+		SourceAccount: kp.Address(),
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 100}},
+		Memo:          txnbuild.MemoText("hello"),
+		Signers:       []*keypair.Full{kp},
+	}
+	tx, err := b.Build(params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), tx.SourceAccount().Sequence)
+	assert.Equal(t, int64(200), tx.BaseFee())
+	assert.Equal(t, txnbuild.MemoText("hello"), tx.Memo())
+	assert.Len(t, tx.Signatures(), 1)
+	assert.NotEqual(t, int64(txnbuild.TimeoutInfinite), tx.Timebounds().MaxTime)
+
+	tx, err = b.Build(params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(43), tx.SourceAccount().Sequence)
+
+	sequence, err := b.Sequence(kp.Address())
+	require.NoError(t, err)
+	assert.Equal(t, int64(43), sequence)
+
+	// An invalid transaction does not use up a sequence number.
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "43"}, nil).Once()
+	_, err = b.Build(Params{SourceAccount: kp.Address()})
+	assert.EqualError(t, err, "failed to build transaction: transaction has no operations")
+	tx, err = b.Build(params)
+	require.NoError(t, err)
+	assert.Equal(t, int64(44), tx.SourceAccount().Sequence)
+
+	client.AssertExpectations(t)
+}
+
+func TestSubmitBadSequence(t *testing.T) {
+	kp := keypair.MustRandom()
+	client := &horizonclient.MockClient{}
+	client.On("FeeStats").Return(feeStats, nil)
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "10"}, nil).Once()
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "20"}, nil).Once()
+	client.On("SubmitTransactionWithOptions", withSequence(11), horizonclient.SubmitTxOpts{}).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxBadSeq, false)).Once()
+	client.On("SubmitTransactionWithOptions", withSequence(21), horizonclient.SubmitTxOpts{}).
+		Return(hProtocol.Transaction{Hash: "abc"}, nil).Once()
+	b := &Builder{Client: client, NetworkPassphrase: network.TestNetworkPassphrase}
+
+	resp, err := b.Submit(Params{
+		SourceAccount: kp.Address(),
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 100}},
+		Signers:       []*keypair.Full{kp},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", resp.Hash)
+	client.AssertExpectations(t)
+}
+
+func TestSubmitFailures(t *testing.T) {
+	kp := keypair.MustRandom()
+	client := &horizonclient.MockClient{}
+	client.On("FeeStats").Return(feeStats, nil)
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "10"}, nil).Once()
+	b := &Builder{Client: client, NetworkPassphrase: network.TestNetworkPassphrase, MaxAttempts: 2}
+	params := Params{
+		SourceAccount: kp.Address(),
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 100}},
+		Signers:       []*keypair.Full{kp},
+	}
+
+	// A transaction that failed in the ledger used its sequence number.
+	client.On("SubmitTransactionWithOptions", withSequence(11), horizonclient.SubmitTxOpts{}).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxFailed, false)).Once()
+	_, err := b.Submit(params)
+	assert.EqualError(t, err, "failed to submit transaction: horizon error: \"\" - check horizon.Error.Problem for more information")
+	sequence, err := b.Sequence(kp.Address())
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), sequence)
+
+	// Any other failure reloads the sequence number.
+	client.On("SubmitTransactionWithOptions", withSequence(12), horizonclient.SubmitTxOpts{}).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxInsufficientFee, false)).Once()
+	_, err = b.Submit(params)
+	assert.Error(t, err)
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "11"}, nil).Once()
+	sequence, err = b.Sequence(kp.Address())
+	require.NoError(t, err)
+	assert.Equal(t, int64(11), sequence)
+
+	// Bad sequence numbers are retried up to MaxAttempts times.
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "11"}, nil).Once()
+	client.On("SubmitTransactionWithOptions", withSequence(12), horizonclient.SubmitTxOpts{}).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxBadSeq, false)).Twice()
+	_, err = b.Submit(params)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "transaction failed after 2 attempts")
+	}
+	client.AssertExpectations(t)
+}
+
+func TestSubmitFeeBump(t *testing.T) {
+	kp := keypair.MustRandom()
+	feeAccount := keypair.MustRandom()
+	client := &horizonclient.MockClient{}
+	client.On("FeeStats").Return(feeStats, nil)
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "10"}, nil).Once()
+	client.On("AccountDetail", horizonclient.AccountRequest{AccountID: kp.Address()}).
+		Return(hProtocol.Account{AccountID: kp.Address(), Sequence: "20"}, nil).Once()
+
+	var submitted []*txnbuild.FeeBumpTransaction
+	client.On("SubmitFeeBumpTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(func(args mock.Arguments) {
+			submitted = append(submitted, args.Get(0).(*txnbuild.FeeBumpTransaction))
+		}).
+		Return(hProtocol.Transaction{}, submissionError(t, xdr.TransactionResultCodeTxBadSeq, true)).Once()
+	client.On("SubmitFeeBumpTransactionWithOptions", mock.Anything, horizonclient.SubmitTxOpts{}).
+		Run(func(args mock.Arguments) {
+			submitted = append(submitted, args.Get(0).(*txnbuild.FeeBumpTransaction))
+		}).
+		Return(hProtocol.Transaction{Hash: "abc"}, nil).Once()
+	b := &Builder{
+		Client:            client,
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		FeeAccount:        feeAccount,
+		FeePercentile:     90,
+	}
+
+	resp, err := b.Submit(Params{
+		SourceAccount: kp.Address(),
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 100}},
+		Signers:       []*keypair.Full{kp},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "abc", resp.Hash)
+	require.Len(t, submitted, 2)
+	assert.Equal(t, int64(11), submitted[0].InnerTransaction().SourceAccount().Sequence)
+	feeBump := submitted[1]
+	assert.Equal(t, int64(21), feeBump.InnerTransaction().SourceAccount().Sequence)
+	assert.Equal(t, int64(txnbuild.MinBaseFee), feeBump.InnerTransaction().BaseFee())
+	assert.Equal(t, int64(1000), feeBump.BaseFee())
+	assert.Equal(t, feeAccount.Address(), feeBump.FeeAccount())
+	assert.Len(t, feeBump.Signatures(), 1)
+	client.AssertExpectations(t)
+}
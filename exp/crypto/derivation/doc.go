@@ -1,3 +1,6 @@
 // Package derivation provides functions for ed25519 key derivation as described in:
 // https://github.com/satoshilabs/slips/blob/master/slip-0010.md
+//
+// To derive Stellar accounts from BIP-39 mnemonics as described in SEP-5, use
+// keypair.FromMnemonic instead.
 package derivation
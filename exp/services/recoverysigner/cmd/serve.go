@@ -56,7 +56,21 @@ func (c *ServeCommand) Command() *cobra.Command {
 			Usage:     "Stellar signing key(s) used for signing transactions comma separated (first key is preferred signer) (will be deprecated with per-account keys in the future)",
 			OptType:   types.String,
 			ConfigKey: &opts.SigningKeys,
-			Required:  true,
+			Required:  false,
+		},
+		{
+			Name:      "signing-key-file",
+			Usage:     "Encrypted keyfile(s) holding Stellar signing key(s) comma separated, used after the keys of signing-key (see keypair.SaveKeyfile and stellar-key-gen --keyfile)",
+			OptType:   types.String,
+			ConfigKey: &opts.SigningKeyFiles,
+			Required:  false,
+		},
+		{
+			Name:      "signing-key-password-file",
+			Usage:     "File holding the password of the signing-key-file keyfiles, read instead of passing the password itself so that it is not exposed in the environment or the process list",
+			OptType:   types.String,
+			ConfigKey: &opts.SigningKeyPasswordFile,
+			Required:  false,
 		},
 		{
 			Name:      "sep10-jwks",
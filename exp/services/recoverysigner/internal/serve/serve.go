@@ -1,8 +1,10 @@
 package serve
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"strings"
 
@@ -21,15 +23,17 @@ import (
 )
 
 type Options struct {
-	Logger               *supportlog.Entry
-	DatabaseURL          string
-	DatabaseMaxOpenConns int
-	Port                 int
-	NetworkPassphrase    string
-	SigningKeys          string
-	SEP10JWKS            string
-	SEP10JWTIssuer       string
-	FirebaseProjectID    string
+	Logger                 *supportlog.Entry
+	DatabaseURL            string
+	DatabaseMaxOpenConns   int
+	Port                   int
+	NetworkPassphrase      string
+	SigningKeys            string
+	SigningKeyFiles        string
+	SigningKeyPasswordFile string
+	SEP10JWKS              string
+	SEP10JWTIssuer         string
+	FirebaseProjectID      string
 
 	AdminPort        int
 	MetricsNamespace string
@@ -74,24 +78,67 @@ type handlerDeps struct {
 	MetricsRegistry    *prometheus.Registry
 }
 
+// loadSigningKeys parses the signing key seeds, followed by the signing keys
+// decrypted from the encrypted keyfiles.
+func loadSigningKeys(opts Options) ([]*keypair.Full, error) {
+	signingKeys := []*keypair.Full{}
+	if opts.SigningKeys != "" {
+		for _, signingKeyStr := range strings.Split(opts.SigningKeys, ",") {
+			signingKey, err := keypair.ParseFull(signingKeyStr)
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing signing key seed")
+			}
+			signingKeys = append(signingKeys, signingKey)
+		}
+	}
+	if opts.SigningKeyFiles != "" {
+		password, err := readPasswordFile(opts.SigningKeyPasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range strings.Split(opts.SigningKeyFiles, ",") {
+			signingKey, err := keypair.LoadKeyfile(path, password)
+			if err != nil {
+				return nil, errors.Wrapf(err, "loading signing key file %s", path)
+			}
+			signingKeys = append(signingKeys, signingKey)
+		}
+	}
+	if len(signingKeys) == 0 {
+		return nil, errors.New("no signing keys, set signing-key or signing-key-file")
+	}
+	return signingKeys, nil
+}
+
+// readPasswordFile reads the password of the signing key files, ignoring
+// the line break at the end of the file.
+func readPasswordFile(path string) ([]byte, error) {
+	if path == "" {
+		return nil, errors.New("signing-key-password-file is required with signing-key-file")
+	}
+	password, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading signing key password file")
+	}
+	return bytes.TrimRight(password, "\r\n"), nil
+}
+
 func getHandlerDeps(opts Options) (handlerDeps, error) {
 	// TODO: Replace this signing key with randomly generating a unique signing
 	// key for each account so that it is not possible to identify which
 	// accounts are recoverable via a recovery signer.
-	signingKeys := []*keypair.Full{}
+	signingKeys, err := loadSigningKeys(opts)
+	if err != nil {
+		return handlerDeps{}, err
+	}
 	signingAddresses := []*keypair.FromAddress{}
-	for i, signingKeyStr := range strings.Split(opts.SigningKeys, ",") {
-		signingKey, err := keypair.ParseFull(signingKeyStr)
-		if err != nil {
-			return handlerDeps{}, errors.Wrap(err, "parsing signing key seed")
-		}
-		signingKeys = append(signingKeys, signingKey)
+	for i, signingKey := range signingKeys {
 		signingAddresses = append(signingAddresses, signingKey.FromAddress())
 		opts.Logger.Info("Signing key ", i, ": ", signingKey.Address())
 	}
 
 	sep10JWKS := jose.JSONWebKeySet{}
-	err := json.Unmarshal([]byte(opts.SEP10JWKS), &sep10JWKS)
+	err = json.Unmarshal([]byte(opts.SEP10JWKS), &sep10JWKS)
 	if err != nil {
 		return handlerDeps{}, errors.Wrap(err, "parsing SEP-10 JSON Web Key (JWK) Set")
 	}
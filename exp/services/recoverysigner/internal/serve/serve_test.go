@@ -0,0 +1,57 @@
+package serve
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadSigningKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "recoverysigner")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	seedKey := keypair.MustRandom()
+	fileKey := keypair.MustRandom()
+	path := filepath.Join(dir, "signing-key.json")
+	_, err = keypair.SaveKeyfile(path, fileKey, []byte("secret"), keypair.LightScryptParams)
+	require.NoError(t, err)
+
+	passwordPath := filepath.Join(dir, "password")
+	require.NoError(t, ioutil.WriteFile(passwordPath, []byte("secret\n"), 0600))
+	wrongPasswordPath := filepath.Join(dir, "wrong-password")
+	require.NoError(t, ioutil.WriteFile(wrongPasswordPath, []byte("wrong"), 0600))
+
+	keys, err := loadSigningKeys(Options{
+		SigningKeys:            seedKey.Seed(),
+		SigningKeyFiles:        path,
+		SigningKeyPasswordFile: passwordPath,
+	})
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, seedKey.Address(), keys[0].Address())
+	assert.Equal(t, fileKey.Address(), keys[1].Address())
+
+	keys, err = loadSigningKeys(Options{SigningKeyFiles: path, SigningKeyPasswordFile: passwordPath})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, fileKey.Address(), keys[0].Address())
+
+	_, err = loadSigningKeys(Options{SigningKeyFiles: path, SigningKeyPasswordFile: wrongPasswordPath})
+	assert.EqualError(t, err, "loading signing key file "+path+": wrong password or corrupted encrypted key")
+
+	_, err = loadSigningKeys(Options{SigningKeyFiles: path})
+	assert.EqualError(t, err, "signing-key-password-file is required with signing-key-file")
+
+	_, err = loadSigningKeys(Options{SigningKeyFiles: path, SigningKeyPasswordFile: filepath.Join(dir, "missing")})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reading signing key password file")
+
+	_, err = loadSigningKeys(Options{})
+	assert.EqualError(t, err, "no signing keys, set signing-key or signing-key-file")
+}
@@ -18,6 +18,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/stellar/go/support/errors"
 	"github.com/stellar/go/xdr"
@@ -32,7 +33,9 @@ type CommandOptions struct {
 	DryRun      bool
 	Force       bool
 	Verify      bool
-	Thorough    bool
+	// Thorough decodes and re-encodes all buckets and checks the bucket list
+	// hash of every HAS against the ledger header of its checkpoint.
+	Thorough bool
 }
 
 type ConnectOptions struct {
@@ -44,6 +47,22 @@ type ConnectOptions struct {
 	S3Region          string
 	S3Endpoint        string
 	UnsignedRequests  bool
+	// CacheDir enables a local disk cache of immutable archive files (buckets
+	// and ledger, transactions and results checkpoint files) when not empty.
+	CacheDir string
+	// CacheMaxSize is the size limit of the cache in bytes. When zero,
+	// DefaultCacheMaxSize is used.
+	CacheMaxSize int64
+	// MaxRetries is the number of times a failed HTTP or S3 request is
+	// retried. When zero, DefaultMaxRetries is used. Negative values disable
+	// retries.
+	MaxRetries int
+	// RetryBackoff is the initial delay between retries, doubled after each
+	// attempt up to MaxRetryBackoff. The actual delay is picked randomly
+	// between zero and that value. When zero, DefaultRetryBackoff and
+	// DefaultMaxRetryBackoff are used.
+	RetryBackoff    time.Duration
+	MaxRetryBackoff time.Duration
 }
 
 type ArchiveBackend interface {
@@ -55,6 +74,12 @@ type ArchiveBackend interface {
 	CanListFiles() bool
 }
 
+// Remover is implemented by the archive backends which can remove files, as
+// required by Prune.
+type Remover interface {
+	RemoveFile(path string) error
+}
+
 type ArchiveInterface interface {
 	GetPathHAS(path string) (HistoryArchiveState, error)
 	PutPathHAS(path string, has HistoryArchiveState, opts *CommandOptions) error
@@ -89,16 +114,28 @@ type Archive struct {
 	actualTxSetHashes       map[uint32]Hash
 	expectTxResultSetHashes map[uint32]Hash
 	actualTxResultSetHashes map[uint32]Hash
+	expectBucketListHashes  map[uint32]Hash
+	actualBucketListHashes  map[uint32]Hash
 
 	invalidBuckets int
 
 	invalidLedgers      int
 	invalidTxSets       int
 	invalidTxResultSets int
+	invalidBucketLists  int
 
 	backend ArchiveBackend
 }
 
+// RequestStats returns the request counters of the HTTP and S3 backends.
+// Other backends return zero values.
+func (a *Archive) RequestStats() RequestStats {
+	if b, ok := a.backend.(interface{ RequestStats() RequestStats }); ok {
+		return b.RequestStats()
+	}
+	return RequestStats{}
+}
+
 func (a *Archive) GetPathHAS(path string) (HistoryArchiveState, error) {
 	var has HistoryArchiveState
 	rdr, err := a.backend.GetFile(path)
@@ -286,6 +323,8 @@ func Connect(u string, opts ConnectOptions) (*Archive, error) {
 		actualTxSetHashes:       make(map[uint32]Hash),
 		expectTxResultSetHashes: make(map[uint32]Hash),
 		actualTxResultSetHashes: make(map[uint32]Hash),
+		expectBucketListHashes:  make(map[uint32]Hash),
+		actualBucketListHashes:  make(map[uint32]Hash),
 	}
 	for _, cat := range Categories() {
 		arch.checkpointFiles[cat] = make(map[uint32]bool)
@@ -321,6 +360,10 @@ func Connect(u string, opts ConnectOptions) (*Archive, error) {
 	} else {
 		err = errors.New("unknown URL scheme: '" + parsed.Scheme + "'")
 	}
+
+	if err == nil && opts.CacheDir != "" {
+		arch.backend, err = makeCachingBackend(arch.backend, opts)
+	}
 	return &arch, err
 }
 
@@ -14,6 +14,7 @@ import (
 	"math/big"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 
 	"github.com/stellar/go/xdr"
@@ -295,6 +296,198 @@ func TestDryRunNoRepair(t *testing.T) {
 	assert.NotEqual(t, 0, countMissing(dst, opts))
 }
 
+func TestVerifyBucketListHash(t *testing.T) {
+	verify := func(corrupt bool) (*Archive, error) {
+		arch := GetTestMockArchive()
+		has := HistoryArchiveState{CurrentLedger: 0x3f}
+		has.CurrentBuckets[0].Curr = Hash{1}.String()
+		has.CurrentBuckets[0].Snap = Hash{2}.String()
+		bucketListHash, err := has.BucketListHash()
+		assert.NoError(t, err)
+
+		entry := xdr.LedgerHeaderHistoryEntry{
+			Header: xdr.LedgerHeader{
+				LedgerSeq:      0x3f,
+				BucketListHash: bucketListHash,
+			},
+		}
+		h, err := HashXdr(&entry.Header)
+		assert.NoError(t, err)
+		entry.Hash = xdr.Hash(h)
+		assert.NoError(t, arch.VerifyLedgerHeaderHistoryEntry(&entry))
+
+		if corrupt {
+			has.CurrentBuckets[0].Snap = Hash{3}.String()
+		}
+		assert.NoError(t, arch.VerifyBucketListHash(0x3f, &has))
+		return arch, arch.ReportInvalid(&CommandOptions{Verify: true, Thorough: true})
+	}
+
+	arch, err := verify(false)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, arch.invalidBucketLists)
+
+	arch, err = verify(true)
+	assert.EqualError(t, err, "Detected 1 objects with unexpected hashes")
+	assert.Equal(t, 1, arch.invalidBucketLists)
+}
+
+func TestDiff(t *testing.T) {
+	defer cleanup()
+	opts := testOptions()
+	src := GetRandomPopulatedArchive()
+	dst := GetTestArchive()
+	Mirror(src, dst, opts)
+
+	has, err := dst.GetCheckpointHAS(0x7f)
+	assert.NoError(t, err)
+	buckets, err := has.Buckets()
+	assert.NoError(t, err)
+	assert.NoError(t, removeFile(dst, CategoryCheckpointPath("history", 0x7f)))
+	assert.NoError(t, removeFile(dst, CategoryCheckpointPath("ledger", 0xff)))
+	assert.NoError(t, removeFile(dst, BucketPath(buckets[0])))
+
+	diff, err := Diff(src, dst, testOptions())
+	assert.NoError(t, err)
+	assert.False(t, diff.Empty())
+	assert.Equal(t, []uint32{0x7f}, diff.Checkpoints["history"])
+	assert.Equal(t, []uint32{0xff}, diff.Checkpoints["ledger"])
+	assert.Empty(t, diff.Checkpoints["results"])
+	assert.Equal(t, []Hash{buckets[0]}, diff.Buckets)
+
+	diff, err = Diff(src, src, testOptions())
+	assert.NoError(t, err)
+	assert.True(t, diff.Empty())
+}
+
+func removeFile(arch *Archive, pth string) error {
+	return arch.backend.(Remover).RemoveFile(pth)
+}
+
+func pruneTestArchive(t *testing.T) (*Archive, Hash, []Hash) {
+	arch := GetRandomPopulatedArchive()
+	extra, err := arch.AddRandomBucket()
+	assert.NoError(t, err)
+	has, err := arch.GetCheckpointHAS(0x7f)
+	assert.NoError(t, err)
+	dropped, err := has.Buckets()
+	assert.NoError(t, err)
+	assert.NoError(t, removeFile(arch, CategoryCheckpointPath("history", 0x7f)))
+	return arch, extra, dropped
+}
+
+func TestPrune(t *testing.T) {
+	defer cleanup()
+	arch, extra, dropped := pruneTestArchive(t)
+	has, err := arch.GetCheckpointHAS(0xbf)
+	assert.NoError(t, err)
+	retained, err := has.Buckets()
+	assert.NoError(t, err)
+
+	assert.NoError(t, Prune(arch, testOptions()))
+	for _, bucket := range append(dropped, extra) {
+		exists, err := arch.BucketExists(bucket)
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	}
+	for _, bucket := range retained {
+		exists, err := arch.BucketExists(bucket)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+func TestPruneDryRun(t *testing.T) {
+	defer cleanup()
+	arch, extra, dropped := pruneTestArchive(t)
+	opts := testOptions()
+	opts.DryRun = true
+
+	assert.NoError(t, Prune(arch, opts))
+	assert.Len(t, arch.CheckBucketsUnreferenced(), len(dropped)+1)
+	for _, bucket := range append(dropped, extra) {
+		exists, err := arch.BucketExists(bucket)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+// publishingBackend publishes a new root HAS the first time buckets are
+// listed, like a publisher running alongside Prune.
+type publishingBackend struct {
+	ArchiveBackend
+	Remover
+	publish func()
+	once    sync.Once
+}
+
+func (b *publishingBackend) ListFiles(pth string) (chan string, chan error) {
+	if pth == "bucket" {
+		b.once.Do(b.publish)
+	}
+	return b.ArchiveBackend.ListFiles(pth)
+}
+
+func TestPruneWhilePublishing(t *testing.T) {
+	defer cleanup()
+	arch, extra, dropped := pruneTestArchive(t)
+	has, err := arch.GetRootHAS()
+	assert.NoError(t, err)
+	arch.backend = &publishingBackend{
+		ArchiveBackend: arch.backend,
+		Remover:        arch.backend.(Remover),
+		publish: func() {
+			has.CurrentLedger += 64
+			assert.NoError(t, arch.PutRootHAS(has, testOptions()))
+		},
+	}
+
+	err = Prune(arch, testOptions())
+	assert.EqualError(t, err, fmt.Sprintf("Root HAS changed from ledger %d to %d while scanning, not pruning", has.CurrentLedger-64, has.CurrentLedger))
+	for _, bucket := range append(dropped, extra) {
+		exists, err := arch.BucketExists(bucket)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+	}
+}
+
+func TestPruneRequiresRemover(t *testing.T) {
+	defer cleanup()
+	arch, _, _ := pruneTestArchive(t)
+	arch.backend = struct{ ArchiveBackend }{arch.backend}
+
+	assert.EqualError(t, Prune(arch, testOptions()), "Pruning requires an archive which can remove files")
+	opts := testOptions()
+	opts.DryRun = true
+	assert.NoError(t, Prune(arch, opts))
+}
+
+func TestStats(t *testing.T) {
+	defer cleanup()
+	arch := GetRandomPopulatedArchive()
+	stats, err := Stats(arch, testOptions(), 100)
+	assert.NoError(t, err)
+
+	checkpoints := testRange().Size()
+	buckets := checkpoints * NumLevels * 3
+	assert.Equal(t, testRange(), stats.Range)
+	assert.Equal(t, FileStats{Files: checkpoints, Size: int64(checkpoints * 1024)}, stats.Categories["ledger"])
+	assert.Equal(t, checkpoints, stats.Categories["history"].Files)
+	assert.Equal(t, FileStats{Files: buckets, Size: int64(buckets * 1024)}, stats.Buckets)
+	assert.Equal(t, buckets, stats.ReferencedBuckets)
+	assert.Equal(t, 0, stats.UnreferencedBuckets)
+
+	// Ranges of 128 ledgers, the last one with a single checkpoint.
+	assert.Len(t, stats.Growth, (checkpoints+1)/2)
+	first := stats.Growth[0]
+	assert.Equal(t, Range{Low: 0x3f, High: 0x7f}, first.Range)
+	assert.Equal(t, 2*len(Categories()), first.Checkpoints.Files)
+	assert.Equal(t, FileStats{Files: 2 * NumLevels * 3, Size: 2 * NumLevels * 3 * 1024}, first.Buckets)
+	last := stats.Growth[len(stats.Growth)-1]
+	assert.Equal(t, Range{Low: 0x3bf, High: 0x3bf}, last.Range)
+	assert.Equal(t, len(Categories()), last.Checkpoints.Files)
+}
+
 func TestNetworkPassphrase(t *testing.T) {
 	makeHASReader := func() io.ReadCloser {
 		return ioutil.NopCloser(strings.NewReader(`
@@ -0,0 +1,358 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"compress/gzip"
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stellar/go/support/errors"
+)
+
+// DefaultCacheMaxSize is the size limit of the local cache used when
+// ConnectOptions.CacheMaxSize is not set.
+const DefaultCacheMaxSize = int64(10 << 30)
+
+const cacheTmpDir = ".tmp"
+
+var cachedBucketRegexp = regexp.MustCompile("bucket-([0-9a-f]{64})\\.xdr\\.gz$")
+
+// CachingArchiveBackend is a read-through cache of the immutable files
+// (buckets and ledger, transactions and results checkpoint files) of another
+// backend. Files are stored on the local disk, the least recently used ones
+// are removed when the cache grows over its size limit. Buckets are only
+// stored when their contents match their hash. All other files, including
+// the root HAS, are always read from the wrapped backend.
+type CachingArchiveBackend struct {
+	backend ArchiveBackend
+	dir     string
+	maxSize int64
+
+	mutex sync.Mutex
+	size  int64
+	// lru contains *cacheEntry values, most recently used first.
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type cacheEntry struct {
+	path string
+	size int64
+}
+
+func isCacheablePath(pth string) bool {
+	if !strings.HasSuffix(pth, ".xdr.gz") || strings.Contains(pth, "..") {
+		return false
+	}
+	for _, cat := range []string{"bucket", "ledger", "transactions", "results"} {
+		if strings.HasPrefix(pth, cat+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (b *CachingArchiveBackend) localPath(pth string) string {
+	return filepath.Join(b.dir, filepath.FromSlash(pth))
+}
+
+func (b *CachingArchiveBackend) lookup(pth string) (*cacheEntry, bool) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	elem, ok := b.entries[pth]
+	if !ok {
+		return nil, false
+	}
+	b.lru.MoveToFront(elem)
+	return elem.Value.(*cacheEntry), true
+}
+
+func (b *CachingArchiveBackend) forget(pth string) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if elem, ok := b.entries[pth]; ok {
+		b.removeElement(elem)
+	}
+}
+
+// removeElement removes the entry from the cache and deletes its file. The
+// caller must hold the mutex.
+func (b *CachingArchiveBackend) removeElement(elem *list.Element) {
+	entry := b.lru.Remove(elem).(*cacheEntry)
+	delete(b.entries, entry.path)
+	b.size -= entry.size
+	if err := os.Remove(b.localPath(entry.path)); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing cached file %s: %v", entry.path, err)
+	}
+}
+
+// add registers a file that was just moved into the cache directory and
+// evicts the least recently used files over the size limit.
+func (b *CachingArchiveBackend) add(pth string, size int64) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if elem, ok := b.entries[pth]; ok {
+		old := b.lru.Remove(elem).(*cacheEntry)
+		b.size -= old.size
+	}
+	b.entries[pth] = b.lru.PushFront(&cacheEntry{path: pth, size: size})
+	b.size += size
+
+	for b.size > b.maxSize && b.lru.Len() > 0 {
+		b.removeElement(b.lru.Back())
+	}
+}
+
+func (b *CachingArchiveBackend) GetFile(pth string) (io.ReadCloser, error) {
+	if !isCacheablePath(pth) {
+		return b.backend.GetFile(pth)
+	}
+
+	if _, ok := b.lookup(pth); ok {
+		local := b.localPath(pth)
+		file, err := os.Open(local)
+		if err == nil {
+			// Keep the LRU order across restarts.
+			now := time.Now()
+			os.Chtimes(local, now, now)
+			return file, nil
+		}
+		// The file was removed behind our back, fetch it again.
+		b.forget(pth)
+	}
+
+	rdr, err := b.backend.GetFile(pth)
+	if err != nil {
+		return nil, err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Join(b.dir, cacheTmpDir), "download")
+	if err != nil {
+		// Serve the file without caching it.
+		log.Printf("Error creating cache file for %s: %v", pth, err)
+		return rdr, nil
+	}
+
+	return &cacheFillReader{
+		backend: b,
+		path:    pth,
+		in:      rdr,
+		tmp:     tmp,
+	}, nil
+}
+
+// commit moves a fully downloaded file into the cache.
+func (b *CachingArchiveBackend) commit(pth string, tmpPath string, size int64) error {
+	if m := cachedBucketRegexp.FindStringSubmatch(pth); m != nil {
+		if err := checkCachedBucketHash(tmpPath, MustDecodeHash(m[1])); err != nil {
+			return err
+		}
+	}
+
+	if size > b.maxSize {
+		return errors.Errorf("file larger than cache size limit (%d bytes)", size)
+	}
+
+	dst := b.localPath(pth)
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, dst); err != nil {
+		return err
+	}
+	b.add(pth, size)
+	return nil
+}
+
+func checkCachedBucketHash(pth string, expect Hash) error {
+	file, err := os.Open(pth)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	rdr, err := gzip.NewReader(bufReadCloser(file))
+	if err != nil {
+		return err
+	}
+	defer rdr.Close()
+
+	hsh := sha256.New()
+	if _, err := io.Copy(hsh, rdr); err != nil {
+		return err
+	}
+	return checkBucketHash(hsh, expect)
+}
+
+func (b *CachingArchiveBackend) Exists(pth string) (bool, error) {
+	if isCacheablePath(pth) {
+		if _, ok := b.lookup(pth); ok {
+			return true, nil
+		}
+	}
+	return b.backend.Exists(pth)
+}
+
+func (b *CachingArchiveBackend) Size(pth string) (int64, error) {
+	if isCacheablePath(pth) {
+		if entry, ok := b.lookup(pth); ok {
+			return entry.size, nil
+		}
+	}
+	return b.backend.Size(pth)
+}
+
+func (b *CachingArchiveBackend) PutFile(pth string, in io.ReadCloser) error {
+	b.forget(pth)
+	return b.backend.PutFile(pth, in)
+}
+
+func (b *CachingArchiveBackend) RemoveFile(pth string) error {
+	remover, ok := b.backend.(Remover)
+	if !ok {
+		return errors.New("RemoveFile not available for the cached archive")
+	}
+	b.forget(pth)
+	return remover.RemoveFile(pth)
+}
+
+func (b *CachingArchiveBackend) ListFiles(pth string) (chan string, chan error) {
+	return b.backend.ListFiles(pth)
+}
+
+func (b *CachingArchiveBackend) CanListFiles() bool {
+	return b.backend.CanListFiles()
+}
+
+// RequestStats returns the request counters of the wrapped backend.
+func (b *CachingArchiveBackend) RequestStats() RequestStats {
+	if backend, ok := b.backend.(interface{ RequestStats() RequestStats }); ok {
+		return backend.RequestStats()
+	}
+	return RequestStats{}
+}
+
+// load registers the files left in the cache directory by previous runs,
+// using their modification times to restore the LRU order.
+func (b *CachingArchiveBackend) load() error {
+	tmpDir := filepath.Join(b.dir, cacheTmpDir)
+	if err := os.RemoveAll(tmpDir); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(tmpDir, 0755); err != nil {
+		return err
+	}
+
+	type cachedFile struct {
+		path string
+		info os.FileInfo
+	}
+	var files []cachedFile
+	err := filepath.Walk(b.dir, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if p == tmpDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(b.dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if isCacheablePath(rel) {
+			files = append(files, cachedFile{rel, info})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].info.ModTime().Before(files[j].info.ModTime())
+	})
+	for _, f := range files {
+		b.add(f.path, f.info.Size())
+	}
+	return nil
+}
+
+// cacheFillReader streams a file from the wrapped backend and copies it to a
+// temporary file, which is moved into the cache once the whole file was read.
+type cacheFillReader struct {
+	backend *CachingArchiveBackend
+	path    string
+	in      io.ReadCloser
+	tmp     *os.File
+	size    int64
+	failed  bool
+	done    bool
+}
+
+func (r *cacheFillReader) Read(p []byte) (int, error) {
+	n, err := r.in.Read(p)
+	if n > 0 && !r.failed {
+		if _, werr := r.tmp.Write(p[:n]); werr != nil {
+			log.Printf("Error writing cache file for %s: %v", r.path, werr)
+			r.failed = true
+		}
+		r.size += int64(n)
+	}
+	if err == io.EOF && !r.failed && !r.done {
+		r.done = true
+		if cerr := r.tmp.Close(); cerr != nil {
+			r.failed = true
+		} else if cerr := r.backend.commit(r.path, r.tmp.Name(), r.size); cerr != nil {
+			log.Printf("Not caching %s: %v", r.path, cerr)
+			r.failed = true
+		}
+	}
+	return n, err
+}
+
+func (r *cacheFillReader) Close() error {
+	if !r.done {
+		// The file was not read to the end, discard what we have.
+		r.tmp.Close()
+		r.failed = true
+	}
+	if r.failed {
+		os.Remove(r.tmp.Name())
+	}
+	return r.in.Close()
+}
+
+func makeCachingBackend(backend ArchiveBackend, opts ConnectOptions) (ArchiveBackend, error) {
+	maxSize := opts.CacheMaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultCacheMaxSize
+	}
+	b := &CachingArchiveBackend{
+		backend: backend,
+		dir:     filepath.Clean(opts.CacheDir),
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: map[string]*list.Element{},
+	}
+	if err := b.load(); err != nil {
+		return nil, errors.Wrap(err, "error loading cache directory")
+	}
+	return b, nil
+}
@@ -0,0 +1,169 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"crypto/sha256"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getTestCachingArchive(t *testing.T, dir string) (*Archive, *MockArchiveBackend) {
+	arch, err := Connect("mock://test", ConnectOptions{CacheDir: dir})
+	require.NoError(t, err)
+	require.IsType(t, &CachingArchiveBackend{}, arch.backend)
+	return arch, arch.backend.(*CachingArchiveBackend).backend.(*MockArchiveBackend)
+}
+
+func putGzippedBucket(t *testing.T, backend ArchiveBackend) (Hash, []byte) {
+	buf := make([]byte, 1024)
+	_, err := rand.Read(buf)
+	require.NoError(t, err)
+
+	var gz bytes.Buffer
+	w := gzip.NewWriter(&gz)
+	_, err = w.Write(buf)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	h := Hash(sha256.Sum256(buf))
+	require.NoError(t, backend.PutFile(BucketPath(h), ioutil.NopCloser(bytes.NewReader(gz.Bytes()))))
+	return h, gz.Bytes()
+}
+
+func readFile(t *testing.T, arch *Archive, pth string) []byte {
+	rdr, err := arch.backend.GetFile(pth)
+	require.NoError(t, err)
+	defer rdr.Close()
+	buf, err := ioutil.ReadAll(rdr)
+	require.NoError(t, err)
+	return buf
+}
+
+func TestCachingArchiveBackendServesFromCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	arch, upstream := getTestCachingArchive(t, dir)
+	h, contents := putGzippedBucket(t, upstream)
+
+	assert.Equal(t, contents, readFile(t, arch, BucketPath(h)))
+
+	// The bucket is now served from disk even if upstream loses it.
+	delete(upstream.files, BucketPath(h))
+	assert.Equal(t, contents, readFile(t, arch, BucketPath(h)))
+	exists, err := arch.BucketExists(h)
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	size, err := arch.backend.Size(BucketPath(h))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(contents)), size)
+
+	// A new instance loads the files cached by the previous one.
+	arch, _ = getTestCachingArchive(t, dir)
+	assert.Equal(t, contents, readFile(t, arch, BucketPath(h)))
+}
+
+func TestCachingArchiveBackendSkipsInvalidBuckets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	arch, upstream := getTestCachingArchive(t, dir)
+	h, _ := putGzippedBucket(t, upstream)
+	other, contents := putGzippedBucket(t, upstream)
+	upstream.files[BucketPath(h)] = contents
+
+	assert.Equal(t, contents, readFile(t, arch, BucketPath(h)))
+
+	delete(upstream.files, BucketPath(h))
+	_, err = arch.backend.GetFile(BucketPath(h))
+	assert.EqualError(t, err, "no such file: "+BucketPath(h))
+
+	// The valid bucket with the same contents is cached.
+	assert.Equal(t, contents, readFile(t, arch, BucketPath(other)))
+	delete(upstream.files, BucketPath(other))
+	assert.Equal(t, contents, readFile(t, arch, BucketPath(other)))
+}
+
+func TestCachingArchiveBackendSkipsMutableFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	arch, _ := getTestCachingArchive(t, dir)
+	assert.NoError(t, arch.AddRandomCheckpoint(0x3f))
+
+	has, err := arch.GetRootHAS()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x3f), has.CurrentLedger)
+
+	assert.NoError(t, arch.AddRandomCheckpoint(0x7f))
+	has, err = arch.GetRootHAS()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x7f), has.CurrentLedger)
+
+	assert.True(t, isCacheablePath(CategoryCheckpointPath("ledger", 0x3f)))
+	assert.True(t, isCacheablePath(CategoryCheckpointPath("transactions", 0x3f)))
+	assert.True(t, isCacheablePath(CategoryCheckpointPath("results", 0x3f)))
+	assert.False(t, isCacheablePath(CategoryCheckpointPath("history", 0x3f)))
+	assert.False(t, isCacheablePath(rootHASPath))
+}
+
+func TestCachingArchiveBackendEvictsLeastRecentlyUsed(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	arch, upstream := getTestCachingArchive(t, dir)
+	first, contents := putGzippedBucket(t, upstream)
+	second, _ := putGzippedBucket(t, upstream)
+	third, _ := putGzippedBucket(t, upstream)
+
+	// Room for two buckets.
+	cache := arch.backend.(*CachingArchiveBackend)
+	cache.maxSize = int64(2*len(contents) + len(contents)/2)
+
+	readFile(t, arch, BucketPath(first))
+	readFile(t, arch, BucketPath(second))
+	readFile(t, arch, BucketPath(first))
+	readFile(t, arch, BucketPath(third))
+
+	assert.Equal(t, 2, cache.lru.Len())
+	assert.Contains(t, cache.entries, BucketPath(first))
+	assert.Contains(t, cache.entries, BucketPath(third))
+	assert.NotContains(t, cache.entries, BucketPath(second))
+	_, err = os.Stat(cache.localPath(BucketPath(second)))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCachingArchiveBackendPartialRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "archive-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	arch, upstream := getTestCachingArchive(t, dir)
+	h, _ := putGzippedBucket(t, upstream)
+
+	rdr, err := arch.backend.GetFile(BucketPath(h))
+	require.NoError(t, err)
+	_, err = rdr.Read(make([]byte, 10))
+	assert.NoError(t, err)
+	assert.NoError(t, rdr.Close())
+
+	cache := arch.backend.(*CachingArchiveBackend)
+	assert.Equal(t, 0, cache.lru.Len())
+	files, err := ioutil.ReadDir(dir + "/" + cacheTmpDir)
+	assert.NoError(t, err)
+	assert.Empty(t, files)
+}
@@ -0,0 +1,126 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// ArchiveDiff lists the files present in one archive but missing in another.
+type ArchiveDiff struct {
+	// Checkpoints lists, by category, the checkpoints which have a file in
+	// the source archive but not in the destination archive.
+	Checkpoints map[string][]uint32
+	// Buckets lists the buckets present in the source archive but not in the
+	// destination archive.
+	Buckets []Hash
+}
+
+// Empty returns true if the destination archive has all the files of the
+// source archive.
+func (d *ArchiveDiff) Empty() bool {
+	for _, chks := range d.Checkpoints {
+		if len(chks) != 0 {
+			return false
+		}
+	}
+	return len(d.Buckets) == 0
+}
+
+// scanRange scans the checkpoint files and buckets of the archive in the
+// range of opts, without clamping the range to the archive's root HAS.
+func (arch *Archive) scanRange(opts *CommandOptions) error {
+	var err error
+	if arch.backend.CanListFiles() {
+		err = arch.ScanCheckpointsFast(opts)
+	} else {
+		err = arch.ScanCheckpointsSlow(opts)
+	}
+	if err != nil {
+		return err
+	}
+	return arch.ScanBuckets(opts)
+}
+
+// Diff returns the checkpoint files and buckets present in src but missing in
+// dst, in the range of opts clamped to the root HAS of src.
+func Diff(src *Archive, dst *Archive, opts *CommandOptions) (*ArchiveDiff, error) {
+	state, e := src.GetRootHAS()
+	if e != nil {
+		return nil, e
+	}
+	opts.Range = opts.Range.clamp(state.Range())
+
+	log.Printf("Scanning source archive in range: %s", opts.Range)
+	var errs uint32
+	errs += noteError(src.scanRange(opts))
+	log.Printf("Scanning destination archive in range: %s", opts.Range)
+	errs += noteError(dst.scanRange(opts))
+	if errs != 0 {
+		return nil, fmt.Errorf("%d errors while scanning archives", errs)
+	}
+
+	diff := &ArchiveDiff{Checkpoints: make(map[string][]uint32)}
+	for _, cat := range Categories() {
+		chks := src.PresentCheckpoints(cat)
+		dst.mutex.Lock()
+		present := dst.checkpointFiles[cat]
+		for _, chk := range chks {
+			if !present[chk] {
+				diff.Checkpoints[cat] = append(diff.Checkpoints[cat], chk)
+			}
+		}
+		dst.mutex.Unlock()
+	}
+
+	src.mutex.Lock()
+	srcBuckets := make([]Hash, 0, len(src.allBuckets))
+	for bucket := range src.allBuckets {
+		srcBuckets = append(srcBuckets, bucket)
+	}
+	src.mutex.Unlock()
+	sort.Slice(srcBuckets, func(i, j int) bool {
+		return bytes.Compare(srcBuckets[i][:], srcBuckets[j][:]) < 0
+	})
+
+	for _, bucket := range srcBuckets {
+		dst.mutex.Lock()
+		found := dst.allBuckets[bucket]
+		dst.mutex.Unlock()
+		if found {
+			continue
+		}
+		// The destination scan only finds the buckets referenced by its own
+		// checkpoints unless it listed all buckets, so check directly.
+		exists, err := dst.BucketExists(bucket)
+		if err != nil {
+			return nil, err
+		}
+		if !exists {
+			diff.Buckets = append(diff.Buckets, bucket)
+		}
+	}
+
+	return diff, nil
+}
+
+// ReportDiff logs the files present in src but missing in dst.
+func ReportDiff(diff *ArchiveDiff, opts *CommandOptions) {
+	for _, cat := range Categories() {
+		missing := diff.Checkpoints[cat]
+		if len(missing) != 0 {
+			log.Printf("Missing %s (%d): %s", cat, len(missing), fmtRangeList(missing))
+		}
+	}
+	for _, bucket := range diff.Buckets {
+		log.Printf("Missing bucket: %s", bucket)
+	}
+	if diff.Empty() {
+		log.Printf("No files missing in range %s", opts.Range)
+	}
+}
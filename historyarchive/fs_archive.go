@@ -69,6 +69,10 @@ func (b *FsArchiveBackend) PutFile(pth string, in io.ReadCloser) error {
 	return e
 }
 
+func (b *FsArchiveBackend) RemoveFile(pth string) error {
+	return os.Remove(path.Join(b.prefix, pth))
+}
+
 func (b *FsArchiveBackend) ListFiles(pth string) (chan string, chan error) {
 	ch := make(chan string)
 	errs := make(chan error)
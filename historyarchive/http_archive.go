@@ -16,27 +16,43 @@ import (
 )
 
 type HttpArchiveBackend struct {
-	ctx    context.Context
-	client http.Client
-	base   url.URL
+	ctx     context.Context
+	client  http.Client
+	base    url.URL
+	retrier *retrier
 }
 
 func checkResp(r *http.Response) error {
 	if r.StatusCode >= 200 && r.StatusCode < 400 {
 		return nil
 	} else {
-		return fmt.Errorf("Bad HTTP response '%s' for GET '%s'",
-			r.Status, r.Request.URL.String())
+		return httpStatusError{
+			StatusCode: r.StatusCode,
+			Status:     r.Status,
+			Method:     r.Request.Method,
+			URL:        r.Request.URL.String(),
+		}
 	}
 }
 
+// GetFile downloads the file, retrying failed requests and resuming the
+// download with a Range request if the connection breaks part-way through.
 func (b *HttpArchiveBackend) GetFile(pth string) (io.ReadCloser, error) {
 	var derived url.URL = b.base
 	derived.Path = path.Join(derived.Path, pth)
-	req, err := http.NewRequest("GET", derived.String(), nil)
+	return b.retrier.open(func(offset int64) (io.ReadCloser, error) {
+		return b.get(derived.String(), offset)
+	})
+}
+
+func (b *HttpArchiveBackend) get(u string, offset int64) (io.ReadCloser, error) {
+	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
 		return nil, err
 	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
 	req = req.WithContext(b.ctx)
 	resp, err := b.client.Do(req)
 	if err != nil {
@@ -52,6 +68,10 @@ func (b *HttpArchiveBackend) GetFile(pth string) (io.ReadCloser, error) {
 		}
 		return nil, err
 	}
+	if offset > 0 && resp.StatusCode != http.StatusPartialContent {
+		// The server ignored the Range header.
+		return skipBytes(resp.Body, offset)
+	}
 	return resp.Body, nil
 }
 
@@ -63,18 +83,33 @@ func (b *HttpArchiveBackend) Head(pth string) (*http.Response, error) {
 		return nil, err
 	}
 	req = req.WithContext(b.ctx)
-	resp, err := b.client.Do(req)
+
+	var resp *http.Response
+	err = b.retrier.do(func() error {
+		var err error
+		resp, err = b.client.Do(req)
+		if err != nil {
+			return err
+		}
+		if resp.Body != nil {
+			resp.Body.Close()
+		}
+		if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+			return checkResp(resp)
+		}
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-
-	if resp.Body != nil {
-		resp.Body.Close()
-	}
-
 	return resp, nil
 }
 
+// RequestStats returns the number of requests, retries and failures.
+func (b *HttpArchiveBackend) RequestStats() RequestStats {
+	return b.retrier.stats()
+}
+
 func (b *HttpArchiveBackend) Exists(pth string) (bool, error) {
 	resp, err := b.Head(pth)
 	if err != nil {
@@ -123,7 +158,8 @@ func (b *HttpArchiveBackend) CanListFiles() bool {
 
 func makeHttpBackend(base *url.URL, opts ConnectOptions) ArchiveBackend {
 	return &HttpArchiveBackend{
-		ctx:  opts.Context,
-		base: *base,
+		ctx:     opts.Context,
+		base:    *base,
+		retrier: makeRetrier(opts),
 	}
 }
@@ -58,6 +58,16 @@ func (b *MockArchiveBackend) PutFile(pth string, in io.ReadCloser) error {
 	return nil
 }
 
+func (b *MockArchiveBackend) RemoveFile(pth string) error {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	if _, ok := b.files[pth]; !ok {
+		return errors.New("no such file: " + pth)
+	}
+	delete(b.files, pth)
+	return nil
+}
+
 func (b *MockArchiveBackend) ListFiles(pth string) (chan string, chan error) {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
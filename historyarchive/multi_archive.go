@@ -0,0 +1,431 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// MultiArchiveOptions configures a MultiArchive.
+type MultiArchiveOptions struct {
+	// Quorum is the number of archives that must agree on the contents of
+	// HAS files and on ledger header hashes. Values lower than 2 disable
+	// cross-verification: files are read from the first archive that has
+	// them.
+	Quorum int
+	// OnDisagreement is called with the name of an archive that failed or
+	// returned contents different from the other archives. When nil, the
+	// archive is reported in the log.
+	OnDisagreement func(archive string, err error)
+}
+
+// MultiArchive reads from several archives of the same network. Reads fail
+// over to the next archive when a file is missing or cannot be read. XDR
+// streams are read to the end and, for buckets, checked against their hash
+// before they are returned, so that a broken file is read from the next
+// archive too. When a quorum is configured, HAS files and ledger headers are
+// read from all archives and only returned when enough of them agree. The
+// root HAS is the latest checkpoint reached by a quorum of archives, so a
+// lagging archive does not hold back the others.
+//
+// Listing methods use the first archive only. Write methods write to all
+// archives.
+type MultiArchive struct {
+	names          []string
+	archives       []ArchiveInterface
+	quorum         int
+	onDisagreement func(archive string, err error)
+}
+
+var _ ArchiveInterface = &MultiArchive{}
+
+// NewMultiArchive returns a MultiArchive reading from the given archives.
+// names are used to report failing archives.
+func NewMultiArchive(names []string, archives []ArchiveInterface, opts MultiArchiveOptions) (*MultiArchive, error) {
+	if len(archives) == 0 {
+		return nil, errors.New("no archives")
+	}
+	if len(names) != len(archives) {
+		return nil, errors.New("the number of names and archives does not match")
+	}
+	if opts.Quorum > len(archives) {
+		return nil, errors.Errorf("quorum (%d) larger than the number of archives (%d)", opts.Quorum, len(archives))
+	}
+
+	m := &MultiArchive{
+		names:          names,
+		archives:       archives,
+		quorum:         opts.Quorum,
+		onDisagreement: opts.OnDisagreement,
+	}
+	if m.onDisagreement == nil {
+		m.onDisagreement = func(archive string, err error) {
+			log.Printf("Archive %s: %v", archive, err)
+		}
+	}
+	return m, nil
+}
+
+// ConnectMulti connects to all the given archive URLs and returns a
+// MultiArchive reading from them.
+func ConnectMulti(urls []string, connectOpts ConnectOptions, opts MultiArchiveOptions) (*MultiArchive, error) {
+	archives := make([]ArchiveInterface, 0, len(urls))
+	for _, u := range urls {
+		archive, err := Connect(u, connectOpts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error connecting to %s", u)
+		}
+		archives = append(archives, archive)
+	}
+	return NewMultiArchive(urls, archives, opts)
+}
+
+func (m *MultiArchive) verifying() bool {
+	return m.quorum > 1
+}
+
+// failover calls op on each archive in turn until it succeeds.
+func (m *MultiArchive) failover(op func(ArchiveInterface) error) error {
+	var err error
+	for i, archive := range m.archives {
+		if err = op(archive); err == nil {
+			return nil
+		}
+		m.onDisagreement(m.names[i], err)
+	}
+	return errors.Wrap(err, "all archives failed")
+}
+
+type archiveResult struct {
+	key   string
+	value interface{}
+	err   error
+}
+
+// agree calls fetch on all archives concurrently and returns the value
+// returned by at least a quorum of them. Archives that failed or returned a
+// different value are reported.
+func (m *MultiArchive) agree(
+	what string,
+	fetch func(ArchiveInterface) (key string, value interface{}, err error),
+) (interface{}, error) {
+	results := make([]archiveResult, len(m.archives))
+	var wg sync.WaitGroup
+	for i, archive := range m.archives {
+		wg.Add(1)
+		go func(i int, archive ArchiveInterface) {
+			defer wg.Done()
+			key, value, err := fetch(archive)
+			results[i] = archiveResult{key, value, err}
+		}(i, archive)
+	}
+	wg.Wait()
+
+	votes := map[string]int{}
+	var best string
+	for _, result := range results {
+		if result.err != nil {
+			continue
+		}
+		votes[result.key]++
+		if votes[result.key] > votes[best] {
+			best = result.key
+		}
+	}
+
+	if votes[best] < m.quorum {
+		for i, result := range results {
+			if result.err != nil {
+				m.onDisagreement(m.names[i], result.err)
+			}
+		}
+		return nil, errors.Errorf(
+			"no quorum for %s: %d of %d archives agree, %d required",
+			what, votes[best], len(m.archives), m.quorum,
+		)
+	}
+
+	var value interface{}
+	for i, result := range results {
+		switch {
+		case result.err != nil:
+			m.onDisagreement(m.names[i], result.err)
+		case result.key != best:
+			m.onDisagreement(m.names[i], errors.Errorf("%s differs from the other archives", what))
+		default:
+			value = result.value
+		}
+	}
+	return value, nil
+}
+
+func hasKey(has HistoryArchiveState) (string, error) {
+	hash, err := has.BucketListHash()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d/%x/%s", has.CurrentLedger, hash, has.NetworkPassphrase), nil
+}
+
+func (m *MultiArchive) agreeHAS(what string, get func(ArchiveInterface) (HistoryArchiveState, error)) (HistoryArchiveState, error) {
+	value, err := m.agree(what, func(archive ArchiveInterface) (string, interface{}, error) {
+		has, err := get(archive)
+		if err != nil {
+			return "", nil, err
+		}
+		key, err := hasKey(has)
+		return key, has, err
+	})
+	if err != nil {
+		return HistoryArchiveState{}, err
+	}
+	return value.(HistoryArchiveState), nil
+}
+
+func (m *MultiArchive) getHAS(what string, get func(ArchiveInterface) (HistoryArchiveState, error)) (HistoryArchiveState, error) {
+	if m.verifying() {
+		return m.agreeHAS(what, get)
+	}
+
+	var has HistoryArchiveState
+	err := m.failover(func(archive ArchiveInterface) error {
+		var err error
+		has, err = get(archive)
+		return err
+	})
+	return has, err
+}
+
+func (m *MultiArchive) GetPathHAS(path string) (HistoryArchiveState, error) {
+	return m.getHAS(path, func(archive ArchiveInterface) (HistoryArchiveState, error) {
+		return archive.GetPathHAS(path)
+	})
+}
+
+func (m *MultiArchive) GetCheckpointHAS(chk uint32) (HistoryArchiveState, error) {
+	return m.getHAS(fmt.Sprintf("checkpoint HAS %d", chk), func(archive ArchiveInterface) (HistoryArchiveState, error) {
+		return archive.GetCheckpointHAS(chk)
+	})
+}
+
+// GetRootHAS returns the HAS of the latest checkpoint. When cross-verifying,
+// it is the latest checkpoint published by at least a quorum of archives.
+func (m *MultiArchive) GetRootHAS() (HistoryArchiveState, error) {
+	if !m.verifying() {
+		return m.getHAS("root HAS", func(archive ArchiveInterface) (HistoryArchiveState, error) {
+			return archive.GetRootHAS()
+		})
+	}
+
+	roots := make([]HistoryArchiveState, len(m.archives))
+	errs := make([]error, len(m.archives))
+	var wg sync.WaitGroup
+	for i, archive := range m.archives {
+		wg.Add(1)
+		go func(i int, archive ArchiveInterface) {
+			defer wg.Done()
+			roots[i], errs[i] = archive.GetRootHAS()
+		}(i, archive)
+	}
+	wg.Wait()
+
+	var ledgers []uint32
+	for i, err := range errs {
+		if err != nil {
+			m.onDisagreement(m.names[i], err)
+			continue
+		}
+		ledgers = append(ledgers, roots[i].CurrentLedger)
+	}
+
+	if len(ledgers) < m.quorum {
+		return HistoryArchiveState{}, errors.Errorf(
+			"no quorum for root HAS: %d of %d archives available, %d required",
+			len(ledgers), len(m.archives), m.quorum,
+		)
+	}
+
+	// The quorum-th highest ledger has been published by at least a quorum
+	// of archives.
+	sort.Slice(ledgers, func(i, j int) bool { return ledgers[i] > ledgers[j] })
+	return m.GetCheckpointHAS(ledgers[m.quorum-1])
+}
+
+func (m *MultiArchive) GetLedgerHeader(ledger uint32) (xdr.LedgerHeaderHistoryEntry, error) {
+	if m.verifying() {
+		value, err := m.agree(
+			fmt.Sprintf("ledger header %d", ledger),
+			func(archive ArchiveInterface) (string, interface{}, error) {
+				header, err := archive.GetLedgerHeader(ledger)
+				if err != nil {
+					return "", nil, err
+				}
+				return hex.EncodeToString(header.Hash[:]), header, nil
+			},
+		)
+		if err != nil {
+			return xdr.LedgerHeaderHistoryEntry{}, err
+		}
+		return value.(xdr.LedgerHeaderHistoryEntry), nil
+	}
+
+	var header xdr.LedgerHeaderHistoryEntry
+	err := m.failover(func(archive ArchiveInterface) error {
+		var err error
+		header, err = archive.GetLedgerHeader(ledger)
+		return err
+	})
+	return header, err
+}
+
+// exists returns true if any of the archives has the file.
+func (m *MultiArchive) exists(op func(ArchiveInterface) (bool, error)) (bool, error) {
+	var lastErr error
+	failed := 0
+	for i, archive := range m.archives {
+		exists, err := op(archive)
+		if err != nil {
+			m.onDisagreement(m.names[i], err)
+			lastErr = err
+			failed++
+			continue
+		}
+		if exists {
+			return true, nil
+		}
+	}
+	if failed == len(m.archives) {
+		return false, errors.Wrap(lastErr, "all archives failed")
+	}
+	return false, nil
+}
+
+func (m *MultiArchive) BucketExists(bucket Hash) (bool, error) {
+	return m.exists(func(archive ArchiveInterface) (bool, error) {
+		return archive.BucketExists(bucket)
+	})
+}
+
+func (m *MultiArchive) CategoryCheckpointExists(cat string, chk uint32) (bool, error) {
+	return m.exists(func(archive ArchiveInterface) (bool, error) {
+		return archive.CategoryCheckpointExists(cat, chk)
+	})
+}
+
+// getXdrStream opens a stream with open from each archive in turn until one
+// of them can be read to the end and, if expected is set, matches it. The
+// stream is then opened again and returned, so files are downloaded twice
+// unless the archives cache them (see ConnectOptions.CacheDir). The stream of
+// the last archive is returned without being checked first, the caller gets
+// the error when reading or closing it.
+func (m *MultiArchive) getXdrStream(open func(ArchiveInterface) (*XdrStream, error), expected *Hash) (*XdrStream, error) {
+	var stream *XdrStream
+	tried := 0
+	err := m.failover(func(archive ArchiveInterface) error {
+		var err error
+		tried++
+		if tried < len(m.archives) {
+			if err = checkXdrStream(open, archive, expected); err != nil {
+				return err
+			}
+		}
+		stream, err = open(archive)
+		if err == nil && expected != nil {
+			stream.SetExpectedHash(*expected)
+		}
+		return err
+	})
+	return stream, err
+}
+
+// checkXdrStream reads a stream of archive to the end and checks its hash.
+func checkXdrStream(open func(ArchiveInterface) (*XdrStream, error), archive ArchiveInterface, expected *Hash) error {
+	stream, err := open(archive)
+	if err != nil {
+		return err
+	}
+	if expected != nil {
+		stream.SetExpectedHash(*expected)
+	}
+	if _, err = io.Copy(ioutil.Discard, stream.rdr); err != nil {
+		stream.closeReaders()
+		return errors.Wrap(err, "error reading stream")
+	}
+	return stream.Close()
+}
+
+func (m *MultiArchive) GetXdrStreamForHash(hash Hash) (*XdrStream, error) {
+	return m.getXdrStream(func(archive ArchiveInterface) (*XdrStream, error) {
+		return archive.GetXdrStreamForHash(hash)
+	}, &hash)
+}
+
+func (m *MultiArchive) GetXdrStream(pth string) (*XdrStream, error) {
+	return m.getXdrStream(func(archive ArchiveInterface) (*XdrStream, error) {
+		return archive.GetXdrStream(pth)
+	}, nil)
+}
+
+// ListBucket lists the buckets of the first archive. Listings are not failed
+// over because archives publish different sets of files, mixing them would
+// not give the contents of any archive.
+func (m *MultiArchive) ListBucket(dp DirPrefix) (chan string, chan error) {
+	return m.archives[0].ListBucket(dp)
+}
+
+// ListAllBuckets lists the buckets of the first archive, see ListBucket.
+func (m *MultiArchive) ListAllBuckets() (chan string, chan error) {
+	return m.archives[0].ListAllBuckets()
+}
+
+// ListAllBucketHashes lists the buckets of the first archive, see
+// ListBucket.
+func (m *MultiArchive) ListAllBucketHashes() (chan Hash, chan error) {
+	return m.archives[0].ListAllBucketHashes()
+}
+
+// ListCategoryCheckpoints lists the checkpoint files of the first archive,
+// see ListBucket.
+func (m *MultiArchive) ListCategoryCheckpoints(cat string, pth string) (chan uint32, chan error) {
+	return m.archives[0].ListCategoryCheckpoints(cat, pth)
+}
+
+// writeAll calls op on all archives and returns the first error.
+func (m *MultiArchive) writeAll(op func(ArchiveInterface) error) error {
+	var firstErr error
+	for i, archive := range m.archives {
+		if err := op(archive); err != nil && firstErr == nil {
+			firstErr = errors.Wrapf(err, "error writing to %s", m.names[i])
+		}
+	}
+	return firstErr
+}
+
+func (m *MultiArchive) PutPathHAS(path string, has HistoryArchiveState, opts *CommandOptions) error {
+	return m.writeAll(func(archive ArchiveInterface) error {
+		return archive.PutPathHAS(path, has, opts)
+	})
+}
+
+func (m *MultiArchive) PutCheckpointHAS(chk uint32, has HistoryArchiveState, opts *CommandOptions) error {
+	return m.writeAll(func(archive ArchiveInterface) error {
+		return archive.PutCheckpointHAS(chk, has, opts)
+	})
+}
+
+func (m *MultiArchive) PutRootHAS(has HistoryArchiveState, opts *CommandOptions) error {
+	return m.writeAll(func(archive ArchiveInterface) error {
+		return archive.PutRootHAS(has, opts)
+	})
+}
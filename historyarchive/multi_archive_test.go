@@ -0,0 +1,248 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/stellar/go/xdr"
+)
+
+type MultiArchiveTestSuite struct {
+	suite.Suite
+	archives []*MockArchive
+	reported []string
+}
+
+func TestMultiArchiveTestSuite(t *testing.T) {
+	suite.Run(t, new(MultiArchiveTestSuite))
+}
+
+func (s *MultiArchiveTestSuite) SetupTest() {
+	s.archives = []*MockArchive{{}, {}, {}}
+	s.reported = nil
+}
+
+func (s *MultiArchiveTestSuite) TearDownTest() {
+	for _, archive := range s.archives {
+		archive.AssertExpectations(s.T())
+	}
+}
+
+func (s *MultiArchiveTestSuite) multiArchive(quorum int) *MultiArchive {
+	archives := []ArchiveInterface{s.archives[0], s.archives[1], s.archives[2]}
+	m, err := NewMultiArchive([]string{"a", "b", "c"}, archives, MultiArchiveOptions{
+		Quorum: quorum,
+		OnDisagreement: func(archive string, err error) {
+			s.reported = append(s.reported, archive+": "+err.Error())
+		},
+	})
+	s.Require().NoError(err)
+	return m
+}
+
+func testHAS(ledger uint32, curr string) HistoryArchiveState {
+	var has HistoryArchiveState
+	has.CurrentLedger = ledger
+	has.CurrentBuckets[0].Curr = curr
+	return has
+}
+
+func (s *MultiArchiveTestSuite) TestFailover() {
+	has := testHAS(127, "aa")
+	s.archives[0].On("GetCheckpointHAS", uint32(127)).
+		Return(HistoryArchiveState{}, errors.New("no such file")).Once()
+	s.archives[1].On("GetCheckpointHAS", uint32(127)).Return(has, nil).Once()
+
+	actual, err := s.multiArchive(0).GetCheckpointHAS(127)
+	s.Assert().NoError(err)
+	s.Assert().Equal(has, actual)
+	s.Assert().Equal([]string{"a: no such file"}, s.reported)
+}
+
+func (s *MultiArchiveTestSuite) TestFailoverAllFail() {
+	for _, archive := range s.archives {
+		archive.On("GetXdrStreamForHash", Hash{1}).
+			Return((*XdrStream)(nil), errors.New("no such file")).Once()
+	}
+
+	_, err := s.multiArchive(0).GetXdrStreamForHash(Hash{1})
+	s.Assert().EqualError(err, "all archives failed: no such file")
+	s.Assert().Len(s.reported, 3)
+}
+
+func (s *MultiArchiveTestSuite) TestQuorumHAS() {
+	has := testHAS(127, "aa")
+	s.archives[0].On("GetCheckpointHAS", uint32(127)).Return(has, nil).Once()
+	s.archives[1].On("GetCheckpointHAS", uint32(127)).Return(testHAS(127, "bb"), nil).Once()
+	s.archives[2].On("GetCheckpointHAS", uint32(127)).Return(has, nil).Once()
+
+	actual, err := s.multiArchive(2).GetCheckpointHAS(127)
+	s.Assert().NoError(err)
+	s.Assert().Equal(has, actual)
+	s.Assert().Equal([]string{"b: checkpoint HAS 127 differs from the other archives"}, s.reported)
+}
+
+func (s *MultiArchiveTestSuite) TestNoQuorumHAS() {
+	s.archives[0].On("GetCheckpointHAS", uint32(127)).Return(testHAS(127, "aa"), nil).Once()
+	s.archives[1].On("GetCheckpointHAS", uint32(127)).Return(testHAS(127, "bb"), nil).Once()
+	s.archives[2].On("GetCheckpointHAS", uint32(127)).
+		Return(HistoryArchiveState{}, errors.New("timeout")).Once()
+
+	_, err := s.multiArchive(2).GetCheckpointHAS(127)
+	s.Assert().EqualError(err, "no quorum for checkpoint HAS 127: 1 of 3 archives agree, 2 required")
+	s.Assert().Equal([]string{"c: timeout"}, s.reported)
+}
+
+func (s *MultiArchiveTestSuite) TestQuorumRootHASWithLaggingArchive() {
+	s.archives[0].On("GetRootHAS").Return(testHAS(191, "aa"), nil).Once()
+	s.archives[1].On("GetRootHAS").Return(testHAS(63, "aa"), nil).Once()
+	s.archives[2].On("GetRootHAS").Return(testHAS(127, "aa"), nil).Once()
+
+	has := testHAS(127, "bb")
+	for _, archive := range s.archives {
+		archive.On("GetCheckpointHAS", uint32(127)).Return(has, nil).Once()
+	}
+
+	actual, err := s.multiArchive(2).GetRootHAS()
+	s.Assert().NoError(err)
+	s.Assert().Equal(has, actual)
+	s.Assert().Empty(s.reported)
+}
+
+func (s *MultiArchiveTestSuite) TestQuorumLedgerHeader() {
+	header := xdr.LedgerHeaderHistoryEntry{Hash: xdr.Hash{1}}
+	s.archives[0].On("GetLedgerHeader", uint32(100)).Return(header, nil).Once()
+	s.archives[1].On("GetLedgerHeader", uint32(100)).Return(header, nil).Once()
+	s.archives[2].On("GetLedgerHeader", uint32(100)).
+		Return(xdr.LedgerHeaderHistoryEntry{Hash: xdr.Hash{2}}, nil).Once()
+
+	actual, err := s.multiArchive(3).GetLedgerHeader(100)
+	s.Assert().EqualError(err, "no quorum for ledger header 100: 2 of 3 archives agree, 3 required")
+
+	s.archives[0].On("GetLedgerHeader", uint32(100)).Return(header, nil).Once()
+	s.archives[1].On("GetLedgerHeader", uint32(100)).Return(header, nil).Once()
+	s.archives[2].On("GetLedgerHeader", uint32(100)).
+		Return(xdr.LedgerHeaderHistoryEntry{Hash: xdr.Hash{2}}, nil).Once()
+
+	actual, err = s.multiArchive(2).GetLedgerHeader(100)
+	s.Assert().NoError(err)
+	s.Assert().Equal(header, actual)
+	s.Assert().Equal([]string{"c: ledger header 100 differs from the other archives"}, s.reported)
+}
+
+func (s *MultiArchiveTestSuite) TestBucketExists() {
+	s.archives[0].On("BucketExists", Hash{1}).Return(false, nil).Once()
+	s.archives[1].On("BucketExists", Hash{1}).Return(false, errors.New("timeout")).Once()
+	s.archives[2].On("BucketExists", Hash{1}).Return(true, nil).Once()
+
+	exists, err := s.multiArchive(0).BucketExists(Hash{1})
+	s.Assert().NoError(err)
+	s.Assert().True(exists)
+}
+
+func TestConnectMulti(t *testing.T) {
+	_, err := ConnectMulti(nil, ConnectOptions{}, MultiArchiveOptions{})
+	assert.EqualError(t, err, "no archives")
+
+	_, err = ConnectMulti([]string{"mock://a"}, ConnectOptions{}, MultiArchiveOptions{Quorum: 2})
+	assert.EqualError(t, err, "quorum (2) larger than the number of archives (1)")
+
+	var reported []string
+	m, err := ConnectMulti(
+		[]string{"mock://a", "mock://b"},
+		ConnectOptions{},
+		MultiArchiveOptions{
+			OnDisagreement: func(archive string, err error) {
+				reported = append(reported, archive)
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	second := m.archives[1].(*Archive)
+	require.NoError(t, second.AddRandomCheckpoint(0x3f))
+
+	has, err := m.GetRootHAS()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x3f), has.CurrentLedger)
+	assert.Equal(t, []string{"mock://a"}, reported)
+}
+
+func TestMultiArchiveBrokenStreams(t *testing.T) {
+	entry := xdr.BucketEntry{
+		Type: xdr.BucketEntryTypeLiveentry,
+		LiveEntry: &xdr.LedgerEntry{
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{
+					AccountId: xdr.MustAddress("GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML"),
+					Balance:   xdr.Int64(200000000),
+				},
+			},
+		},
+	}
+	var bucket bytes.Buffer
+	require.NoError(t, xdr.MarshalFramed(&bucket, entry))
+	hash := Hash(sha256.Sum256(bucket.Bytes()))
+	good, err := gzipBytes(bucket.Bytes())
+	require.NoError(t, err)
+
+	corrupt := append([]byte{}, bucket.Bytes()...)
+	corrupt[len(corrupt)-1] ^= 0xff
+	truncated := good[:len(good)-4]
+	corruptGz, err := gzipBytes(corrupt)
+	require.NoError(t, err)
+
+	var reported []string
+	m, err := ConnectMulti(
+		[]string{"mock://a", "mock://b", "mock://c"},
+		ConnectOptions{},
+		MultiArchiveOptions{
+			OnDisagreement: func(archive string, err error) {
+				reported = append(reported, archive+": "+err.Error())
+			},
+		},
+	)
+	require.NoError(t, err)
+
+	put := func(i int, pth string, contents []byte) {
+		backend := m.archives[i].(*Archive).backend
+		require.NoError(t, backend.PutFile(pth, ioutil.NopCloser(bytes.NewReader(contents))))
+	}
+	put(0, BucketPath(hash), corruptGz)
+	put(1, BucketPath(hash), truncated)
+	put(2, BucketPath(hash), good)
+
+	stream, err := m.GetXdrStreamForHash(hash)
+	require.NoError(t, err)
+	var actual xdr.BucketEntry
+	require.NoError(t, stream.ReadOne(&actual))
+	assert.Equal(t, entry, actual)
+	assert.NoError(t, stream.Close())
+	require.Len(t, reported, 2)
+	assert.Contains(t, reported[0], "mock://a: Stream hash does not match expected hash!")
+	assert.Contains(t, reported[1], "mock://b: error reading stream")
+
+	reported = nil
+	pth := "ledger/00/00/00/ledger-0000003f.xdr.gz"
+	put(0, pth, truncated)
+	put(1, pth, good)
+	stream, err = m.GetXdrStream(pth)
+	require.NoError(t, err)
+	require.NoError(t, stream.ReadOne(&actual))
+	assert.Equal(t, entry, actual)
+	assert.NoError(t, stream.Close())
+	require.Len(t, reported, 1)
+	assert.Contains(t, reported[0], "mock://a: error reading stream")
+}
@@ -0,0 +1,99 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// Prune removes the buckets which are not referenced by the root HAS nor by
+// any checkpoint HAS in the range of opts. The range therefore defines the
+// checkpoints to retain: buckets only referenced by checkpoints outside of it
+// are removed. With opts.DryRun set, the buckets are only logged.
+//
+// Pruning requires a backend which can list files and implements Remover,
+// and nothing is removed if any file of the archive could not be scanned.
+//
+// Prune must not run while checkpoints are being published to the archive: a
+// publisher uploads the buckets of a checkpoint before its HAS, so buckets
+// which are about to be referenced look unreferenced until then. Prune
+// re-reads the root HAS before removing anything and gives up if it changed,
+// but it cannot detect a checkpoint whose HAS is not written yet.
+func Prune(arch *Archive, opts *CommandOptions) error {
+	if !arch.backend.CanListFiles() {
+		return errors.New("Pruning requires an archive which can list files")
+	}
+	remover, canRemove := arch.backend.(Remover)
+	if !canRemove && !opts.DryRun {
+		return errors.New("Pruning requires an archive which can remove files")
+	}
+
+	state, e := arch.GetRootHAS()
+	if e != nil {
+		return e
+	}
+	opts.Range = opts.Range.clamp(state.Range())
+
+	log.Printf("Scanning checkpoints to retain in range: %s", opts.Range)
+	var errs uint32
+	errs += noteError(arch.ScanCheckpointsFast(opts))
+	errs += noteError(arch.ScanAllBuckets())
+	if errs != 0 {
+		return fmt.Errorf("%d errors while scanning archive, not pruning", errs)
+	}
+
+	chks := arch.PresentCheckpoints("history")
+	if len(chks) == 0 {
+		return fmt.Errorf("No checkpoint HAS found in range %s, not pruning", opts.Range)
+	}
+	if _, err := arch.NoteBucketReferences(chks, opts.Concurrency); err != nil {
+		return err
+	}
+	rootBuckets, err := state.Buckets()
+	if err != nil {
+		return err
+	}
+	for _, bucket := range rootBuckets {
+		arch.NoteReferencedBucket(bucket)
+	}
+
+	unreferenced := arch.CheckBucketsUnreferenced()
+	buckets := make([]Hash, 0, len(unreferenced))
+	for bucket := range unreferenced {
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return bytes.Compare(buckets[i][:], buckets[j][:]) < 0
+	})
+
+	latest, err := arch.GetRootHAS()
+	if err != nil {
+		return err
+	}
+	if latest.CurrentLedger != state.CurrentLedger {
+		return fmt.Errorf("Root HAS changed from ledger %d to %d while scanning, not pruning",
+			state.CurrentLedger, latest.CurrentLedger)
+	}
+
+	for _, bucket := range buckets {
+		pth := BucketPath(bucket)
+		if opts.DryRun {
+			log.Printf("dryrun skipping removal of %s", pth)
+			continue
+		}
+		log.Printf("Removing %s", pth)
+		errs += noteError(remover.RemoveFile(pth))
+	}
+
+	log.Printf("Found %d unreferenced buckets (of %d)", len(buckets), len(arch.allBuckets))
+	if errs != 0 {
+		return fmt.Errorf("%d errors while pruning", errs)
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+)
+
+const (
+	// DefaultMaxRetries is the number of times a failed request is retried
+	// when ConnectOptions.MaxRetries is zero.
+	DefaultMaxRetries = 5
+	// DefaultRetryBackoff is the initial delay between retries when
+	// ConnectOptions.RetryBackoff is zero.
+	DefaultRetryBackoff = 500 * time.Millisecond
+	// DefaultMaxRetryBackoff is the maximum delay between retries when
+	// ConnectOptions.MaxRetryBackoff is zero.
+	DefaultMaxRetryBackoff = 30 * time.Second
+)
+
+// RequestStats contains the request counters of a backend.
+type RequestStats struct {
+	// Requests is the number of requests sent, including retries.
+	Requests uint64
+	// Retries is the number of failed requests (or interrupted downloads)
+	// that were retried.
+	Retries uint64
+	// Failures is the number of operations that failed after all retries.
+	Failures uint64
+}
+
+// httpStatusError is returned for unsuccessful HTTP responses.
+type httpStatusError struct {
+	StatusCode int
+	Status     string
+	Method     string
+	URL        string
+}
+
+func (e httpStatusError) Error() string {
+	return fmt.Sprintf("Bad HTTP response '%s' for %s '%s'", e.Status, e.Method, e.URL)
+}
+
+// retrier retries failing requests with jittered exponential backoff.
+type retrier struct {
+	ctx        context.Context
+	maxRetries int
+	backoff    time.Duration
+	maxBackoff time.Duration
+
+	requests uint64
+	retries  uint64
+	failures uint64
+}
+
+func makeRetrier(opts ConnectOptions) *retrier {
+	r := &retrier{
+		ctx:        opts.Context,
+		maxRetries: opts.MaxRetries,
+		backoff:    opts.RetryBackoff,
+		maxBackoff: opts.MaxRetryBackoff,
+	}
+	if r.ctx == nil {
+		r.ctx = context.Background()
+	}
+	if r.maxRetries == 0 {
+		r.maxRetries = DefaultMaxRetries
+	} else if r.maxRetries < 0 {
+		r.maxRetries = 0
+	}
+	if r.backoff <= 0 {
+		r.backoff = DefaultRetryBackoff
+	}
+	if r.maxBackoff <= 0 {
+		r.maxBackoff = DefaultMaxRetryBackoff
+	}
+	return r
+}
+
+func (r *retrier) stats() RequestStats {
+	return RequestStats{
+		Requests: atomic.LoadUint64(&r.requests),
+		Retries:  atomic.LoadUint64(&r.retries),
+		Failures: atomic.LoadUint64(&r.failures),
+	}
+}
+
+func (r *retrier) retryable(err error) bool {
+	if r.ctx.Err() != nil {
+		return false
+	}
+
+	switch e := err.(type) {
+	case httpStatusError:
+		return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+	case awserr.RequestFailure:
+		return e.StatusCode() >= 500 || e.StatusCode() == http.StatusTooManyRequests ||
+			request.IsErrorRetryable(err) || request.IsErrorThrottle(err)
+	}
+
+	// Anything else is a network error (connection refused or reset,
+	// unexpected EOF) that is worth retrying.
+	return true
+}
+
+// wait sleeps before the given retry attempt (starting from zero). It
+// returns early with an error if the context is cancelled.
+func (r *retrier) wait(attempt int) error {
+	backoff := r.maxBackoff
+	if attempt < 32 && r.backoff<<uint(attempt) < r.maxBackoff {
+		backoff = r.backoff << uint(attempt)
+	}
+	// Full jitter, see:
+	// https://aws.amazon.com/blogs/architecture/exponential-backoff-and-jitter/
+	backoff = time.Duration(rand.Int63n(int64(backoff) + 1))
+
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-r.ctx.Done():
+		return r.ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// do calls op until it succeeds, fails with an error that is not worth
+// retrying or the retries are exhausted.
+func (r *retrier) do(op func() error) error {
+	for attempt := 0; ; attempt++ {
+		atomic.AddUint64(&r.requests, 1)
+		err := op()
+		if err == nil {
+			return nil
+		}
+		if attempt >= r.maxRetries || !r.retryable(err) {
+			atomic.AddUint64(&r.failures, 1)
+			return err
+		}
+		atomic.AddUint64(&r.retries, 1)
+		if werr := r.wait(attempt); werr != nil {
+			atomic.AddUint64(&r.failures, 1)
+			return werr
+		}
+	}
+}
+
+// openFunc opens a file starting from the given offset.
+type openFunc func(offset int64) (io.ReadCloser, error)
+
+// open returns a reader which resumes the download from the last received
+// byte when the connection breaks part-way through the file.
+func (r *retrier) open(open openFunc) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	err := r.do(func() error {
+		var err error
+		body, err = open(0)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &resumingReader{retrier: r, open: open, body: body}, nil
+}
+
+type resumingReader struct {
+	retrier *retrier
+	open    openFunc
+	body    io.ReadCloser
+	offset  int64
+	// failed is the number of consecutive interruptions without progress.
+	failed int
+}
+
+func (rr *resumingReader) Read(p []byte) (int, error) {
+	for {
+		n, err := rr.body.Read(p)
+		rr.offset += int64(n)
+		if n > 0 {
+			rr.failed = 0
+		}
+		if err == nil || err == io.EOF {
+			return n, err
+		}
+
+		if rerr := rr.resume(err); rerr != nil {
+			return n, rerr
+		}
+		if n > 0 {
+			return n, nil
+		}
+	}
+}
+
+func (rr *resumingReader) resume(cause error) error {
+	r := rr.retrier
+	rr.body.Close()
+	if rr.failed >= r.maxRetries || !r.retryable(cause) {
+		atomic.AddUint64(&r.failures, 1)
+		return cause
+	}
+	atomic.AddUint64(&r.retries, 1)
+	if err := r.wait(rr.failed); err != nil {
+		atomic.AddUint64(&r.failures, 1)
+		return err
+	}
+	rr.failed++
+
+	return r.do(func() error {
+		body, err := rr.open(rr.offset)
+		if err != nil {
+			return err
+		}
+		rr.body = body
+		return nil
+	})
+}
+
+func (rr *resumingReader) Close() error {
+	return rr.body.Close()
+}
+
+// skipBytes discards the first n bytes of a body which was returned in full
+// even though a range was requested.
+func skipBytes(body io.ReadCloser, n int64) (io.ReadCloser, error) {
+	if _, err := io.CopyN(ioutil.Discard, body, n); err != nil {
+		body.Close()
+		return nil, err
+	}
+	return body, nil
+}
@@ -0,0 +1,159 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func connectTestHTTPArchive(t *testing.T, ctx context.Context, u string) *Archive {
+	arch, err := Connect(u, ConnectOptions{
+		Context:         ctx,
+		MaxRetries:      3,
+		RetryBackoff:    time.Millisecond,
+		MaxRetryBackoff: 5 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	return arch
+}
+
+func TestHTTPBackendRetriesServerErrors(t *testing.T) {
+	var mutex sync.Mutex
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		calls++
+		call := calls
+		mutex.Unlock()
+		if call <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "contents")
+	}))
+	defer server.Close()
+
+	arch := connectTestHTTPArchive(t, context.Background(), server.URL)
+	rdr, err := arch.backend.GetFile("file")
+	require.NoError(t, err)
+	buf, err := ioutil.ReadAll(rdr)
+	assert.NoError(t, err)
+	assert.NoError(t, rdr.Close())
+	assert.Equal(t, "contents", string(buf))
+
+	assert.Equal(t, RequestStats{Requests: 3, Retries: 2}, arch.RequestStats())
+}
+
+func TestHTTPBackendGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	arch := connectTestHTTPArchive(t, context.Background(), server.URL)
+	_, err := arch.backend.GetFile("broken")
+	assert.EqualError(t, err, "Bad HTTP response '500 Internal Server Error' for GET '"+server.URL+"/broken'")
+	assert.Equal(t, RequestStats{Requests: 4, Retries: 3, Failures: 1}, arch.RequestStats())
+
+	// Client errors are not retried.
+	_, err = arch.backend.GetFile("missing")
+	assert.Error(t, err)
+	assert.Equal(t, RequestStats{Requests: 5, Retries: 3, Failures: 2}, arch.RequestStats())
+
+	exists, err := arch.backend.Exists("missing")
+	assert.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestHTTPBackendResumesDownloads(t *testing.T) {
+	contents := strings.Repeat("0123456789", 1000)
+	var mutex sync.Mutex
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		ranges = append(ranges, r.Header.Get("Range"))
+		mutex.Unlock()
+
+		offset := 0
+		if rng := r.Header.Get("Range"); rng != "" {
+			var err error
+			offset, err = strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(rng, "bytes="), "-"))
+			require.NoError(t, err)
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", offset, len(contents)-1, len(contents)))
+			w.Header().Set("Content-Length", strconv.Itoa(len(contents)-offset))
+			w.WriteHeader(http.StatusPartialContent)
+		} else {
+			w.Header().Set("Content-Length", strconv.Itoa(len(contents)))
+		}
+
+		// Send at most 3000 bytes and break the connection.
+		end := offset + 3000
+		if end > len(contents) {
+			end = len(contents)
+		}
+		w.Write([]byte(contents[offset:end]))
+		if end < len(contents) {
+			w.(http.Flusher).Flush()
+			conn, _, err := w.(http.Hijacker).Hijack()
+			require.NoError(t, err)
+			conn.Close()
+		}
+	}))
+	defer server.Close()
+
+	arch := connectTestHTTPArchive(t, context.Background(), server.URL)
+	rdr, err := arch.backend.GetFile("file")
+	require.NoError(t, err)
+	buf, err := ioutil.ReadAll(rdr)
+	assert.NoError(t, err)
+	assert.NoError(t, rdr.Close())
+	assert.Equal(t, contents, string(buf))
+
+	assert.Equal(t, []string{"", "bytes=3000-", "bytes=6000-", "bytes=9000-"}, ranges)
+	assert.Equal(t, RequestStats{Requests: 4, Retries: 3}, arch.RequestStats())
+}
+
+func TestHTTPBackendCancelledContext(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	arch := connectTestHTTPArchive(t, ctx, server.URL)
+	_, err := arch.backend.GetFile("file")
+	assert.Error(t, err)
+	assert.Equal(t, RequestStats{Requests: 1, Failures: 1}, arch.RequestStats())
+}
+
+func TestRetryableErrors(t *testing.T) {
+	r := makeRetrier(ConnectOptions{})
+
+	assert.True(t, r.retryable(httpStatusError{StatusCode: 502}))
+	assert.True(t, r.retryable(httpStatusError{StatusCode: 429}))
+	assert.False(t, r.retryable(httpStatusError{StatusCode: 404}))
+	assert.True(t, r.retryable(awserr.NewRequestFailure(awserr.New("InternalError", "", nil), 500, "")))
+	assert.False(t, r.retryable(awserr.NewRequestFailure(awserr.New("NoSuchKey", "", nil), 404, "")))
+	assert.True(t, r.retryable(fmt.Errorf("connection reset by peer")))
+}
@@ -7,11 +7,13 @@ package historyarchive
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"net/http"
 	"path"
 
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
 	"github.com/stellar/go/support/errors"
@@ -23,13 +25,25 @@ type S3ArchiveBackend struct {
 	bucket           string
 	prefix           string
 	unsignedRequests bool
+	retrier          *retrier
 }
 
+// GetFile downloads the file, retrying failed requests and resuming the
+// download with a ranged request if the connection breaks part-way through.
 func (b *S3ArchiveBackend) GetFile(pth string) (io.ReadCloser, error) {
+	return b.retrier.open(func(offset int64) (io.ReadCloser, error) {
+		return b.get(pth, offset)
+	})
+}
+
+func (b *S3ArchiveBackend) get(pth string, offset int64) (io.ReadCloser, error) {
 	params := &s3.GetObjectInput{
 		Bucket: aws.String(b.bucket),
 		Key:    aws.String(path.Join(b.prefix, pth)),
 	}
+	if offset > 0 {
+		params.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	}
 
 	req, resp := b.svc.GetObjectRequest(params)
 	if b.unsignedRequests {
@@ -50,14 +64,21 @@ func (b *S3ArchiveBackend) Head(pth string) (*http.Response, error) {
 		Key:    aws.String(path.Join(b.prefix, pth)),
 	}
 
-	req, _ := b.svc.HeadObjectRequest(params)
-	if b.unsignedRequests {
-		req.Handlers.Sign.Clear() // makes this request unsigned
-	}
-	req.SetContext(b.ctx)
-	err := req.Send()
+	var req *request.Request
+	err := b.retrier.do(func() error {
+		req, _ = b.svc.HeadObjectRequest(params)
+		if b.unsignedRequests {
+			req.Handlers.Sign.Clear() // makes this request unsigned
+		}
+		req.SetContext(b.ctx)
+		err := req.Send()
+		if req.HTTPResponse != nil && req.HTTPResponse.StatusCode == http.StatusNotFound {
+			return nil
+		}
+		return err
+	})
 
-	if req != nil && req.HTTPResponse.StatusCode == http.StatusNotFound {
+	if req != nil && req.HTTPResponse != nil && req.HTTPResponse.StatusCode == http.StatusNotFound {
 		// Lately the S3 SDK has started treating a 404 as generating a non-nil
 		// 'err', so we have to test for this _before_ we test 'err' for
 		// nil-ness. This is undocumented, as is the err.Code returned in that
@@ -109,23 +130,40 @@ func (b *S3ArchiveBackend) PutFile(pth string, in io.ReadCloser) error {
 	if err != nil {
 		return err
 	}
-	params := &s3.PutObjectInput{
-		Bucket: aws.String(b.bucket),
-		Key:    aws.String(path.Join(b.prefix, pth)),
-		ACL:    aws.String(s3.ObjectCannedACLPublicRead),
-		Body:   bytes.NewReader(buf.Bytes()),
-	}
-	req, _ := b.svc.PutObjectRequest(params)
-	if b.unsignedRequests {
-		req.Handlers.Sign.Clear() // makes this request unsigned
-	}
-	req.SetContext(b.ctx)
-	err = req.Send()
+	err = b.retrier.do(func() error {
+		params := &s3.PutObjectInput{
+			Bucket: aws.String(b.bucket),
+			Key:    aws.String(path.Join(b.prefix, pth)),
+			ACL:    aws.String(s3.ObjectCannedACLPublicRead),
+			Body:   bytes.NewReader(buf.Bytes()),
+		}
+		req, _ := b.svc.PutObjectRequest(params)
+		if b.unsignedRequests {
+			req.Handlers.Sign.Clear() // makes this request unsigned
+		}
+		req.SetContext(b.ctx)
+		return req.Send()
+	})
 
 	in.Close()
 	return err
 }
 
+func (b *S3ArchiveBackend) RemoveFile(pth string) error {
+	return b.retrier.do(func() error {
+		params := &s3.DeleteObjectInput{
+			Bucket: aws.String(b.bucket),
+			Key:    aws.String(path.Join(b.prefix, pth)),
+		}
+		req, _ := b.svc.DeleteObjectRequest(params)
+		if b.unsignedRequests {
+			req.Handlers.Sign.Clear() // makes this request unsigned
+		}
+		req.SetContext(b.ctx)
+		return req.Send()
+	})
+}
+
 func (b *S3ArchiveBackend) ListFiles(pth string) (chan string, chan error) {
 	prefix := path.Join(b.prefix, pth)
 	ch := make(chan string)
@@ -136,12 +174,7 @@ func (b *S3ArchiveBackend) ListFiles(pth string) (chan string, chan error) {
 		MaxKeys: aws.Int64(1000),
 		Prefix:  aws.String(prefix),
 	}
-	req, resp := b.svc.ListObjectsRequest(params)
-	if b.unsignedRequests {
-		req.Handlers.Sign.Clear() // makes this request unsigned
-	}
-	req.SetContext(b.ctx)
-	err := req.Send()
+	resp, err := b.listObjects(params)
 	if err != nil {
 		errs <- err
 		close(ch)
@@ -155,14 +188,11 @@ func (b *S3ArchiveBackend) ListFiles(pth string) (chan string, chan error) {
 				ch <- *c.Key
 			}
 			if *resp.IsTruncated {
-				req, resp = b.svc.ListObjectsRequest(params)
-				if b.unsignedRequests {
-					req.Handlers.Sign.Clear() // makes this request unsigned
-				}
-				req.SetContext(b.ctx)
-				err := req.Send()
+				var err error
+				resp, err = b.listObjects(params)
 				if err != nil {
 					errs <- err
+					break
 				}
 			} else {
 				break
@@ -174,14 +204,35 @@ func (b *S3ArchiveBackend) ListFiles(pth string) (chan string, chan error) {
 	return ch, errs
 }
 
+func (b *S3ArchiveBackend) listObjects(params *s3.ListObjectsInput) (*s3.ListObjectsOutput, error) {
+	var resp *s3.ListObjectsOutput
+	err := b.retrier.do(func() error {
+		var req *request.Request
+		req, resp = b.svc.ListObjectsRequest(params)
+		if b.unsignedRequests {
+			req.Handlers.Sign.Clear() // makes this request unsigned
+		}
+		req.SetContext(b.ctx)
+		return req.Send()
+	})
+	return resp, err
+}
+
 func (b *S3ArchiveBackend) CanListFiles() bool {
 	return true
 }
 
+// RequestStats returns the number of requests, retries and failures.
+func (b *S3ArchiveBackend) RequestStats() RequestStats {
+	return b.retrier.stats()
+}
+
 func makeS3Backend(bucket string, prefix string, opts ConnectOptions) (ArchiveBackend, error) {
 	cfg := &aws.Config{
 		Region:   aws.String(opts.S3Region),
 		Endpoint: aws.String(opts.S3Endpoint),
+		// Retries are handled by the backend, see retrier.
+		MaxRetries: aws.Int(0),
 	}
 	cfg = cfg.WithS3ForcePathStyle(true)
 
@@ -196,6 +247,7 @@ func makeS3Backend(bucket string, prefix string, opts ConnectOptions) (ArchiveBa
 		bucket:           bucket,
 		prefix:           prefix,
 		unsignedRequests: opts.UnsignedRequests,
+		retrier:          makeRetrier(opts),
 	}
 	return &backend, nil
 }
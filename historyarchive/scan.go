@@ -8,6 +8,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -254,6 +255,9 @@ func (arch *Archive) ScanBuckets(opts *CommandOptions) error {
 				}
 				has, e := arch.GetCheckpointHAS(ix)
 				atomic.AddUint32(&errs, noteError(e))
+				if e == nil && opts.Verify && opts.Thorough {
+					atomic.AddUint32(&errs, noteError(arch.VerifyBucketListHash(ix, &has)))
+				}
 				buckets, err := has.Buckets()
 				if err != nil {
 					panic(err)
@@ -305,6 +309,76 @@ func (arch *Archive) ScanBuckets(opts *CommandOptions) error {
 	return nil
 }
 
+// PresentCheckpoints returns the sorted checkpoints for which a file of the
+// given category was found by the last scan.
+func (arch *Archive) PresentCheckpoints(cat string) []uint32 {
+	arch.mutex.Lock()
+	defer arch.mutex.Unlock()
+	seqs := make([]uint32, 0, len(arch.checkpointFiles[cat]))
+	for k, present := range arch.checkpointFiles[cat] {
+		if present {
+			seqs = append(seqs, k)
+		}
+	}
+	sort.Slice(seqs, func(i, j int) bool { return seqs[i] < seqs[j] })
+	return seqs
+}
+
+// NoteBucketReferences reads the HAS of each of the given checkpoints and
+// notes the buckets they reference. It returns, for each referenced bucket,
+// the first of the checkpoints referencing it.
+func (arch *Archive) NoteBucketReferences(chks []uint32, concurrency int) (map[Hash]uint32, error) {
+	if concurrency == 0 {
+		return nil, errors.New("Zero concurrency")
+	}
+
+	var errs uint32
+	var mutex sync.Mutex
+	first := make(map[Hash]uint32)
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+
+	req := make(chan uint32)
+	go func() {
+		for _, chk := range chks {
+			req <- chk
+		}
+		close(req)
+	}()
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for chk := range req {
+				has, err := arch.GetCheckpointHAS(chk)
+				if err != nil {
+					atomic.AddUint32(&errs, noteError(err))
+					continue
+				}
+				buckets, err := has.Buckets()
+				if err != nil {
+					atomic.AddUint32(&errs, noteError(err))
+					continue
+				}
+				mutex.Lock()
+				for _, bucket := range buckets {
+					arch.NoteReferencedBucket(bucket)
+					if prev, ok := first[bucket]; !ok || chk < prev {
+						first[bucket] = chk
+					}
+				}
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	if errs != 0 {
+		return nil, fmt.Errorf("%d errors while reading bucket references", errs)
+	}
+	return first, nil
+}
+
 func (arch *Archive) ClearCachedInfo() {
 	arch.mutex.Lock()
 	defer arch.mutex.Unlock()
@@ -385,6 +459,21 @@ func (arch *Archive) CheckBucketsMissing() map[Hash]bool {
 	return missing
 }
 
+// CheckBucketsUnreferenced returns the existing buckets which are not
+// referenced by any of the scanned checkpoints.
+func (arch *Archive) CheckBucketsUnreferenced() map[Hash]bool {
+	arch.mutex.Lock()
+	defer arch.mutex.Unlock()
+	unreferenced := make(map[Hash]bool)
+	for k := range arch.allBuckets {
+		_, ok := arch.referencedBuckets[k]
+		if !ok {
+			unreferenced[k] = true
+		}
+	}
+	return unreferenced
+}
+
 func (arch *Archive) ReportMissing(opts *CommandOptions) error {
 
 	log.Printf("Examining checkpoint files for gaps")
@@ -0,0 +1,209 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+)
+
+// DefaultStatsRangeSize is the number of ledgers in each range of
+// ArchiveStats.Growth when no size is given.
+const DefaultStatsRangeSize = 1024 * CheckpointFreq
+
+// FileStats is the number and total size of a set of files.
+type FileStats struct {
+	Files int
+	Size  int64
+}
+
+func (s *FileStats) add(size int64) {
+	s.Files++
+	s.Size += size
+}
+
+// RangeStats describes the files added to an archive by the checkpoints of a
+// range: their checkpoint files and the buckets first referenced by them.
+type RangeStats struct {
+	Range       Range
+	Checkpoints FileStats
+	Buckets     FileStats
+}
+
+// ArchiveStats describes the contents of an archive in a range of ledgers.
+type ArchiveStats struct {
+	Range Range
+	// Categories contains the checkpoint files by category.
+	Categories map[string]FileStats
+	// Buckets contains the buckets found by the scan: all buckets when the
+	// scanned range covers the whole archive, the referenced ones otherwise.
+	Buckets FileStats
+	// ReferencedBuckets is the number of buckets referenced by the HAS of
+	// the scanned checkpoints.
+	ReferencedBuckets int
+	// UnreferencedBuckets is the number of buckets found by the scan which
+	// are not referenced by any scanned checkpoint.
+	UnreferencedBuckets int
+	// Growth contains the files added by consecutive ranges of checkpoints.
+	Growth []RangeStats
+}
+
+// fileSizes returns the sizes of the given files.
+func (arch *Archive) fileSizes(paths []string, concurrency int) (map[string]int64, error) {
+	if concurrency == 0 {
+		return nil, errors.New("Zero concurrency")
+	}
+
+	var errs uint32
+	var mutex sync.Mutex
+	sizes := make(map[string]int64, len(paths))
+
+	var wg sync.WaitGroup
+	wg.Add(concurrency)
+
+	req := make(chan string)
+	go func() {
+		for _, pth := range paths {
+			req <- pth
+		}
+		close(req)
+	}()
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			defer wg.Done()
+			for pth := range req {
+				size, err := arch.backend.Size(pth)
+				if err != nil {
+					atomic.AddUint32(&errs, noteError(err))
+					continue
+				}
+				mutex.Lock()
+				sizes[pth] = size
+				mutex.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+	if errs != 0 {
+		return nil, fmt.Errorf("%d errors while getting file sizes", errs)
+	}
+	return sizes, nil
+}
+
+// Stats scans the archive in the range of opts and reports the size of its
+// files. Growth is reported for consecutive ranges of rangeSize ledgers
+// (rounded up to a multiple of the checkpoint frequency), or
+// DefaultStatsRangeSize when zero.
+func Stats(arch *Archive, opts *CommandOptions, rangeSize uint32) (*ArchiveStats, error) {
+	if rangeSize == 0 {
+		rangeSize = DefaultStatsRangeSize
+	}
+	if rem := rangeSize % CheckpointFreq; rem != 0 {
+		rangeSize += CheckpointFreq - rem
+	}
+
+	if err := arch.Scan(opts); err != nil {
+		return nil, err
+	}
+
+	stats := &ArchiveStats{
+		Range:      opts.Range,
+		Categories: make(map[string]FileStats),
+	}
+
+	var paths []string
+	checkpoints := make(map[string][]uint32)
+	for _, cat := range Categories() {
+		checkpoints[cat] = arch.PresentCheckpoints(cat)
+		for _, chk := range checkpoints[cat] {
+			paths = append(paths, CategoryCheckpointPath(cat, chk))
+		}
+	}
+
+	firstReferences, err := arch.NoteBucketReferences(checkpoints["history"], opts.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	arch.mutex.Lock()
+	buckets := make([]Hash, 0, len(arch.allBuckets))
+	for bucket := range arch.allBuckets {
+		buckets = append(buckets, bucket)
+		paths = append(paths, BucketPath(bucket))
+	}
+	arch.mutex.Unlock()
+
+	log.Printf("Getting size of %d files", len(paths))
+	sizes, err := arch.fileSizes(paths, opts.Concurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	growth := make(map[uint32]*RangeStats)
+	rangeOf := func(chk uint32) *RangeStats {
+		i := (chk - opts.Range.Low) / rangeSize
+		rs, ok := growth[i]
+		if !ok {
+			low := opts.Range.Low + i*rangeSize
+			high := low + rangeSize - CheckpointFreq
+			if high > opts.Range.High || high < low {
+				high = opts.Range.High
+			}
+			rs = &RangeStats{Range: Range{Low: low, High: high}}
+			growth[i] = rs
+		}
+		return rs
+	}
+
+	for _, cat := range Categories() {
+		var fs FileStats
+		for _, chk := range checkpoints[cat] {
+			size := sizes[CategoryCheckpointPath(cat, chk)]
+			fs.add(size)
+			rangeOf(chk).Checkpoints.add(size)
+		}
+		stats.Categories[cat] = fs
+	}
+
+	for _, bucket := range buckets {
+		size := sizes[BucketPath(bucket)]
+		stats.Buckets.add(size)
+		if chk, ok := firstReferences[bucket]; ok {
+			stats.ReferencedBuckets++
+			rangeOf(chk).Buckets.add(size)
+		} else {
+			stats.UnreferencedBuckets++
+		}
+	}
+
+	for i := uint32(0); len(stats.Growth) < len(growth); i++ {
+		if rs, ok := growth[i]; ok {
+			stats.Growth = append(stats.Growth, *rs)
+		}
+	}
+	return stats, nil
+}
+
+// ReportStats logs the archive statistics.
+func ReportStats(stats *ArchiveStats) {
+	log.Printf("Archive statistics in range: %s", stats.Range)
+	for _, cat := range Categories() {
+		fs := stats.Categories[cat]
+		log.Printf("  %-12s %8d files %14d bytes", cat, fs.Files, fs.Size)
+	}
+	log.Printf("  %-12s %8d files %14d bytes (%d referenced, %d unreferenced)",
+		"bucket", stats.Buckets.Files, stats.Buckets.Size,
+		stats.ReferencedBuckets, stats.UnreferencedBuckets)
+	log.Printf("Growth by checkpoint range:")
+	for _, rs := range stats.Growth {
+		log.Printf("  %s: %d checkpoint files (%d bytes), %d new buckets (%d bytes)",
+			rs.Range, rs.Checkpoints.Files, rs.Checkpoints.Size,
+			rs.Buckets.Files, rs.Buckets.Size)
+	}
+}
@@ -100,10 +100,27 @@ func (arch *Archive) VerifyLedgerHeaderHistoryEntry(entry *xdr.LedgerHeaderHisto
 	arch.expectLedgerHashes[seq-1] = Hash(entry.Header.PreviousLedgerHash)
 	arch.expectTxSetHashes[seq] = Hash(entry.Header.ScpValue.TxSetHash)
 	arch.expectTxResultSetHashes[seq] = Hash(entry.Header.TxSetResultHash)
+	if IsCheckpoint(seq) {
+		arch.expectBucketListHashes[seq] = Hash(entry.Header.BucketListHash)
+	}
 
 	return nil
 }
 
+// VerifyBucketListHash records the bucket list hash of the HAS of the given
+// checkpoint, to be compared with the bucket list hash in the ledger header
+// of the checkpoint by ReportInvalid.
+func (arch *Archive) VerifyBucketListHash(chk uint32, has *HistoryArchiveState) error {
+	h, err := has.BucketListHash()
+	if err != nil {
+		return err
+	}
+	arch.mutex.Lock()
+	defer arch.mutex.Unlock()
+	arch.actualBucketListHashes[chk] = Hash(h)
+	return nil
+}
+
 func (arch *Archive) VerifyTransactionHistoryEntry(entry *xdr.TransactionHistoryEntry) error {
 	h, err := HashTxSet(&entry.TxSet)
 	if err != nil {
@@ -224,8 +241,9 @@ func (arch *Archive) VerifyBucketEntries(h Hash) error {
 	}
 	defer rdr.Close()
 	hsh := sha256.New()
+	// Entries are only hashed so the same value is reused for all of them.
+	var entry xdr.BucketEntry
 	for {
-		var entry xdr.BucketEntry
 		err = rdr.ReadOne(&entry)
 		if err == nil {
 			err2 := xdr.MarshalFramed(hsh, &entry)
@@ -310,10 +328,20 @@ func (arch *Archive) ReportInvalid(opts *CommandOptions) error {
 
 	reportValidity("bucket", arch.invalidBuckets, len(arch.referencedBuckets))
 
+	if opts.Thorough {
+		arch.invalidBucketLists = compareHashMaps(arch.expectBucketListHashes,
+			arch.actualBucketListHashes, "bucket list",
+			func(eledger uint32, ehash Hash) bool {
+				// Checkpoints without a HAS are reported as missing.
+				return true
+			})
+	}
+
 	totalInvalid := arch.invalidBuckets
 	totalInvalid += arch.invalidLedgers
 	totalInvalid += arch.invalidTxSets
 	totalInvalid += arch.invalidTxResultSets
+	totalInvalid += arch.invalidBucketLists
 
 	if totalInvalid != 0 {
 		return fmt.Errorf("Detected %d objects with unexpected hashes", totalInvalid)
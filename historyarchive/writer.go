@@ -0,0 +1,270 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"io/ioutil"
+	"log"
+
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// WriterServer is the server name written in the HAS files created by
+// ArchiveWriter.
+const WriterServer = "stellar-go historyarchive writer"
+
+// BucketLevel contains the buckets of a level of the bucket list. Empty
+// buckets have a zero hash.
+type BucketLevel struct {
+	Curr Hash
+	Snap Hash
+}
+
+// BucketList is the state of the bucket list after a ledger closed.
+type BucketList [NumLevels]BucketLevel
+
+// HAS returns a HistoryArchiveState of the bucket list at the given ledger.
+func (l BucketList) HAS(ledger uint32, networkPassphrase string) HistoryArchiveState {
+	has := HistoryArchiveState{
+		Version:           1,
+		Server:            WriterServer,
+		CurrentLedger:     ledger,
+		NetworkPassphrase: networkPassphrase,
+	}
+	for i, level := range l {
+		has.CurrentBuckets[i].Curr = level.Curr.String()
+		has.CurrentBuckets[i].Snap = level.Snap.String()
+	}
+	return has
+}
+
+// Hash returns the bucket list hash, as found in ledger headers.
+func (l BucketList) Hash() xdr.Hash {
+	has := l.HAS(0, "")
+	// The levels are valid hex strings, so this cannot fail.
+	h, err := has.BucketListHash()
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// BucketListFunc returns the bucket list after the given checkpoint ledger
+// closed. Its buckets must already be in the archive, see
+// ArchiveWriter.PutBucket.
+type BucketListFunc func(checkpoint uint32) (BucketList, error)
+
+// ArchiveWriter writes a history archive from a sequence of closed ledgers,
+// without stellar-core. Ledger headers, transaction sets, results and SCP
+// messages are taken from LedgerCloseMeta; the bucket list of each
+// checkpoint comes from a state snapshot provided by the caller.
+//
+// Checkpoint files and HAS files are written when the checkpoint ledger is
+// added, incomplete checkpoints are never written.
+type ArchiveWriter struct {
+	archive    *Archive
+	bucketList BucketListFunc
+	opts       *CommandOptions
+
+	started    bool
+	lastHeader xdr.LedgerHeaderHistoryEntry
+	ledgers    []xdr.LedgerHeaderHistoryEntry
+	txs        []xdr.TransactionHistoryEntry
+	results    []xdr.TransactionHistoryResultEntry
+	scp        []xdr.ScpHistoryEntry
+}
+
+// NewArchiveWriter returns an ArchiveWriter writing to the given archive.
+// Existing files are only overwritten when opts.Force is set.
+func NewArchiveWriter(archive *Archive, bucketList BucketListFunc, opts *CommandOptions) *ArchiveWriter {
+	return &ArchiveWriter{
+		archive:    archive,
+		bucketList: bucketList,
+		opts:       opts,
+	}
+}
+
+// AddLedger adds the next closed ledger. The first ledger must be the first
+// ledger of a checkpoint (or ledger 2 in the first checkpoint, as the genesis
+// ledger is never closed) and ledgers must be added in order.
+func (w *ArchiveWriter) AddLedger(meta xdr.LedgerCloseMeta) error {
+	v0, ok := meta.GetV0()
+	if !ok {
+		return errors.Errorf("unsupported LedgerCloseMeta version: %d", meta.V)
+	}
+	header := v0.LedgerHeader
+	seq := uint32(header.Header.LedgerSeq)
+
+	hash, err := HashXdr(&header.Header)
+	if err != nil {
+		return errors.Wrap(err, "error hashing ledger header")
+	}
+	if hash != Hash(header.Hash) {
+		return errors.Errorf("ledger %d has hash %s, expected %s", seq, Hash(header.Hash), hash)
+	}
+
+	if !w.started {
+		if seq > 2 && !IsCheckpoint(seq-1) {
+			return errors.Errorf("ledger %d is not the first ledger of a checkpoint", seq)
+		}
+	} else {
+		lastSeq := uint32(w.lastHeader.Header.LedgerSeq)
+		if seq != lastSeq+1 {
+			return errors.Errorf("expected ledger %d, got %d", lastSeq+1, seq)
+		}
+		if header.Header.PreviousLedgerHash != w.lastHeader.Hash {
+			return errors.Errorf("previous ledger hash of ledger %d does not match ledger %d", seq, lastSeq)
+		}
+	}
+
+	w.ledgers = append(w.ledgers, header)
+	if len(v0.TxSet.Txs) > 0 {
+		w.txs = append(w.txs, xdr.TransactionHistoryEntry{
+			LedgerSeq: header.Header.LedgerSeq,
+			TxSet:     v0.TxSet,
+		})
+
+		resultSet := xdr.TransactionResultSet{
+			Results: make([]xdr.TransactionResultPair, 0, len(v0.TxProcessing)),
+		}
+		for _, tx := range v0.TxProcessing {
+			resultSet.Results = append(resultSet.Results, tx.Result)
+		}
+		w.results = append(w.results, xdr.TransactionHistoryResultEntry{
+			LedgerSeq:   header.Header.LedgerSeq,
+			TxResultSet: resultSet,
+		})
+	}
+	w.scp = append(w.scp, v0.ScpInfo...)
+	w.started = true
+	w.lastHeader = header
+
+	if IsCheckpoint(seq) {
+		return w.writeCheckpoint(seq)
+	}
+	return nil
+}
+
+func (w *ArchiveWriter) writeCheckpoint(chk uint32) error {
+	bucketList, err := w.bucketList(chk)
+	if err != nil {
+		return errors.Wrapf(err, "error getting bucket list of checkpoint %d", chk)
+	}
+	if bucketList.Hash() != w.lastHeader.Header.BucketListHash {
+		return errors.Errorf("bucket list hash of checkpoint %d does not match its ledger header", chk)
+	}
+	for i, level := range bucketList {
+		for _, bucket := range []Hash{level.Curr, level.Snap} {
+			if bucket.IsZero() {
+				continue
+			}
+			exists, err := w.archive.BucketExists(bucket)
+			if err != nil {
+				return err
+			}
+			if !exists {
+				return errors.Errorf("bucket %s of level %d of checkpoint %d is missing", bucket, i, chk)
+			}
+		}
+	}
+
+	log.Printf("Writing checkpoint 0x%8.8x", chk)
+	entries := map[string][]interface{}{}
+	for i := range w.ledgers {
+		entries["ledger"] = append(entries["ledger"], &w.ledgers[i])
+	}
+	for i := range w.txs {
+		entries["transactions"] = append(entries["transactions"], &w.txs[i])
+	}
+	for i := range w.results {
+		entries["results"] = append(entries["results"], &w.results[i])
+	}
+	for i := range w.scp {
+		entries["scp"] = append(entries["scp"], &w.scp[i])
+	}
+	for _, cat := range []string{"ledger", "transactions", "results", "scp"} {
+		if err := w.putXdrGz(CategoryCheckpointPath(cat, chk), entries[cat]); err != nil {
+			return errors.Wrapf(err, "error writing %s file of checkpoint %d", cat, chk)
+		}
+	}
+
+	has := bucketList.HAS(chk, w.archive.networkPassphrase)
+	if err := w.archive.PutCheckpointHAS(chk, has, w.opts); err != nil {
+		return errors.Wrapf(err, "error writing HAS of checkpoint %d", chk)
+	}
+	if err := w.archive.PutRootHAS(has, w.opts); err != nil {
+		return errors.Wrap(err, "error writing root HAS")
+	}
+
+	w.ledgers = nil
+	w.txs = nil
+	w.results = nil
+	w.scp = nil
+	return nil
+}
+
+// PutBucket writes a bucket with the given entries and returns its hash.
+// Empty buckets are not written and have a zero hash.
+func (w *ArchiveWriter) PutBucket(entries []xdr.BucketEntry) (Hash, error) {
+	if len(entries) == 0 {
+		return Hash{}, nil
+	}
+
+	var raw bytes.Buffer
+	for i := range entries {
+		if err := xdr.MarshalFramed(&raw, &entries[i]); err != nil {
+			return Hash{}, err
+		}
+	}
+	h := Hash(sha256.Sum256(raw.Bytes()))
+
+	buf, err := gzipBytes(raw.Bytes())
+	if err != nil {
+		return Hash{}, err
+	}
+	return h, w.putFile(BucketPath(h), buf)
+}
+
+func (w *ArchiveWriter) putXdrGz(pth string, entries []interface{}) error {
+	var raw bytes.Buffer
+	for _, entry := range entries {
+		if err := xdr.MarshalFramed(&raw, entry); err != nil {
+			return err
+		}
+	}
+	buf, err := gzipBytes(raw.Bytes())
+	if err != nil {
+		return err
+	}
+	return w.putFile(pth, buf)
+}
+
+func (w *ArchiveWriter) putFile(pth string, buf []byte) error {
+	exists, err := w.archive.backend.Exists(pth)
+	if err != nil {
+		return err
+	}
+	if exists && !w.opts.Force {
+		log.Printf("skipping existing " + pth)
+		return nil
+	}
+	return w.archive.backend.PutFile(pth, ioutil.NopCloser(bytes.NewReader(buf)))
+}
+
+func gzipBytes(in []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(in); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
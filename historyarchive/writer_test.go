@@ -0,0 +1,197 @@
+// Copyright 2016 Stellar Development Foundation and contributors. Licensed
+// under the Apache License, Version 2.0. See the COPYING file at the root
+// of this distribution or at http://www.apache.org/licenses/LICENSE-2.0
+
+package historyarchive
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/xdr"
+)
+
+func testBucketEntry(balance xdr.Int64) xdr.BucketEntry {
+	return xdr.BucketEntry{
+		Type: xdr.BucketEntryTypeLiveentry,
+		LiveEntry: &xdr.LedgerEntry{
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeAccount,
+				Account: &xdr.AccountEntry{
+					AccountId: xdr.MustAddress("GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB"),
+					Balance:   balance,
+				},
+			},
+		},
+	}
+}
+
+func testTransactionEnvelope(seqNum xdr.SequenceNumber) xdr.TransactionEnvelope {
+	return xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				SourceAccount: xdr.MustMuxedAddress("GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB"),
+				Fee:           100,
+				SeqNum:        seqNum,
+				Operations: []xdr.Operation{
+					{
+						Body: xdr.OperationBody{
+							Type:           xdr.OperationTypeBumpSequence,
+							BumpSequenceOp: &xdr.BumpSequenceOp{BumpTo: seqNum + 10},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// testLedgerCloseMeta returns a ledger closed after prev. Every tenth ledger
+// contains a transaction.
+func testLedgerCloseMeta(t *testing.T, prev xdr.LedgerHeaderHistoryEntry, bucketListHash xdr.Hash) xdr.LedgerCloseMeta {
+	seq := prev.Header.LedgerSeq + 1
+	txSet := xdr.TransactionSet{PreviousLedgerHash: prev.Hash}
+	var processing []xdr.TransactionResultMeta
+	if seq%10 == 0 {
+		tx := testTransactionEnvelope(xdr.SequenceNumber(seq))
+		txSet.Txs = []xdr.TransactionEnvelope{tx}
+		txHash, err := HashXdr(&tx)
+		require.NoError(t, err)
+		processing = []xdr.TransactionResultMeta{{
+			Result: xdr.TransactionResultPair{
+				TransactionHash: xdr.Hash(txHash),
+				Result: xdr.TransactionResult{
+					FeeCharged: 100,
+					Result: xdr.TransactionResultResult{
+						Code:    xdr.TransactionResultCodeTxSuccess,
+						Results: &[]xdr.OperationResult{},
+					},
+				},
+			},
+		}}
+	}
+
+	txSetHash, err := HashTxSet(&txSet)
+	require.NoError(t, err)
+	resultSet := xdr.TransactionResultSet{}
+	for _, tx := range processing {
+		resultSet.Results = append(resultSet.Results, tx.Result)
+	}
+	resultSetHash, err := HashXdr(&resultSet)
+	require.NoError(t, err)
+
+	header := xdr.LedgerHeaderHistoryEntry{
+		Header: xdr.LedgerHeader{
+			LedgerVersion:      15,
+			PreviousLedgerHash: prev.Hash,
+			ScpValue:           xdr.StellarValue{TxSetHash: xdr.Hash(txSetHash)},
+			TxSetResultHash:    xdr.Hash(resultSetHash),
+			BucketListHash:     bucketListHash,
+			LedgerSeq:          seq,
+		},
+	}
+	hash, err := HashXdr(&header.Header)
+	require.NoError(t, err)
+	header.Hash = xdr.Hash(hash)
+
+	return xdr.LedgerCloseMeta{
+		V: 0,
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: header,
+			TxSet:        txSet,
+			TxProcessing: processing,
+		},
+	}
+}
+
+func TestArchiveWriter(t *testing.T) {
+	arch := GetTestMockArchive()
+	bucketLists := map[uint32]BucketList{}
+	writer := NewArchiveWriter(arch, func(chk uint32) (BucketList, error) {
+		return bucketLists[chk], nil
+	}, &CommandOptions{})
+
+	// Each checkpoint has a different bucket list.
+	for _, chk := range []uint32{0x3f, 0x7f} {
+		bucket, err := writer.PutBucket([]xdr.BucketEntry{testBucketEntry(xdr.Int64(chk))})
+		require.NoError(t, err)
+		var bucketList BucketList
+		bucketList[0].Curr = bucket
+		bucketLists[chk] = bucketList
+	}
+
+	prev := xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: 1}}
+	for seq := uint32(2); seq <= 0x7f; seq++ {
+		meta := testLedgerCloseMeta(t, prev, bucketLists[NextCheckpoint(seq)].Hash())
+		require.NoError(t, writer.AddLedger(meta))
+		prev = meta.V0.LedgerHeader
+	}
+
+	has, err := arch.GetRootHAS()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0x7f), has.CurrentLedger)
+	assert.Equal(t, WriterServer, has.Server)
+
+	header, err := arch.GetLedgerHeader(0x50)
+	require.NoError(t, err)
+	assert.Equal(t, xdr.Uint32(0x50), header.Header.LedgerSeq)
+
+	opts := &CommandOptions{
+		Range:       MakeRange(0, 0x7f),
+		Concurrency: 4,
+		Verify:      true,
+		Thorough:    true,
+	}
+	assert.NoError(t, arch.Scan(opts))
+	assert.NoError(t, arch.ReportMissing(opts))
+	assert.NoError(t, arch.ReportInvalid(opts))
+	assert.Len(t, arch.actualTxSetHashes, 12)
+	assert.Len(t, arch.actualBucketListHashes, 2)
+	assert.Empty(t, arch.CheckBucketsMissing())
+}
+
+// addTestLedgers adds the ledgers following prev up to the given ledger and
+// returns the error of the last one.
+func addTestLedgers(t *testing.T, writer *ArchiveWriter, prev xdr.LedgerHeaderHistoryEntry, to uint32, bucketListHash xdr.Hash) error {
+	for seq := uint32(prev.Header.LedgerSeq) + 1; seq < to; seq++ {
+		meta := testLedgerCloseMeta(t, prev, bucketListHash)
+		require.NoError(t, writer.AddLedger(meta))
+		prev = meta.V0.LedgerHeader
+	}
+	return writer.AddLedger(testLedgerCloseMeta(t, prev, bucketListHash))
+}
+
+func TestArchiveWriterErrors(t *testing.T) {
+	arch := GetTestMockArchive()
+	var bucketList BucketList
+	newWriter := func() *ArchiveWriter {
+		return NewArchiveWriter(arch, func(chk uint32) (BucketList, error) {
+			return bucketList, nil
+		}, &CommandOptions{})
+	}
+
+	prev := xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: 9}}
+	meta := testLedgerCloseMeta(t, prev, bucketList.Hash())
+	assert.EqualError(t, newWriter().AddLedger(meta), "ledger 10 is not the first ledger of a checkpoint")
+
+	prev.Header.LedgerSeq = 0x3f
+	err := addTestLedgers(t, newWriter(), prev, 0x7f, xdr.Hash{1})
+	assert.EqualError(t, err, "bucket list hash of checkpoint 127 does not match its ledger header")
+
+	writer := newWriter()
+	meta = testLedgerCloseMeta(t, prev, bucketList.Hash())
+	require.NoError(t, writer.AddLedger(meta))
+	meta = testLedgerCloseMeta(t, meta.V0.LedgerHeader, bucketList.Hash())
+	meta.V0.LedgerHeader.Header.LedgerSeq++
+	hash, err := HashXdr(&meta.V0.LedgerHeader.Header)
+	require.NoError(t, err)
+	meta.V0.LedgerHeader.Hash = xdr.Hash(hash)
+	assert.EqualError(t, writer.AddLedger(meta), "expected ledger 65, got 66")
+
+	bucketList[1].Snap = Hash{1}
+	err = addTestLedgers(t, newWriter(), prev, 0x7f, bucketList.Hash())
+	assert.EqualError(t, err, "bucket "+Hash{1}.String()+" of level 1 of checkpoint 127 is missing")
+}
@@ -20,7 +20,10 @@ import (
 )
 
 type XdrStream struct {
-	buf        bytes.Buffer
+	// header and frame are reused by all ReadOne calls.
+	header     [4]byte
+	frame      []byte
+	decoder    xdr.Decoder
 	rdr        *countReader
 	rdr2       io.ReadCloser
 	sha256Hash hash.Hash
@@ -140,9 +143,11 @@ func (x *XdrStream) closeReaders() error {
 	return nil
 }
 
+// ReadOne reads the next entry of the stream into in. Memory referenced by
+// in (optional values, union arms and slices) is reused, so callers keeping
+// the entries must read each of them into a new value.
 func (x *XdrStream) ReadOne(in interface{}) error {
-	var nbytes uint32
-	err := binary.Read(x.rdr, binary.BigEndian, &nbytes)
+	_, err := io.ReadFull(x.rdr, x.header[:])
 	if err != nil {
 		x.rdr.Close()
 		if err == io.EOF {
@@ -151,24 +156,27 @@ func (x *XdrStream) ReadOne(in interface{}) error {
 		}
 		return errors.Wrap(err, "binary.Read error")
 	}
+	nbytes := binary.BigEndian.Uint32(x.header[:])
 	nbytes &= 0x7fffffff
-	x.buf.Reset()
 	if nbytes == 0 {
 		x.rdr.Close()
 		return io.EOF
 	}
-	x.buf.Grow(int(nbytes))
-	read, err := x.buf.ReadFrom(io.LimitReader(x.rdr, int64(nbytes)))
-	if err != nil {
+	if cap(x.frame) < int(nbytes) {
+		x.frame = make([]byte, nbytes)
+	}
+	x.frame = x.frame[:nbytes]
+	read, err := io.ReadFull(x.rdr, x.frame)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		x.rdr.Close()
 		return err
 	}
-	if read != int64(nbytes) {
+	if read != int(nbytes) {
 		x.rdr.Close()
 		return errors.New("Read wrong number of bytes from XDR")
 	}
 
-	readi, err := xdr.Unmarshal(&x.buf, in)
+	readi, err := x.decoder.Unmarshal(x.frame, in)
 	if err != nil {
 		x.rdr.Close()
 		return err
@@ -5,10 +5,14 @@
 package historyarchive
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"crypto/sha256"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stellar/go/xdr"
@@ -129,3 +133,77 @@ func createXdrStream(entries ...xdr.BucketEntry) *XdrStream {
 
 	return NewXdrStream(ioutil.NopCloser(b))
 }
+
+// readSampleBucket returns the uncompressed contents of
+// testdata/sample-bucket.xdr.gz, a bucket of 672 mainnet ledger entries
+// (accounts, trust lines, offers and data entries).
+func readSampleBucket(b *testing.B) []byte {
+	f, err := os.Open(filepath.Join("testdata", "sample-bucket.xdr.gz"))
+	require.NoError(b, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(b, err)
+	raw, err := ioutil.ReadAll(gz)
+	require.NoError(b, err)
+	return raw
+}
+
+func BenchmarkXdrStreamReadOne(b *testing.B) {
+	raw := readSampleBucket(b)
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		stream := NewXdrStream(ioutil.NopCloser(bytes.NewReader(raw)))
+		for {
+			var entry xdr.BucketEntry
+			err := stream.ReadOne(&entry)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(b, err)
+		}
+	}
+}
+
+// BenchmarkXdrStreamReadOneReuse reads all the entries into the same value.
+func BenchmarkXdrStreamReadOneReuse(b *testing.B) {
+	raw := readSampleBucket(b)
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	var entry xdr.BucketEntry
+	for i := 0; i < b.N; i++ {
+		stream := NewXdrStream(ioutil.NopCloser(bytes.NewReader(raw)))
+		for {
+			err := stream.ReadOne(&entry)
+			if err == io.EOF {
+				break
+			}
+			require.NoError(b, err)
+		}
+	}
+}
+
+// BenchmarkUnmarshalFramed decodes the same entries using xdr.UnmarshalFramed
+// for comparison.
+func BenchmarkUnmarshalFramed(b *testing.B) {
+	raw := readSampleBucket(b)
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		r := bufio.NewReader(bytes.NewReader(raw))
+		for {
+			if _, err := r.Peek(1); err == io.EOF {
+				break
+			}
+			var entry xdr.BucketEntry
+			_, err := xdr.UnmarshalFramed(r, &entry)
+			require.NoError(b, err)
+		}
+	}
+}
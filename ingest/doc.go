@@ -22,10 +22,12 @@ Readers
 Readers are objects that wrap ledger backend and provide higher level, developer
 friendly APIs for reading ledger data.
 
-Currently there are three types of readers (all in ingest/io package):
+Currently there are four types of readers (all in ingest/io package):
   * SingleLedgerStateReader reads ledger entries from history buckets for a
     given checkpoint ledger. Allow building state (all accounts, trust lines
     etc.) at any checkpoint ledger.
+  * LedgerStateReader reads ledger entries at any ledger by applying changes
+    from a ledger backend on top of the state at the nearest checkpoint.
   * LedgerTransactionReader reads transactions for a given ledger sequence.
   * LedgerChangeReader reads all changes to ledger entries created as a result
     of transactions (fees and meta) and protocol upgrades in a given ledger.
@@ -51,5 +53,12 @@ For an object to be a processor, it needs to implement a single method:
 ProcessChange or ProcessTransaction. This is a very simple yet powerful interface
 that allows building many kinds of features.
 
+Runner
+
+The ingest/runner package runs a set of processors against a history archive
+and a ledger backend. It builds state from a checkpoint ledger, follows new
+ledgers, calls a commit hook after every ledger and saves a cursor (in a file
+or a SQL table) so ingestion can be resumed after a restart.
+
 */
 package ingest
@@ -0,0 +1,192 @@
+package io
+
+import (
+	"sort"
+
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// BalanceChange represents a change of the balance of a single asset held by
+// an account or a claimable balance.
+type BalanceChange struct {
+	// Account is the address of the account holding the asset. Empty if the
+	// holder is a claimable balance.
+	Account string
+	// ClaimableBalanceID is the hex encoded ID of the claimable balance holding
+	// the asset. Empty if the holder is an account.
+	ClaimableBalanceID string
+	Asset              xdr.Asset
+	// Amount is the change of the balance in stroops: positive when the holder
+	// gained and negative when the holder lost funds.
+	Amount int64
+	// Reserves is the change of the number of base reserves locked in the
+	// account (native asset only). It changes when subentries are created or
+	// removed and when reserves are sponsored or sponsorship is revoked.
+	Reserves int64
+}
+
+// GetBalanceChanges returns balance changes per holder and asset caused by the
+// entire transaction, including fees. Failed transactions return fee changes
+// only (and changes made by the transaction itself, ex. fee refunds).
+func (t *LedgerTransaction) GetBalanceChanges() ([]BalanceChange, error) {
+	changes, err := t.GetChanges()
+	if err != nil {
+		return nil, err
+	}
+
+	return GetBalanceChangesFromChanges(append(t.GetFeeChanges(), changes...))
+}
+
+// GetFeeBalanceChanges returns balance changes caused by charging the
+// transaction fee.
+func (t *LedgerTransaction) GetFeeBalanceChanges() ([]BalanceChange, error) {
+	return GetBalanceChangesFromChanges(t.GetFeeChanges())
+}
+
+// GetOperationBalanceChanges returns balance changes caused by a single
+// operation. Operations of failed transactions do not change balances so an
+// empty slice is returned for them.
+func (t *LedgerTransaction) GetOperationBalanceChanges(operationIndex uint32) ([]BalanceChange, error) {
+	changes, err := t.GetOperationChanges(operationIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetBalanceChangesFromChanges(changes)
+}
+
+type balanceChangeKey struct {
+	account            string
+	claimableBalanceID string
+	asset              string
+}
+
+// GetBalanceChangesFromChanges squashes changes to accounts, trust lines and
+// claimable balances into balance changes per holder and asset. Holders with
+// no net change are omitted. Merged (removed) accounts and claimed claimable
+// balances lose their entire balance. The result is sorted by holder and
+// asset (native first).
+func GetBalanceChangesFromChanges(changes []Change) ([]BalanceChange, error) {
+	byKey := map[balanceChangeKey]*BalanceChange{}
+
+	add := func(delta BalanceChange) {
+		key := balanceChangeKey{
+			account:            delta.Account,
+			claimableBalanceID: delta.ClaimableBalanceID,
+			asset:              delta.Asset.String(),
+		}
+		existing, ok := byKey[key]
+		if !ok {
+			byKey[key] = &delta
+			return
+		}
+		existing.Amount += delta.Amount
+		existing.Reserves += delta.Reserves
+	}
+
+	for _, change := range changes {
+		switch change.Type {
+		case xdr.LedgerEntryTypeAccount:
+			var pre, post xdr.AccountEntry
+			var address string
+			if change.Pre != nil {
+				pre = change.Pre.Data.MustAccount()
+				address = pre.AccountId.Address()
+			}
+			if change.Post != nil {
+				post = change.Post.Data.MustAccount()
+				address = post.AccountId.Address()
+			}
+
+			add(BalanceChange{
+				Account:  address,
+				Asset:    xdr.MustNewNativeAsset(),
+				Amount:   int64(post.Balance - pre.Balance),
+				Reserves: accountReserves(change.Post) - accountReserves(change.Pre),
+			})
+		case xdr.LedgerEntryTypeTrustline:
+			var pre, post xdr.TrustLineEntry
+			if change.Pre != nil {
+				pre = change.Pre.Data.MustTrustLine()
+			}
+			if change.Post != nil {
+				post = change.Post.Data.MustTrustLine()
+			}
+
+			entry := post
+			if change.Post == nil {
+				entry = pre
+			}
+
+			add(BalanceChange{
+				Account: entry.AccountId.Address(),
+				Asset:   entry.Asset,
+				Amount:  int64(post.Balance - pre.Balance),
+			})
+		case xdr.LedgerEntryTypeClaimableBalance:
+			var pre, post xdr.ClaimableBalanceEntry
+			if change.Pre != nil {
+				pre = change.Pre.Data.MustClaimableBalance()
+			}
+			if change.Post != nil {
+				post = change.Post.Data.MustClaimableBalance()
+			}
+
+			entry := post
+			if change.Post == nil {
+				entry = pre
+			}
+
+			id, err := xdr.MarshalHex(entry.BalanceId)
+			if err != nil {
+				return nil, errors.Wrap(err, "error marshaling claimable balance id")
+			}
+
+			add(BalanceChange{
+				ClaimableBalanceID: id,
+				Asset:              entry.Asset,
+				Amount:             int64(post.Amount - pre.Amount),
+			})
+		}
+	}
+
+	result := make([]BalanceChange, 0, len(byKey))
+	for _, change := range byKey {
+		if change.Amount == 0 && change.Reserves == 0 {
+			continue
+		}
+		result = append(result, *change)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Account != b.Account {
+			return a.Account < b.Account
+		}
+		if a.ClaimableBalanceID != b.ClaimableBalanceID {
+			return a.ClaimableBalanceID < b.ClaimableBalanceID
+		}
+		if a.Asset.Type != b.Asset.Type {
+			// Native asset first
+			return a.Asset.Type < b.Asset.Type
+		}
+		return a.Asset.String() < b.Asset.String()
+	})
+
+	return result, nil
+}
+
+// accountReserves returns the number of base reserves locked in the account:
+// 2 for the account itself, 1 for each subentry and 1 for each sponsored
+// entry or signer, excluding entries sponsored by other accounts. Returns 0
+// for nil entries (account does not exist).
+func accountReserves(entry *xdr.LedgerEntry) int64 {
+	if entry == nil {
+		return 0
+	}
+
+	account := entry.Data.MustAccount()
+	return 2 + int64(account.NumSubEntries) +
+		int64(account.NumSponsoring()) - int64(account.NumSponsored())
+}
@@ -0,0 +1,395 @@
+package io
+
+import (
+	"testing"
+
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	balanceSource      = "GAHK7EEG2WWHVKDNT4CEQFZGKF2LGDSW2IVM4S5DP42RBW3K6BTODB4A"
+	balanceDestination = "GACMZD5VJXTRLKVET72CETCYKELPNCOTTBDC6DHFEUPLG5DHEK534JQX"
+)
+
+func balanceAccountEntry(address string, balance int64, subentries uint32) *xdr.LedgerEntry {
+	return &xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{
+				AccountId:     xdr.MustAddress(address),
+				Balance:       xdr.Int64(balance),
+				NumSubEntries: xdr.Uint32(subentries),
+			},
+		},
+	}
+}
+
+func balanceTrustLineEntry(address string, asset xdr.Asset, balance int64) *xdr.LedgerEntry {
+	return &xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTrustline,
+			TrustLine: &xdr.TrustLineEntry{
+				AccountId: xdr.MustAddress(address),
+				Asset:     asset,
+				Balance:   xdr.Int64(balance),
+			},
+		},
+	}
+}
+
+func updated(pre, post *xdr.LedgerEntry) xdr.LedgerEntryChanges {
+	return xdr.LedgerEntryChanges{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: pre},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: post},
+	}
+}
+
+func TestBalanceChanges(t *testing.T) {
+	usd := xdr.MustNewCreditAsset("USD", balanceDestination)
+
+	var paymentChanges xdr.LedgerEntryChanges
+	paymentChanges = append(paymentChanges, updated(
+		balanceAccountEntry(balanceSource, 900, 0),
+		balanceAccountEntry(balanceSource, 800, 0),
+	)...)
+	paymentChanges = append(paymentChanges, updated(
+		balanceAccountEntry(balanceDestination, 500, 0),
+		balanceAccountEntry(balanceDestination, 600, 0),
+	)...)
+
+	var trustChanges xdr.LedgerEntryChanges
+	trustChanges = append(trustChanges, updated(
+		balanceAccountEntry(balanceSource, 800, 0),
+		balanceAccountEntry(balanceSource, 800, 1),
+	)...)
+	trustChanges = append(trustChanges, xdr.LedgerEntryChange{
+		Type:    xdr.LedgerEntryChangeTypeLedgerEntryCreated,
+		Created: balanceTrustLineEntry(balanceSource, usd, 0),
+	})
+
+	var issueChanges xdr.LedgerEntryChanges
+	issueChanges = append(issueChanges, updated(
+		balanceTrustLineEntry(balanceSource, usd, 0),
+		balanceTrustLineEntry(balanceSource, usd, 50),
+	)...)
+
+	tx := LedgerTransaction{
+		FeeChanges: updated(
+			balanceAccountEntry(balanceSource, 1000, 0),
+			balanceAccountEntry(balanceSource, 900, 0),
+		),
+		Meta: xdr.TransactionMeta{
+			V: 2,
+			V2: &xdr.TransactionMetaV2{
+				Operations: []xdr.OperationMeta{
+					{Changes: paymentChanges},
+					{Changes: trustChanges},
+					{Changes: issueChanges},
+				},
+			},
+		},
+	}
+
+	fees, err := tx.GetFeeBalanceChanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []BalanceChange{
+		{Account: balanceSource, Asset: xdr.MustNewNativeAsset(), Amount: -100},
+	}, fees)
+
+	payment, err := tx.GetOperationBalanceChanges(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []BalanceChange{
+		{Account: balanceDestination, Asset: xdr.MustNewNativeAsset(), Amount: 100},
+		{Account: balanceSource, Asset: xdr.MustNewNativeAsset(), Amount: -100},
+	}, payment)
+
+	trust, err := tx.GetOperationBalanceChanges(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []BalanceChange{
+		{Account: balanceSource, Asset: xdr.MustNewNativeAsset(), Reserves: 1},
+	}, trust)
+
+	all, err := tx.GetBalanceChanges()
+	assert.NoError(t, err)
+	assert.Equal(t, []BalanceChange{
+		{Account: balanceDestination, Asset: xdr.MustNewNativeAsset(), Amount: 100},
+		{Account: balanceSource, Asset: xdr.MustNewNativeAsset(), Amount: -200, Reserves: 1},
+		{Account: balanceSource, Asset: usd, Amount: 50},
+	}, all)
+
+	// Failed transactions change fees only
+	tx.Result.Result.Result.Code = xdr.TransactionResultCodeTxInternalError
+	all, err = tx.GetBalanceChanges()
+	assert.NoError(t, err)
+	assert.Equal(t, fees, all)
+
+	payment, err = tx.GetOperationBalanceChanges(0)
+	assert.NoError(t, err)
+	assert.Empty(t, payment)
+}
+
+func TestBalanceChangesMerge(t *testing.T) {
+	var changes xdr.LedgerEntryChanges
+	changes = append(changes, updated(
+		balanceAccountEntry(balanceDestination, 500, 0),
+		balanceAccountEntry(balanceDestination, 800, 0),
+	)...)
+	changes = append(changes,
+		xdr.LedgerEntryChange{
+			Type:  xdr.LedgerEntryChangeTypeLedgerEntryState,
+			State: balanceAccountEntry(balanceSource, 300, 0),
+		},
+		xdr.LedgerEntryChange{
+			Type:    xdr.LedgerEntryChangeTypeLedgerEntryRemoved,
+			Removed: &xdr.LedgerKey{},
+		},
+	)
+
+	balanceChanges, err := GetBalanceChangesFromChanges(GetChangesFromLedgerEntryChanges(changes))
+	assert.NoError(t, err)
+	assert.Equal(t, []BalanceChange{
+		{Account: balanceDestination, Asset: xdr.MustNewNativeAsset(), Amount: 300},
+		{Account: balanceSource, Asset: xdr.MustNewNativeAsset(), Amount: -300, Reserves: -2},
+	}, balanceChanges)
+}
+
+func TestBalanceChangesClaimableBalance(t *testing.T) {
+	id := xdr.ClaimableBalanceId{
+		Type: xdr.ClaimableBalanceIdTypeClaimableBalanceIdTypeV0,
+		V0:   &xdr.Hash{1, 2, 3},
+	}
+	hexID, err := xdr.MarshalHex(id)
+	assert.NoError(t, err)
+
+	var changes xdr.LedgerEntryChanges
+	changes = append(changes, updated(
+		balanceAccountEntry(balanceSource, 500, 0),
+		balanceAccountEntry(balanceSource, 400, 0),
+	)...)
+	sponsored := balanceAccountEntry(balanceSource, 400, 0)
+	sponsored.Data.Account.Ext = xdr.AccountEntryExt{
+		V: 1,
+		V1: &xdr.AccountEntryExtensionV1{
+			Ext: xdr.AccountEntryExtensionV1Ext{
+				V:  2,
+				V2: &xdr.AccountEntryExtensionV2{NumSponsoring: 1},
+			},
+		},
+	}
+	changes = append(changes, updated(
+		balanceAccountEntry(balanceSource, 400, 0),
+		sponsored,
+	)...)
+	changes = append(changes, xdr.LedgerEntryChange{
+		Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated,
+		Created: &xdr.LedgerEntry{
+			Data: xdr.LedgerEntryData{
+				Type: xdr.LedgerEntryTypeClaimableBalance,
+				ClaimableBalance: &xdr.ClaimableBalanceEntry{
+					BalanceId: id,
+					Asset:     xdr.MustNewNativeAsset(),
+					Amount:    100,
+				},
+			},
+		},
+	})
+
+	balanceChanges, err := GetBalanceChangesFromChanges(GetChangesFromLedgerEntryChanges(changes))
+	assert.NoError(t, err)
+	assert.Equal(t, []BalanceChange{
+		{ClaimableBalanceID: hexID, Asset: xdr.MustNewNativeAsset(), Amount: 100},
+		{Account: balanceSource, Asset: xdr.MustNewNativeAsset(), Amount: -100, Reserves: 1},
+	}, balanceChanges)
+}
+
+// Claimable balance and sponsorship fixtures share the accounts below: the
+// sponsor creates a claimable balance for the claimant, the claimant claims
+// it and the sponsor then sponsors the claimant's trust line.
+const (
+	fixtureSponsor            = "GDHMYFIH3QO524UVSUOCSCEI6CK23OIEJUNXHVUW43PQMXLIHPKPZJ2V"
+	fixtureClaimant           = "GBVXTRL6NIEVEOJIFQCIDDUWCEXT6A5EAAN2S6SWJQRYKKR7D2S7YLBA"
+	fixtureClaimableBalanceID = "0000000056057a8b9969287133f37d23696f6f3a710c79fa77d30c486223edf077d932e8"
+)
+
+type balanceChangesFixture struct {
+	desc          string
+	envelopeXDR   string
+	resultXDR     string
+	metaXDR       string
+	feeChangesXDR string
+	fee           []BalanceChange
+	// operations contains expected balance changes for each operation
+	operations [][]BalanceChange
+	all        []BalanceChange
+}
+
+func (f balanceChangesFixture) ledgerTransaction(t *testing.T) LedgerTransaction {
+	var tx LedgerTransaction
+	require.NoError(t, xdr.SafeUnmarshalBase64(f.envelopeXDR, &tx.Envelope))
+	require.NoError(t, xdr.SafeUnmarshalBase64(f.resultXDR, &tx.Result.Result))
+	require.NoError(t, xdr.SafeUnmarshalBase64(f.metaXDR, &tx.Meta))
+	require.NoError(t, xdr.SafeUnmarshalBase64(f.feeChangesXDR, &tx.FeeChanges))
+	return tx
+}
+
+func TestBalanceChangesFixtures(t *testing.T) {
+	native := xdr.MustNewNativeAsset()
+	brl := xdr.MustNewCreditAsset("BRL", "GCXI6Q73J7F6EUSBZTPW4G4OUGVDHABPYF2U4KO7MVEX52OH5VMVUCRF")
+
+	for _, tc := range []balanceChangesFixture{
+		{
+			desc:          "path payment strict send",
+			envelopeXDR:   "AAAAAPbGHHrGbL7EFLG87cWA6eecM/LaVyzrO+pakFpjQq+PAAAAZAANFvYAAAANAAAAAAAAAAAAAAABAAAAAAAAAA0AAAABQlJMAAAAAACuj0P7T8viUkHM324bjqGqM4AvwXVOKd9lSX7px+1ZWgAAAAAABJPgAAAAAMjq0GsWJu54fjD9Y/wlJJ4a9iAQvF82hRIjT716u5sDAAAAAUFSUwAAAAAAro9D+0/L4lJBzN9uG46hqjOAL8F1TinfZUl+6cftWVoAAAAAAJiWgAAAAAEAAAABQVJTAAAAAACuj0P7T8viUkHM324bjqGqM4AvwXVOKd9lSX7px+1ZWgAAAAAAAAABY0KvjwAAAED0a4tcvZzPT1Q4AkZLFu0yZPKfsRvwQnq2Lb1OBX8aPbPu5UwgznoNmoWUlR36MIQsVqM4ICxLV+L7TAQ7toQI",
+			resultXDR:     "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAANAAAAAAAAAAEAAAAAyOrQaxYm7nh+MP1j/CUknhr2IBC8XzaFEiNPvXq7mwMAAAAAAJmwQAAAAAFBUlMAAAAAAK6PQ/tPy+JSQczfbhuOoaozgC/BdU4p32VJfunH7VlaAAAAAACYloAAAAABQlJMAAAAAACuj0P7T8viUkHM324bjqGqM4AvwXVOKd9lSX7px+1ZWgAAAAAABJPgAAAAAMjq0GsWJu54fjD9Y/wlJJ4a9iAQvF82hRIjT716u5sDAAAAAUFSUwAAAAAAro9D+0/L4lJBzN9uG46hqjOAL8F1TinfZUl+6cftWVoAAAAAAJiWgAAAAAA=",
+			metaXDR:       "AAAAAQAAAAIAAAADAA0aVQAAAAAAAAAA9sYcesZsvsQUsbztxYDp55wz8tpXLOs76lqQWmNCr48AAAAXSHbi7AANFvYAAAAMAAAAAwAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAA0aVQAAAAAAAAAA9sYcesZsvsQUsbztxYDp55wz8tpXLOs76lqQWmNCr48AAAAXSHbi7AANFvYAAAANAAAAAwAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAACAAAAAMADRo0AAAAAQAAAAD2xhx6xmy+xBSxvO3FgOnnnDPy2lcs6zvqWpBaY0KvjwAAAAFCUkwAAAAAAK6PQ/tPy+JSQczfbhuOoaozgC/BdU4p32VJfunH7VlaAAAAAB22gaB//////////wAAAAEAAAABAAAAAAC3GwAAAAAAAAAAAAAAAAAAAAAAAAAAAQANGlUAAAABAAAAAPbGHHrGbL7EFLG87cWA6eecM/LaVyzrO+pakFpjQq+PAAAAAUJSTAAAAAAAro9D+0/L4lJBzN9uG46hqjOAL8F1TinfZUl+6cftWVoAAAAAHbHtwH//////////AAAAAQAAAAEAAAAAALcbAAAAAAAAAAAAAAAAAAAAAAAAAAADAA0aNAAAAAIAAAAAyOrQaxYm7nh+MP1j/CUknhr2IBC8XzaFEiNPvXq7mwMAAAAAAJmwQAAAAAFBUlMAAAAAAK6PQ/tPy+JSQczfbhuOoaozgC/BdU4p32VJfunH7VlaAAAAAUJSTAAAAAAAro9D+0/L4lJBzN9uG46hqjOAL8F1TinfZUl+6cftWVoAAAAAFNyTgAAAAAMAAABkAAAAAAAAAAAAAAAAAAAAAQANGlUAAAACAAAAAMjq0GsWJu54fjD9Y/wlJJ4a9iAQvF82hRIjT716u5sDAAAAAACZsEAAAAABQVJTAAAAAACuj0P7T8viUkHM324bjqGqM4AvwXVOKd9lSX7px+1ZWgAAAAFCUkwAAAAAAK6PQ/tPy+JSQczfbhuOoaozgC/BdU4p32VJfunH7VlaAAAAABRD/QAAAAADAAAAZAAAAAAAAAAAAAAAAAAAAAMADRo0AAAAAQAAAADI6tBrFibueH4w/WP8JSSeGvYgELxfNoUSI0+9erubAwAAAAFCUkwAAAAAAK6PQ/tPy+JSQczfbhuOoaozgC/BdU4p32VJfunH7VlaAAAAAB3kSGB//////////wAAAAEAAAABAAAAAACgN6AAAAAAAAAAAAAAAAAAAAAAAAAAAQANGlUAAAABAAAAAMjq0GsWJu54fjD9Y/wlJJ4a9iAQvF82hRIjT716u5sDAAAAAUJSTAAAAAAAro9D+0/L4lJBzN9uG46hqjOAL8F1TinfZUl+6cftWVoAAAAAHejcQH//////////AAAAAQAAAAEAAAAAAJujwAAAAAAAAAAAAAAAAAAAAAAAAAADAA0aNAAAAAEAAAAAyOrQaxYm7nh+MP1j/CUknhr2IBC8XzaFEiNPvXq7mwMAAAABQVJTAAAAAACuj0P7T8viUkHM324bjqGqM4AvwXVOKd9lSX7px+1ZWgAAAAB2BGcAf/////////8AAAABAAAAAQAAAAAAAAAAAAAAABTck4AAAAAAAAAAAAAAAAEADRpVAAAAAQAAAADI6tBrFibueH4w/WP8JSSeGvYgELxfNoUSI0+9erubAwAAAAFBUlMAAAAAAK6PQ/tPy+JSQczfbhuOoaozgC/BdU4p32VJfunH7VlaAAAAAHYEZwB//////////wAAAAEAAAABAAAAAAAAAAAAAAAAFEP9AAAAAAAAAAAA",
+			feeChangesXDR: "AAAAAgAAAAMADRpIAAAAAAAAAAD2xhx6xmy+xBSxvO3FgOnnnDPy2lcs6zvqWpBaY0KvjwAAABdIduNQAA0W9gAAAAwAAAADAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEADRpVAAAAAAAAAAD2xhx6xmy+xBSxvO3FgOnnnDPy2lcs6zvqWpBaY0KvjwAAABdIduLsAA0W9gAAAAwAAAADAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAA==",
+			fee: []BalanceChange{
+				{Account: "GD3MMHD2YZWL5RAUWG6O3RMA5HTZYM7S3JLSZ2Z35JNJAWTDIKXY737V", Asset: native, Amount: -100},
+			},
+			operations: [][]BalanceChange{
+				{
+					{Account: "GD3MMHD2YZWL5RAUWG6O3RMA5HTZYM7S3JLSZ2Z35JNJAWTDIKXY737V", Asset: brl, Amount: -300000},
+					{Account: "GDEOVUDLCYTO46D6GD6WH7BFESPBV5RACC6F6NUFCIRU7PL2XONQHVGJ", Asset: brl, Amount: 300000},
+				},
+			},
+			all: []BalanceChange{
+				{Account: "GD3MMHD2YZWL5RAUWG6O3RMA5HTZYM7S3JLSZ2Z35JNJAWTDIKXY737V", Asset: native, Amount: -100},
+				{Account: "GD3MMHD2YZWL5RAUWG6O3RMA5HTZYM7S3JLSZ2Z35JNJAWTDIKXY737V", Asset: brl, Amount: -300000},
+				{Account: "GDEOVUDLCYTO46D6GD6WH7BFESPBV5RACC6F6NUFCIRU7PL2XONQHVGJ", Asset: brl, Amount: 300000},
+			},
+		},
+		{
+			desc:          "payment to self",
+			envelopeXDR:   "AAAAABpcjiETZ0uhwxJJhgBPYKWSVJy2TZ2LI87fqV1cUf/UAAAAZAAAADcAAAABAAAAAAAAAAAAAAABAAAAAAAAAAEAAAAAGlyOIRNnS6HDEkmGAE9gpZJUnLZNnYsjzt+pXVxR/9QAAAAAAAAAAAX14QAAAAAAAAAAAVxR/9QAAABAK6pcXYMzAEmH08CZ1LWmvtNDKauhx+OImtP/Lk4hVTMJRVBOebVs5WEPj9iSrgGT0EswuDCZ2i5AEzwgGof9Ag==",
+			resultXDR:     "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAABAAAAAAAAAAA=",
+			metaXDR:       "AAAAAQAAAAIAAAADAAAAOAAAAAAAAAAAGlyOIRNnS6HDEkmGAE9gpZJUnLZNnYsjzt+pXVxR/9QAAAACVAvjnAAAADcAAAAAAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAAOAAAAAAAAAAAGlyOIRNnS6HDEkmGAE9gpZJUnLZNnYsjzt+pXVxR/9QAAAACVAvjnAAAADcAAAABAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAAAA==",
+			feeChangesXDR: "AAAAAgAAAAMAAAA3AAAAAAAAAAAaXI4hE2dLocMSSYYAT2ClklSctk2diyPO36ldXFH/1AAAAAJUC+QAAAAANwAAAAAAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAAA4AAAAAAAAAAAaXI4hE2dLocMSSYYAT2ClklSctk2diyPO36ldXFH/1AAAAAJUC+OcAAAANwAAAAAAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAA==",
+			fee: []BalanceChange{
+				{Account: "GANFZDRBCNTUXIODCJEYMACPMCSZEVE4WZGZ3CZDZ3P2SXK4KH75IK6Y", Asset: native, Amount: -100},
+			},
+			operations: [][]BalanceChange{{}},
+			all: []BalanceChange{
+				{Account: "GANFZDRBCNTUXIODCJEYMACPMCSZEVE4WZGZ3CZDZ3P2SXK4KH75IK6Y", Asset: native, Amount: -100},
+			},
+		},
+		{
+			desc:          "account merge",
+			envelopeXDR:   "AAAAAI77mqNTy9VPgmgn+//uvjP8VJxJ1FHQ4jCrYS+K4+HvAAAAZAAAACsAAAABAAAAAAAAAAAAAAABAAAAAAAAAAgAAAAAYvwdC9CRsrYcDdZWNGsqaNfTR8bywsjubQRHAlb8BfcAAAAAAAAAAYrj4e8AAABA3jJ7wBrRpsrcnqBQWjyzwvVz2v5UJ56G60IhgsaWQFSf+7om462KToc+HJ27aLVOQ83dGh1ivp+VIuREJq/SBw==",
+			resultXDR:     "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAAIAAAAAAAAAAJUC+OcAAAAAA==",
+			metaXDR:       "AAAAAQAAAAIAAAADAAAALAAAAAAAAAAAjvuao1PL1U+CaCf7/+6+M/xUnEnUUdDiMKthL4rj4e8AAAACVAvjnAAAACsAAAAAAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAALAAAAAAAAAAAjvuao1PL1U+CaCf7/+6+M/xUnEnUUdDiMKthL4rj4e8AAAACVAvjnAAAACsAAAABAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAABAAAAAMAAAArAAAAAAAAAABi/B0L0JGythwN1lY0aypo19NHxvLCyO5tBEcCVvwF9w3gtonM3Az4AAAAAAAAABIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAAAsAAAAAAAAAABi/B0L0JGythwN1lY0aypo19NHxvLCyO5tBEcCVvwF9w3gtowg5/CUAAAAAAAAABIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAMAAAAsAAAAAAAAAACO+5qjU8vVT4JoJ/v/7r4z/FScSdRR0OIwq2EviuPh7wAAAAJUC+OcAAAAKwAAAAEAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAIAAAAAAAAAAI77mqNTy9VPgmgn+//uvjP8VJxJ1FHQ4jCrYS+K4+Hv",
+			feeChangesXDR: "AAAAAgAAAAMAAAArAAAAAAAAAACO+5qjU8vVT4JoJ/v/7r4z/FScSdRR0OIwq2EviuPh7wAAAAJUC+QAAAAAKwAAAAAAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAAAsAAAAAAAAAACO+5qjU8vVT4JoJ/v/7r4z/FScSdRR0OIwq2EviuPh7wAAAAJUC+OcAAAAKwAAAAAAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAA==",
+			fee: []BalanceChange{
+				{Account: "GCHPXGVDKPF5KT4CNAT7X77OXYZ7YVE4JHKFDUHCGCVWCL4K4PQ67KKZ", Asset: native, Amount: -100},
+			},
+			operations: [][]BalanceChange{
+				{
+					{Account: "GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H", Asset: native, Amount: 9999999900},
+					{Account: "GCHPXGVDKPF5KT4CNAT7X77OXYZ7YVE4JHKFDUHCGCVWCL4K4PQ67KKZ", Asset: native, Amount: -9999999900, Reserves: -2},
+				},
+			},
+			all: []BalanceChange{
+				{Account: "GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H", Asset: native, Amount: 9999999900},
+				{Account: "GCHPXGVDKPF5KT4CNAT7X77OXYZ7YVE4JHKFDUHCGCVWCL4K4PQ67KKZ", Asset: native, Amount: -10000000000, Reserves: -2},
+			},
+		},
+		{
+			desc:          "failed transaction",
+			envelopeXDR:   "AAAAAPCq/iehD2ASJorqlTyEt0usn2WG3yF4w9xBkgd4itu6AAAAZAAMpboAADNGAAAAAAAAAAAAAAABAAAAAAAAAAMAAAABVEVTVAAAAAAObS6P1g8rj8sCVzRQzYgHhWFkbh1oV+1s47LFPstSpQAAAAAAAAACVAvkAAAAAfcAAAD6AAAAAAAAAAAAAAAAAAAAAXiK27oAAABAHHk5mvM6xBRsvu3RBvzzPIb8GpXaL2M7InPn65LIhFJ2RnHIYrpP6ufZc6SUtKqChNRaN4qw5rjwFXNezmrBCw==",
+			resultXDR:     "AAAAAAAAAGT/////AAAAAQAAAAAAAAAD////+QAAAAA=",
+			metaXDR:       "AAAAAQAAAAIAAAADABDLGAAAAAAAAAAA8Kr+J6EPYBImiuqVPIS3S6yfZYbfIXjD3EGSB3iK27oAAAB2ucIg2AAMpboAADNFAAAA4wAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAQAABHT9ws4fAAAAAAAAAAAAAAAAAAAAAAAAAAEAEMsYAAAAAAAAAADwqv4noQ9gEiaK6pU8hLdLrJ9lht8heMPcQZIHeIrbugAAAHa5wiDYAAylugAAM0YAAADjAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAABAAAEdP3Czh8AAAAAAAAAAAAAAAAAAAAAAAAAAA==",
+			feeChangesXDR: "AAAAAgAAAAMAEMsCAAAAAAAAAADwqv4noQ9gEiaK6pU8hLdLrJ9lht8heMPcQZIHeIrbugAAAHa5wiE8AAylugAAM0UAAADjAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAABAAAEdP3Czh8AAAAAAAAAAAAAAAAAAAAAAAAAAQAQyxgAAAAAAAAAAPCq/iehD2ASJorqlTyEt0usn2WG3yF4w9xBkgd4itu6AAAAdrnCINgADKW6AAAzRQAAAOMAAAAAAAAAAAAAAAABAAAAAAAAAAAAAAEAAAR0/cLOHwAAAAAAAAAAAAAAAAAAAAA=",
+			fee: []BalanceChange{
+				{Account: "GDYKV7RHUEHWAERGRLVJKPEEW5F2ZH3FQ3PSC6GD3RAZEB3YRLN3U75I", Asset: native, Amount: -100},
+			},
+			operations: [][]BalanceChange{{}},
+			all: []BalanceChange{
+				{Account: "GDYKV7RHUEHWAERGRLVJKPEEW5F2ZH3FQ3PSC6GD3RAZEB3YRLN3U75I", Asset: native, Amount: -100},
+			},
+		},
+		{
+			desc:          "create sponsored claimable balance",
+			envelopeXDR:   "AAAAAgAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAGQAAAABAAAAAgAAAAEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAADgAAAAAAAAAABfXhAAAAAAEAAAAAAAAAAGt5xX5qCVI5KCwEgY6WES8/A6QAG6l6VkwjhSo/HqX8AAAAAAAAAAAAAAABaDvU/AAAAECr2XdwuvH57bLtUf8FkNFh6lYpZqmgNQ/q6xJflA2dGJlbMnfUeq7Mxbxeg1yyT6grTDsI3jvNnDwjgSaiFhAG",
+			resultXDR:     "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAAOAAAAAAAAAABWBXqLmWkocTPzfSNpb286cQx5+nfTDEhiI+3wd9ky6AAAAAA=",
+			metaXDR:       "AAAAAgAAAAIAAAADAAAAyAAAAAAAAAAAzswVB9wd3XKVlRwpCIjwla25BE0bc9aW5t8GXWg71PwAAAACVAvjnAAAAAEAAAABAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAAyAAAAAAAAAAAzswVB9wd3XKVlRwpCIjwla25BE0bc9aW5t8GXWg71PwAAAACVAvjnAAAAAEAAAACAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAAAwAAAAAAAADIAAAABAAAAABWBXqLmWkocTPzfSNpb286cQx5+nfTDEhiI+3wd9ky6AAAAAEAAAAAAAAAAGt5xX5qCVI5KCwEgY6WES8/A6QAG6l6VkwjhSo/HqX8AAAAAAAAAAAAAAAABfXhAAAAAAAAAAABAAAAAQAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAAAAAADAAAAyAAAAAAAAAAAzswVB9wd3XKVlRwpCIjwla25BE0bc9aW5t8GXWg71PwAAAACVAvjnAAAAAEAAAACAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAAyAAAAAAAAAAAzswVB9wd3XKVlRwpCIjwla25BE0bc9aW5t8GXWg71PwAAAACThYCnAAAAAEAAAACAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAACAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAA",
+			feeChangesXDR: "AAAAAgAAAAMAAACWAAAAAAAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAJUC+QAAAAAAQAAAAEAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAADIAAAAAAAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAJUC+OcAAAAAQAAAAEAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAA==",
+			fee: []BalanceChange{
+				{Account: fixtureSponsor, Asset: native, Amount: -100},
+			},
+			operations: [][]BalanceChange{
+				{
+					{ClaimableBalanceID: fixtureClaimableBalanceID, Asset: native, Amount: 100000000},
+					{Account: fixtureSponsor, Asset: native, Amount: -100000000, Reserves: 1},
+				},
+			},
+			all: []BalanceChange{
+				{ClaimableBalanceID: fixtureClaimableBalanceID, Asset: native, Amount: 100000000},
+				{Account: fixtureSponsor, Asset: native, Amount: -100000100, Reserves: 1},
+			},
+		},
+		{
+			desc:          "claim sponsored claimable balance",
+			envelopeXDR:   "AAAAAgAAAABrecV+aglSOSgsBIGOlhEvPwOkABupelZMI4UqPx6l/AAAAGQAAAACAAAAAgAAAAEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAADwAAAABWBXqLmWkocTPzfSNpb286cQx5+nfTDEhiI+3wd9ky6AAAAAAAAAABPx6l/AAAAEDvH1J8Kt0IOfdodHBpkjJd+TP2G/NirhK6yog7Hf1xTJzX8vcZr2ZHzsgbna8theLAOMrfv8MIofLzQ7aEN4wG",
+			resultXDR:     "AAAAAAAAAGQAAAAAAAAAAQAAAAAAAAAPAAAAAAAAAAA=",
+			metaXDR:       "AAAAAgAAAAIAAAADAAAA0gAAAAAAAAAAa3nFfmoJUjkoLASBjpYRLz8DpAAbqXpWTCOFKj8epfwAAAAAHc1knAAAAAIAAAABAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAA0gAAAAAAAAAAa3nFfmoJUjkoLASBjpYRLz8DpAAbqXpWTCOFKj8epfwAAAAAHc1knAAAAAIAAAACAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAABgAAAAMAAADIAAAABAAAAABWBXqLmWkocTPzfSNpb286cQx5+nfTDEhiI+3wd9ky6AAAAAEAAAAAAAAAAGt5xX5qCVI5KCwEgY6WES8/A6QAG6l6VkwjhSo/HqX8AAAAAAAAAAAAAAAABfXhAAAAAAAAAAABAAAAAQAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAAAAAACAAAABAAAAABWBXqLmWkocTPzfSNpb286cQx5+nfTDEhiI+3wd9ky6AAAAAMAAADIAAAAAAAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAJOFgKcAAAAAQAAAAIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAABAAAAAAAAAAAAAAAAAAAAAAAAAAIAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAEAAADSAAAAAAAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAJOFgKcAAAAAQAAAAIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAMAAADSAAAAAAAAAABrecV+aglSOSgsBIGOlhEvPwOkABupelZMI4UqPx6l/AAAAAAdzWScAAAAAgAAAAIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAADSAAAAAAAAAABrecV+aglSOSgsBIGOlhEvPwOkABupelZMI4UqPx6l/AAAAAAjw0WcAAAAAgAAAAIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAA=",
+			feeChangesXDR: "AAAAAgAAAAMAAACWAAAAAAAAAABrecV+aglSOSgsBIGOlhEvPwOkABupelZMI4UqPx6l/AAAAAAdzWUAAAAAAgAAAAEAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAADSAAAAAAAAAABrecV+aglSOSgsBIGOlhEvPwOkABupelZMI4UqPx6l/AAAAAAdzWScAAAAAgAAAAEAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAA==",
+			fee: []BalanceChange{
+				{Account: fixtureClaimant, Asset: native, Amount: -100},
+			},
+			operations: [][]BalanceChange{
+				{
+					{ClaimableBalanceID: fixtureClaimableBalanceID, Asset: native, Amount: -100000000},
+					{Account: fixtureClaimant, Asset: native, Amount: 100000000},
+					{Account: fixtureSponsor, Asset: native, Reserves: -1},
+				},
+			},
+			all: []BalanceChange{
+				{ClaimableBalanceID: fixtureClaimableBalanceID, Asset: native, Amount: -100000000},
+				{Account: fixtureClaimant, Asset: native, Amount: 99999900},
+				{Account: fixtureSponsor, Asset: native, Reserves: -1},
+			},
+		},
+		{
+			// The claimant's new subentry is paid for by the sponsor so only
+			// the sponsor's reserves change.
+			desc:          "sponsored trust line",
+			envelopeXDR:   "AAAAAgAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAASwAAAABAAAAAwAAAAEAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAMAAAAAAAAAEAAAAABrecV+aglSOSgsBIGOlhEvPwOkABupelZMI4UqPx6l/AAAAAEAAAAAa3nFfmoJUjkoLASBjpYRLz8DpAAbqXpWTCOFKj8epfwAAAAGAAAAAVVTRAAAAAAA2tvRhKLVJvHr3VwG/a2TWbIodZtNf3nWZon6JUqthUZ//////////wAAAAEAAAAAa3nFfmoJUjkoLASBjpYRLz8DpAAbqXpWTCOFKj8epfwAAAARAAAAAAAAAAJoO9T8AAAAQNsv6LfijW+2+DNhuKp2YFLFvVywwBz0+jDrIkuolqsOB4RFB7NEroF0E5rCbpIba1EaYwiR5zvePm1yETcKTwA/HqX8AAAAQKW8r+8BKs0yeBUyO+OnE7ZPQbUcWkQTrFzf8hY2w9ZP5sP/jYfAZ9kz4bqNs4jOnmems2nWQsJe5sNOCBVYuA0=",
+			resultXDR:     "AAAAAAAAASwAAAAAAAAAAwAAAAAAAAAQAAAAAAAAAAAAAAAGAAAAAAAAAAAAAAARAAAAAAAAAAA=",
+			metaXDR:       "AAAAAgAAAAIAAAADAAAA3AAAAAAAAAAAzswVB9wd3XKVlRwpCIjwla25BE0bc9aW5t8GXWg71PwAAAACThYBcAAAAAEAAAACAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAABAAAA3AAAAAAAAAAAzswVB9wd3XKVlRwpCIjwla25BE0bc9aW5t8GXWg71PwAAAACThYBcAAAAAEAAAADAAAAAAAAAAAAAAAAAAAAAAEAAAAAAAAAAAAAAAAAAAAAAAADAAAAAAAAAAUAAAAAAAAA3AAAAAEAAAAAa3nFfmoJUjkoLASBjpYRLz8DpAAbqXpWTCOFKj8epfwAAAABVVNEAAAAAADa29GEotUm8evdXAb9rZNZsih1m01/edZmifolSq2FRgAAAAAAAAAAf/////////8AAAABAAAAAAAAAAEAAAABAAAAAM7MFQfcHd1ylZUcKQiI8JWtuQRNG3PWlubfBl1oO9T8AAAAAAAAAAMAAADSAAAAAAAAAABrecV+aglSOSgsBIGOlhEvPwOkABupelZMI4UqPx6l/AAAAAAjw0WcAAAAAgAAAAIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAADcAAAAAAAAAABrecV+aglSOSgsBIGOlhEvPwOkABupelZMI4UqPx6l/AAAAAAjw0WcAAAAAgAAAAIAAAABAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAABAAAAAAAAAAAAAAAAAAAAAAAAAAIAAAABAAAAAAAAAAAAAAAAAAAAAAAAAAMAAADcAAAAAAAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAJOFgFwAAAAAQAAAAMAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAADcAAAAAAAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAJOFgFwAAAAAQAAAAMAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAABAAAAAAAAAAAAAAAAAAAAAAAAAAIAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAAA",
+			feeChangesXDR: "AAAAAgAAAAMAAADSAAAAAAAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAJOFgKcAAAAAQAAAAIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAAAAAAEAAADcAAAAAAAAAADOzBUH3B3dcpWVHCkIiPCVrbkETRtz1pbm3wZdaDvU/AAAAAJOFgFwAAAAAQAAAAIAAAAAAAAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAAAAAA==",
+			fee: []BalanceChange{
+				{Account: fixtureSponsor, Asset: native, Amount: -300},
+			},
+			operations: [][]BalanceChange{
+				{},
+				{
+					{Account: fixtureSponsor, Asset: native, Reserves: 1},
+				},
+				{},
+			},
+			all: []BalanceChange{
+				{Account: fixtureSponsor, Asset: native, Amount: -300, Reserves: 1},
+			},
+		},
+	} {
+		t.Run(tc.desc, func(t *testing.T) {
+			tx := tc.ledgerTransaction(t)
+
+			fee, err := tx.GetFeeBalanceChanges()
+			require.NoError(t, err)
+			assert.Equal(t, tc.fee, fee)
+
+			require.Len(t, tx.Envelope.Operations(), len(tc.operations))
+			for i, expected := range tc.operations {
+				actual, err := tx.GetOperationBalanceChanges(uint32(i))
+				require.NoError(t, err)
+				assert.Equal(t, expected, actual, "operation %d", i)
+			}
+
+			all, err := tx.GetBalanceChanges()
+			require.NoError(t, err)
+			assert.Equal(t, tc.all, all)
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package io
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+
+	"github.com/stellar/go/support/errors"
+)
+
+const (
+	// diskTempSetKeySize is the size of the hash of a key stored in
+	// diskTempSet.
+	diskTempSetKeySize = 16
+	// diskTempSetMemoryKeys is the number of keys kept in memory before they
+	// are flushed to a new sorted run file (~16MB of keys).
+	diskTempSetMemoryKeys = 1000000
+	// diskTempSetMaxRuns is the number of run files after which all runs are
+	// merged into a single file.
+	diskTempSetMaxRuns = 8
+)
+
+type diskTempSetKey [diskTempSetKeySize]byte
+
+// diskTempSetRun is a file with sorted, fixed size keys.
+type diskTempSetRun struct {
+	file *os.File
+	size int64
+}
+
+// diskTempSet is a TempSet implementation that keeps most of the keys on
+// disk so it can be used on machines that can't fit the entire set of
+// ledger keys in memory. Keys are stored as truncated SHA-256 hashes in
+// memory until diskTempSetMemoryKeys keys are added. Then they are sorted and
+// written to a new run file. Lookups binary search every run file. To limit
+// the number of disk reads, Preload looks up a batch of keys and caches the
+// results until the next Preload call.
+type diskTempSet struct {
+	dir        string
+	memoryKeys int
+
+	tempDir   string
+	memory    map[diskTempSetKey]struct{}
+	runs      []diskTempSetRun
+	preloaded map[diskTempSetKey]bool
+}
+
+func (s *diskTempSet) hashKey(key string) diskTempSetKey {
+	var k diskTempSetKey
+	sum := sha256.Sum256([]byte(key))
+	copy(k[:], sum[:diskTempSetKeySize])
+	return k
+}
+
+// Open creates a temporary directory for run files.
+func (s *diskTempSet) Open() error {
+	var err error
+	s.tempDir, err = ioutil.TempDir(s.dir, "temp-set")
+	if err != nil {
+		return errors.Wrap(err, "error creating temporary directory")
+	}
+	if s.memoryKeys == 0 {
+		s.memoryKeys = diskTempSetMemoryKeys
+	}
+	s.memory = make(map[diskTempSetKey]struct{})
+	s.preloaded = make(map[diskTempSetKey]bool)
+	return nil
+}
+
+// Add adds a key to TempSet.
+func (s *diskTempSet) Add(key string) error {
+	k := s.hashKey(key)
+	s.memory[k] = struct{}{}
+	if _, ok := s.preloaded[k]; ok {
+		s.preloaded[k] = true
+	}
+
+	if len(s.memory) >= s.memoryKeys {
+		return s.flush()
+	}
+	return nil
+}
+
+// Preload looks up the given keys in run files and caches the results.
+func (s *diskTempSet) Preload(keys []string) error {
+	s.preloaded = make(map[diskTempSetKey]bool, len(keys))
+	hashes := make([]diskTempSetKey, 0, len(keys))
+	for _, key := range keys {
+		k := s.hashKey(key)
+		if _, ok := s.memory[k]; ok {
+			continue
+		}
+		hashes = append(hashes, k)
+	}
+
+	// Sorting keys makes disk reads more local.
+	sort.Slice(hashes, func(i, j int) bool {
+		return bytes.Compare(hashes[i][:], hashes[j][:]) < 0
+	})
+
+	for _, k := range hashes {
+		exist, err := s.existOnDisk(k)
+		if err != nil {
+			return err
+		}
+		s.preloaded[k] = exist
+	}
+	return nil
+}
+
+// Exist check if the key exists in a TempSet.
+func (s *diskTempSet) Exist(key string) (bool, error) {
+	k := s.hashKey(key)
+	if _, ok := s.memory[k]; ok {
+		return true, nil
+	}
+	if exist, ok := s.preloaded[k]; ok {
+		return exist, nil
+	}
+	return s.existOnDisk(k)
+}
+
+// Close removes all run files.
+func (s *diskTempSet) Close() error {
+	for _, run := range s.runs {
+		run.file.Close()
+	}
+	s.runs = nil
+	s.memory = nil
+	s.preloaded = nil
+	return os.RemoveAll(s.tempDir)
+}
+
+func (s *diskTempSet) existOnDisk(k diskTempSetKey) (bool, error) {
+	var buf diskTempSetKey
+	for _, run := range s.runs {
+		count := run.size / diskTempSetKeySize
+		var readErr error
+		i := sort.Search(int(count), func(i int) bool {
+			if readErr != nil {
+				return true
+			}
+			_, readErr = run.file.ReadAt(buf[:], int64(i)*diskTempSetKeySize)
+			return bytes.Compare(buf[:], k[:]) >= 0
+		})
+		if readErr != nil {
+			return false, errors.Wrap(readErr, "error reading run file")
+		}
+		if int64(i) < count {
+			if _, err := run.file.ReadAt(buf[:], int64(i)*diskTempSetKeySize); err != nil {
+				return false, errors.Wrap(err, "error reading run file")
+			}
+			if buf == k {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// flush writes keys kept in memory to a new run file.
+func (s *diskTempSet) flush() error {
+	keys := make([]diskTempSetKey, 0, len(s.memory))
+	for k := range s.memory {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return bytes.Compare(keys[i][:], keys[j][:]) < 0
+	})
+
+	file, err := ioutil.TempFile(s.tempDir, "run")
+	if err != nil {
+		return errors.Wrap(err, "error creating run file")
+	}
+
+	w := bufio.NewWriter(file)
+	for _, k := range keys {
+		if _, err = w.Write(k[:]); err != nil {
+			file.Close()
+			return errors.Wrap(err, "error writing run file")
+		}
+	}
+	if err = w.Flush(); err != nil {
+		file.Close()
+		return errors.Wrap(err, "error writing run file")
+	}
+
+	s.runs = append(s.runs, diskTempSetRun{
+		file: file,
+		size: int64(len(keys)) * diskTempSetKeySize,
+	})
+	s.memory = make(map[diskTempSetKey]struct{})
+
+	if len(s.runs) > diskTempSetMaxRuns {
+		return s.mergeRuns()
+	}
+	return nil
+}
+
+// mergeRuns merges all run files into a single run file.
+func (s *diskTempSet) mergeRuns() error {
+	merged := s.runs[0]
+	for _, run := range s.runs[1:] {
+		next, err := s.merge(merged, run)
+		if err != nil {
+			return err
+		}
+		merged.file.Close()
+		os.Remove(merged.file.Name())
+		run.file.Close()
+		os.Remove(run.file.Name())
+		merged = next
+	}
+	s.runs = []diskTempSetRun{merged}
+	return nil
+}
+
+func (s *diskTempSet) merge(a, b diskTempSetRun) (diskTempSetRun, error) {
+	file, err := ioutil.TempFile(s.tempDir, "run")
+	if err != nil {
+		return diskTempSetRun{}, errors.Wrap(err, "error creating run file")
+	}
+
+	ra := bufio.NewReader(io.NewSectionReader(a.file, 0, a.size))
+	rb := bufio.NewReader(io.NewSectionReader(b.file, 0, b.size))
+	w := bufio.NewWriter(file)
+
+	var ka, kb diskTempSetKey
+	okA, err := readRunKey(ra, &ka)
+	if err != nil {
+		file.Close()
+		return diskTempSetRun{}, err
+	}
+	okB, err := readRunKey(rb, &kb)
+	if err != nil {
+		file.Close()
+		return diskTempSetRun{}, err
+	}
+
+	var size int64
+	for okA || okB {
+		var next diskTempSetKey
+		switch {
+		case okA && okB && ka == kb:
+			next = ka
+			okA, err = readRunKey(ra, &ka)
+			if err == nil {
+				okB, err = readRunKey(rb, &kb)
+			}
+		case !okB || (okA && bytes.Compare(ka[:], kb[:]) < 0):
+			next = ka
+			okA, err = readRunKey(ra, &ka)
+		default:
+			next = kb
+			okB, err = readRunKey(rb, &kb)
+		}
+		if err != nil {
+			file.Close()
+			return diskTempSetRun{}, err
+		}
+
+		if _, err = w.Write(next[:]); err != nil {
+			file.Close()
+			return diskTempSetRun{}, errors.Wrap(err, "error writing run file")
+		}
+		size += diskTempSetKeySize
+	}
+
+	if err = w.Flush(); err != nil {
+		file.Close()
+		return diskTempSetRun{}, errors.Wrap(err, "error writing run file")
+	}
+
+	return diskTempSetRun{file: file, size: size}, nil
+}
+
+func readRunKey(r io.Reader, k *diskTempSetKey) (bool, error) {
+	_, err := io.ReadFull(r, k[:])
+	if err == io.EOF {
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrap(err, "error reading run file")
+	}
+	return true, nil
+}
@@ -0,0 +1,42 @@
+package io
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiskTempSet(t *testing.T) {
+	s := diskTempSet{memoryKeys: 10}
+	assert.NoError(t, s.Open())
+
+	// Generate enough keys to create and merge run files.
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, s.Add(fmt.Sprintf("key-%d", i)))
+	}
+	assert.Len(t, s.runs, 2)
+
+	assert.NoError(t, s.Preload([]string{"key-1", "key-99", "a"}))
+	for i := 0; i < 100; i++ {
+		v, err := s.Exist(fmt.Sprintf("key-%d", i))
+		assert.NoError(t, err)
+		assert.True(t, v)
+	}
+
+	v, err := s.Exist("a")
+	assert.NoError(t, err)
+	assert.False(t, v)
+
+	v, err = s.Exist("b")
+	assert.NoError(t, err)
+	assert.False(t, v)
+
+	// Adding a preloaded key
+	assert.NoError(t, s.Add("a"))
+	v, err = s.Exist("a")
+	assert.NoError(t, err)
+	assert.True(t, v)
+
+	assert.NoError(t, s.Close())
+}
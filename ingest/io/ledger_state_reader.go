@@ -0,0 +1,200 @@
+package io
+
+import (
+	"context"
+	"io"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/support/errors"
+)
+
+// LedgerStateReader is a ChangeReader that streams ledger entries present at
+// any ledger, not only at checkpoint ledgers. It reads the state at the
+// nearest checkpoint before the requested ledger (or the genesis state for
+// ledgers before the first checkpoint) and applies changes from all the
+// ledgers that follow, up to and including the requested ledger.
+//
+// As with SingleLedgerStateReader, every returned Change has Pre set to nil
+// and Post set to the ledger entry.
+//
+// The ledger backend must return transaction meta (ex. captive stellar-core
+// or stellar-core DB). HistoryArchiveBackend cannot be used because history
+// archives do not contain meta.
+type LedgerStateReader struct {
+	sequence    uint32
+	stateReader ChangeReader
+	// changes are squashed changes applied after the checkpoint ledger.
+	changes []Change
+	// modified contains ledger keys of entries that were updated or removed
+	// after the checkpoint ledger.
+	modified    map[string]bool
+	stateDone   bool
+	changeIndex int
+}
+
+// Ensure LedgerStateReader implements ChangeReader
+var _ ChangeReader = &LedgerStateReader{}
+
+// MakeLedgerStateReader is a factory method for LedgerStateReader. It reads
+// and squashes all changes between the nearest checkpoint and the given
+// ledger so it can take a while to return when the backend is slow.
+func MakeLedgerStateReader(
+	ctx context.Context,
+	archive historyarchive.ArchiveInterface,
+	backend ledgerbackend.LedgerBackend,
+	networkPassphrase string,
+	sequence uint32,
+) (*LedgerStateReader, error) {
+	if sequence == 0 {
+		return nil, errors.New("ledger sequence must be greater than 0")
+	}
+
+	var checkpoint uint32
+	var stateReader ChangeReader
+	if sequence < historyarchive.CheckpointFreq-1 {
+		// There is no checkpoint before this ledger, start from genesis.
+		checkpoint = 1
+		stateReader = &GenesisLedgerStateReader{NetworkPassphrase: networkPassphrase}
+	} else {
+		checkpoint = sequence
+		if !historyarchive.IsCheckpoint(checkpoint) {
+			checkpoint = historyarchive.PrevCheckpoint(sequence)
+		}
+	}
+
+	reader := &LedgerStateReader{sequence: sequence}
+
+	if checkpoint < sequence {
+		if err := reader.applyLedgers(backend, networkPassphrase, checkpoint+1); err != nil {
+			return nil, err
+		}
+	}
+
+	if stateReader == nil {
+		var err error
+		stateReader, err = MakeSingleLedgerStateReader(ctx, archive, checkpoint)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating state reader for checkpoint %d", checkpoint)
+		}
+	}
+	reader.stateReader = stateReader
+
+	return reader, nil
+}
+
+// applyLedgers squashes changes in ledgers [from, r.sequence] and stores
+// the result in r.changes and r.modified.
+func (r *LedgerStateReader) applyLedgers(
+	backend ledgerbackend.LedgerBackend,
+	networkPassphrase string,
+	from uint32,
+) error {
+	ledgerRange := ledgerbackend.BoundedRange(from, r.sequence)
+	prepared, err := backend.IsPrepared(ledgerRange)
+	if err != nil {
+		return errors.Wrap(err, "error checking if range is prepared")
+	}
+	if !prepared {
+		if err = backend.PrepareRange(ledgerRange); err != nil {
+			return errors.Wrapf(err, "error preparing range %s", ledgerRange)
+		}
+	}
+
+	cache := NewLedgerEntryChangeCache()
+	for seq := from; seq <= r.sequence; seq++ {
+		changeReader, err := NewLedgerChangeReader(backend, networkPassphrase, seq)
+		if err != nil {
+			return errors.Wrapf(err, "error creating change reader for ledger %d", seq)
+		}
+
+		for {
+			change, err := changeReader.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				changeReader.Close()
+				return errors.Wrapf(err, "error reading changes in ledger %d", seq)
+			}
+
+			if err = cache.AddChange(change); err != nil {
+				changeReader.Close()
+				return errors.Wrapf(err, "error applying change in ledger %d", seq)
+			}
+		}
+		changeReader.Close()
+	}
+
+	r.changes = cache.GetChanges()
+	r.modified = make(map[string]bool, len(r.changes))
+	for _, change := range r.changes {
+		if change.Pre == nil {
+			continue
+		}
+
+		key, err := change.Pre.LedgerKey().MarshalBinaryBase64()
+		if err != nil {
+			return errors.Wrap(err, "Error MarshalBinaryBase64")
+		}
+		r.modified[key] = true
+	}
+
+	return nil
+}
+
+// Read returns a new ledger entry change on each call, returning io.EOF when
+// the stream ends. Entries from the checkpoint state are returned first,
+// followed by entries created or updated after the checkpoint.
+func (r *LedgerStateReader) Read() (Change, error) {
+	for !r.stateDone {
+		change, err := r.stateReader.Read()
+		if err == io.EOF {
+			r.stateDone = true
+			break
+		}
+		if err != nil {
+			return Change{}, err
+		}
+
+		if len(r.modified) > 0 {
+			key, err := change.Post.LedgerKey().MarshalBinaryBase64()
+			if err != nil {
+				return Change{}, errors.Wrap(err, "Error MarshalBinaryBase64")
+			}
+			if r.modified[key] {
+				continue
+			}
+		}
+
+		return change, nil
+	}
+
+	for r.changeIndex < len(r.changes) {
+		change := r.changes[r.changeIndex]
+		r.changeIndex++
+		if change.Post == nil {
+			// Entry removed after the checkpoint.
+			continue
+		}
+
+		return Change{
+			Type: change.Type,
+			Post: change.Post,
+		}, nil
+	}
+
+	return Change{}, io.EOF
+}
+
+// GetSequence returns the ledger sequence of the state being read.
+func (r *LedgerStateReader) GetSequence() uint32 {
+	return r.sequence
+}
+
+// Close should be called when reading is finished.
+func (r *LedgerStateReader) Close() error {
+	r.changes = nil
+	r.modified = nil
+	return r.stateReader.Close()
+}
@@ -0,0 +1,133 @@
+package io
+
+import (
+	"context"
+	"io"
+	"sort"
+	"testing"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+)
+
+func accountEntry(address string, balance int64) xdr.LedgerEntry {
+	return xdr.LedgerEntry{
+		Data: xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{
+				AccountId: xdr.MustAddress(address),
+				Balance:   xdr.Int64(balance),
+			},
+		},
+	}
+}
+
+func upgradeLedger(sequence uint32, changes xdr.LedgerEntryChanges) xdr.LedgerCloseMeta {
+	return xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{LedgerSeq: xdr.Uint32(sequence)},
+			},
+			UpgradesProcessing: []xdr.UpgradeEntryMeta{
+				{Changes: changes},
+			},
+		},
+	}
+}
+
+func TestLedgerStateReaderFromGenesis(t *testing.T) {
+	backend := &ledgerbackend.MockDatabaseBackend{}
+	master := keypair.Master(network.TestNetworkPassphrase).Address()
+
+	masterPre := accountEntry(master, int64(amount.MustParse("100000000000")))
+	masterPre.LastModifiedLedgerSeq = 1
+	masterPost := accountEntry(master, 100)
+	created := accountEntry(feeAddress, 200)
+	removed := accountEntry(metaAddress, 300)
+
+	backend.On("IsPrepared", ledgerbackend.BoundedRange(2, 3)).Return(false, nil).Once()
+	backend.On("PrepareRange", ledgerbackend.BoundedRange(2, 3)).Return(nil).Once()
+	backend.On("GetLedger", uint32(2)).Return(true, upgradeLedger(2, xdr.LedgerEntryChanges{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &masterPre},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryUpdated, Updated: &masterPost},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: &created},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated, Created: &removed},
+	}), nil).Once()
+	backend.On("GetLedger", uint32(3)).Return(true, upgradeLedger(3, xdr.LedgerEntryChanges{
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryState, State: &removed},
+		{Type: xdr.LedgerEntryChangeTypeLedgerEntryRemoved, Removed: &xdr.LedgerKey{}},
+	}), nil).Once()
+
+	reader, err := MakeLedgerStateReader(
+		context.Background(),
+		&historyarchive.MockArchive{},
+		backend,
+		network.TestNetworkPassphrase,
+		3,
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(3), reader.GetSequence())
+
+	entries := []balanceEntry{}
+	for {
+		change, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		assert.Nil(t, change.Pre)
+		entries = append(entries, parseChange(change))
+	}
+	assert.NoError(t, reader.Close())
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].address < entries[j].address
+	})
+	assert.Equal(t, []balanceEntry{
+		{feeAddress, 200},
+		{master, 100},
+	}, entries)
+	backend.AssertExpectations(t)
+}
+
+func TestLedgerStateReaderGenesisLedger(t *testing.T) {
+	backend := &ledgerbackend.MockDatabaseBackend{}
+
+	reader, err := MakeLedgerStateReader(
+		context.Background(),
+		&historyarchive.MockArchive{},
+		backend,
+		network.TestNetworkPassphrase,
+		1,
+	)
+	assert.NoError(t, err)
+
+	change, err := reader.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, keypair.Master(network.TestNetworkPassphrase).Address(), parseChange(change).address)
+
+	_, err = reader.Read()
+	assert.Equal(t, io.EOF, err)
+	backend.AssertExpectations(t)
+}
+
+func TestLedgerStateReaderLedgerError(t *testing.T) {
+	backend := &ledgerbackend.MockDatabaseBackend{}
+	backend.On("IsPrepared", ledgerbackend.BoundedRange(2, 2)).Return(true, nil).Once()
+	backend.On("GetLedger", uint32(2)).Return(false, xdr.LedgerCloseMeta{}, nil).Once()
+
+	_, err := MakeLedgerStateReader(
+		context.Background(),
+		&historyarchive.MockArchive{},
+		backend,
+		network.TestNetworkPassphrase,
+		2,
+	)
+	assert.EqualError(t, err, "error creating change reader for ledger 2: ledger not found")
+	backend.AssertExpectations(t)
+}
@@ -34,6 +34,9 @@ type SingleLedgerStateReader struct {
 	closeOnce  sync.Once
 	done       chan bool
 
+	filter          stateFilter
+	parallelBuckets int
+
 	// This should be set to true in tests only
 	disableBucketListHashValidation bool
 	sleep                           func(time.Duration)
@@ -70,37 +73,48 @@ const (
 	preloadedEntries = 20000
 
 	sleepDuration = time.Second
+
+	// parallelBucketBufferSize defines how many decoded entries of a single
+	// bucket can be buffered when buckets are read in parallel.
+	parallelBucketBufferSize = 10000
 )
 
 // MakeSingleLedgerStateReader is a factory method for SingleLedgerStateReader.
+// Options can be used to filter returned entries, read buckets in parallel
+// or change the temp store.
 func MakeSingleLedgerStateReader(
 	ctx context.Context,
 	archive historyarchive.ArchiveInterface,
 	sequence uint32,
+	options ...StateReaderOption,
 ) (*SingleLedgerStateReader, error) {
 	has, err := archive.GetCheckpointHAS(sequence)
 	if err != nil {
 		return nil, errors.Wrapf(err, "unable to get checkpoint HAS at ledger sequence %d", sequence)
 	}
 
-	tempStore := &memoryTempSet{}
-	err = tempStore.Open()
-	if err != nil {
-		return nil, errors.Wrap(err, "unable to get open temp store")
-	}
-
-	return &SingleLedgerStateReader{
+	msr := &SingleLedgerStateReader{
 		ctx:        ctx,
 		has:        &has,
 		archive:    archive,
-		tempStore:  tempStore,
+		tempStore:  &memoryTempSet{},
 		sequence:   sequence,
 		readChan:   make(chan readResult, msrBufferSize),
 		streamOnce: sync.Once{},
 		closeOnce:  sync.Once{},
 		done:       make(chan bool),
 		sleep:      time.Sleep,
-	}, nil
+	}
+	for _, option := range options {
+		option(msr)
+	}
+
+	err = msr.tempStore.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to get open temp store")
+	}
+
+	return msr, nil
 }
 
 func (msr *SingleLedgerStateReader) bucketExists(hash historyarchive.Hash) (bool, error) {
@@ -173,26 +187,189 @@ func (msr *SingleLedgerStateReader) streamBuckets() {
 		}
 	}
 
+	if msr.parallelBuckets > 1 {
+		msr.streamBucketsInParallel(buckets)
+		return
+	}
+
 	for i, hash := range buckets {
-		exists, err := msr.bucketExists(hash)
-		if err != nil {
-			msr.readChan <- msr.error(
-				errors.Wrapf(err, "error checking if bucket exists: %s", hash),
-			)
+		if err := msr.checkBucketExists(hash); err != nil {
+			msr.readChan <- msr.error(err)
 			return
 		}
 
-		if !exists {
+		rdr, err := msr.newXDRStream(hash)
+		if err != nil {
 			msr.readChan <- msr.error(
-				errors.Errorf("bucket hash does not exist: %s", hash),
+				errors.Wrapf(err, "cannot get xdr stream for hash '%s'", hash.String()),
 			)
 			return
 		}
 
+		source := &streamBucketSource{msr: msr, stream: rdr, hash: hash}
+		oldestBucket := i == len(buckets)-1
+		if shouldContinue := msr.streamBucketContents(hash, source, oldestBucket); !shouldContinue {
+			break
+		}
+	}
+}
+
+// streamBucketsInParallel streams buckets like streamBuckets but up to
+// msr.parallelBuckets buckets are downloaded and decoded at the same time.
+// Decoded entries are sent to a bounded channel per bucket and consumed in
+// the bucket list order.
+func (msr *SingleLedgerStateReader) streamBucketsInParallel(buckets []historyarchive.Hash) {
+	sources := make([]chan readBucketResult, len(buckets))
+	for i := range sources {
+		sources[i] = make(chan readBucketResult, parallelBucketBufferSize)
+	}
+
+	// slots limits the number of buckets being read at the same time. A slot
+	// is released when the bucket has been fully processed.
+	slots := make(chan struct{}, msr.parallelBuckets)
+	go func() {
+		for i, hash := range buckets {
+			select {
+			case slots <- struct{}{}:
+			case <-msr.done:
+				return
+			}
+			go msr.prefetchBucket(hash, sources[i])
+		}
+	}()
+
+	for i, hash := range buckets {
+		source := &prefetchedBucketSource{results: sources[i]}
 		oldestBucket := i == len(buckets)-1
-		if shouldContinue := msr.streamBucketContents(hash, oldestBucket); !shouldContinue {
+		shouldContinue := msr.streamBucketContents(hash, source, oldestBucket)
+		<-slots
+		if !shouldContinue {
+			break
+		}
+	}
+}
+
+// readBucketResult is the result of reading a single bucket entry.
+type readBucketResult struct {
+	entry xdr.BucketEntry
+	e     error
+}
+
+// prefetchBucket reads all the entries of a bucket and sends them to
+// results. The channel is closed when the bucket is read or Close() is
+// called.
+func (msr *SingleLedgerStateReader) prefetchBucket(hash historyarchive.Hash, results chan<- readBucketResult) {
+	defer close(results)
+
+	send := func(result readBucketResult) bool {
+		select {
+		case results <- result:
+			return true
+		case <-msr.done:
+			return false
+		}
+	}
+
+	if err := msr.checkBucketExists(hash); err != nil {
+		send(readBucketResult{e: err})
+		return
+	}
+
+	rdr, err := msr.newXDRStream(hash)
+	if err != nil {
+		send(readBucketResult{
+			e: errors.Wrapf(err, "cannot get xdr stream for hash '%s'", hash.String()),
+		})
+		return
+	}
+
+	for {
+		entry, err := msr.readBucketEntry(rdr, hash)
+		if err == io.EOF {
 			break
 		}
+		if err != nil {
+			rdr.Close()
+			send(readBucketResult{e: err})
+			return
+		}
+		if !send(readBucketResult{entry: entry}) {
+			rdr.Close()
+			return
+		}
+	}
+
+	if err := rdr.Close(); err != nil {
+		send(readBucketResult{e: errors.Wrap(err, "Error closing xdr stream")})
+	}
+}
+
+// bucketSource returns consecutive entries of a single bucket.
+type bucketSource interface {
+	// next returns the next bucket entry or io.EOF if there are no more
+	// entries.
+	next() (xdr.BucketEntry, error)
+	close() error
+}
+
+// streamBucketSource reads entries directly from an XdrStream.
+type streamBucketSource struct {
+	msr    *SingleLedgerStateReader
+	stream *historyarchive.XdrStream
+	hash   historyarchive.Hash
+}
+
+func (s *streamBucketSource) next() (xdr.BucketEntry, error) {
+	return s.msr.readBucketEntry(s.stream, s.hash)
+}
+
+func (s *streamBucketSource) close() error {
+	return s.stream.Close()
+}
+
+// prefetchedBucketSource reads entries sent by prefetchBucket.
+type prefetchedBucketSource struct {
+	results <-chan readBucketResult
+}
+
+func (s *prefetchedBucketSource) next() (xdr.BucketEntry, error) {
+	result, ok := <-s.results
+	if !ok {
+		return xdr.BucketEntry{}, io.EOF
+	}
+	return result.entry, result.e
+}
+
+func (s *prefetchedBucketSource) close() error {
+	// Errors (including hash validation errors) are returned by next().
+	return nil
+}
+
+// checkBucketExists returns an error if a bucket does not exist in the
+// archive.
+func (msr *SingleLedgerStateReader) checkBucketExists(hash historyarchive.Hash) error {
+	exists, err := msr.bucketExists(hash)
+	if err != nil {
+		return errors.Wrapf(err, "error checking if bucket exists: %s", hash)
+	}
+
+	if !exists {
+		return errors.Errorf("bucket hash does not exist: %s", hash)
+	}
+	return nil
+}
+
+// bucketEntryKey returns the ledger key of a live or dead bucket entry or
+// false if the entry does not have a ledger key (METAENTRY).
+func bucketEntryKey(entry xdr.BucketEntry) (xdr.LedgerKey, bool) {
+	switch entry.Type {
+	case xdr.BucketEntryTypeLiveentry, xdr.BucketEntryTypeInitentry:
+		liveEntry := entry.MustLiveEntry()
+		return liveEntry.LedgerKey(), true
+	case xdr.BucketEntryTypeDeadentry:
+		return entry.MustDeadEntry(), true
+	default:
+		return xdr.LedgerKey{}, false
 	}
 }
 
@@ -204,6 +381,8 @@ func (msr *SingleLedgerStateReader) readBucketEntry(stream *historyarchive.XdrSt
 	xdr.BucketEntry,
 	error,
 ) {
+	// Entries are kept in batches and sent to readChan so each of them is
+	// decoded into a new value, see XdrStream.ReadOne.
 	var entry xdr.BucketEntry
 	var err error
 	currentPosition := stream.BytesRead()
@@ -259,17 +438,14 @@ func (msr *SingleLedgerStateReader) newXDRStream(hash historyarchive.Hash) (
 }
 
 // streamBucketContents pushes value onto the read channel, returning false when the channel needs to be closed otherwise true
-func (msr *SingleLedgerStateReader) streamBucketContents(hash historyarchive.Hash, oldestBucket bool) bool {
-	rdr, e := msr.newXDRStream(hash)
-	if e != nil {
-		msr.readChan <- msr.error(
-			errors.Wrapf(e, "cannot get xdr stream for hash '%s'", hash.String()),
-		)
-		return false
-	}
-
+func (msr *SingleLedgerStateReader) streamBucketContents(
+	hash historyarchive.Hash,
+	source bucketSource,
+	oldestBucket bool,
+) bool {
+	var e error
 	defer func() {
-		err := rdr.Close()
+		err := source.close()
 		if err != nil {
 			msr.readChan <- msr.error(errors.Wrap(err, "Error closing xdr stream"))
 			// Stop streaming from the rest of the files.
@@ -297,7 +473,7 @@ LoopBucketEntry:
 
 			for i := 0; i < preloadedEntries; i++ {
 				var entry xdr.BucketEntry
-				entry, e = msr.readBucketEntry(rdr, hash)
+				entry, e = source.next()
 				if e != nil {
 					if e == io.EOF {
 						if len(batch) == 0 {
@@ -313,18 +489,17 @@ LoopBucketEntry:
 					return false
 				}
 
+				// Generate a key
+				key, hasKey := bucketEntryKey(entry)
+				if hasKey && !msr.filter.matchesType(key.Type) {
+					// Entries of filtered out types are never returned so there's
+					// no need to track their keys.
+					continue
+				}
+
 				batch = append(batch, entry)
 
-				// Generate a key
-				var key xdr.LedgerKey
-
-				switch entry.Type {
-				case xdr.BucketEntryTypeLiveentry, xdr.BucketEntryTypeInitentry:
-					liveEntry := entry.MustLiveEntry()
-					key = liveEntry.LedgerKey()
-				case xdr.BucketEntryTypeDeadentry:
-					key = entry.MustDeadEntry()
-				default:
+				if !hasKey {
 					// No ledger key associated with this entry, continue to the next one.
 					continue
 				}
@@ -414,11 +589,13 @@ LoopBucketEntry:
 			if !seen {
 				// Return LEDGER_ENTRY_STATE changes only now.
 				liveEntry := entry.MustLiveEntry()
-				entryChange := xdr.LedgerEntryChange{
-					Type:  xdr.LedgerEntryChangeTypeLedgerEntryState,
-					State: &liveEntry,
+				if msr.filter.matches(liveEntry) {
+					entryChange := xdr.LedgerEntryChange{
+						Type:  xdr.LedgerEntryChangeTypeLedgerEntryState,
+						State: &liveEntry,
+					}
+					msr.readChan <- readResult{entryChange, nil}
 				}
-				msr.readChan <- readResult{entryChange, nil}
 
 				// We don't update `tempStore` for INITENTRY because CAP-20 says:
 				// > a bucket entry marked INITENTRY implies that either no entry
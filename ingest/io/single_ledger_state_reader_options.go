@@ -0,0 +1,142 @@
+package io
+
+import (
+	"github.com/stellar/go/xdr"
+)
+
+// StateReaderOption values can be passed into MakeSingleLedgerStateReader to
+// customize a SingleLedgerStateReader instance.
+type StateReaderOption func(msr *SingleLedgerStateReader)
+
+// FilterLedgerEntryTypes configures the reader to return only entries of the
+// given types. Buckets entries of other types are skipped without updating
+// the temp store what decreases memory usage.
+func FilterLedgerEntryTypes(types ...xdr.LedgerEntryType) StateReaderOption {
+	return func(msr *SingleLedgerStateReader) {
+		if msr.filter.types == nil {
+			msr.filter.types = map[xdr.LedgerEntryType]bool{}
+		}
+		for _, t := range types {
+			msr.filter.types[t] = true
+		}
+	}
+}
+
+// FilterAccounts configures the reader to return only entries connected to
+// one of the given accounts: the account entries, trust lines, offers and
+// data entries owned by the accounts and claimable balances the accounts
+// can claim.
+func FilterAccounts(accounts ...xdr.AccountId) StateReaderOption {
+	return func(msr *SingleLedgerStateReader) {
+		if msr.filter.accounts == nil {
+			msr.filter.accounts = map[string]bool{}
+		}
+		for _, account := range accounts {
+			msr.filter.accounts[account.Address()] = true
+		}
+	}
+}
+
+// FilterAssets configures the reader to return only entries connected to one
+// of the given assets: trust lines, offers selling or buying the assets and
+// claimable balances. Account entries are returned only if the native asset
+// is in the list. Data entries are never returned.
+func FilterAssets(assets ...xdr.Asset) StateReaderOption {
+	return func(msr *SingleLedgerStateReader) {
+		if msr.filter.assets == nil {
+			msr.filter.assets = map[string]bool{}
+		}
+		for _, asset := range assets {
+			msr.filter.assets[asset.String()] = true
+		}
+	}
+}
+
+// ParallelBuckets configures the reader to download and decode up to n
+// buckets in parallel. Entries are still processed in the bucket list
+// order so the result is the same as for sequential reads but the memory
+// usage grows with n.
+func ParallelBuckets(n int) StateReaderOption {
+	return func(msr *SingleLedgerStateReader) {
+		msr.parallelBuckets = n
+	}
+}
+
+// DiskTempSet configures the reader to keep the set of seen ledger keys in
+// run files in the given directory (os.TempDir() if empty) instead of
+// memory. This is slower but requires a fraction of the memory needed by
+// the default, in-memory store.
+func DiskTempSet(dir string) StateReaderOption {
+	return func(msr *SingleLedgerStateReader) {
+		msr.tempStore = &diskTempSet{dir: dir}
+	}
+}
+
+// stateFilter decides which ledger entries should be returned by
+// SingleLedgerStateReader. Empty filter returns all entries. When both
+// accounts and assets are set, entries must match both.
+type stateFilter struct {
+	types    map[xdr.LedgerEntryType]bool
+	accounts map[string]bool
+	assets   map[string]bool
+}
+
+// matchesType returns true if entries of type t can be returned. Entries of
+// other types can be skipped entirely.
+func (f stateFilter) matchesType(t xdr.LedgerEntryType) bool {
+	return len(f.types) == 0 || f.types[t]
+}
+
+// matches returns true if entry should be returned.
+func (f stateFilter) matches(entry xdr.LedgerEntry) bool {
+	if !f.matchesType(entry.Data.Type) {
+		return false
+	}
+	if len(f.accounts) > 0 && !f.matchesAccount(entry) {
+		return false
+	}
+	if len(f.assets) > 0 && !f.matchesAsset(entry) {
+		return false
+	}
+	return true
+}
+
+func (f stateFilter) matchesAccount(entry xdr.LedgerEntry) bool {
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeAccount:
+		return f.accounts[accountAddress(entry.Data.MustAccount().AccountId)]
+	case xdr.LedgerEntryTypeTrustline:
+		return f.accounts[accountAddress(entry.Data.MustTrustLine().AccountId)]
+	case xdr.LedgerEntryTypeOffer:
+		return f.accounts[accountAddress(entry.Data.MustOffer().SellerId)]
+	case xdr.LedgerEntryTypeData:
+		return f.accounts[accountAddress(entry.Data.MustData().AccountId)]
+	case xdr.LedgerEntryTypeClaimableBalance:
+		for _, claimant := range entry.Data.MustClaimableBalance().Claimants {
+			if f.accounts[accountAddress(claimant.MustV0().Destination)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// accountAddress returns the strkey address of id.
+func accountAddress(id xdr.AccountId) string {
+	return id.Address()
+}
+
+func (f stateFilter) matchesAsset(entry xdr.LedgerEntry) bool {
+	switch entry.Data.Type {
+	case xdr.LedgerEntryTypeAccount:
+		return f.assets[xdr.MustNewNativeAsset().String()]
+	case xdr.LedgerEntryTypeTrustline:
+		return f.assets[entry.Data.MustTrustLine().Asset.String()]
+	case xdr.LedgerEntryTypeOffer:
+		offer := entry.Data.MustOffer()
+		return f.assets[offer.Selling.String()] || f.assets[offer.Buying.String()]
+	case xdr.LedgerEntryTypeClaimableBalance:
+		return f.assets[entry.Data.MustClaimableBalance().Asset.String()]
+	}
+	return false
+}
@@ -2,10 +2,14 @@ package io
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -14,6 +18,7 @@ import (
 	"github.com/stellar/go/support/errors"
 	"github.com/stellar/go/xdr"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -258,6 +263,127 @@ func (s *SingleLedgerStateReaderTestSuite) TestMalformedProtocol11BucketNoMeta()
 	s.Assert().Equal("Error while reading from buckets: Read INITENTRY from version <11 bucket: 0@517bea4c6627a688a8ce501febd8c562e737e3d86b29689d9956217640f3c74b", err.Error())
 }
 
+// mockBuckets returns the given streams for the first buckets and empty
+// streams for the rest of the buckets.
+func (s *SingleLedgerStateReaderTestSuite) mockBuckets(streams ...*historyarchive.XdrStream) {
+	nextBucket := s.getNextBucketChannel()
+
+	for _, stream := range streams {
+		s.mockArchive.
+			On("GetXdrStreamForHash", <-nextBucket).
+			Return(stream, nil).Once()
+	}
+
+	for hash := range nextBucket {
+		s.mockArchive.
+			On("GetXdrStreamForHash", hash).
+			Return(createXdrStream(), nil).Once()
+	}
+}
+
+func (s *SingleLedgerStateReaderTestSuite) readAllAccounts() []string {
+	accounts := []string{}
+	for {
+		change, err := s.reader.Read()
+		if err == io.EOF {
+			break
+		}
+		s.Require().NoError(err)
+		id := change.Post.Data.MustAccount().AccountId
+		accounts = append(accounts, id.Address())
+	}
+	return accounts
+}
+
+// TestFilterAccounts tests if only entries of given accounts are returned.
+func (s *SingleLedgerStateReaderTestSuite) TestFilterAccounts() {
+	FilterAccounts(xdr.MustAddress("GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF"))(s.reader)
+
+	s.mockBuckets(
+		createXdrStream(
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML", 1),
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF", 1),
+		),
+		createXdrStream(
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF", 2),
+		),
+	)
+
+	s.Assert().Equal(
+		[]string{"GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF"},
+		s.readAllAccounts(),
+	)
+}
+
+// TestFilterLedgerEntryTypes tests if entries of other types are skipped.
+func (s *SingleLedgerStateReaderTestSuite) TestFilterLedgerEntryTypes() {
+	FilterLedgerEntryTypes(xdr.LedgerEntryTypeTrustline)(s.reader)
+
+	s.mockBuckets(
+		createXdrStream(
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML", 1),
+		),
+	)
+
+	_, err := s.reader.Read()
+	s.Require().Equal(io.EOF, err)
+}
+
+// TestParallelBuckets tests if reading buckets in parallel returns the same
+// entries as reading them sequentially.
+func (s *SingleLedgerStateReaderTestSuite) TestParallelBuckets() {
+	ParallelBuckets(4)(s.reader)
+
+	s.mockBuckets(
+		createXdrStream(
+			entryAccount(xdr.BucketEntryTypeDeadentry, "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML", 1),
+		),
+		createXdrStream(
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML", 1),
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF", 1),
+		),
+		createXdrStream(
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF", 2),
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GB6IPC7LIOSRY26MXHQ3QJ32MTELYAA6YFIRBXZVVGTU7AOI4KUFOQ54", 1),
+		),
+	)
+
+	s.Assert().Equal(
+		[]string{
+			"GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF",
+			"GB6IPC7LIOSRY26MXHQ3QJ32MTELYAA6YFIRBXZVVGTU7AOI4KUFOQ54",
+		},
+		s.readAllAccounts(),
+	)
+}
+
+// TestDiskTempSet tests reading buckets using diskTempSet.
+func (s *SingleLedgerStateReaderTestSuite) TestDiskTempSet() {
+	tempStore := &diskTempSet{memoryKeys: 1}
+	s.Require().NoError(tempStore.Open())
+	s.reader.tempStore = tempStore
+
+	s.mockBuckets(
+		createXdrStream(
+			entryAccount(xdr.BucketEntryTypeDeadentry, "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML", 1),
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF", 1),
+		),
+		createXdrStream(
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GC3C4AKRBQLHOJ45U4XG35ESVWRDECWO5XLDGYADO6DPR3L7KIDVUMML", 1),
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF", 2),
+			entryAccount(xdr.BucketEntryTypeLiveentry, "GB6IPC7LIOSRY26MXHQ3QJ32MTELYAA6YFIRBXZVVGTU7AOI4KUFOQ54", 1),
+		),
+	)
+
+	s.Assert().Equal(
+		[]string{
+			"GCMNSW2UZMSH3ZFRLWP6TW2TG4UX4HLSYO5HNIKUSFMLN2KFSF26JKWF",
+			"GB6IPC7LIOSRY26MXHQ3QJ32MTELYAA6YFIRBXZVVGTU7AOI4KUFOQ54",
+		},
+		s.readAllAccounts(),
+	)
+}
+
 func TestBucketExistsTestSuite(t *testing.T) {
 	suite.Run(t, new(BucketExistsTestSuite))
 }
@@ -883,3 +1009,54 @@ var hasExample = `{
         }
     ]
 }`
+
+// BenchmarkSingleLedgerStateReader reads a bucket of 672 mainnet ledger
+// entries from historyarchive/testdata.
+func BenchmarkSingleLedgerStateReader(b *testing.B) {
+	f, err := os.Open(filepath.Join("..", "..", "historyarchive", "testdata", "sample-bucket.xdr.gz"))
+	require.NoError(b, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(b, err)
+	raw, err := ioutil.ReadAll(gz)
+	require.NoError(b, err)
+
+	ledgerSeq := uint32(63)
+	bucketHash := historyarchive.Hash(sha256.Sum256(raw))
+	zero := historyarchive.Hash{}.String()
+	has := historyarchive.HistoryArchiveState{CurrentLedger: ledgerSeq}
+	for i := range has.CurrentBuckets {
+		has.CurrentBuckets[i].Curr = zero
+		has.CurrentBuckets[i].Snap = zero
+	}
+	has.CurrentBuckets[0].Curr = bucketHash.String()
+
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		archive := &historyarchive.MockArchive{}
+		archive.On("GetCheckpointHAS", ledgerSeq).Return(has, nil)
+		archive.On("BucketExists", bucketHash).Return(true, nil)
+		archive.
+			On("GetXdrStreamForHash", bucketHash).
+			Return(historyarchive.NewXdrStream(ioutil.NopCloser(bytes.NewReader(raw))), nil)
+		b.StartTimer()
+
+		reader, err := MakeSingleLedgerStateReader(context.Background(), archive, ledgerSeq)
+		require.NoError(b, err)
+		count := 0
+		for {
+			_, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(b, err)
+			count++
+		}
+		require.Equal(b, 672, count)
+		require.NoError(b, reader.Close())
+	}
+}
@@ -62,18 +62,21 @@ type metaResult struct {
 // until xdr.LedgerCloseMeta objects channel is empty. This prevents memory
 // exhaustion when network closes a series a large ledgers.
 type bufferedLedgerMetaReader struct {
-	r      *bufio.Reader
-	c      chan metaResult
-	runner stellarCoreRunnerInterface
+	r       *bufio.Reader
+	decoder *xdr.FramedDecoder
+	c       chan metaResult
+	runner  stellarCoreRunnerInterface
 }
 
 // newBufferedLedgerMetaReader creates a new meta reader that will shutdown
 // when stellar-core terminates.
 func newBufferedLedgerMetaReader(runner stellarCoreRunnerInterface) bufferedLedgerMetaReader {
+	r := bufio.NewReaderSize(runner.getMetaPipe(), metaPipeBufferSize)
 	return bufferedLedgerMetaReader{
-		c:      make(chan metaResult, ledgerReadAheadBufferSize),
-		r:      bufio.NewReaderSize(runner.getMetaPipe(), metaPipeBufferSize),
-		runner: runner,
+		c:       make(chan metaResult, ledgerReadAheadBufferSize),
+		r:       r,
+		decoder: xdr.NewFramedDecoder(r),
+		runner:  runner,
 	}
 }
 
@@ -83,7 +86,7 @@ func newBufferedLedgerMetaReader(runner stellarCoreRunnerInterface) bufferedLedg
 //   * The next ledger available in the buffer exceeds the meta pipe buffer size.
 //     In such case the method will block until LedgerCloseMeta buffer is empty.
 func (b *bufferedLedgerMetaReader) readLedgerMetaFromPipe() (*xdr.LedgerCloseMeta, error) {
-	frameLength, err := xdr.ReadFrameLength(b.r)
+	frameLength, err := b.decoder.ReadFrameLength()
 	if err != nil {
 		select {
 		case <-b.runner.getProcessExitChan():
@@ -102,8 +105,10 @@ func (b *bufferedLedgerMetaReader) readLedgerMetaFromPipe() (*xdr.LedgerCloseMet
 		}
 	}
 
+	// The frame buffer of the decoder is reused but each ledger is decoded
+	// into a new value as it is sent to the channel.
 	var xlcm xdr.LedgerCloseMeta
-	_, err = xdr.Unmarshal(b.r, &xlcm)
+	err = b.decoder.DecodeFrame(frameLength, &xlcm)
 	if err != nil {
 		if err == io.EOF {
 			err = errors.Wrap(err, "got EOF from subprocess")
@@ -93,13 +93,15 @@ type CaptiveStellarCore struct {
 // NewCaptive returns a new CaptiveStellarCore.
 //
 // All parameters are required, except configPath which is not required when
-// working with BoundedRanges only.
+// working with BoundedRanges only. When several historyURLs are given, reads
+// fail over to the next archive if a file is missing or cannot be read.
 func NewCaptive(executablePath, configPath, networkPassphrase string, historyURLs []string) (*CaptiveStellarCore, error) {
-	archive, err := historyarchive.Connect(
-		historyURLs[0],
+	archive, err := historyarchive.ConnectMulti(
+		historyURLs,
 		historyarchive.ConnectOptions{
 			NetworkPassphrase: networkPassphrase,
 		},
+		historyarchive.MultiArchiveOptions{},
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "error connecting to history archive")
@@ -3,6 +3,7 @@ package ledgerbackend
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
@@ -39,13 +40,17 @@ type LedgerResponse struct {
 type Base64Ledger xdr.LedgerCloseMeta
 
 func (r *Base64Ledger) UnmarshalJSON(b []byte) error {
-	var base64 string
-	if err := json.Unmarshal(b, &base64); err != nil {
+	var encoded string
+	if err := json.Unmarshal(b, &encoded); err != nil {
+		return err
+	}
+	raw, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
 		return err
 	}
 
 	var parsed xdr.LedgerCloseMeta
-	if err := xdr.SafeUnmarshalBase64(base64, &parsed); err != nil {
+	if err := xdr.SafeUnmarshal(raw, &parsed); err != nil {
 		return err
 	}
 	*r = Base64Ledger(parsed)
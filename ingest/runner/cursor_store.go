@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/errors"
+)
+
+// CursorStore persists the sequence of the last committed ledger.
+type CursorStore interface {
+	// GetCursor returns the last committed ledger sequence or 0 if no ledger
+	// has been committed yet.
+	GetCursor() (uint32, error)
+	// SetCursor saves the last committed ledger sequence.
+	SetCursor(sequence uint32) error
+}
+
+// FileCursorStore is a CursorStore keeping the cursor in a file. Updates are
+// written to a temporary file which is then renamed so the cursor file is
+// never left partially written after a crash.
+type FileCursorStore struct {
+	Path string
+}
+
+var _ CursorStore = (*FileCursorStore)(nil)
+
+// GetCursor returns the cursor saved in the file or 0 if the file does not
+// exist.
+func (s *FileCursorStore) GetCursor() (uint32, error) {
+	contents, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "error reading cursor file")
+	}
+
+	cursor, err := strconv.ParseUint(strings.TrimSpace(string(contents)), 10, 32)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid cursor file contents: %q", contents)
+	}
+	return uint32(cursor), nil
+}
+
+// SetCursor atomically replaces the cursor file.
+func (s *FileCursorStore) SetCursor(sequence uint32) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.Path), filepath.Base(s.Path)+".tmp")
+	if err != nil {
+		return errors.Wrap(err, "error creating temporary cursor file")
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err = tmp.WriteString(strconv.FormatUint(uint64(sequence), 10) + "\n"); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "error writing temporary cursor file")
+	}
+	if err = tmp.Sync(); err != nil {
+		tmp.Close()
+		return errors.Wrap(err, "error syncing temporary cursor file")
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrap(err, "error closing temporary cursor file")
+	}
+
+	if err = os.Rename(tmp.Name(), s.Path); err != nil {
+		return errors.Wrap(err, "error renaming temporary cursor file")
+	}
+	return nil
+}
+
+// CursorTableSchema is the Postgres schema of the table used by
+// SQLCursorStore.
+const CursorTableSchema = `CREATE TABLE IF NOT EXISTS ingest_cursors (
+	name varchar(255) NOT NULL PRIMARY KEY,
+	ledger bigint NOT NULL
+);`
+
+// SQLCursorStore is a CursorStore keeping cursors in the `ingest_cursors`
+// Postgres table (see CursorTableSchema). Many runners can share the table
+// using different names.
+//
+// If processors write to the same database using Session, calling SetCursor
+// inside the Commit hook (before committing the DB transaction) and setting
+// Config.CursorStoreInCommit stores data and cursor atomically so no ledger
+// is ever processed twice.
+type SQLCursorStore struct {
+	Session db.SessionInterface
+	Name    string
+}
+
+var _ CursorStore = (*SQLCursorStore)(nil)
+
+// GetCursor returns the cursor saved in the DB or 0 if there is no row for
+// the store name.
+func (s *SQLCursorStore) GetCursor() (uint32, error) {
+	query := sq.Select("ingest_cursors.ledger").
+		From("ingest_cursors").
+		Where("ingest_cursors.name = ?", s.Name)
+
+	var ledger uint32
+	if err := s.Session.Get(&ledger, query); err != nil {
+		if errors.Cause(err) == sql.ErrNoRows {
+			return 0, nil
+		}
+		return 0, errors.Wrap(err, "could not get cursor")
+	}
+	return ledger, nil
+}
+
+// SetCursor inserts or updates the cursor row for the store name.
+func (s *SQLCursorStore) SetCursor(sequence uint32) error {
+	query := sq.Insert("ingest_cursors").
+		Columns("name", "ledger").
+		Values(s.Name, sequence).
+		Suffix("ON CONFLICT (name) DO UPDATE SET ledger=EXCLUDED.ledger")
+
+	_, err := s.Session.Exec(query)
+	return errors.Wrap(err, "could not set cursor")
+}
@@ -0,0 +1,64 @@
+package runner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stellar/go/support/db"
+	"github.com/stellar/go/support/db/dbtest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSQLCursorStore(t *testing.T) {
+	tdb := dbtest.Postgres(t).Load(CursorTableSchema)
+	defer tdb.Close()
+	session := &db.Session{DB: tdb.Open(), Ctx: context.Background()}
+	defer session.DB.Close()
+
+	store := &SQLCursorStore{Session: session, Name: "first"}
+	other := &SQLCursorStore{Session: session, Name: "second"}
+
+	cursor, err := store.GetCursor()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), cursor)
+
+	require.NoError(t, store.SetCursor(127))
+	require.NoError(t, store.SetCursor(128))
+	require.NoError(t, other.SetCursor(63))
+
+	cursor, err = store.GetCursor()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(128), cursor)
+
+	cursor, err = other.GetCursor()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(63), cursor)
+}
+
+func TestSQLCursorStoreInTransaction(t *testing.T) {
+	tdb := dbtest.Postgres(t).Load(CursorTableSchema)
+	defer tdb.Close()
+	session := &db.Session{DB: tdb.Open(), Ctx: context.Background()}
+	defer session.DB.Close()
+
+	store := &SQLCursorStore{Session: session, Name: "runner"}
+	require.NoError(t, store.SetCursor(100))
+
+	// A rolled back transaction does not move the cursor.
+	require.NoError(t, session.Begin())
+	require.NoError(t, store.SetCursor(101))
+	require.NoError(t, session.Rollback())
+
+	cursor, err := store.GetCursor()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(100), cursor)
+
+	require.NoError(t, session.Begin())
+	require.NoError(t, store.SetCursor(101))
+	require.NoError(t, session.Commit())
+
+	cursor, err = store.GetCursor()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(101), cursor)
+}
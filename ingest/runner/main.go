@@ -0,0 +1,352 @@
+// Package runner provides a reusable ingestion loop for custom indexers built
+// on top of the ingest/io package.
+//
+// A Runner builds the ledger state from a history archive checkpoint, then
+// follows the network ledger by ledger, passing every change and transaction
+// to user-provided processors. After every ledger (and after the state has
+// been built) the Commit hook is called and the ledger sequence is saved in
+// a CursorStore, so a restarted Runner resumes from the last committed ledger.
+//
+// By default the Runner saves the cursor after Commit returns, so ledgers are
+// processed with at-least-once semantics: a crash after Commit but before the
+// cursor is saved will replay that ledger on the next start. Processors must
+// then be idempotent per ledger. For exactly-once processing, save the cursor
+// in the Commit hook, in the same DB transaction as the processors' data (see
+// SQLCursorStore), and set Config.CursorStoreInCommit so the Runner does not
+// save it again.
+package runner
+
+import (
+	"context"
+	"time"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest/adapters"
+	"github.com/stellar/go/ingest/io"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/support/errors"
+	logpkg "github.com/stellar/go/support/log"
+)
+
+const defaultPollInterval = time.Second
+
+// Config configures a Runner.
+type Config struct {
+	// HistoryArchive is used to build state at a checkpoint ledger.
+	HistoryArchive historyarchive.ArchiveInterface
+	// LedgerBackend is used to stream ledgers after the state is built.
+	LedgerBackend ledgerbackend.LedgerBackend
+	// NetworkPassphrase is the passphrase of the network being ingested.
+	NetworkPassphrase string
+	// CursorStore keeps the sequence of the last committed ledger.
+	CursorStore CursorStore
+	// CursorStoreInCommit, if true, means the Commit hook saves the cursor
+	// itself (ex. by calling SQLCursorStore.SetCursor in the transaction it
+	// commits) so the Runner never calls CursorStore.SetCursor. Requires
+	// Commit.
+	CursorStoreInCommit bool
+
+	// ChangeProcessors receive ledger entry changes: the entire state when
+	// it is built from a checkpoint and then the changes in every ledger.
+	ChangeProcessors []io.ChangeProcessor
+	// TransactionProcessors receive every transaction (successful and
+	// failed) in every ingested ledger.
+	TransactionProcessors []io.LedgerTransactionProcessor
+
+	// Begin, if set, is called before any processor is run for a ledger (or
+	// for the state at a checkpoint ledger).
+	Begin func(sequence uint32) error
+	// Commit, if set, is called after all processors have successfully
+	// processed a ledger. The cursor is saved only when Commit returns nil,
+	// unless CursorStoreInCommit is set.
+	Commit func(sequence uint32) error
+	// Rollback, if set, is called when processing of a ledger started with
+	// Begin fails.
+	Rollback func(sequence uint32)
+
+	// StartCheckpoint is the checkpoint ledger the state is built from when
+	// the cursor store is empty. When 0, the latest checkpoint in the history
+	// archive is used.
+	StartCheckpoint uint32
+	// PollInterval defines how long to wait before asking the ledger backend
+	// for a ledger that is not available yet. Defaults to one second.
+	PollInterval time.Duration
+	// Log is the logger used by the runner. Defaults to the default logger.
+	Log *logpkg.Entry
+}
+
+// Runner runs processors against state and ledgers. Use New to create one.
+type Runner struct {
+	config         Config
+	historyAdapter adapters.HistoryArchiveAdapterInterface
+	log            *logpkg.Entry
+}
+
+// New returns a new Runner for the given config.
+func New(config Config) (*Runner, error) {
+	if config.HistoryArchive == nil {
+		return nil, errors.New("HistoryArchive is required")
+	}
+	if config.LedgerBackend == nil {
+		return nil, errors.New("LedgerBackend is required")
+	}
+	if config.CursorStore == nil {
+		return nil, errors.New("CursorStore is required")
+	}
+	if config.CursorStoreInCommit && config.Commit == nil {
+		return nil, errors.New("Commit is required when CursorStoreInCommit is set")
+	}
+	if config.NetworkPassphrase == "" {
+		return nil, errors.New("NetworkPassphrase is required")
+	}
+	if config.StartCheckpoint != 0 && !historyarchive.IsCheckpoint(config.StartCheckpoint) {
+		return nil, errors.Errorf("StartCheckpoint %d is not a checkpoint ledger", config.StartCheckpoint)
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = defaultPollInterval
+	}
+
+	log := config.Log
+	if log == nil {
+		log = logpkg.DefaultLogger
+	}
+
+	return &Runner{
+		config:         config,
+		historyAdapter: adapters.MakeHistoryArchiveAdapter(config.HistoryArchive),
+		log:            log.WithField("service", "runner"),
+	}, nil
+}
+
+// Run starts ingestion and blocks until ctx is cancelled or an error occurs.
+// If the cursor store is empty, the state is built from a checkpoint ledger
+// first. Otherwise, ingestion resumes from the ledger following the cursor.
+// Run returns nil when ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) error {
+	cursor, err := r.config.CursorStore.GetCursor()
+	if err != nil {
+		return errors.Wrap(err, "error getting cursor")
+	}
+
+	if cursor == 0 {
+		checkpoint := r.config.StartCheckpoint
+		if checkpoint == 0 {
+			checkpoint, err = r.historyAdapter.GetLatestLedgerSequence()
+			if err != nil {
+				return errors.Wrap(err, "error getting latest checkpoint")
+			}
+		}
+
+		if err = r.BuildState(ctx, checkpoint); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		cursor = checkpoint
+	} else {
+		r.log.WithField("cursor", cursor).Info("Resuming ingestion")
+	}
+
+	return r.follow(ctx, cursor+1)
+}
+
+// BuildState streams the entire ledger state at the given checkpoint ledger
+// to the change processors and commits the checkpoint as the cursor.
+func (r *Runner) BuildState(ctx context.Context, checkpoint uint32) error {
+	if !historyarchive.IsCheckpoint(checkpoint) {
+		return errors.Errorf("ledger %d is not a checkpoint ledger", checkpoint)
+	}
+
+	r.log.WithField("ledger", checkpoint).Info("Building state")
+	startTime := time.Now()
+
+	err := r.inLedger(checkpoint, func() error {
+		reader, err := r.historyAdapter.GetState(ctx, checkpoint)
+		if err != nil {
+			return errors.Wrap(err, "error creating state reader")
+		}
+		defer reader.Close()
+
+		return io.StreamChanges(groupChangeProcessors(r.config.ChangeProcessors), reader)
+	})
+	if err != nil {
+		return errors.Wrapf(err, "error building state at ledger %d", checkpoint)
+	}
+
+	if err = r.setCursor(checkpoint); err != nil {
+		return err
+	}
+
+	r.log.WithFields(logpkg.F{
+		"ledger":   checkpoint,
+		"duration": time.Since(startTime).Seconds(),
+	}).Info("State built")
+	return nil
+}
+
+// ReingestRange runs the transaction processors for every ledger in the
+// [from, to] range. Change processors are not run because the current state
+// would be corrupted by replaying old changes. The cursor is not modified.
+func (r *Runner) ReingestRange(ctx context.Context, from, to uint32) error {
+	if from == 0 || from > to {
+		return errors.Errorf("invalid range: [%d, %d]", from, to)
+	}
+
+	err := r.config.LedgerBackend.PrepareRange(ledgerbackend.BoundedRange(from, to))
+	if err != nil {
+		return errors.Wrap(err, "error preparing range")
+	}
+
+	for sequence := from; sequence <= to; sequence++ {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		reader, err := io.NewLedgerTransactionReader(
+			r.config.LedgerBackend, r.config.NetworkPassphrase, sequence,
+		)
+		if err != nil {
+			return errors.Wrapf(err, "error creating transaction reader for ledger %d", sequence)
+		}
+
+		err = r.inLedger(sequence, func() error {
+			return io.StreamLedgerTransactions(
+				groupTransactionProcessors(r.config.TransactionProcessors),
+				reader,
+			)
+		})
+		reader.Close()
+		if err != nil {
+			return errors.Wrapf(err, "error reingesting ledger %d", sequence)
+		}
+	}
+
+	return nil
+}
+
+// follow ingests ledgers starting at from until ctx is cancelled.
+func (r *Runner) follow(ctx context.Context, from uint32) error {
+	err := r.config.LedgerBackend.PrepareRange(ledgerbackend.UnboundedRange(from))
+	if err != nil {
+		return errors.Wrap(err, "error preparing range")
+	}
+
+	for sequence := from; ; {
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		reader, err := io.NewLedgerChangeReader(
+			r.config.LedgerBackend, r.config.NetworkPassphrase, sequence,
+		)
+		if err == io.ErrNotFound {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(r.config.PollInterval):
+			}
+			continue
+		}
+		if err != nil {
+			return errors.Wrapf(err, "error creating change reader for ledger %d", sequence)
+		}
+
+		startTime := time.Now()
+		err = r.processLedger(sequence, reader)
+		reader.Close()
+		if err != nil {
+			return errors.Wrapf(err, "error processing ledger %d", sequence)
+		}
+
+		if err = r.setCursor(sequence); err != nil {
+			return err
+		}
+
+		r.log.WithFields(logpkg.F{
+			"ledger":   sequence,
+			"duration": time.Since(startTime).Seconds(),
+		}).Info("Processed ledger")
+		sequence++
+	}
+}
+
+// setCursor saves the cursor unless the Commit hook has already done it.
+func (r *Runner) setCursor(sequence uint32) error {
+	if r.config.CursorStoreInCommit {
+		return nil
+	}
+	if err := r.config.CursorStore.SetCursor(sequence); err != nil {
+		return errors.Wrap(err, "error setting cursor")
+	}
+	return nil
+}
+
+// processLedger runs change processors and then transaction processors for
+// a single ledger.
+func (r *Runner) processLedger(sequence uint32, reader *io.LedgerChangeReader) error {
+	return r.inLedger(sequence, func() error {
+		err := io.StreamChanges(groupChangeProcessors(r.config.ChangeProcessors), reader)
+		if err != nil {
+			return errors.Wrap(err, "error processing changes")
+		}
+
+		reader.LedgerTransactionReader.Rewind()
+		err = io.StreamLedgerTransactions(
+			groupTransactionProcessors(r.config.TransactionProcessors),
+			reader.LedgerTransactionReader,
+		)
+		if err != nil {
+			return errors.Wrap(err, "error processing transactions")
+		}
+		return nil
+	})
+}
+
+// inLedger wraps process with Begin, Commit and Rollback hooks.
+func (r *Runner) inLedger(sequence uint32, process func() error) error {
+	if r.config.Begin != nil {
+		if err := r.config.Begin(sequence); err != nil {
+			return errors.Wrap(err, "error in Begin hook")
+		}
+	}
+
+	if err := process(); err != nil {
+		if r.config.Rollback != nil {
+			r.config.Rollback(sequence)
+		}
+		return err
+	}
+
+	if r.config.Commit != nil {
+		if err := r.config.Commit(sequence); err != nil {
+			if r.config.Rollback != nil {
+				r.config.Rollback(sequence)
+			}
+			return errors.Wrap(err, "error in Commit hook")
+		}
+	}
+	return nil
+}
+
+type groupChangeProcessors []io.ChangeProcessor
+
+func (g groupChangeProcessors) ProcessChange(change io.Change) error {
+	for _, p := range g {
+		if err := p.ProcessChange(change); err != nil {
+			return errors.Wrapf(err, "error in %T.ProcessChange", p)
+		}
+	}
+	return nil
+}
+
+type groupTransactionProcessors []io.LedgerTransactionProcessor
+
+func (g groupTransactionProcessors) ProcessTransaction(tx io.LedgerTransaction) error {
+	for _, p := range g {
+		if err := p.ProcessTransaction(tx); err != nil {
+			return errors.Wrapf(err, "error in %T.ProcessTransaction", p)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,223 @@
+package runner
+
+import (
+	"context"
+	stdio "io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest/adapters"
+	"github.com/stellar/go/ingest/io"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+type memoryCursorStore struct {
+	cursor uint32
+}
+
+func (s *memoryCursorStore) GetCursor() (uint32, error) {
+	return s.cursor, nil
+}
+
+func (s *memoryCursorStore) SetCursor(sequence uint32) error {
+	s.cursor = sequence
+	return nil
+}
+
+func ledgerCloseMeta(sequence uint32) xdr.LedgerCloseMeta {
+	return xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: xdr.LedgerHeaderHistoryEntry{
+				Header: xdr.LedgerHeader{
+					LedgerSeq: xdr.Uint32(sequence),
+				},
+			},
+		},
+	}
+}
+
+type RunnerTestSuite struct {
+	suite.Suite
+	ctx             context.Context
+	cancel          context.CancelFunc
+	historyAdapter  *adapters.MockHistoryArchiveAdapter
+	ledgerBackend   *ledgerbackend.MockDatabaseBackend
+	changeProcessor *io.MockChangeProcessor
+	cursorStore     *memoryCursorStore
+	committed       []uint32
+	runner          *Runner
+}
+
+func (s *RunnerTestSuite) SetupTest() {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	s.historyAdapter = &adapters.MockHistoryArchiveAdapter{}
+	s.ledgerBackend = &ledgerbackend.MockDatabaseBackend{}
+	s.changeProcessor = &io.MockChangeProcessor{}
+	s.cursorStore = &memoryCursorStore{}
+	s.committed = nil
+
+	var err error
+	s.runner, err = New(Config{
+		HistoryArchive:    &historyarchive.MockArchive{},
+		LedgerBackend:     s.ledgerBackend,
+		NetworkPassphrase: network.TestNetworkPassphrase,
+		CursorStore:       s.cursorStore,
+		ChangeProcessors:  []io.ChangeProcessor{s.changeProcessor},
+		Commit: func(sequence uint32) error {
+			s.committed = append(s.committed, sequence)
+			return nil
+		},
+	})
+	s.Assert().NoError(err)
+	s.runner.historyAdapter = s.historyAdapter
+}
+
+func (s *RunnerTestSuite) TearDownTest() {
+	s.cancel()
+	s.historyAdapter.AssertExpectations(s.T())
+	s.ledgerBackend.AssertExpectations(s.T())
+	s.changeProcessor.AssertExpectations(s.T())
+}
+
+func (s *RunnerTestSuite) TestBuildStateAndFollow() {
+	entry := xdr.LedgerEntry{}
+	reader := &io.MockChangeReader{}
+	reader.On("Read").Return(io.Change{Post: &entry}, nil).Once()
+	reader.On("Read").Return(io.Change{}, stdio.EOF).Once()
+	reader.On("Close").Return(nil).Once()
+
+	s.historyAdapter.On("GetLatestLedgerSequence").Return(uint32(63), nil).Once()
+	s.historyAdapter.On("GetState", s.ctx, uint32(63)).Return(reader, nil).Once()
+	s.changeProcessor.On("ProcessChange", io.Change{Post: &entry}).Return(nil).Once()
+
+	s.ledgerBackend.On("PrepareRange", ledgerbackend.UnboundedRange(64)).Return(nil).Once()
+	s.ledgerBackend.On("GetLedger", uint32(64)).Return(true, ledgerCloseMeta(64), nil).Once()
+	s.ledgerBackend.On("GetLedger", uint32(65)).
+		Return(false, xdr.LedgerCloseMeta{}, nil).
+		Run(func(mock.Arguments) { s.cancel() }).
+		Once()
+
+	s.Assert().NoError(s.runner.Run(s.ctx))
+	s.Assert().Equal([]uint32{63, 64}, s.committed)
+	s.Assert().Equal(uint32(64), s.cursorStore.cursor)
+	reader.AssertExpectations(s.T())
+}
+
+func (s *RunnerTestSuite) TestResume() {
+	s.cursorStore.cursor = 100
+
+	s.ledgerBackend.On("PrepareRange", ledgerbackend.UnboundedRange(101)).Return(nil).Once()
+	s.ledgerBackend.On("GetLedger", uint32(101)).
+		Return(true, ledgerCloseMeta(101), nil).
+		Run(func(mock.Arguments) { s.cancel() }).
+		Once()
+
+	s.Assert().NoError(s.runner.Run(s.ctx))
+	s.Assert().Equal([]uint32{101}, s.committed)
+	s.Assert().Equal(uint32(101), s.cursorStore.cursor)
+}
+
+func (s *RunnerTestSuite) TestCommitErrorDoesNotMoveCursor() {
+	s.cursorStore.cursor = 100
+	rolledBack := false
+	s.runner.config.Commit = func(sequence uint32) error {
+		return errors.New("commit failed")
+	}
+	s.runner.config.Rollback = func(sequence uint32) {
+		rolledBack = true
+	}
+
+	s.ledgerBackend.On("PrepareRange", ledgerbackend.UnboundedRange(101)).Return(nil).Once()
+	s.ledgerBackend.On("GetLedger", uint32(101)).Return(true, ledgerCloseMeta(101), nil).Once()
+
+	err := s.runner.Run(s.ctx)
+	s.Assert().EqualError(err, "error processing ledger 101: error in Commit hook: commit failed")
+	s.Assert().True(rolledBack)
+	s.Assert().Equal(uint32(100), s.cursorStore.cursor)
+}
+
+func (s *RunnerTestSuite) TestCursorStoreInCommit() {
+	s.cursorStore.cursor = 100
+	s.runner.config.CursorStoreInCommit = true
+	s.runner.config.Commit = func(sequence uint32) error {
+		s.committed = append(s.committed, sequence)
+		// The cursor must not be saved by the runner before Commit returns.
+		s.Assert().Equal(uint32(100), s.cursorStore.cursor)
+		return nil
+	}
+
+	s.ledgerBackend.On("PrepareRange", ledgerbackend.UnboundedRange(101)).Return(nil).Once()
+	s.ledgerBackend.On("GetLedger", uint32(101)).Return(true, ledgerCloseMeta(101), nil).Once()
+	s.ledgerBackend.On("GetLedger", uint32(102)).
+		Return(true, ledgerCloseMeta(102), nil).
+		Run(func(mock.Arguments) { s.cancel() }).
+		Once()
+
+	s.Assert().NoError(s.runner.Run(s.ctx))
+	s.Assert().Equal([]uint32{101, 102}, s.committed)
+	s.Assert().Equal(uint32(100), s.cursorStore.cursor)
+}
+
+func (s *RunnerTestSuite) TestReingestRange() {
+	s.cursorStore.cursor = 100
+
+	s.ledgerBackend.On("PrepareRange", ledgerbackend.BoundedRange(10, 11)).Return(nil).Once()
+	s.ledgerBackend.On("GetLedger", uint32(10)).Return(true, ledgerCloseMeta(10), nil).Once()
+	s.ledgerBackend.On("GetLedger", uint32(11)).Return(true, ledgerCloseMeta(11), nil).Once()
+
+	s.Assert().NoError(s.runner.ReingestRange(s.ctx, 10, 11))
+	s.Assert().Equal([]uint32{10, 11}, s.committed)
+	s.Assert().Equal(uint32(100), s.cursorStore.cursor)
+}
+
+func (s *RunnerTestSuite) TestBuildStateInvalidCheckpoint() {
+	err := s.runner.BuildState(s.ctx, 64)
+	s.Assert().EqualError(err, "ledger 64 is not a checkpoint ledger")
+}
+
+func TestRunnerTestSuite(t *testing.T) {
+	suite.Run(t, new(RunnerTestSuite))
+}
+
+func TestNewCursorStoreInCommitRequiresCommit(t *testing.T) {
+	_, err := New(Config{
+		HistoryArchive:      &historyarchive.MockArchive{},
+		LedgerBackend:       &ledgerbackend.MockDatabaseBackend{},
+		NetworkPassphrase:   network.TestNetworkPassphrase,
+		CursorStore:         &memoryCursorStore{},
+		CursorStoreInCommit: true,
+	})
+	assert.EqualError(t, err, "Commit is required when CursorStoreInCommit is set")
+}
+
+func TestFileCursorStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "runner")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := &FileCursorStore{Path: filepath.Join(dir, "cursor")}
+
+	cursor, err := store.GetCursor()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0), cursor)
+
+	assert.NoError(t, store.SetCursor(127))
+	assert.NoError(t, store.SetCursor(128))
+
+	cursor, err = store.GetCursor()
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(128), cursor)
+
+	files, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, files, 1)
+}
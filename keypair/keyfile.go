@@ -0,0 +1,248 @@
+package keypair
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+var (
+	// ErrWrongPassword is returned when an encrypted key cannot be decrypted,
+	// because the password is wrong or the encrypted key has been modified.
+	ErrWrongPassword = errors.New("wrong password or corrupted encrypted key")
+
+	// ErrUnsupportedEncryptedKey is returned when an encrypted key uses a
+	// version, key derivation function or cipher which is not supported.
+	ErrUnsupportedEncryptedKey = errors.New("unsupported encrypted key")
+
+	// ErrInvalidScryptParams is returned when scrypt parameters are out of
+	// bounds, so that a crafted keyfile cannot exhaust memory or time.
+	ErrInvalidScryptParams = errors.New("invalid or too expensive scrypt parameters")
+)
+
+const (
+	// EncryptedKeyVersion is the version of the encrypted key format written
+	// by EncryptKey.
+	EncryptedKeyVersion = 1
+
+	kdfScrypt               = "scrypt"
+	cipherXChaCha20Poly1305 = "xchacha20-poly1305"
+	saltSize                = 32
+
+	// Bounds of the scrypt parameters. Deriving a key takes 128·N·r bytes of
+	// memory and time proportional to N·r·p.
+	maxScryptN      = 1 << 20
+	maxScryptRP     = 32
+	maxScryptMemory = 1 << 30
+)
+
+// ScryptParams are the cost parameters of the scrypt key derivation function
+// which derives encryption keys from passwords.
+type ScryptParams struct {
+	N int `json:"n"`
+	R int `json:"r"`
+	P int `json:"p"`
+}
+
+// validate returns ErrInvalidScryptParams unless N is a power of two up to
+// 2^20, r and p are positive, r·p is at most 32 and deriving a key takes at
+// most 1 GB of memory.
+func (p ScryptParams) validate() error {
+	if p.N < 2 || p.N > maxScryptN || p.N&(p.N-1) != 0 ||
+		p.R < 1 || p.P < 1 || p.R > maxScryptRP || p.P > maxScryptRP || p.R*p.P > maxScryptRP ||
+		128*int64(p.N)*int64(p.R) > maxScryptMemory {
+		return ErrInvalidScryptParams
+	}
+	return nil
+}
+
+var (
+	// DefaultScryptParams are the parameters for encrypted keys which are
+	// decrypted interactively. Deriving a key takes 128 MB of memory.
+	DefaultScryptParams = ScryptParams{N: 1 << 17, R: 8, P: 1}
+
+	// LightScryptParams are cheaper parameters, for encrypted keys which are
+	// decrypted frequently or on constrained machines. Deriving a key takes
+	// 16 MB of memory.
+	LightScryptParams = ScryptParams{N: 1 << 14, R: 8, P: 1}
+)
+
+// EncryptedKey is a secret key encrypted with a password, stored as JSON.
+//
+// The encryption key is derived from the password with scrypt, and the raw
+// ed25519 seed is encrypted with XChaCha20-Poly1305. The version, ID and
+// address are authenticated, so that they cannot be changed without the
+// decryption failing.
+type EncryptedKey struct {
+	Version int `json:"version"`
+	// ID is a random identifier of the encrypted key, which tells apart
+	// several encrypted copies of the same key.
+	ID string `json:"id"`
+	// Address is the public key of the encrypted secret key.
+	Address string `json:"address"`
+	// Name is an optional label of the key.
+	Name   string             `json:"name,omitempty"`
+	Crypto EncryptedKeyCrypto `json:"crypto"`
+}
+
+// EncryptedKeyCrypto holds the parameters and output of the encryption of an
+// EncryptedKey.
+type EncryptedKeyCrypto struct {
+	KDF        string       `json:"kdf"`
+	KDFParams  ScryptParams `json:"kdf_params"`
+	Salt       []byte       `json:"salt"`
+	Cipher     string       `json:"cipher"`
+	Nonce      []byte       `json:"nonce"`
+	Ciphertext []byte       `json:"ciphertext"`
+}
+
+// EncryptKey encrypts the secret key of a keypair with a password, deriving
+// the encryption key with the given scrypt parameters.
+func EncryptKey(kp *Full, password []byte, params ScryptParams) (*EncryptedKey, error) {
+	if err := params.validate(); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	salt := make([]byte, saltSize)
+	nonce := make([]byte, chacha20poly1305.NonceSizeX)
+	for _, b := range [][]byte{id, salt, nonce} {
+		if _, err := io.ReadFull(rand.Reader, b); err != nil {
+			return nil, err
+		}
+	}
+
+	ek := &EncryptedKey{
+		Version: EncryptedKeyVersion,
+		ID:      hex.EncodeToString(id),
+		Address: kp.Address(),
+		Crypto: EncryptedKeyCrypto{
+			KDF:       kdfScrypt,
+			KDFParams: params,
+			Salt:      salt,
+			Cipher:    cipherXChaCha20Poly1305,
+			Nonce:     nonce,
+		},
+	}
+
+	aead, err := ek.aead(password)
+	if err != nil {
+		return nil, err
+	}
+	ek.Crypto.Ciphertext = aead.Seal(nil, nonce, kp.rawSeed(), ek.additionalData())
+	return ek, nil
+}
+
+// Decrypt decrypts the secret key with a password. It returns
+// ErrWrongPassword if the password is wrong, and ErrInvalidScryptParams
+// without deriving a key if the scrypt parameters are out of bounds.
+func (ek *EncryptedKey) Decrypt(password []byte) (*Full, error) {
+	if ek.Version != EncryptedKeyVersion ||
+		ek.Crypto.KDF != kdfScrypt ||
+		ek.Crypto.Cipher != cipherXChaCha20Poly1305 ||
+		len(ek.Crypto.Salt) != saltSize ||
+		len(ek.Crypto.Nonce) != chacha20poly1305.NonceSizeX {
+		return nil, ErrUnsupportedEncryptedKey
+	}
+	if err := ek.Crypto.KDFParams.validate(); err != nil {
+		return nil, err
+	}
+
+	aead, err := ek.aead(password)
+	if err != nil {
+		return nil, err
+	}
+	rawSeed, err := aead.Open(nil, ek.Crypto.Nonce, ek.Crypto.Ciphertext, ek.additionalData())
+	if err != nil || len(rawSeed) != 32 {
+		return nil, ErrWrongPassword
+	}
+
+	var seed [32]byte
+	copy(seed[:], rawSeed)
+	kp, err := FromRawSeed(seed)
+	if err != nil {
+		return nil, err
+	}
+	if kp.Address() != ek.Address {
+		return nil, ErrWrongPassword
+	}
+	return kp, nil
+}
+
+func (ek *EncryptedKey) aead(password []byte) (cipher.AEAD, error) {
+	params := ek.Crypto.KDFParams
+	key, err := scrypt.Key(password, ek.Crypto.Salt, params.N, params.R, params.P, chacha20poly1305.KeySize)
+	if err != nil {
+		return nil, fmt.Errorf("deriving encryption key: %v", err)
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+func (ek *EncryptedKey) additionalData() []byte {
+	return []byte(fmt.Sprintf("stellar-encrypted-key:%d:%s:%s", ek.Version, ek.ID, ek.Address))
+}
+
+// ParseEncryptedKey parses the JSON encoding of an encrypted key.
+func ParseEncryptedKey(data []byte) (*EncryptedKey, error) {
+	var ek EncryptedKey
+	if err := json.Unmarshal(data, &ek); err != nil {
+		return nil, fmt.Errorf("parsing encrypted key: %v", err)
+	}
+	if ek.Version != EncryptedKeyVersion {
+		return nil, ErrUnsupportedEncryptedKey
+	}
+	if _, err := ParseAddress(ek.Address); err != nil {
+		return nil, fmt.Errorf("parsing encrypted key address: %v", err)
+	}
+	return &ek, nil
+}
+
+// SaveKeyfile encrypts the secret key of a keypair with a password and
+// writes it to a new file, which only the current user can read. It fails if
+// the file already exists.
+func SaveKeyfile(path string, kp *Full, password []byte, params ScryptParams) (*EncryptedKey, error) {
+	ek, err := EncryptKey(kp, password, params)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.MarshalIndent(ek, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	_, err = file.Write(append(data, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ek, nil
+}
+
+// LoadKeyfile reads an encrypted key from a file and decrypts it with a
+// password.
+func LoadKeyfile(path string, password []byte) (*Full, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ek, err := ParseEncryptedKey(data)
+	if err != nil {
+		return nil, err
+	}
+	return ek.Decrypt(password)
+}
@@ -0,0 +1,146 @@
+package keypair
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("keypair encrypted keys", func() {
+	// Cheap parameters, to keep the tests fast.
+	params := ScryptParams{N: 1 << 10, R: 8, P: 1}
+	password := []byte("correct horse battery staple")
+	var kp *Full
+
+	BeforeEach(func() {
+		kp = MustParseFull(seed)
+	})
+
+	It("encrypts and decrypts keys", func() {
+		ek, err := EncryptKey(kp, password, params)
+		Expect(err).To(BeNil())
+		Expect(ek.Version).To(Equal(EncryptedKeyVersion))
+		Expect(ek.Address).To(Equal(address))
+		Expect(ek.ID).To(HaveLen(32))
+		Expect(ek.Crypto.KDFParams).To(Equal(params))
+		Expect(string(ek.Crypto.Ciphertext)).ToNot(ContainSubstring(string(kp.rawSeed())))
+
+		data, err := json.Marshal(ek)
+		Expect(err).To(BeNil())
+		Expect(string(data)).ToNot(ContainSubstring(seed))
+		parsed, err := ParseEncryptedKey(data)
+		Expect(err).To(BeNil())
+		Expect(parsed).To(Equal(ek))
+
+		decrypted, err := parsed.Decrypt(password)
+		Expect(err).To(BeNil())
+		Expect(decrypted.Seed()).To(Equal(seed))
+
+		// Each encryption uses a new ID, salt and nonce.
+		other, err := EncryptKey(kp, password, params)
+		Expect(err).To(BeNil())
+		Expect(other.ID).ToNot(Equal(ek.ID))
+		Expect(other.Crypto.Ciphertext).ToNot(Equal(ek.Crypto.Ciphertext))
+	})
+
+	It("rejects wrong passwords and modified keys", func() {
+		ek, err := EncryptKey(kp, password, params)
+		Expect(err).To(BeNil())
+
+		_, err = ek.Decrypt([]byte("wrong"))
+		Expect(err).To(Equal(ErrWrongPassword))
+
+		modified := *ek
+		modified.ID = "00000000000000000000000000000000"
+		_, err = modified.Decrypt(password)
+		Expect(err).To(Equal(ErrWrongPassword))
+
+		modified = *ek
+		modified.Crypto.KDFParams.N = 1 << 11
+		_, err = modified.Decrypt(password)
+		Expect(err).To(Equal(ErrWrongPassword))
+
+		modified = *ek
+		modified.Crypto.Cipher = "aes-128-ctr"
+		_, err = modified.Decrypt(password)
+		Expect(err).To(Equal(ErrUnsupportedEncryptedKey))
+
+		_, err = ParseEncryptedKey([]byte(`{"version":2,"address":"` + address + `"}`))
+		Expect(err).To(Equal(ErrUnsupportedEncryptedKey))
+		_, err = ParseEncryptedKey([]byte(`{"version":1,"address":"GABC"}`))
+		Expect(err).To(HaveOccurred())
+	})
+
+	DescribeTable("rejects out of bounds scrypt parameters before deriving a key",
+		func(invalid ScryptParams) {
+			_, err := EncryptKey(kp, password, invalid)
+			Expect(err).To(Equal(ErrInvalidScryptParams))
+
+			ek, err := EncryptKey(kp, password, params)
+			Expect(err).To(BeNil())
+			ek.Crypto.KDFParams = invalid
+			_, err = ek.Decrypt(password)
+			Expect(err).To(Equal(ErrInvalidScryptParams))
+		},
+		Entry("N too large", ScryptParams{N: 1 << 30, R: 8, P: 1}),
+		Entry("N not a power of two", ScryptParams{N: 1000, R: 8, P: 1}),
+		Entry("N too small", ScryptParams{N: 1, R: 8, P: 1}),
+		Entry("r·p too large", ScryptParams{N: 1 << 10, R: 8, P: 8}),
+		Entry("p too large", ScryptParams{N: 1 << 10, R: 1, P: 1 << 30}),
+		Entry("r zero", ScryptParams{N: 1 << 10, R: 0, P: 1}),
+		Entry("p negative", ScryptParams{N: 1 << 10, R: 8, P: -1}),
+		Entry("memory too large", ScryptParams{N: 1 << 20, R: 16, P: 1}),
+	)
+
+	It("accepts the predefined scrypt parameters", func() {
+		Expect(DefaultScryptParams.validate()).To(BeNil())
+		Expect(LightScryptParams.validate()).To(BeNil())
+		Expect(ScryptParams{N: 1 << 20, R: 8, P: 1}.validate()).To(BeNil())
+	})
+
+	It("rejects salts and nonces of the wrong length", func() {
+		ek, err := EncryptKey(kp, password, params)
+		Expect(err).To(BeNil())
+
+		modified := *ek
+		modified.Crypto.Salt = ek.Crypto.Salt[:16]
+		_, err = modified.Decrypt(password)
+		Expect(err).To(Equal(ErrUnsupportedEncryptedKey))
+
+		modified = *ek
+		modified.Crypto.Nonce = ek.Crypto.Nonce[:12]
+		_, err = modified.Decrypt(password)
+		Expect(err).To(Equal(ErrUnsupportedEncryptedKey))
+	})
+
+	It("saves and loads keyfiles", func() {
+		dir, err := ioutil.TempDir("", "keypair")
+		Expect(err).To(BeNil())
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "key.json")
+
+		ek, err := SaveKeyfile(path, kp, password, params)
+		Expect(err).To(BeNil())
+		Expect(ek.Address).To(Equal(address))
+
+		info, err := os.Stat(path)
+		Expect(err).To(BeNil())
+		Expect(info.Mode().Perm()).To(Equal(os.FileMode(0600)))
+
+		loaded, err := LoadKeyfile(path, password)
+		Expect(err).To(BeNil())
+		Expect(loaded.Seed()).To(Equal(seed))
+
+		_, err = LoadKeyfile(path, []byte("wrong"))
+		Expect(err).To(Equal(ErrWrongPassword))
+
+		// Existing files are not overwritten.
+		_, err = SaveKeyfile(path, MustRandom(), password, params)
+		Expect(os.IsExist(err)).To(BeTrue())
+	})
+})
@@ -0,0 +1,270 @@
+package keypair
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+var (
+	// ErrInvalidMnemonic is returned when a mnemonic is not made of words of
+	// a single wordlist, or when its checksum does not match.
+	ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+	// ErrInvalidEntropy is returned when the entropy of a mnemonic is not
+	// between 128 and 256 bits long, in steps of 32 bits.
+	ErrInvalidEntropy = errors.New("entropy must be 128 to 256 bits long, in steps of 32 bits")
+
+	// ErrUnknownLanguage is returned when a wordlist is requested for a
+	// language which is not supported.
+	ErrUnknownLanguage = errors.New("unknown mnemonic language")
+)
+
+const (
+	// SEP5PathFormat is the format of the path at which accounts are derived
+	// as described in SEP-5. Use with fmt.Sprintf and the account index.
+	SEP5PathFormat = "m/44'/148'/%d'"
+
+	// DefaultEntropyBits is the entropy of new mnemonics, which have 24 words.
+	DefaultEntropyBits = 256
+
+	// As in https://github.com/satoshilabs/slips/blob/master/slip-0010.md
+	slip10SeedModifier = "ed25519 seed"
+	firstHardenedIndex = uint32(0x80000000)
+	purposeIndex       = 44
+	stellarCoinType    = 148
+)
+
+// Language identifies a BIP-39 wordlist.
+type Language string
+
+// The languages of the BIP-39 wordlists.
+const (
+	English            Language = "english"
+	Japanese           Language = "japanese"
+	Korean             Language = "korean"
+	Spanish            Language = "spanish"
+	ChineseSimplified  Language = "chinese_simplified"
+	ChineseTraditional Language = "chinese_traditional"
+	French             Language = "french"
+	Italian            Language = "italian"
+)
+
+// Languages lists the supported mnemonic languages, in the order in which
+// mnemonics are matched against them.
+var Languages = []Language{
+	English,
+	Japanese,
+	Korean,
+	Spanish,
+	ChineseSimplified,
+	ChineseTraditional,
+	French,
+	Italian,
+}
+
+type wordlist struct {
+	words   []string
+	indexes map[string]int
+	once    sync.Once
+}
+
+var wordlistsByLanguage = map[Language]*wordlist{
+	English:            {words: wordlists.English},
+	Japanese:           {words: wordlists.Japanese},
+	Korean:             {words: wordlists.Korean},
+	Spanish:            {words: wordlists.Spanish},
+	ChineseSimplified:  {words: wordlists.ChineseSimplified},
+	ChineseTraditional: {words: wordlists.ChineseTraditional},
+	French:             {words: wordlists.French},
+	Italian:            {words: wordlists.Italian},
+}
+
+// index returns the position of a word, which must be NFKD normalized, in the
+// wordlist.
+func (w *wordlist) index(word string) (int, bool) {
+	w.once.Do(func() {
+		w.indexes = make(map[string]int, len(w.words))
+		for i, word := range w.words {
+			w.indexes[norm.NFKD.String(word)] = i
+		}
+	})
+	i, ok := w.indexes[word]
+	return i, ok
+}
+
+// Wordlist returns the 2048 words of the BIP-39 wordlist of a language.
+func Wordlist(language Language) ([]string, error) {
+	w, ok := wordlistsByLanguage[language]
+	if !ok {
+		return nil, ErrUnknownLanguage
+	}
+	return append([]string(nil), w.words...), nil
+}
+
+// NewMnemonic generates a mnemonic in the given language from entropyBits
+// bits of random entropy. entropyBits must be between 128 and 256, in steps of
+// 32, which gives mnemonics of 12 to 24 words.
+func NewMnemonic(language Language, entropyBits int) (string, error) {
+	if entropyBits < 128 || entropyBits > 256 || entropyBits%32 != 0 {
+		return "", ErrInvalidEntropy
+	}
+
+	entropy := make([]byte, entropyBits/8)
+	_, err := io.ReadFull(rand.Reader, entropy)
+	if err != nil {
+		return "", err
+	}
+
+	return MnemonicFromEntropy(entropy, language)
+}
+
+// MnemonicFromEntropy encodes entropy, which must be 16 to 32 bytes long in
+// steps of 4 bytes, as a mnemonic in the given language.
+func MnemonicFromEntropy(entropy []byte, language Language) (string, error) {
+	w, ok := wordlistsByLanguage[language]
+	if !ok {
+		return "", ErrUnknownLanguage
+	}
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", ErrInvalidEntropy
+	}
+
+	// The entropy is followed by a checksum of one bit for every 32 bits of
+	// entropy, and split into groups of 11 bits which index the wordlist.
+	checksumBits := uint(len(entropy) / 4)
+	hash := sha256.Sum256(entropy)
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, checksumBits)
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	count := (len(entropy)*8 + int(checksumBits)) / 11
+	words := make([]string, count)
+	mask := big.NewInt(2047)
+	for i := count - 1; i >= 0; i-- {
+		words[i] = w.words[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+
+	separator := " "
+	if language == Japanese {
+		separator = "\u3000"
+	}
+	return strings.Join(words, separator), nil
+}
+
+// MnemonicLanguage returns the language of a mnemonic, checking that all of
+// its words belong to the wordlist of the language and that its checksum
+// matches.
+func MnemonicLanguage(mnemonic string) (Language, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return "", ErrInvalidMnemonic
+	}
+
+	for _, language := range Languages {
+		if mnemonicEntropy(words, wordlistsByLanguage[language]) != nil {
+			return language, nil
+		}
+	}
+	return "", ErrInvalidMnemonic
+}
+
+// ValidateMnemonic returns ErrInvalidMnemonic if the mnemonic is not valid in
+// any of the supported languages.
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicLanguage(mnemonic)
+	return err
+}
+
+// mnemonicEntropy decodes the entropy of a mnemonic in the given wordlist,
+// returning nil if a word is not in the wordlist or if the checksum does not
+// match.
+func mnemonicEntropy(words []string, w *wordlist) []byte {
+	data := new(big.Int)
+	for _, word := range words {
+		i, ok := w.index(word)
+		if !ok {
+			return nil
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(i)))
+	}
+
+	checksumBits := uint(len(words) / 3)
+	checksum := new(big.Int).And(data, big.NewInt(1<<checksumBits-1)).Int64()
+	data.Rsh(data, checksumBits)
+
+	entropy := make([]byte, len(words)*4/3)
+	b := data.Bytes()
+	copy(entropy[len(entropy)-len(b):], b)
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil
+	}
+	return entropy
+}
+
+// MnemonicSeed validates a mnemonic and returns the 64 byte BIP-39 seed of
+// the mnemonic and an optional passphrase.
+func MnemonicSeed(mnemonic, passphrase string) ([]byte, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+
+	normalized := strings.Join(strings.Fields(norm.NFKD.String(mnemonic)), " ")
+	salt := "mnemonic" + norm.NFKD.String(passphrase)
+	return pbkdf2.Key([]byte(normalized), []byte(salt), 2048, 64, sha512.New), nil
+}
+
+// FromMnemonic derives the keypair of the account with the given index from
+// a mnemonic and an optional passphrase, at the path m/44'/148'/index' as
+// described in SEP-5.
+func FromMnemonic(mnemonic, passphrase string, index uint32) (*Full, error) {
+	seed, err := MnemonicSeed(mnemonic, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return FromBIP39Seed(seed, index)
+}
+
+// FromBIP39Seed derives the keypair of the account with the given index from
+// a BIP-39 seed, at the path m/44'/148'/index' as described in SEP-5.
+func FromBIP39Seed(seed []byte, index uint32) (*Full, error) {
+	if index >= firstHardenedIndex {
+		return nil, fmt.Errorf("account index must be below %d", firstHardenedIndex)
+	}
+
+	mac := hmac.New(sha512.New, []byte(slip10SeedModifier))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	key, chainCode := sum[:32], sum[32:]
+
+	// Only hardened derivation is possible with ed25519 keys.
+	for _, i := range []uint32{purposeIndex, stellarCoinType, index} {
+		data := make([]byte, 37)
+		copy(data[1:], key)
+		binary.BigEndian.PutUint32(data[33:], firstHardenedIndex+i)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data)
+		sum = mac.Sum(nil)
+		key, chainCode = sum[:32], sum[32:]
+	}
+
+	var rawSeed [32]byte
+	copy(rawSeed[:], key)
+	return FromRawSeed(rawSeed)
+}
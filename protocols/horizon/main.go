@@ -318,6 +318,7 @@ type Root struct {
 	NetworkPassphrase            string `json:"network_passphrase"`
 	CurrentProtocolVersion       int32  `json:"current_protocol_version"`
 	CoreSupportedProtocolVersion int32  `json:"core_supported_protocol_version"`
+	IngestFilterEnabled          bool   `json:"ingest_filter_enabled"`
 }
 
 // Signer represents one of an account's signers.
@@ -672,9 +673,7 @@ type ClaimableBalance struct {
 }
 
 type ClaimableBalances struct {
-	Links struct {
-		Self hal.Link `json:"self"`
-	} `json:"_links"`
+	Links hal.Links `json:"_links"`
 
 	Embedded struct {
 		Records []ClaimableBalance `json:"records"`
@@ -0,0 +1,424 @@
+// Package sep7 builds and parses the `web+stellar:` URIs defined in SEP-7,
+// which let applications delegate signing or paying to a wallet. See
+// https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0007.md
+package sep7
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
+)
+
+// Scheme is the scheme of SEP-7 URIs.
+const Scheme = "web+stellar:"
+
+// MsgMaxLength is the maximum number of characters of the msg parameter.
+const MsgMaxLength = 300
+
+const (
+	operationPay = "pay"
+	operationTx  = "tx"
+
+	callbackPrefix = "url:"
+)
+
+// ErrUnknownOperation is the error returned when parsing a URI whose
+// operation is neither pay nor tx.
+var ErrUnknownOperation = errors.New("unknown operation")
+
+// Request is a SEP-7 request, either a *PayRequest or a *TxRequest.
+type Request interface {
+	// URI returns the request encoded as a web+stellar URI.
+	URI() (string, error)
+
+	options() *Options
+}
+
+// Options are the parameters shared by pay and tx requests.
+type Options struct {
+	// Callback is the URL the wallet should POST the signed transaction to,
+	// instead of submitting it to the network.
+	Callback string
+	// Msg is a message displayed to the user, of at most MsgMaxLength
+	// characters.
+	Msg string
+	// NetworkPassphrase is the passphrase of the network the transaction
+	// should be signed for. An empty value means the public network.
+	NetworkPassphrase string
+	// OriginDomain is the domain of the application which created the
+	// request. It requires the request to be signed using the
+	// URI_REQUEST_SIGNING_KEY of the domain's stellar.toml.
+	OriginDomain string
+	// Signature is the base64 signature of the request, see Sign and Verify.
+	Signature string
+}
+
+// PayRequest is a `pay` request, asking the wallet to pay an amount to a
+// destination.
+type PayRequest struct {
+	// Destination is the account ID to pay.
+	Destination string
+	// Amount is the amount to pay. If it is empty the user chooses it.
+	Amount string
+	// Asset is the asset to pay, nil means lumens.
+	Asset txnbuild.Asset
+	// Memo is the memo of the payment transaction.
+	Memo txnbuild.Memo
+	Options
+}
+
+// TxRequest is a `tx` request, asking the wallet to sign a transaction.
+type TxRequest struct {
+	// XDR is the base64 encoded transaction envelope to sign.
+	XDR string
+	// Replace lists the fields of the transaction the user should replace,
+	// using the format defined in SEP-11.
+	Replace string
+	// Pubkey is the account ID which should sign the transaction.
+	Pubkey string
+	// Chain is a SEP-7 URI which led to this request.
+	Chain string
+	Options
+}
+
+func (r *PayRequest) options() *Options {
+	return &r.Options
+}
+
+func (r *TxRequest) options() *Options {
+	return &r.Options
+}
+
+// NewTxRequest returns a tx request for the given transaction.
+func NewTxRequest(tx *txnbuild.Transaction) (*TxRequest, error) {
+	xdr, err := tx.Base64()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode transaction")
+	}
+	return &TxRequest{XDR: xdr}, nil
+}
+
+// NewFeeBumpTxRequest returns a tx request for the given fee bump
+// transaction.
+func NewFeeBumpTxRequest(tx *txnbuild.FeeBumpTransaction) (*TxRequest, error) {
+	xdr, err := tx.Base64()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not encode transaction")
+	}
+	return &TxRequest{XDR: xdr}, nil
+}
+
+// Transaction returns the transaction of the request.
+func (r *TxRequest) Transaction() (*txnbuild.GenericTransaction, error) {
+	return txnbuild.TransactionFromXDR(r.XDR)
+}
+
+// NewPayRequest returns a pay request for the payment of a transaction with
+// a single payment operation.
+func NewPayRequest(tx *txnbuild.Transaction) (*PayRequest, error) {
+	ops := tx.Operations()
+	if len(ops) != 1 {
+		return nil, errors.Errorf("expected 1 operation but got %d", len(ops))
+	}
+	payment, ok := ops[0].(*txnbuild.Payment)
+	if !ok {
+		return nil, errors.Errorf("expected a payment operation but got %T", ops[0])
+	}
+	return &PayRequest{
+		Destination: payment.Destination,
+		Amount:      payment.Amount,
+		Asset:       payment.Asset,
+		Memo:        tx.Memo(),
+	}, nil
+}
+
+// Transaction returns a transaction paying the request using the given
+// parameters. The payment operation is appended to params.Operations, and
+// the memo of the request replaces params.Memo if it is set.
+func (r *PayRequest) Transaction(params txnbuild.TransactionParams) (*txnbuild.Transaction, error) {
+	if r.Amount == "" {
+		return nil, errors.New("amount is required to build a transaction")
+	}
+	asset := r.Asset
+	if asset == nil {
+		asset = txnbuild.NativeAsset{}
+	}
+	params.Operations = append(params.Operations, &txnbuild.Payment{
+		Destination: r.Destination,
+		Amount:      r.Amount,
+		Asset:       asset,
+	})
+	if r.Memo != nil {
+		params.Memo = r.Memo
+	}
+	return txnbuild.NewTransaction(params)
+}
+
+// params collects the query parameters of a URI in order.
+type params []string
+
+func (p *params) add(key, value string) {
+	if value != "" {
+		*p = append(*p, key+"="+escape(value))
+	}
+}
+
+// escape percent-encodes a parameter value. Spaces are encoded as %20
+// rather than +, like in the examples of SEP-7.
+func escape(value string) string {
+	return strings.Replace(url.QueryEscape(value), "+", "%20", -1)
+}
+
+func (o Options) validate() error {
+	if o.Callback != "" {
+		u, err := url.Parse(o.Callback)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.Errorf("invalid callback %q", o.Callback)
+		}
+	}
+	if n := len([]rune(o.Msg)); n > MsgMaxLength {
+		return errors.Errorf("msg is %d characters long, the maximum is %d", n, MsgMaxLength)
+	}
+	if o.Signature != "" && o.OriginDomain == "" {
+		return errors.New("signature requires origin_domain")
+	}
+	return nil
+}
+
+func (o Options) add(p *params) {
+	if o.Callback != "" {
+		p.add("callback", callbackPrefix+o.Callback)
+	}
+	p.add("msg", o.Msg)
+	p.add("network_passphrase", o.NetworkPassphrase)
+	p.add("origin_domain", o.OriginDomain)
+	// The signature must be the last parameter.
+	p.add("signature", o.Signature)
+}
+
+func uri(operation string, p params) string {
+	return Scheme + operation + "?" + strings.Join(p, "&")
+}
+
+// URI returns the request encoded as a web+stellar:pay URI.
+func (r *PayRequest) URI() (string, error) {
+	if !strkey.IsValidEd25519PublicKey(r.Destination) {
+		return "", errors.Errorf("invalid destination %q", r.Destination)
+	}
+	if r.Amount != "" {
+		if _, err := amount.Parse(r.Amount); err != nil {
+			return "", errors.Wrapf(err, "invalid amount %q", r.Amount)
+		}
+	}
+	if err := r.Options.validate(); err != nil {
+		return "", err
+	}
+
+	var p params
+	p.add("destination", r.Destination)
+	p.add("amount", r.Amount)
+	if r.Asset != nil && !r.Asset.IsNative() {
+		if r.Asset.GetCode() == "" || !strkey.IsValidEd25519PublicKey(r.Asset.GetIssuer()) {
+			return "", errors.Errorf("invalid asset %s:%s", r.Asset.GetCode(), r.Asset.GetIssuer())
+		}
+		p.add("asset_code", r.Asset.GetCode())
+		p.add("asset_issuer", r.Asset.GetIssuer())
+	}
+	if r.Memo != nil {
+		memo, memoType, err := encodeMemo(r.Memo)
+		if err != nil {
+			return "", err
+		}
+		p.add("memo", memo)
+		p.add("memo_type", memoType)
+	}
+	r.Options.add(&p)
+	return uri(operationPay, p), nil
+}
+
+// URI returns the request encoded as a web+stellar:tx URI.
+func (r *TxRequest) URI() (string, error) {
+	if _, err := r.Transaction(); err != nil {
+		return "", errors.Wrap(err, "invalid xdr")
+	}
+	if r.Pubkey != "" && !strkey.IsValidEd25519PublicKey(r.Pubkey) {
+		return "", errors.Errorf("invalid pubkey %q", r.Pubkey)
+	}
+	if r.Chain != "" && !strings.HasPrefix(r.Chain, Scheme) {
+		return "", errors.Errorf("invalid chain %q", r.Chain)
+	}
+	if err := r.Options.validate(); err != nil {
+		return "", err
+	}
+
+	var p params
+	p.add("xdr", r.XDR)
+	p.add("replace", r.Replace)
+	p.add("pubkey", r.Pubkey)
+	p.add("chain", r.Chain)
+	r.Options.add(&p)
+	return uri(operationTx, p), nil
+}
+
+func encodeMemo(memo txnbuild.Memo) (string, string, error) {
+	switch memo := memo.(type) {
+	case txnbuild.MemoText:
+		if memo == "" {
+			// An empty text memo is the same as no memo.
+			return "", "", nil
+		}
+		if _, err := memo.ToXDR(); err != nil {
+			return "", "", err
+		}
+		return string(memo), "MEMO_TEXT", nil
+	case txnbuild.MemoID:
+		return strconv.FormatUint(uint64(memo), 10), "MEMO_ID", nil
+	case txnbuild.MemoHash:
+		return base64.StdEncoding.EncodeToString(memo[:]), "MEMO_HASH", nil
+	case txnbuild.MemoReturn:
+		return base64.StdEncoding.EncodeToString(memo[:]), "MEMO_RETURN", nil
+	}
+	return "", "", errors.Errorf("unsupported memo %T", memo)
+}
+
+func decodeMemo(memo, memoType string) (txnbuild.Memo, error) {
+	switch memoType {
+	case "", "MEMO_TEXT":
+		text := txnbuild.MemoText(memo)
+		if _, err := text.ToXDR(); err != nil {
+			return nil, err
+		}
+		return text, nil
+	case "MEMO_ID":
+		id, err := strconv.ParseUint(memo, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("invalid memo id %q", memo)
+		}
+		return txnbuild.MemoID(id), nil
+	case "MEMO_HASH", "MEMO_RETURN":
+		raw, err := base64.StdEncoding.DecodeString(memo)
+		if err != nil || len(raw) != 32 {
+			return nil, errors.Errorf("invalid memo hash %q", memo)
+		}
+		var hash [32]byte
+		copy(hash[:], raw)
+		if memoType == "MEMO_HASH" {
+			return txnbuild.MemoHash(hash), nil
+		}
+		return txnbuild.MemoReturn(hash), nil
+	}
+	return nil, errors.Errorf("invalid memo_type %q", memoType)
+}
+
+// Parse parses a web+stellar URI, returning a *PayRequest or a *TxRequest.
+// The signature is not verified, see Verify.
+func Parse(uri string) (Request, error) {
+	operation, values, err := split(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	var options Options
+	if callback := values["callback"]; callback != "" {
+		if !strings.HasPrefix(callback, callbackPrefix) {
+			return nil, errors.Errorf("invalid callback %q", callback)
+		}
+		options.Callback = strings.TrimPrefix(callback, callbackPrefix)
+	}
+	options.Msg = values["msg"]
+	options.NetworkPassphrase = values["network_passphrase"]
+	options.OriginDomain = values["origin_domain"]
+	options.Signature = values["signature"]
+	if err = options.validate(); err != nil {
+		return nil, err
+	}
+
+	switch operation {
+	case operationPay:
+		r := &PayRequest{
+			Destination: values["destination"],
+			Amount:      values["amount"],
+			Options:     options,
+		}
+		if !strkey.IsValidEd25519PublicKey(r.Destination) {
+			return nil, errors.Errorf("invalid destination %q", r.Destination)
+		}
+		if r.Amount != "" {
+			if _, err = amount.Parse(r.Amount); err != nil {
+				return nil, errors.Wrapf(err, "invalid amount %q", r.Amount)
+			}
+		}
+		code, issuer := values["asset_code"], values["asset_issuer"]
+		switch {
+		case code == "" && issuer == "":
+		case code != "" && strkey.IsValidEd25519PublicKey(issuer):
+			r.Asset = txnbuild.CreditAsset{Code: code, Issuer: issuer}
+		default:
+			return nil, errors.Errorf("invalid asset %s:%s", code, issuer)
+		}
+		if memo, ok := values["memo"]; ok {
+			if r.Memo, err = decodeMemo(memo, values["memo_type"]); err != nil {
+				return nil, err
+			}
+		} else if _, ok := values["memo_type"]; ok {
+			return nil, errors.New("memo_type requires memo")
+		}
+		return r, nil
+	case operationTx:
+		r := &TxRequest{
+			XDR:     values["xdr"],
+			Replace: values["replace"],
+			Pubkey:  values["pubkey"],
+			Chain:   values["chain"],
+			Options: options,
+		}
+		if _, err = r.Transaction(); err != nil {
+			return nil, errors.Wrap(err, "invalid xdr")
+		}
+		if r.Pubkey != "" && !strkey.IsValidEd25519PublicKey(r.Pubkey) {
+			return nil, errors.Errorf("invalid pubkey %q", r.Pubkey)
+		}
+		return r, nil
+	}
+	return nil, errors.Wrap(ErrUnknownOperation, operation)
+}
+
+// split returns the operation and the decoded parameters of a URI.
+func split(uri string) (string, map[string]string, error) {
+	if !strings.HasPrefix(uri, Scheme) {
+		return "", nil, errors.Errorf("expected a %s URI", Scheme)
+	}
+	parts := strings.SplitN(strings.TrimPrefix(uri, Scheme), "?", 2)
+	values := map[string]string{}
+	if len(parts) == 1 {
+		return parts[0], values, nil
+	}
+	for _, param := range strings.Split(parts[1], "&") {
+		if param == "" {
+			continue
+		}
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return "", nil, errors.Errorf("invalid parameter %q", param)
+		}
+		key, err := url.QueryUnescape(kv[0])
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "invalid parameter %q", param)
+		}
+		value, err := url.QueryUnescape(kv[1])
+		if err != nil {
+			return "", nil, errors.Wrapf(err, "invalid parameter %q", param)
+		}
+		if _, ok := values[key]; ok {
+			return "", nil, errors.Errorf("duplicate parameter %s", key)
+		}
+		values[key] = value
+	}
+	return parts[0], values, nil
+}
@@ -0,0 +1,168 @@
+package sep7
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	destination = "GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO"
+	issuer      = "GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ"
+)
+
+func TestPayRequestURI(t *testing.T) {
+	r := &PayRequest{
+		Destination: destination,
+		Amount:      "120.1234567",
+		Memo:        txnbuild.MemoText("skdjfasf"),
+		Options: Options{
+			Msg:          "pay me with lumens",
+			OriginDomain: "someDomain.com",
+		},
+	}
+	uri, err := r.URI()
+	require.NoError(t, err)
+	assert.Equal(
+		t,
+		"web+stellar:pay?destination=GCALNQQBXAPZ2WIRSDDBMSTAKCUH5SG6U76YBFLQLIXJTF7FE5AX7AOO&amount=120.1234567&memo=skdjfasf&memo_type=MEMO_TEXT&msg=pay%20me%20with%20lumens&origin_domain=someDomain.com",
+		uri,
+	)
+
+	parsed, err := Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, r, parsed)
+}
+
+func TestPayRequestRoundTrip(t *testing.T) {
+	for _, r := range []*PayRequest{
+		{Destination: destination},
+		{
+			Destination: destination,
+			Amount:      "10",
+			Asset:       txnbuild.CreditAsset{Code: "USD", Issuer: issuer},
+			Memo:        txnbuild.MemoID(123),
+			Options: Options{
+				Callback:          "https://example.com/callback?a=b&c=d",
+				Msg:               "order #12 & co",
+				NetworkPassphrase: network.TestNetworkPassphrase,
+			},
+		},
+		{Destination: destination, Memo: txnbuild.MemoHash{1, 2, 3}},
+		{Destination: destination, Memo: txnbuild.MemoReturn{4, 5, 6}},
+	} {
+		uri, err := r.URI()
+		require.NoError(t, err)
+		parsed, err := Parse(uri)
+		require.NoError(t, err)
+		assert.Equal(t, r, parsed, uri)
+	}
+}
+
+func TestTxRequestRoundTrip(t *testing.T) {
+	kp := keypair.MustParseFull("SBPQUZ6G4FZNWFHKUWC5BEYWF6R52E3SEP7R3GWYSM2XTKGF5LNTWW4R")
+	sourceAccount := txnbuild.NewSimpleAccount(kp.Address(), 1)
+	tx, err := txnbuild.NewTransaction(
+		txnbuild.TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 10}},
+			BaseFee:       txnbuild.MinBaseFee,
+			Timebounds:    txnbuild.NewInfiniteTimeout(),
+		},
+	)
+	require.NoError(t, err)
+
+	r, err := NewTxRequest(tx)
+	require.NoError(t, err)
+	r.Replace = "sourceAccount:X,seqNum:Y;X:account to use,Y:sequence number"
+	r.Pubkey = kp.Address()
+	r.Chain = "web+stellar:pay?destination=" + destination
+	r.Callback = "https://example.com/callback"
+
+	uri, err := r.URI()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(uri, "web+stellar:tx?xdr=AAAAAgAAAADg3G3hclysZlFitS%2Bs5zWyiiJD5B0STWy5LXCj6i5yxQ"), uri)
+
+	parsed, err := Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, r, parsed)
+
+	generic, err := parsed.(*TxRequest).Transaction()
+	require.NoError(t, err)
+	parsedTx, ok := generic.Transaction()
+	require.True(t, ok)
+	expected, err := tx.Base64()
+	require.NoError(t, err)
+	actual, err := parsedTx.Base64()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestPayRequestTransaction(t *testing.T) {
+	kp := keypair.MustParseFull("SBPQUZ6G4FZNWFHKUWC5BEYWF6R52E3SEP7R3GWYSM2XTKGF5LNTWW4R")
+	sourceAccount := txnbuild.NewSimpleAccount(kp.Address(), 1)
+	params := txnbuild.TransactionParams{
+		SourceAccount: &sourceAccount,
+		BaseFee:       txnbuild.MinBaseFee,
+		Timebounds:    txnbuild.NewInfiniteTimeout(),
+	}
+
+	r := &PayRequest{Destination: destination, Memo: txnbuild.MemoText("invoice 1")}
+	_, err := r.Transaction(params)
+	assert.EqualError(t, err, "amount is required to build a transaction")
+
+	r.Amount = "12.5"
+	tx, err := r.Transaction(params)
+	require.NoError(t, err)
+	assert.Equal(t, txnbuild.MemoText("invoice 1"), tx.Memo())
+	require.Len(t, tx.Operations(), 1)
+	payment := tx.Operations()[0].(*txnbuild.Payment)
+	assert.Equal(t, destination, payment.Destination)
+	assert.Equal(t, "12.5", payment.Amount)
+	assert.Equal(t, txnbuild.NativeAsset{}, payment.Asset)
+
+	fromTx, err := NewPayRequest(tx)
+	require.NoError(t, err)
+	assert.Equal(t, destination, fromTx.Destination)
+	assert.Equal(t, txnbuild.MemoText("invoice 1"), fromTx.Memo)
+
+	params.Operations = []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 10}}
+	tx, err = txnbuild.NewTransaction(params)
+	require.NoError(t, err)
+	_, err = NewPayRequest(tx)
+	assert.EqualError(t, err, "expected a payment operation but got *txnbuild.BumpSequence")
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, testCase := range []struct {
+		uri string
+		err string
+	}{
+		{"https://example.com", "expected a web+stellar: URI"},
+		{"web+stellar:sign?xdr=AAAA", "sign: unknown operation"},
+		{"web+stellar:pay?destination=GABC", `invalid destination "GABC"`},
+		{"web+stellar:pay?destination=" + destination + "&amount=abc", `invalid amount "abc": invalid amount format: abc`},
+		{"web+stellar:pay?destination=" + destination + "&asset_code=USD", "invalid asset USD:"},
+		{"web+stellar:pay?destination=" + destination + "&memo=1&memo_type=MEMO_FOO", `invalid memo_type "MEMO_FOO"`},
+		{"web+stellar:pay?destination=" + destination + "&memo=a&memo_type=MEMO_ID", `invalid memo id "a"`},
+		{"web+stellar:pay?destination=" + destination + "&memo_type=MEMO_ID", "memo_type requires memo"},
+		{"web+stellar:pay?destination=" + destination + "&callback=https://example.com", `invalid callback "https://example.com"`},
+		{"web+stellar:pay?destination=" + destination + "&msg=" + strings.Repeat("a", 301), "msg is 301 characters long, the maximum is 300"},
+		{"web+stellar:pay?destination=" + destination + "&signature=abc", "signature requires origin_domain"},
+		{"web+stellar:pay?destination=" + destination + "&destination=" + destination, "duplicate parameter destination"},
+		{"web+stellar:pay?destination", `invalid parameter "destination"`},
+		{"web+stellar:tx?xdr=AAAA", "invalid xdr: unable to unmarshal transaction envelope: xdr:DecodeInt: unexpected EOF while decoding 4 bytes - read: '[0 0 0]'"},
+	} {
+		_, err := Parse(testCase.uri)
+		assert.EqualError(t, err, testCase.err, testCase.uri)
+	}
+
+	_, err := Parse("web+stellar:sign")
+	assert.Equal(t, ErrUnknownOperation, errors.Cause(err))
+}
@@ -0,0 +1,104 @@
+package sep7
+
+import (
+	"encoding/base64"
+	"net/url"
+	"strings"
+
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/support/errors"
+)
+
+// signaturePrefix is prepended to the URI to form the signed payload: 35
+// zero bytes, the byte 4 and the string "stellar.sep.7 - URI Scheme".
+var signaturePrefix = append(
+	append(make([]byte, 35), 4),
+	"stellar.sep.7 - URI Scheme"...,
+)
+
+const signatureParam = "&signature="
+
+// ErrNotSigned is the error returned by Verify when the URI has no
+// signature.
+var ErrNotSigned = errors.New("uri is not signed")
+
+// ErrInvalidSignature is the error returned by Verify when the signature
+// does not match the URI_REQUEST_SIGNING_KEY of the origin domain.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+func signaturePayload(unsigned string) []byte {
+	payload := make([]byte, 0, len(signaturePrefix)+len(unsigned))
+	payload = append(payload, signaturePrefix...)
+	return append(payload, unsigned...)
+}
+
+// Sign signs the request with the given key, which should be the
+// URI_REQUEST_SIGNING_KEY of the origin domain, and returns the signed URI.
+// The Signature of the request is updated.
+func Sign(r Request, kp *keypair.Full) (string, error) {
+	options := r.options()
+	if options.OriginDomain == "" {
+		return "", errors.New("origin_domain is required to sign a request")
+	}
+
+	previous := options.Signature
+	options.Signature = ""
+	unsigned, err := r.URI()
+	if err != nil {
+		options.Signature = previous
+		return "", err
+	}
+	signature, err := kp.SignBase64(signaturePayload(unsigned))
+	if err != nil {
+		options.Signature = previous
+		return "", errors.Wrap(err, "could not sign uri")
+	}
+	options.Signature = signature
+	return unsigned + signatureParam + escape(signature), nil
+}
+
+// Verify checks the signature of a URI against the URI_REQUEST_SIGNING_KEY
+// published in the stellar.toml of its origin domain, and returns the parsed
+// request. The signature covers the URI exactly as received rather than the
+// parameters it decodes to.
+func Verify(uri string, client stellartoml.ClientInterface) (Request, error) {
+	r, err := Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	options := r.options()
+	if options.Signature == "" {
+		return nil, ErrNotSigned
+	}
+
+	i := strings.LastIndex(uri, signatureParam)
+	if i < 0 || strings.Contains(uri[i+len(signatureParam):], "&") {
+		return nil, errors.New("signature must be the last parameter")
+	}
+	unsigned := uri[:i]
+	signature, err := url.QueryUnescape(uri[i+len(signatureParam):])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid signature")
+	}
+	rawSignature, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid signature")
+	}
+
+	resp, err := client.GetStellarToml(options.OriginDomain)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get the stellar.toml of %s", options.OriginDomain)
+	}
+	if resp.UriRequestSigningKey == "" {
+		return nil, errors.Errorf("%s has no URI_REQUEST_SIGNING_KEY", options.OriginDomain)
+	}
+	kp, err := keypair.ParseAddress(resp.UriRequestSigningKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid URI_REQUEST_SIGNING_KEY of %s", options.OriginDomain)
+	}
+	if err := kp.Verify(signaturePayload(unsigned), rawSignature); err != nil {
+		return nil, ErrInvalidSignature
+	}
+	return r, nil
+}
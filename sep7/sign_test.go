@@ -0,0 +1,96 @@
+package sep7
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/clients/stellartoml"
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	signer := keypair.MustParseFull("SBPOVRVKTTV7W3IOX2FJPSMPCJ5L2WU2YKTP3HCLYPXNI5MDIGREVNYC")
+	client := &stellartoml.MockClient{}
+	client.On("GetStellarToml", "someDomain.com").
+		Return(&stellartoml.Response{UriRequestSigningKey: signer.Address()}, nil)
+
+	r := &PayRequest{
+		Destination: destination,
+		Amount:      "120.1234567",
+		Memo:        txnbuild.MemoText("skdjfasf"),
+		Options: Options{
+			Msg:          "pay me with lumens",
+			OriginDomain: "someDomain.com",
+		},
+	}
+	uri, err := Sign(r, signer)
+	require.NoError(t, err)
+	require.NotEmpty(t, r.Signature)
+	assert.True(t, strings.HasSuffix(uri, "&signature="+escape(r.Signature)))
+
+	// The URI of the signed request is the signed URI.
+	encoded, err := r.URI()
+	require.NoError(t, err)
+	assert.Equal(t, uri, encoded)
+
+	verified, err := Verify(uri, client)
+	require.NoError(t, err)
+	assert.Equal(t, r, verified)
+
+	// Signing again replaces the signature.
+	again, err := Sign(r, signer)
+	require.NoError(t, err)
+	assert.Equal(t, uri, again)
+
+	// Any change to the signed part invalidates the signature, even if it
+	// decodes to the same parameters.
+	tampered := strings.Replace(uri, "120.1234567", "1200.1234567", 1)
+	_, err = Verify(tampered, client)
+	assert.Equal(t, ErrInvalidSignature, err)
+	tampered = strings.Replace(uri, "pay%20me", "pay+me", 1)
+	_, err = Verify(tampered, client)
+	assert.Equal(t, ErrInvalidSignature, err)
+
+	other := keypair.MustParseFull("SBPQUZ6G4FZNWFHKUWC5BEYWF6R52E3SEP7R3GWYSM2XTKGF5LNTWW4R")
+	otherURI, err := Sign(r, other)
+	require.NoError(t, err)
+	_, err = Verify(otherURI, client)
+	assert.Equal(t, ErrInvalidSignature, err)
+}
+
+func TestVerifyErrors(t *testing.T) {
+	signer := keypair.MustParseFull("SBPOVRVKTTV7W3IOX2FJPSMPCJ5L2WU2YKTP3HCLYPXNI5MDIGREVNYC")
+	client := &stellartoml.MockClient{}
+	client.On("GetStellarToml", "nokey.com").
+		Return(&stellartoml.Response{}, nil)
+	client.On("GetStellarToml", "down.com").
+		Return((*stellartoml.Response)(nil), errors.New("connection refused"))
+
+	r := &PayRequest{Destination: destination}
+	_, err := Sign(r, signer)
+	assert.EqualError(t, err, "origin_domain is required to sign a request")
+
+	uri, err := r.URI()
+	require.NoError(t, err)
+	_, err = Verify(uri, client)
+	assert.Equal(t, ErrNotSigned, err)
+
+	r.OriginDomain = "nokey.com"
+	uri, err = Sign(r, signer)
+	require.NoError(t, err)
+	_, err = Verify(uri, client)
+	assert.EqualError(t, err, "nokey.com has no URI_REQUEST_SIGNING_KEY")
+
+	r.OriginDomain = "down.com"
+	uri, err = Sign(r, signer)
+	require.NoError(t, err)
+	_, err = Verify(uri, client)
+	assert.EqualError(t, err, "could not get the stellar.toml of down.com: connection refused")
+
+	_, err = Verify(uri+"&msg=hi", client)
+	assert.EqualError(t, err, "signature must be the last parameter")
+}
@@ -1,8 +1,6 @@
 package internal
 
 import (
-	"fmt"
-
 	"github.com/stellar/go/clients/horizonclient"
 	"github.com/stellar/go/keypair"
 	hProtocol "github.com/stellar/go/protocols/horizon"
@@ -10,9 +8,9 @@ import (
 	"github.com/stellar/go/txnbuild"
 )
 
-const createAccountAlreadyExistXDR = "AAAAAAAAAGT/////AAAAAQAAAAAAAAAA/////AAAAAA="
-
-var ErrAccountExists error = errors.New(fmt.Sprintf("createAccountAlreadyExist (%s)", createAccountAlreadyExistXDR))
+// ErrAccountExists is returned when the account to fund already exists. Its
+// message is the readable form of the create account operation result.
+var ErrAccountExists error = errors.New("op_already_exists (the destination account already exists)")
 
 // Minion contains a Stellar channel account and Go channels to communicate with friendbot.
 type Minion struct {
@@ -67,13 +65,15 @@ func SubmitTransaction(minion *Minion, hclient *horizonclient.Client, tx string)
 		switch e := err.(type) {
 		case *horizonclient.Error:
 			minion.checkHandleBadSequence(e)
-			resStr, resErr := e.ResultString()
+			txErr, resErr := e.TransactionError()
 			if resErr != nil {
 				errStr += ": error getting horizon error code: " + resErr.Error()
-			} else if resStr == createAccountAlreadyExistXDR {
+			} else if txErr == nil {
+				errStr += ": " + e.Error()
+			} else if isAccountExists(txErr) {
 				return nil, errors.Wrap(ErrAccountExists, errStr)
 			} else {
-				errStr += ": horizon error string: " + resStr
+				errStr += ": " + txErr.Error()
 			}
 			return nil, errors.New(errStr)
 		}
@@ -82,6 +82,12 @@ func SubmitTransaction(minion *Minion, hclient *horizonclient.Client, tx string)
 	return &result, nil
 }
 
+// isAccountExists returns true if the create account operation of a friendbot
+// transaction failed because the destination account already exists.
+func isAccountExists(txErr *txnbuild.TransactionError) bool {
+	return len(txErr.Operations) == 1 && txErr.Operations[0].Result.Code == "op_already_exists"
+}
+
 // CheckSequenceRefresh establishes the minion's initial sequence number, if needed.
 // This should also be passed to the minion.
 func CheckSequenceRefresh(minion *Minion, hclient *horizonclient.Client) error {
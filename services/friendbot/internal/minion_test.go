@@ -1,6 +1,9 @@
 package internal
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"sync"
 	"testing"
 
@@ -8,6 +11,7 @@ import (
 	"github.com/stellar/go/keypair"
 	hProtocol "github.com/stellar/go/protocols/horizon"
 	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/support/render/problem"
 	"github.com/stellar/go/txnbuild"
 	"github.com/stretchr/testify/assert"
 )
@@ -135,3 +139,37 @@ func TestMinion_CorrectNumberOfTxSubmissions(t *testing.T) {
 	wg.Wait()
 	assert.Equal(t, numTests, numTxSubmits)
 }
+
+func TestSubmitTransaction_Errors(t *testing.T) {
+	var resultXDR string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(problem.P{
+			Type:   "https://stellar.org/horizon-errors/transaction_failed",
+			Title:  "Transaction Failed",
+			Status: http.StatusBadRequest,
+			Extras: map[string]interface{}{"result_xdr": resultXDR},
+		})
+	}))
+	defer server.Close()
+	hclient := &horizonclient.Client{HorizonURL: server.URL}
+
+	// op_already_exists
+	resultXDR = "AAAAAAAAAGT/////AAAAAQAAAAAAAAAA/////AAAAAA="
+	_, err := SubmitTransaction(&Minion{}, hclient, "tx")
+	assert.Equal(t, ErrAccountExists, errors.Cause(err))
+	assert.EqualError(t, err, "submitting tx to horizon: op_already_exists (the destination account already exists)")
+
+	// op_underfunded
+	resultXDR = "AAAAAAAAAGT/////AAAAAQAAAAAAAAAA/////gAAAAA="
+	minion := &Minion{}
+	_, err = SubmitTransaction(minion, hclient, "tx")
+	assert.EqualError(t, err, "submitting tx to horizon: transaction failed: tx_failed (one of the operations failed): "+
+		"operation 0 (create_account): op_underfunded (the source account does not hold enough of the asset)")
+	assert.False(t, minion.forceRefreshSequence)
+
+	// tx_bad_seq
+	resultXDR = "AAAAAAAAAAD////7AAAAAA=="
+	_, err = SubmitTransaction(minion, hclient, "tx")
+	assert.EqualError(t, err, "submitting tx to horizon: transaction failed: tx_bad_seq (the sequence number does not match the source account)")
+}
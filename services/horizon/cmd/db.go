@@ -221,12 +221,15 @@ var dbReingestRangeCmd = &cobra.Command{
 		ingestConfig := ingest.Config{
 			NetworkPassphrase:           config.NetworkPassphrase,
 			HistorySession:              horizonSession,
-			HistoryArchiveURL:           config.HistoryArchiveURLs[0],
+			HistoryArchiveURLs:          config.HistoryArchiveURLs,
+			HistoryArchiveQuorum:        config.HistoryArchiveQuorum,
 			MaxReingestRetries:          int(retries),
 			ReingestRetryBackoffSeconds: int(retryBackoffSeconds),
 			EnableCaptiveCore:           config.EnableCaptiveCoreIngestion,
 			StellarCoreBinaryPath:       config.StellarCoreBinaryPath,
 			RemoteCaptiveCoreURL:        config.RemoteCaptiveCoreURL,
+			FilterAccounts:              config.IngestFilterAccounts,
+			FilterAssets:                config.IngestFilterAssets,
 		}
 
 		if !ingestConfig.EnableCaptiveCore {
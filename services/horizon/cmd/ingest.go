@@ -101,10 +101,13 @@ var ingestVerifyRangeCmd = &cobra.Command{
 		ingestConfig := ingest.Config{
 			NetworkPassphrase:     config.NetworkPassphrase,
 			HistorySession:        horizonSession,
-			HistoryArchiveURL:     config.HistoryArchiveURLs[0],
+			HistoryArchiveURLs:    config.HistoryArchiveURLs,
+			HistoryArchiveQuorum:  config.HistoryArchiveQuorum,
 			EnableCaptiveCore:     config.EnableCaptiveCoreIngestion,
 			StellarCoreBinaryPath: config.StellarCoreBinaryPath,
 			RemoteCaptiveCoreURL:  config.RemoteCaptiveCoreURL,
+			FilterAccounts:        config.IngestFilterAccounts,
+			FilterAssets:          config.IngestFilterAssets,
 		}
 
 		if !ingestConfig.EnableCaptiveCore {
@@ -184,10 +187,10 @@ var ingestStressTestCmd = &cobra.Command{
 		}
 
 		ingestConfig := ingest.Config{
-			NetworkPassphrase: config.NetworkPassphrase,
-			HistorySession:    horizonSession,
-			HistoryArchiveURL: config.HistoryArchiveURLs[0],
-			EnableCaptiveCore: config.EnableCaptiveCoreIngestion,
+			NetworkPassphrase:  config.NetworkPassphrase,
+			HistorySession:     horizonSession,
+			HistoryArchiveURLs: config.HistoryArchiveURLs,
+			EnableCaptiveCore:  config.EnableCaptiveCoreIngestion,
 		}
 
 		if config.EnableCaptiveCoreIngestion {
@@ -266,10 +269,10 @@ var ingestInitGenesisStateCmd = &cobra.Command{
 		}
 
 		ingestConfig := ingest.Config{
-			NetworkPassphrase: config.NetworkPassphrase,
-			HistorySession:    horizonSession,
-			HistoryArchiveURL: config.HistoryArchiveURLs[0],
-			EnableCaptiveCore: config.EnableCaptiveCoreIngestion,
+			NetworkPassphrase:  config.NetworkPassphrase,
+			HistorySession:     horizonSession,
+			HistoryArchiveURLs: config.HistoryArchiveURLs,
+			EnableCaptiveCore:  config.EnableCaptiveCoreIngestion,
 		}
 
 		if config.EnableCaptiveCoreIngestion {
@@ -24,6 +24,9 @@ type GetRootHandler struct {
 	NetworkPassphrase string
 	FriendbotURL      *url.URL
 	HorizonVersion    string
+	// IngestFilterEnabled is true if history ingestion is restricted to
+	// selected accounts and assets.
+	IngestFilterEnabled bool
 }
 
 func (handler GetRootHandler) GetResource(w HeaderWriter, r *http.Request) (interface{}, error) {
@@ -46,6 +49,7 @@ func (handler GetRootHandler) GetResource(w HeaderWriter, r *http.Request) (inte
 		coreSettings.CurrentProtocolVersion,
 		coreSettings.CoreSupportedProtocolVersion,
 		handler.FriendbotURL,
+		handler.IngestFilterEnabled,
 		templates,
 	)
 	return res, nil
@@ -461,19 +461,20 @@ func (a *App) init() error {
 	initTxSubMetrics(a)
 
 	routerConfig := httpx.RouterConfig{
-		DBSession:          a.historyQ.Session,
-		TxSubmitter:        a.submitter,
-		RateQuota:          a.config.RateQuota,
-		SSEUpdateFrequency: a.config.SSEUpdateFrequency,
-		StaleThreshold:     a.config.StaleThreshold,
-		ConnectionTimeout:  a.config.ConnectionTimeout,
-		NetworkPassphrase:  a.config.NetworkPassphrase,
-		MaxPathLength:      a.config.MaxPathLength,
-		PathFinder:         a.paths,
-		PrometheusRegistry: a.prometheusRegistry,
-		CoreGetter:         a,
-		HorizonVersion:     a.horizonVersion,
-		FriendbotURL:       a.config.FriendbotURL,
+		DBSession:           a.historyQ.Session,
+		TxSubmitter:         a.submitter,
+		RateQuota:           a.config.RateQuota,
+		SSEUpdateFrequency:  a.config.SSEUpdateFrequency,
+		StaleThreshold:      a.config.StaleThreshold,
+		ConnectionTimeout:   a.config.ConnectionTimeout,
+		NetworkPassphrase:   a.config.NetworkPassphrase,
+		MaxPathLength:       a.config.MaxPathLength,
+		PathFinder:          a.paths,
+		PrometheusRegistry:  a.prometheusRegistry,
+		CoreGetter:          a,
+		HorizonVersion:      a.horizonVersion,
+		FriendbotURL:        a.config.FriendbotURL,
+		IngestFilterEnabled: len(a.config.IngestFilterAccounts) > 0 || len(a.config.IngestFilterAssets) > 0,
 	}
 
 	var err error
@@ -5,6 +5,7 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"github.com/stellar/go/xdr"
 	"github.com/stellar/throttled"
 )
 
@@ -13,8 +14,11 @@ import (
 type Config struct {
 	DatabaseURL        string
 	HistoryArchiveURLs []string
-	Port               uint
-	AdminPort          uint
+	// HistoryArchiveQuorum is the number of history archives which must
+	// agree on the archive state and ledger headers during ingestion.
+	HistoryArchiveQuorum int
+	Port                 uint
+	AdminPort            uint
 
 	EnableCaptiveCoreIngestion bool
 	StellarCoreBinaryPath      string
@@ -66,6 +70,11 @@ type Config struct {
 	// IngestDisableStateVerification disables state verification
 	// `System.verifyState()` when set to `true`.
 	IngestDisableStateVerification bool
+	// IngestFilterAccounts and IngestFilterAssets restrict the transactions
+	// written to the history tables to the ones involving the given accounts
+	// or assets. State ingestion is not affected.
+	IngestFilterAccounts []string
+	IngestFilterAssets   []xdr.Asset
 	// ApplyMigrations will apply pending migrations to the horizon database
 	// before starting the horizon service
 	ApplyMigrations bool
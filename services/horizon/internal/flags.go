@@ -13,6 +13,7 @@ import (
 	support "github.com/stellar/go/support/config"
 	"github.com/stellar/go/support/db"
 	"github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
 	"github.com/stellar/throttled"
 )
 
@@ -145,6 +146,13 @@ func Flags() (*Config, support.ConfigOptions) {
 			},
 			Usage: "comma-separated list of stellar history archives to connect with",
 		},
+		&support.ConfigOption{
+			Name:        "history-archive-quorum",
+			ConfigKey:   &config.HistoryArchiveQuorum,
+			OptType:     types.Int,
+			FlagDefault: 0,
+			Usage:       "number of history archives (from --history-archive-urls) which must agree on checkpoint states and ledger headers, archives which disagree are reported in the logs. When lower than 2, archives are only used for failover",
+		},
 		&support.ConfigOption{
 			Name:        "port",
 			ConfigKey:   &config.Port,
@@ -341,6 +349,36 @@ func Flags() (*Config, support.ConfigOptions) {
 			FlagDefault: false,
 			Usage:       "ingestion system runs a verification routing to compare state in local database with history buckets, this can be disabled however it's not recommended",
 		},
+		&support.ConfigOption{
+			Name:        "ingest-filter-accounts",
+			ConfigKey:   &config.IngestFilterAccounts,
+			OptType:     types.String,
+			FlagDefault: "",
+			CustomSetValue: func(co *support.ConfigOption) {
+				var accounts []string
+				for _, account := range strings.Split(viper.GetString(co.Name), ",") {
+					if account = strings.TrimSpace(account); account != "" {
+						accounts = append(accounts, account)
+					}
+				}
+				*(co.ConfigKey.(*[]string)) = accounts
+			},
+			Usage: "comma-separated list of accounts, when set (together with --ingest-filter-assets) only transactions involving the given accounts or assets are stored in history tables",
+		},
+		&support.ConfigOption{
+			Name:        "ingest-filter-assets",
+			ConfigKey:   &config.IngestFilterAssets,
+			OptType:     types.String,
+			FlagDefault: "",
+			CustomSetValue: func(co *support.ConfigOption) {
+				assets, err := xdr.BuildAssets(viper.GetString(co.Name))
+				if err != nil {
+					stdLog.Fatalf("Could not parse ingest-filter-assets: %v", err)
+				}
+				*(co.ConfigKey.(*[]xdr.Asset)) = assets
+			},
+			Usage: "comma-separated list of assets (in CODE:ISSUER format or native), when set (together with --ingest-filter-accounts) only transactions involving the given accounts or assets are stored in history tables",
+		},
 		&support.ConfigOption{
 			Name:        "apply-migrations",
 			ConfigKey:   &config.ApplyMigrations,
@@ -0,0 +1,42 @@
+package horizon
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIngestFilterAccountsFlag(t *testing.T) {
+	for _, tc := range []struct {
+		value    string
+		expected []string
+	}{
+		{"", nil},
+		{" , ", nil},
+		{
+			"GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB",
+			[]string{"GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB"},
+		},
+		{
+			" GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB, ,GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H ,",
+			[]string{
+				"GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB",
+				"GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H",
+			},
+		},
+	} {
+		config, flags := Flags()
+		cmd := &cobra.Command{}
+		require.NoError(t, flags.Init(cmd))
+		require.NoError(t, cmd.PersistentFlags().Parse([]string{"--ingest-filter-accounts=" + tc.value}))
+
+		for _, co := range flags {
+			if co.Name == "ingest-filter-accounts" {
+				co.SetValue()
+			}
+		}
+		assert.Equal(t, tc.expected, config.IngestFilterAccounts, "%q", tc.value)
+	}
+}
@@ -41,6 +41,8 @@ type RouterConfig struct {
 	CoreGetter         actions.CoreSettingsGetter
 	HorizonVersion     string
 	FriendbotURL       *url.URL
+	// IngestFilterEnabled is reported in the root resource.
+	IngestFilterEnabled bool
 }
 
 type Router struct {
@@ -104,10 +106,11 @@ func (r *Router) addRoutes(config *RouterConfig, rateLimiter *throttled.HTTPRate
 	}
 
 	r.Method(http.MethodGet, "/", ObjectActionHandler{Action: actions.GetRootHandler{
-		CoreSettingsGetter: config.CoreGetter,
-		NetworkPassphrase:  config.NetworkPassphrase,
-		FriendbotURL:       config.FriendbotURL,
-		HorizonVersion:     config.HorizonVersion,
+		CoreSettingsGetter:  config.CoreGetter,
+		NetworkPassphrase:   config.NetworkPassphrase,
+		FriendbotURL:        config.FriendbotURL,
+		HorizonVersion:      config.HorizonVersion,
+		IngestFilterEnabled: config.IngestFilterEnabled,
 	}})
 
 	streamHandler := sse.StreamHandler{
@@ -2,6 +2,7 @@ package ingest
 
 import (
 	"github.com/stellar/go/ingest/io"
+	"github.com/stellar/go/services/horizon/internal/ingest/processors"
 	"github.com/stellar/go/support/errors"
 )
 
@@ -50,3 +51,26 @@ func (g groupTransactionProcessors) Commit() error {
 	}
 	return nil
 }
+
+// filteredTransactionProcessor passes to the wrapped processor only the
+// transactions matching the filter.
+type filteredTransactionProcessor struct {
+	filter    *processors.TransactionFilter
+	sequence  uint32
+	processor horizonTransactionProcessor
+}
+
+func (f *filteredTransactionProcessor) ProcessTransaction(tx io.LedgerTransaction) error {
+	matches, err := f.filter.MatchesTransaction(f.sequence, tx)
+	if err != nil {
+		return errors.Wrap(err, "error filtering transaction")
+	}
+	if !matches {
+		return nil
+	}
+	return f.processor.ProcessTransaction(tx)
+}
+
+func (f *filteredTransactionProcessor) Commit() error {
+	return f.processor.Commit()
+}
@@ -6,6 +6,9 @@ import (
 	"testing"
 
 	"github.com/stellar/go/ingest/io"
+	"github.com/stellar/go/services/horizon/internal/ingest/processors"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 )
@@ -183,3 +186,39 @@ func (s *GroupTransactionProcessorsTestSuiteLedger) TestCommitSucceeds() {
 	err := s.processors.Commit()
 	s.Assert().NoError(err)
 }
+
+func TestFilteredTransactionProcessor(t *testing.T) {
+	account := "GAUJETIZVEP2NRYLUESJ3LS66NVCEGMON4UDCBCSBEVPIID773P2W6AY"
+	filter, err := processors.NewTransactionFilter([]string{account}, nil)
+	assert.NoError(t, err)
+
+	newTransaction := func(address string) io.LedgerTransaction {
+		source := xdr.MustAddress(address)
+		return io.LedgerTransaction{
+			Envelope: xdr.TransactionEnvelope{
+				Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+				V1: &xdr.TransactionV1Envelope{
+					Tx: xdr.Transaction{SourceAccount: source.ToMuxedAccount()},
+				},
+			},
+			Meta: xdr.TransactionMeta{V: 2, V2: &xdr.TransactionMetaV2{}},
+		}
+	}
+	matching := newTransaction(account)
+	other := newTransaction("GAXI33UCLQTCKM2NMRBS7XYBR535LLEVAHL5YBN4FTCB4HZHT7ZA5CVK")
+
+	wrapped := &mockHorizonTransactionProcessor{}
+	defer wrapped.AssertExpectations(t)
+	wrapped.On("ProcessTransaction", matching).Return(nil).Once()
+	wrapped.On("Commit").Return(nil).Once()
+
+	processor := &filteredTransactionProcessor{
+		filter:    filter,
+		sequence:  20,
+		processor: wrapped,
+	}
+
+	assert.NoError(t, processor.ProcessTransaction(matching))
+	assert.NoError(t, processor.ProcessTransaction(other))
+	assert.NoError(t, processor.Commit())
+}
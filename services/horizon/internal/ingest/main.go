@@ -16,9 +16,11 @@ import (
 	ingesterrors "github.com/stellar/go/ingest/errors"
 	"github.com/stellar/go/ingest/ledgerbackend"
 	"github.com/stellar/go/services/horizon/internal/db2/history"
+	"github.com/stellar/go/services/horizon/internal/ingest/processors"
 	"github.com/stellar/go/support/db"
 	"github.com/stellar/go/support/errors"
 	logpkg "github.com/stellar/go/support/log"
+	"github.com/stellar/go/xdr"
 )
 
 const (
@@ -72,10 +74,21 @@ type Config struct {
 	RemoteCaptiveCoreURL  string
 	NetworkPassphrase     string
 
-	HistorySession           *db.Session
-	HistoryArchiveURL        string
+	HistorySession *db.Session
+	// HistoryArchiveURLs are the archives to read history from. When several
+	// archives are given, reads fail over to the next archive on errors.
+	HistoryArchiveURLs []string
+	// HistoryArchiveQuorum is the number of archives which must agree on the
+	// contents of HAS files and ledger headers. See
+	// historyarchive.MultiArchiveOptions.
+	HistoryArchiveQuorum     int
 	DisableStateVerification bool
 
+	// FilterAccounts and FilterAssets limit the transactions ingested into
+	// history tables, see processors.TransactionFilter.
+	FilterAccounts []string
+	FilterAssets   []xdr.Asset
+
 	MaxReingestRetries          int
 	ReingestRetryBackoffSeconds int
 }
@@ -148,14 +161,25 @@ type system struct {
 }
 
 func NewSystem(config Config) (System, error) {
+	transactionFilter, err := processors.NewTransactionFilter(config.FilterAccounts, config.FilterAssets)
+	if err != nil {
+		return nil, errors.Wrap(err, "error creating transaction filter")
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 
-	archive, err := historyarchive.Connect(
-		config.HistoryArchiveURL,
+	archive, err := historyarchive.ConnectMulti(
+		config.HistoryArchiveURLs,
 		historyarchive.ConnectOptions{
 			Context:           ctx,
 			NetworkPassphrase: config.NetworkPassphrase,
 		},
+		historyarchive.MultiArchiveOptions{
+			Quorum: config.HistoryArchiveQuorum,
+			OnDisagreement: func(archive string, err error) {
+				log.WithField("archive", archive).WithError(err).Warn("History archive failed or disagrees with other archives")
+			},
+		},
 	)
 	if err != nil {
 		cancel()
@@ -176,7 +200,7 @@ func NewSystem(config Config) (System, error) {
 				config.StellarCoreBinaryPath,
 				config.StellarCoreConfigPath,
 				config.NetworkPassphrase,
-				[]string{config.HistoryArchiveURL},
+				config.HistoryArchiveURLs,
 			)
 			if err != nil {
 				cancel()
@@ -217,6 +241,7 @@ func NewSystem(config Config) (System, error) {
 			historyQ:       historyQ,
 			historyAdapter: historyAdapter,
 			ledgerBackend:  ledgerBackend,
+			filter:         transactionFilter,
 		},
 	}
 
@@ -87,7 +87,7 @@ func TestNewSystem(t *testing.T) {
 			Ctx: context.Background(),
 		},
 		DisableStateVerification: true,
-		HistoryArchiveURL:        "https://history.stellar.org/prd/core-live/core_live_001",
+		HistoryArchiveURLs:       []string{"https://history.stellar.org/prd/core-live/core_live_001"},
 	}
 
 	sIface, err := NewSystem(config)
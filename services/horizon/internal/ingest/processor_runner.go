@@ -68,6 +68,7 @@ type ProcessorRunner struct {
 	historyQ       history.IngestionQ
 	historyAdapter adapters.HistoryArchiveAdapterInterface
 	ledgerBackend  ledgerbackend.LedgerBackend
+	filter         *processors.TransactionFilter
 	logMemoryStats bool
 }
 
@@ -118,14 +119,35 @@ func (s *ProcessorRunner) buildTransactionProcessor(
 	}
 
 	sequence := uint32(ledger.Header.LedgerSeq)
+	if !s.filter.Enabled() {
+		return groupTransactionProcessors{
+			statsLedgerTransactionProcessor,
+			processors.NewEffectProcessor(s.historyQ, sequence),
+			processors.NewLedgerProcessor(s.historyQ, ledger, CurrentVersion),
+			processors.NewOperationProcessor(s.historyQ, sequence),
+			processors.NewTradeProcessor(s.historyQ, ledger),
+			processors.NewParticipantsProcessor(s.historyQ, sequence),
+			processors.NewTransactionProcessor(s.historyQ, sequence),
+		}
+	}
+
+	// Ledgers are always ingested so the ledger stats (ex. transaction
+	// counts) stay correct, only the history of matching transactions is
+	// written.
 	return groupTransactionProcessors{
 		statsLedgerTransactionProcessor,
-		processors.NewEffectProcessor(s.historyQ, sequence),
 		processors.NewLedgerProcessor(s.historyQ, ledger, CurrentVersion),
-		processors.NewOperationProcessor(s.historyQ, sequence),
-		processors.NewTradeProcessor(s.historyQ, ledger),
-		processors.NewParticipantsProcessor(s.historyQ, sequence),
-		processors.NewTransactionProcessor(s.historyQ, sequence),
+		&filteredTransactionProcessor{
+			filter:   s.filter,
+			sequence: sequence,
+			processor: groupTransactionProcessors{
+				processors.NewEffectProcessor(s.historyQ, sequence),
+				processors.NewOperationProcessor(s.historyQ, sequence),
+				processors.NewTradeProcessor(s.historyQ, ledger),
+				processors.NewParticipantsProcessor(s.historyQ, sequence),
+				processors.NewTransactionProcessor(s.historyQ, sequence),
+			},
+		},
 	}
 }
 
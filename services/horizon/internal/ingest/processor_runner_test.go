@@ -336,6 +336,46 @@ func TestProcessorRunnerBuildTransactionProcessor(t *testing.T) {
 	assert.IsType(t, &processors.TransactionProcessor{}, processor.(groupTransactionProcessors)[6])
 }
 
+func TestProcessorRunnerBuildFilteredTransactionProcessor(t *testing.T) {
+	maxBatchSize := 100000
+
+	q := &mockDBQ{}
+	defer mock.AssertExpectationsForObjects(t, q)
+
+	q.MockQOperations.On("NewOperationBatchInsertBuilder", maxBatchSize).
+		Return(&history.MockOperationsBatchInsertBuilder{}).Twice() // Twice = with/without failed
+	q.MockQTransactions.On("NewTransactionBatchInsertBuilder", maxBatchSize).
+		Return(&history.MockTransactionsBatchInsertBuilder{}).Twice()
+
+	filter, err := processors.NewTransactionFilter(
+		[]string{"GAUJETIZVEP2NRYLUESJ3LS66NVCEGMON4UDCBCSBEVPIID773P2W6AY"},
+		nil,
+	)
+	assert.NoError(t, err)
+
+	runner := ProcessorRunner{
+		config:   Config{},
+		historyQ: q,
+		filter:   filter,
+	}
+
+	stats := &io.StatsLedgerTransactionProcessor{}
+	ledger := xdr.LedgerHeaderHistoryEntry{}
+	processor := runner.buildTransactionProcessor(stats, ledger)
+	assert.IsType(t, groupTransactionProcessors{}, processor)
+
+	assert.IsType(t, &statsLedgerTransactionProcessor{}, processor.(groupTransactionProcessors)[0])
+	assert.IsType(t, &processors.LedgersProcessor{}, processor.(groupTransactionProcessors)[1])
+	assert.IsType(t, &filteredTransactionProcessor{}, processor.(groupTransactionProcessors)[2])
+
+	filtered := processor.(groupTransactionProcessors)[2].(*filteredTransactionProcessor).processor
+	assert.IsType(t, &processors.EffectProcessor{}, filtered.(groupTransactionProcessors)[0])
+	assert.IsType(t, &processors.OperationProcessor{}, filtered.(groupTransactionProcessors)[1])
+	assert.IsType(t, &processors.TradeProcessor{}, filtered.(groupTransactionProcessors)[2])
+	assert.IsType(t, &processors.ParticipantsProcessor{}, filtered.(groupTransactionProcessors)[3])
+	assert.IsType(t, &processors.TransactionProcessor{}, filtered.(groupTransactionProcessors)[4])
+}
+
 func TestProcessorRunnerRunAllProcessorsOnLedger(t *testing.T) {
 	maxBatchSize := 100000
 
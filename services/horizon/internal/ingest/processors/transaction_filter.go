@@ -0,0 +1,160 @@
+package processors
+
+import (
+	"github.com/stellar/go/ingest/io"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// TransactionFilter decides which transactions are written to the history
+// tables. A transaction matches when one of its participants is in the
+// accounts allowlist or when one of its operations or ledger entry changes
+// involves an asset in the assets allowlist. A nil or empty filter matches
+// every transaction.
+type TransactionFilter struct {
+	accounts map[string]struct{}
+	assets   map[string]struct{}
+}
+
+// NewTransactionFilter returns a TransactionFilter for the given account
+// addresses and assets.
+func NewTransactionFilter(accounts []string, assets []xdr.Asset) (*TransactionFilter, error) {
+	filter := &TransactionFilter{
+		accounts: map[string]struct{}{},
+		assets:   map[string]struct{}{},
+	}
+
+	for _, address := range accounts {
+		if _, err := xdr.AddressToAccountId(address); err != nil {
+			return nil, errors.Wrapf(err, "invalid account in filter: %s", address)
+		}
+		filter.accounts[address] = struct{}{}
+	}
+
+	for _, asset := range assets {
+		filter.assets[asset.String()] = struct{}{}
+	}
+
+	return filter, nil
+}
+
+// Enabled returns true if the filter restricts the ingested transactions.
+func (f *TransactionFilter) Enabled() bool {
+	return f != nil && (len(f.accounts) > 0 || len(f.assets) > 0)
+}
+
+// MatchesTransaction returns true if the history of the transaction should be
+// ingested.
+func (f *TransactionFilter) MatchesTransaction(sequence uint32, transaction io.LedgerTransaction) (bool, error) {
+	if !f.Enabled() {
+		return true, nil
+	}
+
+	if len(f.accounts) > 0 {
+		participants, err := participantsForTransaction(sequence, transaction)
+		if err != nil {
+			return false, errors.Wrap(err, "could not determine transaction participants")
+		}
+
+		for _, participant := range participants {
+			if _, ok := f.accounts[participant.Address()]; ok {
+				return true, nil
+			}
+		}
+	}
+
+	if len(f.assets) > 0 {
+		assets, err := assetsForTransaction(transaction)
+		if err != nil {
+			return false, errors.Wrap(err, "could not determine transaction assets")
+		}
+
+		for _, asset := range assets {
+			if _, ok := f.assets[asset.String()]; ok {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// assetsForTransaction returns the assets referenced by the operations of the
+// transaction and by the trust lines, offers and claimable balances it
+// changed.
+func assetsForTransaction(transaction io.LedgerTransaction) ([]xdr.Asset, error) {
+	var assets []xdr.Asset
+
+	for _, op := range transaction.Envelope.Operations() {
+		source := transaction.Envelope.SourceAccount().ToAccountId()
+		if op.SourceAccount != nil {
+			source = op.SourceAccount.ToAccountId()
+		}
+		assets = append(assets, assetsForOperation(op, source)...)
+	}
+
+	// Legacy meta is not supported by GetChanges, operation bodies are enough
+	// for those transactions.
+	if transaction.Meta.V == 0 {
+		return assets, nil
+	}
+
+	changes, err := transaction.GetChanges()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, change := range changes {
+		for _, entry := range []*xdr.LedgerEntry{change.Pre, change.Post} {
+			if entry == nil {
+				continue
+			}
+			switch entry.Data.Type {
+			case xdr.LedgerEntryTypeTrustline:
+				assets = append(assets, entry.Data.TrustLine.Asset)
+			case xdr.LedgerEntryTypeOffer:
+				assets = append(assets, entry.Data.Offer.Selling, entry.Data.Offer.Buying)
+			case xdr.LedgerEntryTypeClaimableBalance:
+				assets = append(assets, entry.Data.ClaimableBalance.Asset)
+			}
+		}
+	}
+
+	return assets, nil
+}
+
+func assetsForOperation(op xdr.Operation, source xdr.AccountId) []xdr.Asset {
+	var native xdr.Asset
+	native.Type = xdr.AssetTypeAssetTypeNative
+
+	body := op.Body
+	switch body.Type {
+	case xdr.OperationTypeCreateAccount, xdr.OperationTypeAccountMerge, xdr.OperationTypeInflation:
+		return []xdr.Asset{native}
+	case xdr.OperationTypePayment:
+		return []xdr.Asset{body.MustPaymentOp().Asset}
+	case xdr.OperationTypePathPaymentStrictReceive:
+		payment := body.MustPathPaymentStrictReceiveOp()
+		return append([]xdr.Asset{payment.SendAsset, payment.DestAsset}, payment.Path...)
+	case xdr.OperationTypePathPaymentStrictSend:
+		payment := body.MustPathPaymentStrictSendOp()
+		return append([]xdr.Asset{payment.SendAsset, payment.DestAsset}, payment.Path...)
+	case xdr.OperationTypeManageSellOffer:
+		offer := body.MustManageSellOfferOp()
+		return []xdr.Asset{offer.Selling, offer.Buying}
+	case xdr.OperationTypeManageBuyOffer:
+		offer := body.MustManageBuyOfferOp()
+		return []xdr.Asset{offer.Selling, offer.Buying}
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		offer := body.MustCreatePassiveSellOfferOp()
+		return []xdr.Asset{offer.Selling, offer.Buying}
+	case xdr.OperationTypeChangeTrust:
+		return []xdr.Asset{body.MustChangeTrustOp().Line}
+	case xdr.OperationTypeAllowTrust:
+		return []xdr.Asset{body.MustAllowTrustOp().Asset.ToAsset(source)}
+	case xdr.OperationTypeCreateClaimableBalance:
+		return []xdr.Asset{body.MustCreateClaimableBalanceOp().Asset}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,147 @@
+package processors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/stellar/go/xdr"
+)
+
+func TestTransactionFilterDisabled(t *testing.T) {
+	var filter *TransactionFilter
+	assert.False(t, filter.Enabled())
+
+	filter, err := NewTransactionFilter(nil, nil)
+	assert.NoError(t, err)
+	assert.False(t, filter.Enabled())
+
+	matches, err := filter.MatchesTransaction(20, createTransaction(true, 1))
+	assert.NoError(t, err)
+	assert.True(t, matches)
+}
+
+func TestTransactionFilterInvalidAccount(t *testing.T) {
+	_, err := NewTransactionFilter([]string{"GABC"}, nil)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid account in filter: GABC")
+}
+
+func TestTransactionFilterAccounts(t *testing.T) {
+	source := "GAUJETIZVEP2NRYLUESJ3LS66NVCEGMON4UDCBCSBEVPIID773P2W6AY"
+	destination := "GAXI33UCLQTCKM2NMRBS7XYBR535LLEVAHL5YBN4FTCB4HZHT7ZA5CVK"
+	other := "GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"
+
+	tx := createTransaction(true, 1)
+	tx.Envelope.Operations()[0].Body = xdr.OperationBody{
+		Type: xdr.OperationTypeCreateAccount,
+		CreateAccountOp: &xdr.CreateAccountOp{
+			Destination: xdr.MustAddress(destination),
+		},
+	}
+
+	for _, testCase := range []struct {
+		account string
+		matches bool
+	}{
+		{source, true},
+		{destination, true},
+		{other, false},
+	} {
+		filter, err := NewTransactionFilter([]string{testCase.account}, nil)
+		assert.NoError(t, err)
+		assert.True(t, filter.Enabled())
+
+		matches, err := filter.MatchesTransaction(20, tx)
+		assert.NoError(t, err)
+		assert.Equal(t, testCase.matches, matches, testCase.account)
+	}
+}
+
+func TestTransactionFilterAssets(t *testing.T) {
+	issuer := xdr.MustAddress("GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H")
+	usd := xdr.MustNewCreditAsset("USD", issuer.Address())
+	eur := xdr.MustNewCreditAsset("EUR", issuer.Address())
+
+	filter, err := NewTransactionFilter(nil, []xdr.Asset{usd})
+	assert.NoError(t, err)
+
+	payment := createTransaction(true, 1)
+	payment.Envelope.Operations()[0].Body = xdr.OperationBody{
+		Type: xdr.OperationTypePayment,
+		PaymentOp: &xdr.PaymentOp{
+			Destination: issuer.ToMuxedAccount(),
+			Asset:       usd,
+			Amount:      100,
+		},
+	}
+	matches, err := filter.MatchesTransaction(20, payment)
+	assert.NoError(t, err)
+	assert.True(t, matches)
+
+	pathPayment := createTransaction(true, 1)
+	pathPayment.Envelope.Operations()[0].Body = xdr.OperationBody{
+		Type: xdr.OperationTypePathPaymentStrictSend,
+		PathPaymentStrictSendOp: &xdr.PathPaymentStrictSendOp{
+			SendAsset:   xdr.MustNewNativeAsset(),
+			Destination: issuer.ToMuxedAccount(),
+			DestAsset:   eur,
+			Path:        []xdr.Asset{usd},
+		},
+	}
+	matches, err = filter.MatchesTransaction(20, pathPayment)
+	assert.NoError(t, err)
+	assert.True(t, matches)
+
+	allowTrust := createTransaction(true, 1)
+	allowTrust.Envelope.V1.Tx.SourceAccount = issuer.ToMuxedAccount()
+	allowTrust.Envelope.Operations()[0].Body = xdr.OperationBody{
+		Type: xdr.OperationTypeAllowTrust,
+		AllowTrustOp: &xdr.AllowTrustOp{
+			Trustor: xdr.MustAddress("GAXI33UCLQTCKM2NMRBS7XYBR535LLEVAHL5YBN4FTCB4HZHT7ZA5CVK"),
+			Asset:   xdr.MustNewAllowTrustAsset("USD"),
+		},
+	}
+	matches, err = filter.MatchesTransaction(20, allowTrust)
+	assert.NoError(t, err)
+	assert.True(t, matches)
+
+	// The trust line created in meta references the asset even though the
+	// operation body does not.
+	trustLine := createTransaction(true, 1)
+	trustLine.Meta.V2.Operations[0].Changes = xdr.LedgerEntryChanges{
+		{
+			Type: xdr.LedgerEntryChangeTypeLedgerEntryCreated,
+			Created: &xdr.LedgerEntry{
+				Data: xdr.LedgerEntryData{
+					Type: xdr.LedgerEntryTypeTrustline,
+					TrustLine: &xdr.TrustLineEntry{
+						AccountId: issuer,
+						Asset:     usd,
+						Balance:   100,
+					},
+				},
+			},
+		},
+	}
+	matches, err = filter.MatchesTransaction(20, trustLine)
+	assert.NoError(t, err)
+	assert.True(t, matches)
+
+	other := createTransaction(true, 1)
+	other.Envelope.Operations()[0].Body = xdr.OperationBody{
+		Type: xdr.OperationTypePayment,
+		PaymentOp: &xdr.PaymentOp{
+			Destination: issuer.ToMuxedAccount(),
+			Asset:       eur,
+			Amount:      100,
+		},
+	}
+	matches, err = filter.MatchesTransaction(20, other)
+	assert.NoError(t, err)
+	assert.False(t, matches)
+
+	matches, err = filter.MatchesTransaction(20, createTransaction(true, 1))
+	assert.NoError(t, err)
+	assert.False(t, matches)
+}
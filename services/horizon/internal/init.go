@@ -61,11 +61,9 @@ func initExpIngester(app *App) {
 		HistorySession: mustNewDBSession(
 			app.config.DatabaseURL, ingest.MaxDBConnections, ingest.MaxDBConnections,
 		),
-		NetworkPassphrase: app.config.NetworkPassphrase,
-		// TODO:
-		// Use the first archive for now. We don't have a mechanism to
-		// use multiple archives at the same time currently.
-		HistoryArchiveURL:        app.config.HistoryArchiveURLs[0],
+		NetworkPassphrase:        app.config.NetworkPassphrase,
+		HistoryArchiveURLs:       app.config.HistoryArchiveURLs,
+		HistoryArchiveQuorum:     app.config.HistoryArchiveQuorum,
 		StellarCoreURL:           app.config.StellarCoreURL,
 		StellarCoreCursor:        app.config.CursorName,
 		StellarCoreBinaryPath:    app.config.StellarCoreBinaryPath,
@@ -73,6 +71,8 @@ func initExpIngester(app *App) {
 		RemoteCaptiveCoreURL:     app.config.RemoteCaptiveCoreURL,
 		EnableCaptiveCore:        app.config.EnableCaptiveCoreIngestion,
 		DisableStateVerification: app.config.IngestDisableStateVerification,
+		FilterAccounts:           app.config.IngestFilterAccounts,
+		FilterAssets:             app.config.IngestFilterAssets,
 	})
 
 	if err != nil {
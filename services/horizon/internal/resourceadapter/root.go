@@ -20,6 +20,7 @@ func PopulateRoot(
 	currentProtocolVersion int32,
 	coreSupportedProtocolVersion int32,
 	friendBotURL *url.URL,
+	ingestFilterEnabled bool,
 	templates map[string]string,
 ) {
 	dest.IngestSequence = ledgerState.ExpHistoryLatest
@@ -31,6 +32,7 @@ func PopulateRoot(
 	dest.NetworkPassphrase = passphrase
 	dest.CurrentProtocolVersion = currentProtocolVersion
 	dest.CoreSupportedProtocolVersion = coreSupportedProtocolVersion
+	dest.IngestFilterEnabled = ingestFilterEnabled
 
 	lb := hal.LinkBuilder{Base: horizonContext.BaseURL(ctx)}
 	if friendBotURL != nil {
@@ -29,6 +29,7 @@ func TestPopulateRoot(t *testing.T) {
 		100,
 		101,
 		urlMustParse(t, "https://friendbot.example.com"),
+		false,
 		templates,
 	)
 
@@ -39,6 +40,7 @@ func TestPopulateRoot(t *testing.T) {
 	assert.Equal(t, "cVersion", res.StellarCoreVersion)
 	assert.Equal(t, "passphrase", res.NetworkPassphrase)
 	assert.Equal(t, "https://friendbot.example.com/{?addr}", res.Links.Friendbot.Href)
+	assert.False(t, res.IngestFilterEnabled)
 
 	// Without testbot
 	res = &horizon.Root{}
@@ -51,6 +53,7 @@ func TestPopulateRoot(t *testing.T) {
 		100,
 		101,
 		nil,
+		false,
 		templates,
 	)
 
@@ -72,9 +75,11 @@ func TestPopulateRoot(t *testing.T) {
 		100,
 		101,
 		urlMustParse(t, "https://friendbot.example.com"),
+		true,
 		templates,
 	)
 
+	assert.True(t, res.IngestFilterEnabled)
 	assert.Equal(t, templates["accounts"], res.Links.Accounts.Href)
 	assert.Equal(t, "/offers/{offer_id}", res.Links.Offer.Href)
 	assert.Equal(
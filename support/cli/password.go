@@ -0,0 +1,58 @@
+// Package cli contains helpers shared by the command line tools of this
+// repository.
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/howeyc/gopass"
+	"github.com/stellar/go/support/errors"
+)
+
+// PasswordEnv is the environment variable from which the tools read the
+// password of encrypted keyfiles (see keypair.LoadKeyfile), instead of
+// prompting for it.
+const PasswordEnv = "STELLAR_KEY_PASSWORD"
+
+// getPassword reads a password from the terminal without echoing it.
+var getPassword = gopass.GetPasswdMasked
+
+// ReadPassword returns the password of an existing keyfile, read from
+// PasswordEnv or prompted for after writing prompt to prompts.
+func ReadPassword(prompts io.Writer, prompt string) ([]byte, error) {
+	if password := os.Getenv(PasswordEnv); password != "" {
+		return []byte(password), nil
+	}
+
+	fmt.Fprint(prompts, prompt)
+	return getPassword()
+}
+
+// ReadNewPassword returns the password to encrypt a new keyfile with, read
+// from PasswordEnv or prompted for twice.
+func ReadNewPassword(prompts io.Writer) ([]byte, error) {
+	if password := os.Getenv(PasswordEnv); password != "" {
+		return []byte(password), nil
+	}
+
+	fmt.Fprint(prompts, "Password: ")
+	password, err := getPassword()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprint(prompts, "Repeat password: ")
+	repeated, err := getPassword()
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(password, repeated) {
+		return nil, errors.New("passwords do not match")
+	}
+	if len(password) == 0 {
+		return nil, errors.New("password is empty")
+	}
+	return password, nil
+}
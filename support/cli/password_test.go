@@ -0,0 +1,61 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// enterPasswords makes getPassword return the given passwords in order.
+func enterPasswords(t *testing.T, passwords ...string) func() {
+	original := getPassword
+	getPassword = func() ([]byte, error) {
+		require.NotEmpty(t, passwords, "unexpected password prompt")
+		password := passwords[0]
+		passwords = passwords[1:]
+		return []byte(password), nil
+	}
+	return func() { getPassword = original }
+}
+
+func TestReadPassword(t *testing.T) {
+	defer enterPasswords(t, "typed")()
+	var prompts bytes.Buffer
+
+	password, err := ReadPassword(&prompts, "Password of key.json: ")
+	require.NoError(t, err)
+	assert.Equal(t, "typed", string(password))
+	assert.Equal(t, "Password of key.json: ", prompts.String())
+
+	require.NoError(t, os.Setenv(PasswordEnv, "from env"))
+	defer os.Unsetenv(PasswordEnv)
+	prompts.Reset()
+	password, err = ReadPassword(&prompts, "Password of key.json: ")
+	require.NoError(t, err)
+	assert.Equal(t, "from env", string(password))
+	assert.Empty(t, prompts.String())
+}
+
+func TestReadNewPassword(t *testing.T) {
+	defer enterPasswords(t, "secret", "secret", "secret", "typo", "", "")()
+	var prompts bytes.Buffer
+
+	password, err := ReadNewPassword(&prompts)
+	require.NoError(t, err)
+	assert.Equal(t, "secret", string(password))
+	assert.Equal(t, "Password: Repeat password: ", prompts.String())
+
+	_, err = ReadNewPassword(&prompts)
+	assert.EqualError(t, err, "passwords do not match")
+	_, err = ReadNewPassword(&prompts)
+	assert.EqualError(t, err, "password is empty")
+
+	require.NoError(t, os.Setenv(PasswordEnv, "from env"))
+	defer os.Unsetenv(PasswordEnv)
+	password, err = ReadNewPassword(&prompts)
+	require.NoError(t, err)
+	assert.Equal(t, "from env", string(password))
+}
@@ -1,73 +1,73 @@
 package main
 
 import (
-	"context"
-	"flag"
-	"fmt"
-	stdio "io"
-	"log"
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
 
 	"github.com/stellar/go/historyarchive"
-	"github.com/stellar/go/ingest/adapters"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
 )
 
-func main() {
-	ledgerPtr := flag.Uint64("ledger", 0, "`ledger to analyze` (tip: has to be of the form `ledger = 64*n - 1`, where n is > 0)")
-	flag.Parse()
-	var seqNum uint32 = uint32(*ledgerPtr)
+const defaultArchiveURL = "s3://history.stellar.org/prd/core-live/core_live_001/"
 
-	if seqNum == 0 {
-		flag.Usage()
-		return
-	}
-
-	archive, e := archive()
-	if e != nil {
-		panic(e)
-	}
-	haa := adapters.MakeHistoryArchiveAdapter(archive)
-
-	sr, e := haa.GetState(context.Background(), seqNum)
-	if e != nil {
-		panic(e)
-	}
+// CLI params
+var (
+	archiveURL        string
+	s3Region          string
+	networkPassphrase string
+)
 
-	accounts := map[string]bool{}
-	var i uint64 = 0
-	var count uint64 = 0
-	for {
-		le, e := sr.Read()
-		if e != nil {
-			panic(e)
-		}
-		if e == stdio.EOF {
-			log.Printf("total seen %d entries of which %d were accounts", i, count)
-			return
-		}
+var rootCmd = &cobra.Command{
+	Use:   "archive-reader",
+	Short: "archive-reader queries accounts and transactions in a history archive",
+	Long: `archive-reader queries accounts and transactions in a history archive,
+without a database or a stellar-core instance. Results are printed as JSON.`,
+}
 
-		if ae, valid := le.Post.Data.GetAccount(); valid {
-			addr := ae.AccountId.Address()
-			if _, exists := accounts[addr]; exists {
-				log.Fatalf("error, total seen %d entries of which %d were unique accounts; repeated account: %s", i, count, addr)
-			}
+func init() {
+	rootCmd.PersistentFlags().StringVar(&archiveURL, "archive-url", defaultArchiveURL, "URL of the history archive to query")
+	rootCmd.PersistentFlags().StringVar(&s3Region, "s3region", "eu-west-1", "S3 region of the archive, for s3:// URLs")
+	rootCmd.PersistentFlags().StringVar(&networkPassphrase, "network-passphrase", network.PublicNetworkPassphrase, "network passphrase of the archive")
 
-			accounts[addr] = true
-			count += 1
-		}
-		i += 1
+	rootCmd.AddCommand(stateCmd)
+	rootCmd.AddCommand(transactionsCmd)
+}
 
-		if i%1000 == 0 {
-			log.Printf("seen %d entries of which %d were accounts", i, count)
-		}
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		os.Exit(1)
 	}
 }
 
 func archive() (*historyarchive.Archive, error) {
 	return historyarchive.Connect(
-		fmt.Sprintf("s3://history.stellar.org/prd/core-live/core_live_001/"),
+		archiveURL,
 		historyarchive.ConnectOptions{
-			S3Region:         "eu-west-1",
-			UnsignedRequests: true,
+			S3Region:          s3Region,
+			UnsignedRequests:  true,
+			NetworkPassphrase: networkPassphrase,
 		},
 	)
 }
+
+func parseAccount(address string) (xdr.AccountId, error) {
+	if address == "" {
+		return xdr.AccountId{}, errors.New("--account is required")
+	}
+	account, err := xdr.AddressToAccountId(address)
+	if err != nil {
+		return xdr.AccountId{}, errors.Wrapf(err, "invalid account %s", address)
+	}
+	return account, nil
+}
+
+func printJSON(out io.Writer, v interface{}) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+)
+
+const (
+	testAccount    = "GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB"
+	testOther      = "GBRPYHIL2CI3FNQ4BXLFMNDLFJUNPU2HY3ZMFSHONUCEOASW7QC7OX2H"
+	testIssuer     = "GCXI6Q73J7F6EUSBZTPW4G4OUGVDHABPYF2U4KO7MVEX52OH5VMVUCRF"
+	testPassphrase = network.TestNetworkPassphrase
+)
+
+var testBalanceID = xdr.ClaimableBalanceId{
+	Type: xdr.ClaimableBalanceIdTypeClaimableBalanceIdTypeV0,
+	V0:   &xdr.Hash{1, 2, 3},
+}
+
+func liveEntry(data xdr.LedgerEntryData) xdr.BucketEntry {
+	return xdr.BucketEntry{
+		Type:      xdr.BucketEntryTypeLiveentry,
+		LiveEntry: &xdr.LedgerEntry{LastModifiedLedgerSeq: 50, Data: data},
+	}
+}
+
+// testState returns the state of the test archive: entries of testAccount
+// and of testOther.
+func testState() []xdr.BucketEntry {
+	usd := xdr.MustNewCreditAsset("USD", testIssuer)
+	return []xdr.BucketEntry{
+		liveEntry(xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{
+				AccountId:     xdr.MustAddress(testAccount),
+				Balance:       1000000000,
+				SeqNum:        4294967310,
+				NumSubEntries: 3,
+			},
+		}),
+		liveEntry(xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeTrustline,
+			TrustLine: &xdr.TrustLineEntry{
+				AccountId: xdr.MustAddress(testAccount),
+				Asset:     usd,
+				Balance:   25000000,
+				Limit:     1000000000,
+			},
+		}),
+		liveEntry(xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeOffer,
+			Offer: &xdr.OfferEntry{
+				SellerId: xdr.MustAddress(testAccount),
+				OfferId:  7,
+				Selling:  xdr.MustNewNativeAsset(),
+				Buying:   usd,
+				Amount:   50000000,
+				Price:    xdr.Price{N: 1, D: 2},
+			},
+		}),
+		liveEntry(xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeData,
+			Data: &xdr.DataEntry{
+				AccountId: xdr.MustAddress(testAccount),
+				DataName:  "hello",
+				DataValue: []byte("world"),
+			},
+		}),
+		liveEntry(xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeClaimableBalance,
+			ClaimableBalance: &xdr.ClaimableBalanceEntry{
+				BalanceId: testBalanceID,
+				Claimants: []xdr.Claimant{{
+					Type: xdr.ClaimantTypeClaimantTypeV0,
+					V0: &xdr.ClaimantV0{
+						Destination: xdr.MustAddress(testAccount),
+						Predicate:   xdr.ClaimPredicate{Type: xdr.ClaimPredicateTypeClaimPredicateUnconditional},
+					},
+				}},
+				Asset:  xdr.MustNewNativeAsset(),
+				Amount: 30000000,
+			},
+		}),
+		liveEntry(xdr.LedgerEntryData{
+			Type: xdr.LedgerEntryTypeAccount,
+			Account: &xdr.AccountEntry{
+				AccountId: xdr.MustAddress(testOther),
+				Balance:   500000000,
+			},
+		}),
+	}
+}
+
+func testTransaction(source string, seqNum xdr.SequenceNumber, op xdr.OperationBody) xdr.TransactionEnvelope {
+	return xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeTypeEnvelopeTypeTx,
+		V1: &xdr.TransactionV1Envelope{
+			Tx: xdr.Transaction{
+				SourceAccount: xdr.MustMuxedAddress(source),
+				Fee:           100,
+				SeqNum:        seqNum,
+				Operations:    []xdr.Operation{{Body: op}},
+			},
+		},
+	}
+}
+
+// testTransactions returns the transactions of the test archive by ledger.
+func testTransactions() map[uint32]xdr.TransactionEnvelope {
+	return map[uint32]xdr.TransactionEnvelope{
+		// testAccount is the source
+		10: testTransaction(testAccount, 4294967301, xdr.OperationBody{
+			Type:           xdr.OperationTypeBumpSequence,
+			BumpSequenceOp: &xdr.BumpSequenceOp{BumpTo: 4294967310},
+		}),
+		// testAccount is the destination
+		20: testTransaction(testOther, 1, xdr.OperationBody{
+			Type: xdr.OperationTypePayment,
+			PaymentOp: &xdr.PaymentOp{
+				Destination: xdr.MustMuxedAddress(testAccount),
+				Asset:       xdr.MustNewNativeAsset(),
+				Amount:      10000000,
+			},
+		}),
+		// testAccount is not involved
+		30: testTransaction(testOther, 2, xdr.OperationBody{
+			Type:           xdr.OperationTypeBumpSequence,
+			BumpSequenceOp: &xdr.BumpSequenceOp{BumpTo: 10},
+		}),
+	}
+}
+
+func testLedgerCloseMeta(t *testing.T, prev xdr.LedgerHeaderHistoryEntry, bucketListHash xdr.Hash, tx *xdr.TransactionEnvelope) xdr.LedgerCloseMeta {
+	txSet := xdr.TransactionSet{PreviousLedgerHash: prev.Hash}
+	var processing []xdr.TransactionResultMeta
+	if tx != nil {
+		txSet.Txs = []xdr.TransactionEnvelope{*tx}
+		hash, err := network.HashTransactionInEnvelope(*tx, testPassphrase)
+		require.NoError(t, err)
+		processing = []xdr.TransactionResultMeta{{
+			Result: xdr.TransactionResultPair{
+				TransactionHash: hash,
+				Result: xdr.TransactionResult{
+					FeeCharged: 100,
+					Result: xdr.TransactionResultResult{
+						Code:    xdr.TransactionResultCodeTxSuccess,
+						Results: &[]xdr.OperationResult{},
+					},
+				},
+			},
+		}}
+	}
+
+	txSetHash, err := historyarchive.HashTxSet(&txSet)
+	require.NoError(t, err)
+	resultSet := xdr.TransactionResultSet{}
+	for _, tx := range processing {
+		resultSet.Results = append(resultSet.Results, tx.Result)
+	}
+	resultSetHash, err := historyarchive.HashXdr(&resultSet)
+	require.NoError(t, err)
+
+	header := xdr.LedgerHeaderHistoryEntry{
+		Header: xdr.LedgerHeader{
+			LedgerVersion:      15,
+			PreviousLedgerHash: prev.Hash,
+			ScpValue:           xdr.StellarValue{TxSetHash: xdr.Hash(txSetHash)},
+			TxSetResultHash:    xdr.Hash(resultSetHash),
+			BucketListHash:     bucketListHash,
+			LedgerSeq:          prev.Header.LedgerSeq + 1,
+		},
+	}
+	hash, err := historyarchive.HashXdr(&header.Header)
+	require.NoError(t, err)
+	header.Hash = xdr.Hash(hash)
+
+	return xdr.LedgerCloseMeta{
+		V0: &xdr.LedgerCloseMetaV0{
+			LedgerHeader: header,
+			TxSet:        txSet,
+			TxProcessing: processing,
+		},
+	}
+}
+
+// writeTestArchive writes the first checkpoint of a file archive in a
+// temporary directory and returns its URL.
+func writeTestArchive(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "archive-reader")
+	require.NoError(t, err)
+	url := "file://" + dir
+
+	arch, err := historyarchive.Connect(url, historyarchive.ConnectOptions{})
+	require.NoError(t, err)
+
+	var bucketList historyarchive.BucketList
+	writer := historyarchive.NewArchiveWriter(arch, func(uint32) (historyarchive.BucketList, error) {
+		return bucketList, nil
+	}, &historyarchive.CommandOptions{})
+	bucketList[0].Curr, err = writer.PutBucket(testState())
+	require.NoError(t, err)
+
+	txs := testTransactions()
+	prev := xdr.LedgerHeaderHistoryEntry{Header: xdr.LedgerHeader{LedgerSeq: 1}}
+	for seq := uint32(2); seq <= 63; seq++ {
+		var tx *xdr.TransactionEnvelope
+		if envelope, ok := txs[seq]; ok {
+			tx = &envelope
+		}
+		meta := testLedgerCloseMeta(t, prev, bucketList.Hash(), tx)
+		require.NoError(t, writer.AddLedger(meta))
+		prev = meta.V0.LedgerHeader
+	}
+
+	return url, func() { os.RemoveAll(dir) }
+}
+
+// run executes archive-reader with the given arguments and returns its
+// output. Flags are reset first as they are kept between runs.
+func run(args ...string) (string, error) {
+	archiveURL = defaultArchiveURL
+	networkPassphrase = network.PublicNetworkPassphrase
+	stateAccount, stateLedger = "", 0
+	txAccount, txFrom, txTo = "", 0, 0
+
+	var out bytes.Buffer
+	rootCmd.SetOutput(&out)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestArguments(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		err  string
+	}{
+		{[]string{"state"}, "--account is required"},
+		{[]string{"state", "--account", "GABC"}, "invalid account GABC: strkey is 4 bytes long; minimum valid length is 5"},
+		{[]string{"state", "--account", testAccount, "--ledger", "64"}, "ledger 64 is not a checkpoint ledger, try 63 or 127"},
+		{[]string{"transactions"}, "--account is required"},
+		{[]string{"transactions", "--account", testAccount}, "--from is required"},
+		{[]string{"transactions", "--account", testAccount, "--from", "20", "--to", "10"}, "invalid range: --to 10 is lower than --from 20"},
+		{[]string{"transactions", "--account", testAccount, "--from", "x"}, `invalid argument "x" for --from: strconv.ParseUint: parsing "x": invalid syntax`},
+	} {
+		_, err := run(tc.args...)
+		assert.EqualError(t, err, tc.err, "%v", tc.args)
+	}
+}
+
+func TestState(t *testing.T) {
+	url, cleanup := writeTestArchive(t)
+	defer cleanup()
+
+	for _, args := range [][]string{
+		{"state", "--archive-url", url, "--network-passphrase", testPassphrase, "--account", testAccount, "--ledger", "63"},
+		// The latest checkpoint is used by default
+		{"state", "--archive-url", url, "--network-passphrase", testPassphrase, "--account", testAccount},
+	} {
+		out, err := run(args...)
+		require.NoError(t, err)
+
+		var result stateResult
+		require.NoError(t, json.Unmarshal([]byte(out), &result))
+		assert.Equal(t, testAccount, result.Account)
+		assert.Equal(t, uint32(63), result.Ledger)
+
+		byType := map[string]ledgerEntry{}
+		for _, entry := range result.Entries {
+			assert.Equal(t, uint32(50), entry.LastModifiedLedger)
+			var le xdr.LedgerEntry
+			require.NoError(t, xdr.SafeUnmarshalBase64(entry.XDR, &le))
+			entry.XDR = ""
+			byType[entry.Type] = entry
+		}
+		assert.Equal(t, map[string]ledgerEntry{
+			"account": {
+				Type: "account", LastModifiedLedger: 50,
+				Balance: "100.0000000", SeqNum: 4294967310,
+			},
+			"trustline": {
+				Type: "trustline", LastModifiedLedger: 50,
+				Asset: "USD:" + testIssuer, Balance: "2.5000000", Limit: "100.0000000",
+			},
+			"offer": {
+				Type: "offer", LastModifiedLedger: 50,
+				OfferID: 7, Selling: "native", Buying: "USD:" + testIssuer,
+				Amount: "5.0000000", Price: "0.5000000",
+			},
+			"data": {
+				Type: "data", LastModifiedLedger: 50,
+				Name: "hello", Value: []byte("world"),
+			},
+			"claimable_balance": {
+				Type: "claimable_balance", LastModifiedLedger: 50,
+				BalanceID: "00000000" + hex.EncodeToString(testBalanceID.V0[:]),
+				Asset:     "native", Amount: "3.0000000",
+			},
+		}, byType)
+	}
+}
+
+func TestStateOutput(t *testing.T) {
+	url, cleanup := writeTestArchive(t)
+	defer cleanup()
+
+	out, err := run("state", "--archive-url", url, "--network-passphrase", testPassphrase, "--account", testOther)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "account": "`+testOther+`",
+  "ledger": 63,
+  "entries": [
+    {
+      "type": "account",
+      "last_modified_ledger": 50,
+      "balance": "50.0000000",
+      "xdr": "AAAAMgAAAAAAAAAAYvwdC9CRsrYcDdZWNGsqaNfTR8bywsjubQRHAlb8BfcAAAAAHc1lAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+    }
+  ]
+}
+`, out)
+}
+
+func TestTransactions(t *testing.T) {
+	url, cleanup := writeTestArchive(t)
+	defer cleanup()
+
+	out, err := run(
+		"transactions", "--archive-url", url, "--network-passphrase", testPassphrase,
+		"--account", testAccount, "--from", "2", "--to", "63",
+	)
+	require.NoError(t, err)
+
+	var result transactionsResult
+	require.NoError(t, json.Unmarshal([]byte(out), &result))
+	assert.Equal(t, testAccount, result.Account)
+	assert.Equal(t, uint32(2), result.From)
+	assert.Equal(t, uint32(63), result.To)
+
+	txs := testTransactions()
+	require.Len(t, result.Transactions, 2)
+	for i, ledger := range []uint32{10, 20} {
+		tx := result.Transactions[i]
+		expected := txs[ledger]
+		hash, err := network.HashTransactionInEnvelope(expected, testPassphrase)
+		require.NoError(t, err)
+		envelope, err := xdr.MarshalBase64(expected)
+		require.NoError(t, err)
+
+		assert.Equal(t, ledger, tx.Ledger)
+		assert.Equal(t, uint32(1), tx.Index)
+		assert.Equal(t, hex.EncodeToString(hash[:]), tx.Hash)
+		assert.True(t, tx.Successful)
+		assert.Equal(t, int64(100), tx.FeeCharged)
+		assert.Equal(t, envelope, tx.EnvelopeXDR)
+		assert.NotEmpty(t, tx.ResultXDR)
+	}
+
+	// --to defaults to --from
+	out, err = run(
+		"transactions", "--archive-url", url, "--network-passphrase", testPassphrase,
+		"--account", testAccount, "--from", "30",
+	)
+	require.NoError(t, err)
+	assert.Equal(t, `{
+  "account": "`+testAccount+`",
+  "from": 30,
+  "to": 30,
+  "transactions": []
+}
+`, out)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	stdio "io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/historyarchive"
+	"github.com/stellar/go/ingest/io"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	stateAccount string
+	stateLedger  uint32
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "prints the ledger entries of an account at a checkpoint ledger",
+	Long: `prints the ledger entries of an account at a checkpoint ledger: the account
+entry, its trust lines, offers and data entries, and the claimable balances it
+can claim. History archives only contain the state at checkpoint ledgers, of
+the form 64*n - 1.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runState(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	stateCmd.Flags().StringVar(&stateAccount, "account", "", "account to query")
+	stateCmd.Flags().Uint32Var(&stateLedger, "ledger", 0, "checkpoint ledger to query, the latest checkpoint if zero")
+}
+
+type stateResult struct {
+	Account string        `json:"account"`
+	Ledger  uint32        `json:"ledger"`
+	Entries []ledgerEntry `json:"entries"`
+}
+
+type ledgerEntry struct {
+	Type               string `json:"type"`
+	LastModifiedLedger uint32 `json:"last_modified_ledger"`
+	// Fields which depend on the entry type.
+	Balance   string `json:"balance,omitempty"`
+	SeqNum    int64  `json:"sequence,omitempty"`
+	Asset     string `json:"asset,omitempty"`
+	Limit     string `json:"limit,omitempty"`
+	OfferID   int64  `json:"offer_id,omitempty"`
+	Selling   string `json:"selling,omitempty"`
+	Buying    string `json:"buying,omitempty"`
+	Amount    string `json:"amount,omitempty"`
+	Price     string `json:"price,omitempty"`
+	Name      string `json:"name,omitempty"`
+	Value     []byte `json:"value,omitempty"`
+	BalanceID string `json:"balance_id,omitempty"`
+	// XDR is the base64 encoded LedgerEntry.
+	XDR string `json:"xdr"`
+}
+
+func runState(out stdio.Writer) error {
+	account, err := parseAccount(stateAccount)
+	if err != nil {
+		return err
+	}
+
+	arch, err := archive()
+	if err != nil {
+		return errors.Wrap(err, "error connecting to archive")
+	}
+
+	ledger := stateLedger
+	if ledger == 0 {
+		has, err := arch.GetRootHAS()
+		if err != nil {
+			return errors.Wrap(err, "error getting root HAS")
+		}
+		ledger = has.CurrentLedger
+	}
+	if !historyarchive.IsCheckpoint(ledger) {
+		return errors.Errorf(
+			"ledger %d is not a checkpoint ledger, try %d or %d",
+			ledger, historyarchive.PrevCheckpoint(ledger), historyarchive.NextCheckpoint(ledger),
+		)
+	}
+
+	reader, err := io.MakeSingleLedgerStateReader(
+		context.Background(),
+		arch,
+		ledger,
+		io.FilterAccounts(account),
+	)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	result := stateResult{
+		Account: account.Address(),
+		Ledger:  ledger,
+		Entries: []ledgerEntry{},
+	}
+	for {
+		change, err := reader.Read()
+		if err == stdio.EOF {
+			break
+		}
+		if err != nil {
+			return errors.Wrap(err, "error reading state")
+		}
+
+		entry, err := makeLedgerEntry(*change.Post)
+		if err != nil {
+			return err
+		}
+		result.Entries = append(result.Entries, entry)
+	}
+
+	return printJSON(out, result)
+}
+
+func makeLedgerEntry(le xdr.LedgerEntry) (ledgerEntry, error) {
+	raw, err := xdr.MarshalBase64(le)
+	if err != nil {
+		return ledgerEntry{}, errors.Wrap(err, "error encoding ledger entry")
+	}
+	entry := ledgerEntry{
+		LastModifiedLedger: uint32(le.LastModifiedLedgerSeq),
+		XDR:                raw,
+	}
+
+	switch le.Data.Type {
+	case xdr.LedgerEntryTypeAccount:
+		a := le.Data.MustAccount()
+		entry.Type = "account"
+		entry.Balance = amount.String(a.Balance)
+		entry.SeqNum = int64(a.SeqNum)
+	case xdr.LedgerEntryTypeTrustline:
+		tl := le.Data.MustTrustLine()
+		entry.Type = "trustline"
+		entry.Asset = tl.Asset.StringCanonical()
+		entry.Balance = amount.String(tl.Balance)
+		entry.Limit = amount.String(tl.Limit)
+	case xdr.LedgerEntryTypeOffer:
+		o := le.Data.MustOffer()
+		entry.Type = "offer"
+		entry.OfferID = int64(o.OfferId)
+		entry.Selling = o.Selling.StringCanonical()
+		entry.Buying = o.Buying.StringCanonical()
+		entry.Amount = amount.String(o.Amount)
+		entry.Price = o.Price.String()
+	case xdr.LedgerEntryTypeData:
+		d := le.Data.MustData()
+		entry.Type = "data"
+		entry.Name = string(d.DataName)
+		entry.Value = d.DataValue
+	case xdr.LedgerEntryTypeClaimableBalance:
+		cb := le.Data.MustClaimableBalance()
+		entry.Type = "claimable_balance"
+		id, err := cb.BalanceId.MarshalBinary()
+		if err != nil {
+			return ledgerEntry{}, errors.Wrap(err, "error encoding balance id")
+		}
+		entry.BalanceID = hex.EncodeToString(id)
+		entry.Asset = cb.Asset.StringCanonical()
+		entry.Amount = amount.String(cb.Amount)
+	default:
+		return ledgerEntry{}, errors.Errorf("unknown ledger entry type %d", le.Data.Type)
+	}
+	return entry, nil
+}
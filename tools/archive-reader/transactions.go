@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/hex"
+	stdio "io"
+
+	"github.com/spf13/cobra"
+
+	"github.com/stellar/go/ingest/io"
+	"github.com/stellar/go/ingest/ledgerbackend"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+var (
+	txAccount string
+	txFrom    uint32
+	txTo      uint32
+)
+
+var transactionsCmd = &cobra.Command{
+	Use:   "transactions",
+	Short: "prints the transactions involving an account in a range of ledgers",
+	Long: `prints the transactions involving an account in a range of ledgers. History
+archives do not contain transaction meta, so an account is involved in a
+transaction if it is its source or fee source, the source of one of its
+operations, or the destination, trustor, claimant or sponsored account of one
+of its operations.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runTransactions(cmd.OutOrStdout())
+	},
+}
+
+func init() {
+	transactionsCmd.Flags().StringVar(&txAccount, "account", "", "account to query")
+	transactionsCmd.Flags().Uint32Var(&txFrom, "from", 0, "first ledger of the range")
+	transactionsCmd.Flags().Uint32Var(&txTo, "to", 0, "last ledger of the range, the first ledger if zero")
+}
+
+type transactionsResult struct {
+	Account      string        `json:"account"`
+	From         uint32        `json:"from"`
+	To           uint32        `json:"to"`
+	Transactions []transaction `json:"transactions"`
+}
+
+type transaction struct {
+	Ledger      uint32 `json:"ledger"`
+	Index       uint32 `json:"index"`
+	Hash        string `json:"hash"`
+	Successful  bool   `json:"successful"`
+	FeeCharged  int64  `json:"fee_charged"`
+	EnvelopeXDR string `json:"envelope_xdr"`
+	ResultXDR   string `json:"result_xdr"`
+}
+
+func runTransactions(out stdio.Writer) error {
+	account, err := parseAccount(txAccount)
+	if err != nil {
+		return err
+	}
+	if txFrom == 0 {
+		return errors.New("--from is required")
+	}
+	to := txTo
+	if to == 0 {
+		to = txFrom
+	}
+	if to < txFrom {
+		return errors.Errorf("invalid range: --to %d is lower than --from %d", to, txFrom)
+	}
+
+	arch, err := archive()
+	if err != nil {
+		return errors.Wrap(err, "error connecting to archive")
+	}
+	backend := ledgerbackend.NewHistoryArchiveBackendFromArchive(arch)
+	defer backend.Close()
+
+	result := transactionsResult{
+		Account:      account.Address(),
+		From:         txFrom,
+		To:           to,
+		Transactions: []transaction{},
+	}
+	for seq := txFrom; seq <= to; seq++ {
+		txs, err := ledgerTransactions(backend, seq, account.Address())
+		if err != nil {
+			return err
+		}
+		result.Transactions = append(result.Transactions, txs...)
+	}
+
+	return printJSON(out, result)
+}
+
+// ledgerTransactions returns the transactions of the ledger involving the
+// given account.
+func ledgerTransactions(backend ledgerbackend.LedgerBackend, seq uint32, address string) ([]transaction, error) {
+	reader, err := io.NewLedgerTransactionReader(backend, networkPassphrase, seq)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading ledger %d", seq)
+	}
+	defer reader.Close()
+
+	var txs []transaction
+	for {
+		tx, err := reader.Read()
+		if err == stdio.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrapf(err, "error reading transaction in ledger %d", seq)
+		}
+		if !participants(tx.Envelope)[address] {
+			continue
+		}
+
+		envelope, err := xdr.MarshalBase64(tx.Envelope)
+		if err != nil {
+			return nil, errors.Wrap(err, "error encoding envelope")
+		}
+		result, err := xdr.MarshalBase64(tx.Result.Result)
+		if err != nil {
+			return nil, errors.Wrap(err, "error encoding result")
+		}
+		txs = append(txs, transaction{
+			Ledger:      seq,
+			Index:       tx.Index,
+			Hash:        hex.EncodeToString(tx.Result.TransactionHash[:]),
+			Successful:  tx.Result.Result.Successful(),
+			FeeCharged:  int64(tx.Result.Result.FeeCharged),
+			EnvelopeXDR: envelope,
+			ResultXDR:   result,
+		})
+	}
+	return txs, nil
+}
+
+// participants returns the addresses of the accounts found in the envelope.
+func participants(envelope xdr.TransactionEnvelope) map[string]bool {
+	addresses := map[string]bool{}
+	addAccount := func(aid xdr.AccountId) {
+		addresses[aid.Address()] = true
+	}
+	addMuxed := func(m xdr.MuxedAccount) {
+		addAccount(m.ToAccountId())
+	}
+
+	addMuxed(envelope.SourceAccount())
+	if envelope.IsFeeBump() {
+		addMuxed(envelope.FeeBumpAccount())
+	}
+
+	for _, op := range envelope.Operations() {
+		if op.SourceAccount != nil {
+			addMuxed(*op.SourceAccount)
+		}
+
+		switch op.Body.Type {
+		case xdr.OperationTypeCreateAccount:
+			addAccount(op.Body.MustCreateAccountOp().Destination)
+		case xdr.OperationTypePayment:
+			addMuxed(op.Body.MustPaymentOp().Destination)
+		case xdr.OperationTypePathPaymentStrictReceive:
+			addMuxed(op.Body.MustPathPaymentStrictReceiveOp().Destination)
+		case xdr.OperationTypePathPaymentStrictSend:
+			addMuxed(op.Body.MustPathPaymentStrictSendOp().Destination)
+		case xdr.OperationTypeAllowTrust:
+			addAccount(op.Body.MustAllowTrustOp().Trustor)
+		case xdr.OperationTypeAccountMerge:
+			addMuxed(op.Body.MustDestination())
+		case xdr.OperationTypeCreateClaimableBalance:
+			for _, c := range op.Body.MustCreateClaimableBalanceOp().Claimants {
+				addAccount(c.MustV0().Destination)
+			}
+		case xdr.OperationTypeBeginSponsoringFutureReserves:
+			addAccount(op.Body.MustBeginSponsoringFutureReservesOp().SponsoredId)
+		}
+	}
+	return addresses
+}
@@ -40,13 +40,14 @@ func status(a string, opts *Options) {
 }
 
 type Options struct {
-	Low         int
-	High        uint32
-	Last        int
-	Recent      bool
-	Profile     bool
-	CommandOpts historyarchive.CommandOptions
-	ConnectOpts historyarchive.ConnectOptions
+	Low            int
+	High           uint32
+	Last           int
+	Recent         bool
+	Profile        bool
+	StatsRangeSize uint32
+	CommandOpts    historyarchive.CommandOptions
+	ConnectOpts    historyarchive.ConnectOptions
 }
 
 func (opts *Options) SetRange(srcArch *historyarchive.Archive, dstArch *historyarchive.Archive) {
@@ -142,6 +143,40 @@ func repair(src string, dst string, opts *Options) {
 	}
 }
 
+func diff(src string, dst string, opts *Options) {
+	srcArch := historyarchive.MustConnect(src, opts.ConnectOpts)
+	dstArch := historyarchive.MustConnect(dst, opts.ConnectOpts)
+	opts.SetRange(srcArch, dstArch)
+	log.Printf("comparing %v -> %v\n", src, dst)
+	d, e := historyarchive.Diff(srcArch, dstArch, &opts.CommandOpts)
+	if e != nil {
+		log.Fatal(e)
+	}
+	historyarchive.ReportDiff(d, &opts.CommandOpts)
+	if !d.Empty() {
+		os.Exit(1)
+	}
+}
+
+func prune(a string, opts *Options) {
+	arch := historyarchive.MustConnect(a, opts.ConnectOpts)
+	opts.SetRange(arch, nil)
+	e := historyarchive.Prune(arch, &opts.CommandOpts)
+	if e != nil {
+		log.Fatal(e)
+	}
+}
+
+func stats(a string, opts *Options) {
+	arch := historyarchive.MustConnect(a, opts.ConnectOpts)
+	opts.SetRange(arch, nil)
+	s, e := historyarchive.Stats(arch, &opts.CommandOpts, opts.StatsRangeSize)
+	if e != nil {
+		log.Fatal(e)
+	}
+	historyarchive.ReportStats(s)
+}
+
 func main() {
 
 	var opts Options
@@ -233,7 +268,7 @@ func main() {
 		&opts.CommandOpts.Thorough,
 		"thorough",
 		false,
-		"decode and re-encode all buckets",
+		"decode and re-encode all buckets, check bucket list hashes against ledger headers",
 	)
 
 	rootCmd.PersistentFlags().BoolVar(
@@ -284,6 +319,47 @@ func main() {
 		},
 	})
 
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "diff",
+		Short: "list checkpoint files and buckets present in the first archive but not in the second",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.MaybeProfile()
+			src, dst := srcDst(args)
+			diff(src, dst, &opts)
+		},
+	})
+
+	rootCmd.AddCommand(&cobra.Command{
+		Use:   "prune",
+		Short: "remove buckets not referenced by any checkpoint in range (see --dryrun)",
+		Long: `Remove the buckets which are not referenced by the root HAS nor by any
+checkpoint in range (see --dryrun).
+
+Do not prune an archive while checkpoints are being published to it: the
+buckets of a checkpoint are uploaded before its HAS, so they could be removed
+before they are referenced.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.MaybeProfile()
+			prune(firstArg(args), &opts)
+		},
+	})
+
+	statsCmd := &cobra.Command{
+		Use:   "stats",
+		Short: "report size by category, bucket counts and growth per checkpoint range",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts.MaybeProfile()
+			stats(firstArg(args), &opts)
+		},
+	}
+	statsCmd.Flags().Uint32Var(
+		&opts.StatsRangeSize,
+		"range-size",
+		historyarchive.DefaultStatsRangeSize,
+		"number of ledgers in each range of the growth report",
+	)
+	rootCmd.AddCommand(statsCmd)
+
 	rootCmd.AddCommand(&cobra.Command{
 		Use: "dumpxdr",
 		Run: func(cmd *cobra.Command, args []string) {
@@ -3,83 +3,138 @@ package commands
 import (
 	"encoding/hex"
 	"fmt"
-	"regexp"
+	"os"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/stellar/go/exp/crypto/derivation"
 	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/support/errors"
-	"github.com/tyler-smith/go-bip39"
 )
 
-var wordsRegexp = regexp.MustCompile(`^[a-z]+$`)
 var count, startID uint32
+var mnemonicFlag string
+
+// passphraseEnv is the environment variable from which the passphrase of the
+// mnemonic code is read, instead of prompting for it.
+const passphraseEnv = "STELLAR_HD_WALLET_PASSPHRASE"
 
 var allowedNumbers = map[uint32]bool{12: true, 15: true, 18: true, 21: true, 24: true}
 
+type accountOutput struct {
+	Path      string `json:"path"`
+	Index     uint32 `json:"index"`
+	PublicKey string `json:"public_key"`
+	SecretKey string `json:"secret_key"`
+}
+
+type accountsOutput struct {
+	Mnemonic  string          `json:"mnemonic"`
+	Language  string          `json:"language"`
+	BIP39Seed string          `json:"bip39_seed"`
+	Accounts  []accountOutput `json:"accounts"`
+}
+
 var AccountsCmd = &cobra.Command{
 	Use:   "accounts",
 	Short: "Display accounts for a given mnemonic code",
-	Long:  "",
+	Long: `Display accounts for a given mnemonic code, in any of the BIP-39 languages.
+
+The mnemonic is read from the terminal, unless it is given with the
+--mnemonic flag. The passphrase is read from the ` + passphraseEnv + `
+environment variable if it is set, otherwise it is prompted for when the
+mnemonic is read from the terminal.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		printf("How many words? ")
-		wordsCount := readUint()
-		if _, exist := allowedNumbers[wordsCount]; !exist {
-			return errors.New("Invalid value, allowed values: 12, 15, 18, 21, 24")
-		}
+		mnemonic := mnemonicFlag
+		passphrase := os.Getenv(passphraseEnv)
+		if mnemonic == "" {
+			var err error
+			mnemonic, err = readMnemonic()
+			if err != nil {
+				return err
+			}
 
-		words := make([]string, wordsCount)
-		for i := uint32(0); i < wordsCount; i++ {
-			printf("Enter word #%-4d", i+1)
-			words[i] = readString()
-			if !wordsRegexp.MatchString(words[i]) {
-				println("Invalid word, try again.")
-				i--
+			if passphrase == "" {
+				printf("Enter password (leave empty if none): ")
+				passphrase = readString()
 			}
 		}
 
-		printf("Enter password (leave empty if none): ")
-		password := readString()
-
-		mnemonic := strings.Join(words, " ")
-		println("Mnemonic:", mnemonic)
-
-		seed, err := bip39.NewSeedWithErrorChecking(mnemonic, password)
+		language, err := keypair.MnemonicLanguage(mnemonic)
 		if err != nil {
 			return errors.New("Invalid words or checksum")
 		}
 
-		println("BIP39 Seed:", hex.EncodeToString(seed))
+		seed, err := keypair.MnemonicSeed(mnemonic, passphrase)
+		if err != nil {
+			return errors.Wrap(err, "Error deriving seed")
+		}
 
 		masterKey, err := derivation.DeriveForPath(derivation.StellarAccountPrefix, seed)
 		if err != nil {
 			return errors.Wrap(err, "Error deriving master key")
 		}
 
-		println("m/44'/148' key:", hex.EncodeToString(masterKey.Key))
-
-		println("")
-
-		for i := uint32(startID); i < startID+count; i++ {
-			key, err := masterKey.Derive(derivation.FirstHardenedIndex + i)
+		output := accountsOutput{
+			Mnemonic:  mnemonic,
+			Language:  string(language),
+			BIP39Seed: hex.EncodeToString(seed),
+		}
+		for i := startID; i < startID+count; i++ {
+			kp, err := keypair.FromBIP39Seed(seed, i)
 			if err != nil {
-				return errors.Wrap(err, "Error deriving child key")
+				return errors.Wrap(err, "Error deriving key pair")
 			}
 
-			kp, err := keypair.FromRawSeed(key.RawSeed())
-			if err != nil {
-				return errors.Wrap(err, "Error creating key pair")
-			}
+			output.Accounts = append(output.Accounts, accountOutput{
+				Path:      fmt.Sprintf(keypair.SEP5PathFormat, i),
+				Index:     i,
+				PublicKey: kp.Address(),
+				SecretKey: kp.Seed(),
+			})
+		}
+
+		if jsonOutput {
+			return printJSON(output)
+		}
 
-			println(fmt.Sprintf(derivation.StellarAccountPathFormat, i), kp.Address(), kp.Seed())
+		println("Mnemonic:", output.Mnemonic)
+		println("Language:", output.Language)
+		println("BIP39 Seed:", output.BIP39Seed)
+		println("m/44'/148' key:", hex.EncodeToString(masterKey.Key))
+		println("")
+		for _, account := range output.Accounts {
+			println(account.Path, account.PublicKey, account.SecretKey)
 		}
 
 		return nil
 	},
 }
 
+// readMnemonic reads the words of a mnemonic from the terminal, one by one.
+func readMnemonic() (string, error) {
+	printf("How many words? ")
+	wordsCount := readUint()
+	if _, exist := allowedNumbers[wordsCount]; !exist {
+		return "", errors.New("Invalid value, allowed values: 12, 15, 18, 21, 24")
+	}
+
+	words := make([]string, wordsCount)
+	for i := uint32(0); i < wordsCount; i++ {
+		printf("Enter word #%-4d", i+1)
+		words[i] = strings.TrimSpace(readString())
+		if words[i] == "" || len(strings.Fields(words[i])) != 1 {
+			println("Invalid word, try again.")
+			i--
+		}
+	}
+
+	return strings.Join(words, " "), nil
+}
+
 func init() {
 	AccountsCmd.Flags().Uint32VarP(&count, "count", "c", 10, "number of accounts to display")
 	AccountsCmd.Flags().Uint32VarP(&startID, "start", "s", 0, "ID of the first wallet to display")
+	AccountsCmd.Flags().StringVarP(&mnemonicFlag, "mnemonic", "m", "", "mnemonic code to import, instead of entering its words one by one")
+	AccountsCmd.Flags().BoolVar(&jsonOutput, "json", false, "print the accounts as JSON")
 }
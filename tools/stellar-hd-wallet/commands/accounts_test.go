@@ -3,11 +3,14 @@ package commands
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestAccounts(t *testing.T) {
@@ -112,3 +115,70 @@ m/44'/148'/9' GAKFARYSPI33KUJE7HYLT47DCX2PFWJ77W3LZMRBPSGPGYPMSDBE7W7X SALJ5LPBT
 		})
 	}
 }
+
+func TestAccountsFlags(t *testing.T) {
+	mnemonicFlag = "cable spray genius state float twenty onion head street palace net private method loan turn phrase state blanket interest dry amazing dress blast tube"
+	require.NoError(t, os.Setenv(passphraseEnv, "p4ssphr4se"))
+	jsonOutput = true
+	count, startID = 2, 1
+	defer func() {
+		os.Unsetenv(passphraseEnv)
+		mnemonicFlag, jsonOutput = "", false
+		count, startID = 10, 0
+	}()
+	reader = bufio.NewReader(&bytes.Buffer{})
+	out = &bytes.Buffer{}
+
+	err := AccountsCmd.RunE(nil, []string{})
+	require.NoError(t, err)
+
+	var output accountsOutput
+	require.NoError(t, json.Unmarshal(out.(*bytes.Buffer).Bytes(), &output))
+	assert.Equal(t, mnemonicFlag, output.Mnemonic)
+	assert.Equal(t, "english", output.Language)
+	assert.Equal(t, []accountOutput{
+		{
+			Path:      "m/44'/148'/1'",
+			Index:     1,
+			PublicKey: "GDY47CJARRHHL66JH3RJURDYXAMIQ5DMXZLP3TDAUJ6IN2GUOFX4OJOC",
+			SecretKey: "SBQPDFUGLMWJYEYXFRM5TQX3AX2BR47WKI4FDS7EJQUSEUUVY72MZPJF",
+		},
+		{
+			Path:      "m/44'/148'/2'",
+			Index:     2,
+			PublicKey: "GCLAQF5H5LGJ2A6ACOMNEHSWYDJ3VKVBUBHDWFGRBEPAVZ56L4D7JJID",
+			SecretKey: "SAF2LXRW6FOSVQNC4HHIIDURZL4SCGCG7UEGG23ZQG6Q2DKIGMPZV6BZ",
+		},
+	}, output.Accounts)
+
+	mnemonicFlag = "cable spray genius"
+	err = AccountsCmd.RunE(nil, []string{})
+	assert.EqualError(t, err, "Invalid words or checksum")
+}
+
+func TestAccountsMasterKey(t *testing.T) {
+	words := strings.Split("illness spike retreat truth genius clock brain pass fit cave bargain toe", " ")
+	input := fmt.Sprintf("%d\n%s\n\n", len(words), strings.Join(words, "\n"))
+	reader = bufio.NewReader(bytes.NewBufferString(input))
+	out = &bytes.Buffer{}
+
+	err := AccountsCmd.RunE(nil, []string{})
+	require.NoError(t, err)
+	assert.Contains(t, out.(*bytes.Buffer).String(), "\nm/44'/148' key: e0eec84fe165cd427cb7bc9b6cfdef0555aa1cb6f9043ff1fe986c3c8ddd22e3\n\n")
+}
+
+func TestAccountsPassphraseEnv(t *testing.T) {
+	require.NoError(t, os.Setenv(passphraseEnv, "p4ssphr4se"))
+	defer os.Unsetenv(passphraseEnv)
+
+	words := strings.Split("cable spray genius state float twenty onion head street palace net private method loan turn phrase state blanket interest dry amazing dress blast tube", " ")
+	input := fmt.Sprintf("%d\n%s\n", len(words), strings.Join(words, "\n"))
+	reader = bufio.NewReader(bytes.NewBufferString(input))
+	out = &bytes.Buffer{}
+
+	err := AccountsCmd.RunE(nil, []string{})
+	require.NoError(t, err)
+	output := out.(*bytes.Buffer).String()
+	assert.NotContains(t, output, "Enter password")
+	assert.Contains(t, output, "m/44'/148'/0' GDAHPZ2NSYIIHZXM56Y36SBVTV5QKFIZGYMMBHOU53ETUSWTP62B63EQ SAFWTGXVS7ELMNCXELFWCFZOPMHUZ5LXNBGUVRCY3FHLFPXK4QPXYP2X\n")
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"io"
 	"log"
@@ -12,6 +13,7 @@ import (
 
 var reader = bufio.NewReader(os.Stdin)
 var out io.Writer = os.Stdout
+var jsonOutput bool
 
 func readString() string {
 	line, _ := reader.ReadString('\n')
@@ -35,3 +37,9 @@ func printf(format string, a ...interface{}) {
 func println(a ...interface{}) {
 	fmt.Fprintln(out, a...)
 }
+
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
@@ -4,30 +4,43 @@ import (
 	"strings"
 
 	"github.com/spf13/cobra"
+	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/support/errors"
-	"github.com/tyler-smith/go-bip39"
 )
 
-const DefaultEntropySize = 256
+var languageFlag string
+var wordsFlag uint32
+
+type newOutput struct {
+	Mnemonic string `json:"mnemonic"`
+	Language string `json:"language"`
+}
 
 var NewCmd = &cobra.Command{
 	Use:   "new",
 	Short: "Generates a new mnemonic code",
 	Long:  "",
 	RunE: func(cmd *cobra.Command, args []string) error {
-		entropy, err := bip39.NewEntropy(DefaultEntropySize)
-		if err != nil {
-			return errors.Wrap(err, "Error generating entropy")
+		if _, exist := allowedNumbers[wordsFlag]; !exist {
+			return errors.New("Invalid number of words, allowed values: 12, 15, 18, 21, 24")
 		}
+		language := keypair.Language(languageFlag)
 
-		mnemonic, err := bip39.NewMnemonic(entropy)
-		if err != nil {
+		// Every 3 words encode 32 bits of entropy.
+		mnemonic, err := keypair.NewMnemonic(language, int(wordsFlag/3*32))
+		if err == keypair.ErrUnknownLanguage {
+			return errors.Errorf("Unknown language %q, allowed values: %s", languageFlag, languageNames())
+		} else if err != nil {
 			return errors.Wrap(err, "Error generating mnemonic code")
 		}
 
-		words := strings.Split(mnemonic, " ")
+		if jsonOutput {
+			return printJSON(newOutput{Mnemonic: mnemonic, Language: string(language)})
+		}
+
+		words := strings.Fields(mnemonic)
 		for i := 0; i < len(words); i++ {
-			printf("word %02d/24: %10s", i+1, words[i])
+			printf("word %02d/%d: %10s", i+1, len(words), words[i])
 			readString()
 		}
 
@@ -40,3 +53,17 @@ var NewCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func languageNames() string {
+	names := make([]string, len(keypair.Languages))
+	for i, language := range keypair.Languages {
+		names[i] = string(language)
+	}
+	return strings.Join(names, ", ")
+}
+
+func init() {
+	NewCmd.Flags().StringVarP(&languageFlag, "language", "l", string(keypair.English), "language of the mnemonic code: "+languageNames())
+	NewCmd.Flags().Uint32VarP(&wordsFlag, "words", "w", 24, "number of words of the mnemonic code: 12, 15, 18, 21 or 24")
+	NewCmd.Flags().BoolVar(&jsonOutput, "json", false, "print the mnemonic code as JSON")
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	languageFlag, wordsFlag, jsonOutput = "spanish", 12, true
+	defer func() {
+		languageFlag, wordsFlag, jsonOutput = "english", 24, false
+	}()
+	reader = bufio.NewReader(&bytes.Buffer{})
+	out = &bytes.Buffer{}
+
+	err := NewCmd.RunE(nil, []string{})
+	require.NoError(t, err)
+
+	var output newOutput
+	require.NoError(t, json.Unmarshal(out.(*bytes.Buffer).Bytes(), &output))
+	assert.Equal(t, "spanish", output.Language)
+	assert.Len(t, strings.Fields(output.Mnemonic), 12)
+	language, err := keypair.MnemonicLanguage(output.Mnemonic)
+	require.NoError(t, err)
+	assert.Equal(t, keypair.Spanish, language)
+
+	wordsFlag = 13
+	err = NewCmd.RunE(nil, []string{})
+	assert.EqualError(t, err, "Invalid number of words, allowed values: 12, 15, 18, 21, 24")
+
+	languageFlag, wordsFlag = "klingon", 12
+	err = NewCmd.RunE(nil, []string{})
+	assert.EqualError(t, err, `Unknown language "klingon", allowed values: english, japanese, korean, spanish, chinese_simplified, chinese_traditional, french, italian`)
+}
@@ -7,6 +7,7 @@ import (
 
 	"github.com/spf13/cobra"
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/support/cli"
 )
 
 func main() {
@@ -24,6 +25,8 @@ func run(args []string, stdout io.Writer, stderr io.Writer) int {
 
 	outFormat := "{{.PublicKey}}\n{{.SecretKey}}\n"
 	cmd.Flags().StringVarP(&outFormat, "format", "f", outFormat, "Format of output")
+	keyfile := ""
+	cmd.Flags().StringVarP(&keyfile, "keyfile", "k", "", "Write the secret key to this file, encrypted with a password read from "+cli.PasswordEnv+" or prompted for, instead of printing it")
 
 	cmd.RunE = func(cmd *cobra.Command, args []string) error {
 		tmpl, err := template.New("").Parse(outFormat)
@@ -41,6 +44,22 @@ func run(args []string, stdout io.Writer, stderr io.Writer) int {
 			SecretKey: key.Seed(),
 		}
 
+		if keyfile != "" {
+			if !cmd.Flags().Changed("format") {
+				tmpl = template.Must(template.New("").Parse("{{.PublicKey}}\n"))
+			}
+			password, err := cli.ReadNewPassword(stderr)
+			if err != nil {
+				return err
+			}
+			_, err = keypair.SaveKeyfile(keyfile, key, password, keypair.DefaultScryptParams)
+			if err != nil {
+				return err
+			}
+			data.SecretKey = ""
+			data.Keyfile = keyfile
+		}
+
 		err = tmpl.Execute(stdout, data)
 		if err != nil {
 			return err
@@ -59,4 +78,5 @@ func run(args []string, stdout io.Writer, stderr io.Writer) int {
 type outData struct {
 	PublicKey string
 	SecretKey string
+	Keyfile   string
 }
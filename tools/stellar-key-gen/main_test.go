@@ -1,11 +1,15 @@
 package main
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"testing"
 
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/support/cli"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -122,3 +126,43 @@ func TestRun_random(t *testing.T) {
 		})
 	}
 }
+
+func TestRun_keyfile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "stellar-key-gen")
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	keyfile := filepath.Join(dir, "key.json")
+
+	os.Setenv(cli.PasswordEnv, "password")
+	defer os.Unsetenv(cli.PasswordEnv)
+
+	args := []string{"-k", keyfile}
+	stdout := strings.Builder{}
+	stderr := strings.Builder{}
+
+	exitCode := run(args, &stdout, &stderr)
+
+	t.Logf("exit code: %d", exitCode)
+	t.Logf("stdout: %q", stdout.String())
+	t.Logf("stderr: %q", stderr.String())
+
+	// Exit code should be zero for success.
+	assert.Equal(t, 0, exitCode)
+
+	// Stdout should only be the public key, the secret key is in the keyfile.
+	lines := strings.Split(stdout.String(), "\n")
+	if assert.Len(t, lines, 2) {
+		f, err := keypair.LoadKeyfile(keyfile, []byte("password"))
+		if assert.NoError(t, err) {
+			assert.Equal(t, f.Address(), lines[0])
+			assert.Equal(t, "", lines[1])
+		}
+	}
+
+	// Existing keyfiles should not be overwritten.
+	exitCode = run(args, &strings.Builder{}, &stderr)
+	assert.Equal(t, 1, exitCode)
+	assert.Contains(t, stderr.String(), "file exists")
+}
@@ -10,19 +10,23 @@ import (
 	"fmt"
 	"github.com/stellar/go/keypair"
 	"github.com/stellar/go/network"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/howeyc/gopass"
+	"github.com/stellar/go/support/cli"
 	"github.com/stellar/go/txnbuild"
 	"github.com/stellar/go/xdr"
 )
 
 var in *bufio.Reader
 
-var infile = flag.String("infile", "", "transaction envelope")
+var infile = flag.String("infile", "", "transaction envelope (base64 or txrep)")
+var outputTxRep = flag.Bool("txrep", false, "print the signed envelope in txrep format instead of base64")
+var keyfile = flag.String("keyfile", "", "encrypted keyfile holding the seed to sign with, decrypted with a password read from "+cli.PasswordEnv+" or prompted for")
 
 func main() {
 	flag.Parse()
@@ -35,7 +39,7 @@ func main() {
 
 	if *infile == "" {
 		// read envelope
-		env, err = readLine("Enter envelope (base64): ", false)
+		env, err = readEnvelope("Enter envelope (base64 or txrep, end txrep with an empty line): ")
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -57,70 +61,165 @@ func main() {
 	}
 
 	// parse the envelope
-	var txe xdr.TransactionEnvelope
-	err = xdr.SafeUnmarshalBase64(env, &txe)
+	parsed, err := parseEnvelope(env)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Println("")
-	fmt.Println("Transaction Summary:")
-	sourceAccount := txe.SourceAccount().ToAccountId()
-	fmt.Printf("  type: %s\n", txe.Type.String())
-	fmt.Printf("  source: %s\n", sourceAccount.Address())
-	fmt.Printf("  ops: %d\n", len(txe.Operations()))
-	fmt.Printf("  sigs: %d\n", len(txe.Signatures()))
-	if txe.IsFeeBump() {
-		fmt.Printf("  fee bump sigs: %d\n", len(txe.FeeBumpSignatures()))
-	}
-	fmt.Println("")
-
-	// TODO: add operation details
+	printTransaction(os.Stdout, parsed)
 
 	// read seed
-	seed, err := readLine("Enter seed: ", true)
+	kp, err := readKey()
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	// sign the transaction
-	kp, err := keypair.ParseFull(seed)
+	newEnv, err := sign(parsed, kp, *outputTxRep)
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	parsed, err := txnbuild.TransactionFromXDR(env)
-	if err != nil {
-		log.Fatal(err)
-	}
+	fmt.Print("\n==== Result ====\n\n")
+	fmt.Print("```\n")
+	fmt.Println(strings.TrimSuffix(newEnv, "\n"))
+	fmt.Print("```\n")
+
+}
+
+// envelope is implemented by txnbuild.Transaction and
+// txnbuild.FeeBumpTransaction.
+type envelope interface {
+	ToXDR() xdr.TransactionEnvelope
+	TxRep() (string, error)
+	Base64() (string, error)
+}
 
-	var newEnv string
+func toEnvelope(parsed *txnbuild.GenericTransaction) envelope {
 	if tx, ok := parsed.Transaction(); ok {
-		tx, err = tx.Sign(network.PublicNetworkPassphrase, kp)
+		return tx
+	}
+	tx, _ := parsed.FeeBump()
+	return tx
+}
+
+// printTransaction prints a summary of the transaction followed by its txrep.
+// Transactions which cannot be printed in txrep format, such as those with
+// muxed accounts, are printed in base64 instead.
+func printTransaction(w io.Writer, parsed *txnbuild.GenericTransaction) {
+	tx := toEnvelope(parsed)
+	txe := tx.ToXDR()
+	details, err := tx.TxRep()
+	if err != nil {
+		fmt.Fprintf(w, "Cannot print the transaction in txrep format: %v\n", err)
+		details, err = tx.Base64()
 		if err != nil {
-			log.Fatal(err)
+			details = err.Error()
 		}
-		newEnv, err = tx.Base64()
+		details += "\n"
+	}
+
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Transaction Summary:")
+	sourceAccount := txe.SourceAccount().ToAccountId()
+	fmt.Fprintf(w, "  type: %s\n", txe.Type.String())
+	fmt.Fprintf(w, "  source: %s\n", sourceAccount.Address())
+	fmt.Fprintf(w, "  ops: %d\n", len(txe.Operations()))
+	fmt.Fprintf(w, "  sigs: %d\n", len(txe.Signatures()))
+	if txe.IsFeeBump() {
+		fmt.Fprintf(w, "  fee bump sigs: %d\n", len(txe.FeeBumpSignatures()))
+	}
+	fmt.Fprintln(w, "")
+
+	fmt.Fprintln(w, "Transaction Details:")
+	fmt.Fprint(w, "```\n")
+	fmt.Fprint(w, details)
+	fmt.Fprint(w, "```\n")
+	fmt.Fprintln(w, "")
+}
+
+// sign signs the transaction for the public network and returns the signed
+// envelope in base64, or in txrep format if requested. Transactions which
+// cannot be written in txrep format are returned in base64 with a warning.
+func sign(parsed *txnbuild.GenericTransaction, kp *keypair.Full, outputTxRep bool) (string, error) {
+	var signed envelope
+	if tx, ok := parsed.Transaction(); ok {
+		signedTx, err := tx.Sign(network.PublicNetworkPassphrase, kp)
 		if err != nil {
-			log.Fatal(err)
+			return "", err
 		}
+		signed = signedTx
 	} else {
 		tx, _ := parsed.FeeBump()
-		tx, err = tx.Sign(network.PublicNetworkPassphrase, kp)
+		signedTx, err := tx.Sign(network.PublicNetworkPassphrase, kp)
 		if err != nil {
-			log.Fatal(err)
+			return "", err
+		}
+		signed = signedTx
+	}
+
+	if outputTxRep {
+		txrep, err := signed.TxRep()
+		if err == nil {
+			return txrep, nil
 		}
-		newEnv, err = tx.Base64()
+		log.Printf("Cannot write the envelope in txrep format, writing it in base64: %v", err)
+	}
+	return signed.Base64()
+}
+
+// readKey reads the seed to sign with, or decrypts it from the keyfile.
+func readKey() (*keypair.Full, error) {
+	if *keyfile == "" {
+		seed, err := readLine("Enter seed: ", true)
 		if err != nil {
-			log.Fatal(err)
+			return nil, err
 		}
+		return keypair.ParseFull(seed)
 	}
 
-	fmt.Print("\n==== Result ====\n\n")
-	fmt.Print("```\n")
-	fmt.Println(newEnv)
-	fmt.Print("```\n")
+	password, err := cli.ReadPassword(os.Stdout, "Enter password of "+*keyfile+":\n")
+	if err != nil {
+		return nil, err
+	}
+	return keypair.LoadKeyfile(*keyfile, password)
+}
+
+// parseEnvelope parses a base64 encoded or txrep transaction envelope.
+func parseEnvelope(env string) (*txnbuild.GenericTransaction, error) {
+	env = strings.TrimSpace(env)
+	if !strings.HasPrefix(env, "type:") {
+		return txnbuild.TransactionFromXDR(env)
+	}
+	return txnbuild.TransactionFromTxRep(env)
+}
+
+// readEnvelope reads a base64 envelope from a single line, or a txrep
+// envelope until an empty line.
+func readEnvelope(prompt string) (string, error) {
+	first, err := readLine(prompt, false)
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(strings.TrimSpace(first), "type:") {
+		return first, nil
+	}
 
+	lines := []string{first}
+	for {
+		line, err := in.ReadString('\n')
+		if err == io.EOF {
+			lines = append(lines, line)
+			break
+		} else if err != nil {
+			return "", err
+		}
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		lines = append(lines, strings.TrimSuffix(line, "\n"))
+	}
+	return strings.Join(lines, "\n"), nil
 }
 
 func readLine(prompt string, private bool) (string, error) {
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/txnbuild"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func muxed(kp keypair.KP, id uint64) xdr.MuxedAccount {
+	aid := xdr.MustAddress(kp.Address())
+	return xdr.MuxedAccount{
+		Type: xdr.CryptoKeyTypeKeyTypeMuxedEd25519,
+		Med25519: &xdr.MuxedAccountMed25519{
+			Id:      xdr.Uint64(id),
+			Ed25519: *aid.Ed25519,
+		},
+	}
+}
+
+// muxedEnvelope returns a base64 envelope with a muxed source account and a
+// payment to a muxed destination.
+func muxedEnvelope(t *testing.T, source, destination keypair.KP) string {
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: source.Address(), Sequence: 1},
+		Operations: []txnbuild.Operation{
+			&txnbuild.Payment{Destination: destination.Address(), Amount: "10", Asset: txnbuild.NativeAsset{}},
+		},
+		BaseFee:    txnbuild.MinBaseFee,
+		Timebounds: txnbuild.NewInfiniteTimeout(),
+	})
+	require.NoError(t, err)
+
+	txe := tx.ToXDR()
+	txe.V1.Tx.SourceAccount = muxed(source, 1)
+	txe.V1.Tx.Operations[0].Body.PaymentOp.Destination = muxed(destination, 2)
+	env, err := xdr.MarshalBase64(txe)
+	require.NoError(t, err)
+	return env
+}
+
+func TestSignMuxedEnvelope(t *testing.T) {
+	kp := keypair.MustRandom()
+	destination := keypair.MustRandom()
+	env := muxedEnvelope(t, kp, destination)
+
+	parsed, err := parseEnvelope(env)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	printTransaction(&out, parsed)
+	assert.Contains(t, out.String(), "Cannot print the transaction in txrep format")
+	assert.Contains(t, out.String(), "  source: "+kp.Address()+"\n")
+	assert.Contains(t, out.String(), env+"\n")
+
+	for _, outputTxRep := range []bool{false, true} {
+		signedEnv, err := sign(parsed, kp, outputTxRep)
+		require.NoError(t, err)
+
+		var signed xdr.TransactionEnvelope
+		require.NoError(t, xdr.SafeUnmarshalBase64(signedEnv, &signed))
+		assert.Equal(t, muxed(kp, 1), signed.V1.Tx.SourceAccount)
+		assert.Equal(t, muxed(destination, 2), signed.V1.Tx.Operations[0].Body.PaymentOp.Destination)
+		require.Len(t, signed.V1.Signatures, 1)
+
+		hash, err := network.HashTransactionInEnvelope(signed, network.PublicNetworkPassphrase)
+		require.NoError(t, err)
+		assert.NoError(t, kp.Verify(hash[:], signed.V1.Signatures[0].Signature))
+	}
+}
+
+func TestSignTxRep(t *testing.T) {
+	kp := keypair.MustRandom()
+	tx, err := txnbuild.NewTransaction(txnbuild.TransactionParams{
+		SourceAccount: &txnbuild.SimpleAccount{AccountID: kp.Address(), Sequence: 1},
+		Operations:    []txnbuild.Operation{&txnbuild.BumpSequence{BumpTo: 5}},
+		BaseFee:       txnbuild.MinBaseFee,
+		Timebounds:    txnbuild.NewInfiniteTimeout(),
+	})
+	require.NoError(t, err)
+	txrep, err := tx.TxRep()
+	require.NoError(t, err)
+
+	parsed, err := parseEnvelope(txrep)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	printTransaction(&out, parsed)
+	assert.Contains(t, out.String(), "tx.sourceAccount: "+kp.Address()+"\n")
+
+	signedEnv, err := sign(parsed, kp, true)
+	require.NoError(t, err)
+	assert.Contains(t, signedEnv, "signatures.len: 1\n")
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"os"
@@ -8,22 +9,38 @@ import (
 	"strings"
 
 	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/support/cli"
 )
 
 var prefix string
+var keyfile = flag.String("keyfile", "", "write the secret seed to this file, encrypted with a password read from "+cli.PasswordEnv+" or prompted for, instead of printing it")
 
 const alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
 
 func main() {
-
-	if len(os.Args) != 2 {
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 1 {
 		usage()
 		os.Exit(1)
 	}
 
-	prefix = strings.ToUpper(os.Args[1])
+	prefix = strings.ToUpper(flag.Arg(0))
 	checkPlausible()
 
+	// The password is read before searching, which can take a long time.
+	var password []byte
+	if *keyfile != "" {
+		if _, err := os.Stat(*keyfile); err == nil {
+			log.Fatalf("%s already exists", *keyfile)
+		}
+		var err error
+		password, err = cli.ReadNewPassword(os.Stdout)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
 	for {
 		kp, err := keypair.Random()
 
@@ -36,7 +53,15 @@ func main() {
 		// character prefix.
 		if strings.HasPrefix(kp.Address()[2:], prefix) {
 			fmt.Println("Found!")
-			fmt.Printf("Secret seed: %s\n", kp.Seed())
+			if *keyfile != "" {
+				_, err = keypair.SaveKeyfile(*keyfile, kp, password, keypair.DefaultScryptParams)
+				if err != nil {
+					log.Fatal(err)
+				}
+				fmt.Printf("Secret seed: encrypted in %s\n", *keyfile)
+			} else {
+				fmt.Printf("Secret seed: %s\n", kp.Seed())
+			}
 			fmt.Printf("Public: %s\n", kp.Address())
 			os.Exit(0)
 		}
@@ -44,7 +69,7 @@ func main() {
 }
 
 func usage() {
-	fmt.Printf("Usage:\n\tstellar-vanity-gen PREFIX\n")
+	fmt.Printf("Usage:\n\tstellar-vanity-gen [-keyfile FILE] PREFIX\n")
 }
 
 // aborts the attempt if a desired character is not a valid base32 digit
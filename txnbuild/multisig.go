@@ -0,0 +1,388 @@
+package txnbuild
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"sort"
+
+	"github.com/stellar/go/keypair"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// ThresholdCategory is the threshold an operation needs the signatures of
+// its source account to meet. See
+// https://developers.stellar.org/docs/glossary/multisig/#thresholds.
+type ThresholdCategory int
+
+// Threshold categories, from lowest to highest.
+const (
+	ThresholdCategoryLow ThresholdCategory = iota
+	ThresholdCategoryMedium
+	ThresholdCategoryHigh
+)
+
+// String returns the name of the threshold category.
+func (c ThresholdCategory) String() string {
+	switch c {
+	case ThresholdCategoryLow:
+		return "low"
+	case ThresholdCategoryMedium:
+		return "medium"
+	case ThresholdCategoryHigh:
+		return "high"
+	}
+	return "unknown"
+}
+
+// maxSignerWeight is the maximum weight a signer contributes to a threshold.
+const maxSignerWeight = 255
+
+// AccountSigners describes the signers and thresholds of an account, as
+// returned by Horizon's account endpoint. The master key must be included in
+// Signers with its weight.
+type AccountSigners struct {
+	Signers         SignerSummary
+	LowThreshold    Threshold
+	MediumThreshold Threshold
+	HighThreshold   Threshold
+}
+
+// Threshold returns the threshold of the account for the given category.
+func (a AccountSigners) Threshold(category ThresholdCategory) Threshold {
+	switch category {
+	case ThresholdCategoryLow:
+		return a.LowThreshold
+	case ThresholdCategoryMedium:
+		return a.MediumThreshold
+	default:
+		return a.HighThreshold
+	}
+}
+
+// OperationThreshold returns the threshold category required by an
+// operation, following the rules of Stellar Core.
+func OperationThreshold(op Operation) ThresholdCategory {
+	switch op := op.(type) {
+	case *AllowTrust, *BumpSequence, *Inflation, *ClaimClaimableBalance:
+		return ThresholdCategoryLow
+	case *AccountMerge:
+		return ThresholdCategoryHigh
+	case *SetOptions:
+		if op.MasterWeight != nil || op.LowThreshold != nil || op.MediumThreshold != nil ||
+			op.HighThreshold != nil || op.Signer != nil {
+			return ThresholdCategoryHigh
+		}
+	}
+	return ThresholdCategoryMedium
+}
+
+// RequiredThresholds returns the accounts which must sign the transaction
+// and the highest threshold category each of them must meet. The source
+// account of the transaction must meet at least the low threshold.
+func (t *Transaction) RequiredThresholds() map[string]ThresholdCategory {
+	required := map[string]ThresholdCategory{
+		t.sourceAccount.AccountID: ThresholdCategoryLow,
+	}
+	for _, op := range t.operations {
+		accountID := t.sourceAccount.AccountID
+		if source := op.GetSourceAccount(); source != nil {
+			accountID = source.GetAccountID()
+		}
+		category := OperationThreshold(op)
+		if current, ok := required[accountID]; !ok || category > current {
+			required[accountID] = category
+		}
+	}
+	return required
+}
+
+// RequiredThresholds returns the accounts which must sign the fee bump
+// transaction, which is only its fee account with the low threshold. The
+// accounts required by the inner transaction are returned by its
+// RequiredThresholds method.
+func (t *FeeBumpTransaction) RequiredThresholds() map[string]ThresholdCategory {
+	return map[string]ThresholdCategory{t.feeAccount: ThresholdCategoryLow}
+}
+
+// AccountSignatureStatus describes the signatures of a transaction for one
+// of the accounts which must sign it.
+type AccountSignatureStatus struct {
+	AccountID string
+	// Category is the threshold category the account must meet.
+	Category ThresholdCategory
+	// Threshold is the threshold of the account for Category.
+	Threshold Threshold
+	// Weight is the total weight of the signers with a valid signature.
+	Weight int32
+	// Signed lists the signers with a valid signature. Pre-authorized
+	// transaction signers matching the transaction are included.
+	Signed []string
+	// Missing lists the signers without a signature.
+	Missing []string
+}
+
+// Satisfied returns true if the signatures meet the threshold of the
+// account. At least one signature is needed even if the threshold is 0.
+func (s AccountSignatureStatus) Satisfied() bool {
+	needed := int32(s.Threshold)
+	if needed == 0 {
+		needed = 1
+	}
+	return s.Weight >= needed
+}
+
+// SignatureStatus describes the signatures of a transaction.
+type SignatureStatus struct {
+	// Accounts lists the accounts which must sign the transaction, sorted
+	// in the order they appear in the transaction.
+	Accounts []AccountSignatureStatus
+	// ExtraSignatures lists the signatures which do not match a signer of
+	// the accounts. The network rejects transactions with extra signatures.
+	ExtraSignatures []xdr.DecoratedSignature
+	// Inner is the status of the inner transaction of a fee bump
+	// transaction.
+	Inner *SignatureStatus
+}
+
+// Ready returns true if the transaction has all the signatures it needs and
+// no extra signatures, and can be submitted.
+func (s SignatureStatus) Ready() bool {
+	for _, account := range s.Accounts {
+		if !account.Satisfied() {
+			return false
+		}
+	}
+	if len(s.ExtraSignatures) > 0 {
+		return false
+	}
+	return s.Inner == nil || s.Inner.Ready()
+}
+
+// SignatureStatus returns the signatures of the transaction which are valid,
+// missing and extra, given the signers and thresholds of every account
+// returned by RequiredThresholds.
+func (t *Transaction) SignatureStatus(network string, accounts map[string]AccountSigners) (SignatureStatus, error) {
+	hash, err := t.Hash(network)
+	if err != nil {
+		return SignatureStatus{}, errors.Wrap(err, "failed to hash transaction")
+	}
+
+	order := []string{t.sourceAccount.AccountID}
+	for _, op := range t.operations {
+		if source := op.GetSourceAccount(); source != nil {
+			order = append(order, source.GetAccountID())
+		}
+	}
+	return signatureStatus(hash, t.signatures, order, t.RequiredThresholds(), accounts)
+}
+
+// SignatureStatus returns the signatures of the fee bump transaction and of
+// its inner transaction which are valid, missing and extra, given the
+// signers and thresholds of the fee account and of every account required
+// by the inner transaction.
+func (t *FeeBumpTransaction) SignatureStatus(network string, accounts map[string]AccountSigners) (SignatureStatus, error) {
+	hash, err := t.Hash(network)
+	if err != nil {
+		return SignatureStatus{}, errors.Wrap(err, "failed to hash transaction")
+	}
+
+	status, err := signatureStatus(hash, t.signatures, []string{t.feeAccount}, t.RequiredThresholds(), accounts)
+	if err != nil {
+		return SignatureStatus{}, err
+	}
+	inner, err := t.inner.SignatureStatus(network, accounts)
+	if err != nil {
+		return SignatureStatus{}, errors.Wrap(err, "inner transaction")
+	}
+	status.Inner = &inner
+	return status, nil
+}
+
+func signatureStatus(
+	hash [32]byte,
+	signatures []xdr.DecoratedSignature,
+	order []string,
+	required map[string]ThresholdCategory,
+	accounts map[string]AccountSigners,
+) (SignatureStatus, error) {
+	var status SignatureStatus
+	used := make([]bool, len(signatures))
+	seen := map[string]bool{}
+	for _, accountID := range order {
+		if seen[accountID] {
+			continue
+		}
+		seen[accountID] = true
+
+		account, ok := accounts[accountID]
+		if !ok {
+			return SignatureStatus{}, errors.Errorf("missing signers of account %s", accountID)
+		}
+		category := required[accountID]
+		accountStatus := AccountSignatureStatus{
+			AccountID: accountID,
+			Category:  category,
+			Threshold: account.Threshold(category),
+		}
+		for _, signer := range sortedSigners(account.Signers) {
+			weight := account.Signers[signer]
+			if weight <= 0 {
+				continue
+			}
+			signed, err := signedBy(hash, signatures, used, signer)
+			if err != nil {
+				return SignatureStatus{}, errors.Wrapf(err, "invalid signer %s of account %s", signer, accountID)
+			}
+			if !signed {
+				accountStatus.Missing = append(accountStatus.Missing, signer)
+				continue
+			}
+			if weight > maxSignerWeight {
+				weight = maxSignerWeight
+			}
+			accountStatus.Weight += weight
+			accountStatus.Signed = append(accountStatus.Signed, signer)
+		}
+		status.Accounts = append(status.Accounts, accountStatus)
+	}
+
+	for i, signature := range signatures {
+		if !used[i] {
+			status.ExtraSignatures = append(status.ExtraSignatures, signature)
+		}
+	}
+	return status, nil
+}
+
+// signedBy returns true if one of the signatures is valid for the signer,
+// marking the signatures it matched as used. A signature can be used by
+// several signers, like the network does.
+func signedBy(hash [32]byte, signatures []xdr.DecoratedSignature, used []bool, signer string) (bool, error) {
+	version, err := strkey.Version(signer)
+	if err != nil {
+		return false, err
+	}
+	switch version {
+	case strkey.VersionByteAccountID:
+		kp, err := keypair.ParseAddress(signer)
+		if err != nil {
+			return false, err
+		}
+		hint := kp.Hint()
+		signed := false
+		for i, signature := range signatures {
+			if signature.Hint == hint && kp.Verify(hash[:], signature.Signature) == nil {
+				used[i] = true
+				signed = true
+			}
+		}
+		return signed, nil
+	case strkey.VersionByteHashTx:
+		preAuthHash, err := strkey.Decode(version, signer)
+		if err != nil {
+			return false, err
+		}
+		return bytes.Equal(preAuthHash, hash[:]), nil
+	case strkey.VersionByteHashX:
+		hashX, err := strkey.Decode(version, signer)
+		if err != nil {
+			return false, err
+		}
+		signed := false
+		for i, signature := range signatures {
+			if !bytes.Equal(signature.Hint[:], hashX[len(hashX)-4:]) {
+				continue
+			}
+			if preimageHash := sha256.Sum256(signature.Signature); bytes.Equal(preimageHash[:], hashX) {
+				used[i] = true
+				signed = true
+			}
+		}
+		return signed, nil
+	}
+	return false, errors.Errorf("unsupported signer type %v", version)
+}
+
+// sortedSigners returns the signers in a stable order.
+func sortedSigners(signers SignerSummary) []string {
+	keys := make([]string, 0, len(signers))
+	for signer := range signers {
+		keys = append(keys, signer)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MergeSignatures returns a new Transaction instance with the signatures of
+// the current instance and of the given copies of the same transaction.
+// Duplicate signatures are only kept once.
+func (t *Transaction) MergeSignatures(others ...*Transaction) (*Transaction, error) {
+	body, err := marshallBinary(t.envelope, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode transaction")
+	}
+
+	signatures := mergeSignatures(nil, t.signatures)
+	for i, other := range others {
+		otherBody, err := marshallBinary(other.envelope, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode transaction")
+		}
+		if !bytes.Equal(body, otherBody) {
+			return nil, errors.Errorf("transaction %d is not a copy of the transaction", i)
+		}
+		signatures = mergeSignatures(signatures, other.signatures)
+	}
+
+	newTx := new(Transaction)
+	*newTx = *t
+	newTx.signatures = signatures
+	return newTx, nil
+}
+
+// MergeSignatures returns a new FeeBumpTransaction instance with the
+// signatures of the current instance and of the given copies of the same
+// fee bump transaction. The inner transactions must be identical, including
+// their signatures, because they are part of the signed fee bump
+// transaction.
+func (t *FeeBumpTransaction) MergeSignatures(others ...*FeeBumpTransaction) (*FeeBumpTransaction, error) {
+	body, err := marshallBinary(t.envelope, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to encode transaction")
+	}
+
+	signatures := mergeSignatures(nil, t.signatures)
+	for i, other := range others {
+		otherBody, err := marshallBinary(other.envelope, nil)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to encode transaction")
+		}
+		if !bytes.Equal(body, otherBody) {
+			return nil, errors.Errorf("transaction %d is not a copy of the transaction", i)
+		}
+		signatures = mergeSignatures(signatures, other.signatures)
+	}
+
+	newTx := new(FeeBumpTransaction)
+	*newTx = *t
+	newTx.signatures = signatures
+	return newTx, nil
+}
+
+func mergeSignatures(signatures, others []xdr.DecoratedSignature) []xdr.DecoratedSignature {
+	for _, other := range others {
+		duplicate := false
+		for _, signature := range signatures {
+			if signature.Hint == other.Hint && bytes.Equal(signature.Signature, other.Signature) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			signatures = append(signatures, other)
+		}
+	}
+	return signatures
+}
@@ -0,0 +1,235 @@
+package txnbuild
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperationThreshold(t *testing.T) {
+	weight := Threshold(1)
+	for _, testCase := range []struct {
+		op       Operation
+		expected ThresholdCategory
+	}{
+		{&AllowTrust{}, ThresholdCategoryLow},
+		{&BumpSequence{}, ThresholdCategoryLow},
+		{&Inflation{}, ThresholdCategoryLow},
+		{&ClaimClaimableBalance{}, ThresholdCategoryLow},
+		{&Payment{}, ThresholdCategoryMedium},
+		{&ManageData{}, ThresholdCategoryMedium},
+		{&SetOptions{SetFlags: []AccountFlag{AuthRequired}}, ThresholdCategoryMedium},
+		{&SetOptions{MasterWeight: &weight}, ThresholdCategoryHigh},
+		{&SetOptions{Signer: &Signer{}}, ThresholdCategoryHigh},
+		{&AccountMerge{}, ThresholdCategoryHigh},
+	} {
+		assert.Equal(t, testCase.expected, OperationThreshold(testCase.op), "%T", testCase.op)
+	}
+}
+
+func newMultisigTransaction(t *testing.T) *Transaction {
+	kp0, kp1 := newKeypair0(), newKeypair1()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 1)
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations: []Operation{
+				&Payment{Destination: kp1.Address(), Amount: "10", Asset: NativeAsset{}},
+				&BumpSequence{BumpTo: 10, SourceAccount: &SimpleAccount{AccountID: kp1.Address()}},
+			},
+			BaseFee:    MinBaseFee,
+			Timebounds: NewInfiniteTimeout(),
+		},
+	)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestSignatureStatus(t *testing.T) {
+	kp0, kp1, kp2 := newKeypair0(), newKeypair1(), newKeypair2()
+	tx := newMultisigTransaction(t)
+
+	assert.Equal(t, map[string]ThresholdCategory{
+		kp0.Address(): ThresholdCategoryMedium,
+		kp1.Address(): ThresholdCategoryLow,
+	}, tx.RequiredThresholds())
+
+	accounts := map[string]AccountSigners{
+		kp0.Address(): {
+			Signers:         SignerSummary{kp0.Address(): 1, kp2.Address(): 1},
+			LowThreshold:    1,
+			MediumThreshold: 2,
+			HighThreshold:   3,
+		},
+		kp1.Address(): {
+			Signers: SignerSummary{kp1.Address(): 1},
+		},
+	}
+
+	status, err := tx.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.False(t, status.Ready())
+	assert.Equal(t, []AccountSignatureStatus{
+		{
+			AccountID: kp0.Address(),
+			Category:  ThresholdCategoryMedium,
+			Threshold: 2,
+			Missing:   sortedSigners(accounts[kp0.Address()].Signers),
+		},
+		{
+			AccountID: kp1.Address(),
+			Category:  ThresholdCategoryLow,
+			Missing:   []string{kp1.Address()},
+		},
+	}, status.Accounts)
+
+	signed0, err := tx.Sign(network.TestNetworkPassphrase, kp0)
+	require.NoError(t, err)
+	status, err = signed0.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.False(t, status.Ready())
+	assert.Equal(t, int32(1), status.Accounts[0].Weight)
+	assert.Equal(t, []string{kp0.Address()}, status.Accounts[0].Signed)
+	assert.Equal(t, []string{kp2.Address()}, status.Accounts[0].Missing)
+	assert.False(t, status.Accounts[0].Satisfied())
+	assert.False(t, status.Accounts[1].Satisfied())
+
+	// A signature for another network does not count and is extra.
+	wrongNetwork, err := tx.Sign(network.PublicNetworkPassphrase, kp2)
+	require.NoError(t, err)
+	status, err = wrongNetwork.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.Len(t, status.ExtraSignatures, 1)
+	assert.Equal(t, int32(0), status.Accounts[0].Weight)
+
+	signed12, err := tx.Sign(network.TestNetworkPassphrase, kp1, kp2)
+	require.NoError(t, err)
+	merged, err := signed0.MergeSignatures(signed12, signed0)
+	require.NoError(t, err)
+	assert.Len(t, merged.Signatures(), 3)
+	assert.Len(t, signed0.Signatures(), 1)
+
+	status, err = merged.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.True(t, status.Ready())
+	assert.Empty(t, status.ExtraSignatures)
+	assert.Equal(t, int32(2), status.Accounts[0].Weight)
+	assert.True(t, status.Accounts[1].Satisfied())
+
+	// An unrelated signature makes the transaction invalid.
+	extra, err := merged.Sign(network.TestNetworkPassphrase, newKeypair("SBZVMB74Z76QZ3ZOY7UTDFYKMEGKW5XFJEB6PFKBF4UYSSWHG4EDH7PY"))
+	require.NoError(t, err)
+	extra, err = extra.SignHashX([]byte("preimage"))
+	require.NoError(t, err)
+	status, err = extra.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.Len(t, status.ExtraSignatures, 1)
+	assert.False(t, status.Ready())
+
+	delete(accounts, kp1.Address())
+	_, err = merged.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	assert.EqualError(t, err, "missing signers of account "+kp1.Address())
+}
+
+func TestSignatureStatusPreAuthAndHashX(t *testing.T) {
+	kp0, kp1 := newKeypair0(), newKeypair1()
+	tx := newMultisigTransaction(t)
+	hash, err := tx.Hash(network.TestNetworkPassphrase)
+	require.NoError(t, err)
+	preAuth, err := strkey.Encode(strkey.VersionByteHashTx, hash[:])
+	require.NoError(t, err)
+	preimage := []byte("secret")
+	preimageHash := sha256.Sum256(preimage)
+	hashX, err := strkey.Encode(strkey.VersionByteHashX, preimageHash[:])
+	require.NoError(t, err)
+
+	accounts := map[string]AccountSigners{
+		kp0.Address(): {
+			Signers:         SignerSummary{kp0.Address(): 0, preAuth: 1},
+			MediumThreshold: 1,
+		},
+		kp1.Address(): {
+			Signers: SignerSummary{kp1.Address(): 300, hashX: 1},
+		},
+	}
+
+	signed, err := tx.SignHashX(preimage)
+	require.NoError(t, err)
+	status, err := signed.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.True(t, status.Ready())
+	assert.Equal(t, []string{preAuth}, status.Accounts[0].Signed)
+	assert.Empty(t, status.Accounts[0].Missing)
+	assert.Equal(t, []string{hashX}, status.Accounts[1].Signed)
+
+	signed, err = signed.Sign(network.TestNetworkPassphrase, kp1)
+	require.NoError(t, err)
+	status, err = signed.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.Equal(t, int32(256), status.Accounts[1].Weight)
+}
+
+func TestFeeBumpSignatureStatus(t *testing.T) {
+	kp0, kp1, kp2 := newKeypair0(), newKeypair1(), newKeypair2()
+	inner, err := newMultisigTransaction(t).Sign(network.TestNetworkPassphrase, kp0, kp1)
+	require.NoError(t, err)
+	feeBump, err := NewFeeBumpTransaction(
+		FeeBumpTransactionParams{Inner: inner, FeeAccount: kp2.Address(), BaseFee: 2 * MinBaseFee},
+	)
+	require.NoError(t, err)
+
+	accounts := map[string]AccountSigners{
+		kp0.Address(): {Signers: SignerSummary{kp0.Address(): 1}},
+		kp1.Address(): {Signers: SignerSummary{kp1.Address(): 1}},
+		kp2.Address(): {Signers: SignerSummary{kp2.Address(): 1}},
+	}
+	status, err := feeBump.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.False(t, status.Ready())
+	require.NotNil(t, status.Inner)
+	assert.True(t, status.Inner.Ready())
+	assert.Equal(t, []string{kp2.Address()}, status.Accounts[0].Missing)
+
+	signed, err := feeBump.Sign(network.TestNetworkPassphrase, kp2)
+	require.NoError(t, err)
+	merged, err := feeBump.MergeSignatures(signed)
+	require.NoError(t, err)
+	status, err = merged.SignatureStatus(network.TestNetworkPassphrase, accounts)
+	require.NoError(t, err)
+	assert.True(t, status.Ready())
+
+	// Fee bumps of different inner signatures are different transactions.
+	otherInner, err := inner.Sign(network.TestNetworkPassphrase, kp2)
+	require.NoError(t, err)
+	other, err := NewFeeBumpTransaction(
+		FeeBumpTransactionParams{Inner: otherInner, FeeAccount: kp2.Address(), BaseFee: 2 * MinBaseFee},
+	)
+	require.NoError(t, err)
+	_, err = feeBump.MergeSignatures(other)
+	assert.EqualError(t, err, "transaction 0 is not a copy of the transaction")
+}
+
+func TestMergeSignaturesDifferentTransactions(t *testing.T) {
+	tx := newMultisigTransaction(t)
+	kp0 := newKeypair0()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 2)
+	other, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations:    []Operation{&BumpSequence{BumpTo: 10}},
+			BaseFee:       MinBaseFee,
+			Timebounds:    NewInfiniteTimeout(),
+		},
+	)
+	require.NoError(t, err)
+	_, err = tx.MergeSignatures(other)
+	assert.EqualError(t, err, "transaction 0 is not a copy of the transaction")
+
+	var signature xdr.DecoratedSignature
+	assert.Len(t, mergeSignatures([]xdr.DecoratedSignature{signature}, []xdr.DecoratedSignature{signature}), 1)
+}
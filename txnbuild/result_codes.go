@@ -0,0 +1,523 @@
+package txnbuild
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// FailureClass describes what has to happen before a failed transaction can
+// succeed.
+type FailureClass int
+
+const (
+	// Retryable failures may go away if the transaction is submitted again,
+	// possibly rebuilt with a new sequence number, timebounds or fee.
+	Retryable FailureClass = iota + 1
+	// UserFixable failures need the state of the ledger to change first, for
+	// example an account to be funded, a trustline to be added or more
+	// signatures to be collected.
+	UserFixable
+	// Permanent failures are caused by the transaction itself, which will
+	// never succeed.
+	Permanent
+)
+
+// String returns the name of the class.
+func (c FailureClass) String() string {
+	switch c {
+	case Retryable:
+		return "retryable"
+	case UserFixable:
+		return "user fixable"
+	case Permanent:
+		return "permanent"
+	}
+	return "none"
+}
+
+// ResultCode describes a transaction or operation result code. Code is the
+// string Horizon uses for the result code, e.g. "op_underfunded". Class is
+// zero for the success codes.
+type ResultCode struct {
+	Code        string
+	Description string
+	Class       FailureClass
+}
+
+// TransactionError is a readable form of a failed transaction result.
+type TransactionError struct {
+	Result ResultCode
+	// InnerResult is set when the inner transaction of a fee bump transaction
+	// failed.
+	InnerResult *ResultCode
+	FeeCharged  int64
+	// Operations holds the operations of the transaction which failed, if
+	// any.
+	Operations []*OperationError
+}
+
+// OperationError is a readable form of a failed operation result. The fields
+// describing the operation are only set when the operation is known, and
+// when they apply to its type.
+type OperationError struct {
+	Result        ResultCode
+	Index         int
+	Type          string
+	SourceAccount string
+	Destination   string
+	Asset         string
+	Amount        string
+}
+
+func (e *OperationError) Error() string {
+	var details []string
+	if e.Type != "" {
+		details = append(details, e.Type)
+	}
+	if e.Amount != "" {
+		details = append(details, e.Amount)
+	}
+	if e.Asset != "" {
+		details = append(details, e.Asset)
+	}
+	if e.Destination != "" {
+		details = append(details, "to "+e.Destination)
+	}
+	return fmt.Sprintf(
+		"operation %d (%s): %s (%s)",
+		e.Index, strings.Join(details, " "), e.Result.Code, e.Result.Description,
+	)
+}
+
+func (e *TransactionError) Error() string {
+	result := e.Result
+	if e.InnerResult != nil {
+		result = *e.InnerResult
+	}
+	msg := fmt.Sprintf("transaction failed: %s (%s)", result.Code, result.Description)
+	for _, op := range e.Operations {
+		msg += ": " + op.Error()
+	}
+	return msg
+}
+
+// Class returns the class of the failure. When operations failed it is the
+// most severe class of the operation failures.
+func (e *TransactionError) Class() FailureClass {
+	result := e.Result
+	if e.InnerResult != nil {
+		result = *e.InnerResult
+	}
+	if result.Code != "tx_failed" {
+		return result.Class
+	}
+	class := FailureClass(0)
+	for _, op := range e.Operations {
+		if op.Result.Class > class {
+			class = op.Result.Class
+		}
+	}
+	if class == 0 {
+		class = result.Class
+	}
+	return class
+}
+
+// NewTransactionError decodes the result of a transaction. ops are the
+// operations of the transaction, or of the inner transaction for a fee bump
+// transaction, and can be nil if they are not known. It returns nil if the
+// transaction succeeded.
+func NewTransactionError(result xdr.TransactionResult, ops []Operation) *TransactionError {
+	txErr := &TransactionError{
+		Result:     transactionResultCode(result.Result.Code),
+		FeeCharged: int64(result.FeeCharged),
+	}
+	code := result.Result.Code
+	opResults := result.Result.Results
+	if inner, ok := result.Result.GetInnerResultPair(); ok {
+		innerResult := transactionResultCode(inner.Result.Result.Code)
+		txErr.InnerResult = &innerResult
+		code = inner.Result.Result.Code
+		opResults = inner.Result.Result.Results
+	}
+	if code == xdr.TransactionResultCodeTxSuccess {
+		return nil
+	}
+	if code != xdr.TransactionResultCodeTxFailed || opResults == nil {
+		return txErr
+	}
+
+	for i, opResult := range *opResults {
+		var op Operation
+		if i < len(ops) {
+			op = ops[i]
+		}
+		if opErr := newOperationError(i, opResult, op); opErr != nil {
+			txErr.Operations = append(txErr.Operations, opErr)
+		}
+	}
+	return txErr
+}
+
+// TransactionErrorFromXDR decodes the base64 encoded result of a transaction.
+// envelopeXDR is the base64 encoded envelope of the transaction, which is
+// used to describe the failed operations. It can be empty. It returns nil if
+// the transaction succeeded.
+func TransactionErrorFromXDR(resultXDR, envelopeXDR string) (*TransactionError, error) {
+	var result xdr.TransactionResult
+	if err := xdr.SafeUnmarshalBase64(resultXDR, &result); err != nil {
+		return nil, errors.Wrap(err, "unable to decode transaction result")
+	}
+
+	var ops []Operation
+	if envelopeXDR != "" {
+		gtx, err := TransactionFromXDR(envelopeXDR)
+		if err != nil {
+			return nil, errors.Wrap(err, "unable to decode transaction envelope")
+		}
+		if tx, ok := gtx.Transaction(); ok {
+			ops = tx.Operations()
+		} else if feeBump, ok := gtx.FeeBump(); ok {
+			ops = feeBump.InnerTransaction().Operations()
+		}
+	}
+
+	return NewTransactionError(result, ops), nil
+}
+
+func transactionResultCode(code xdr.TransactionResultCode) ResultCode {
+	if result, ok := transactionResultCodes[code]; ok {
+		return result
+	}
+	return ResultCode{fmt.Sprintf("tx_unknown_%d", code), "the result code is not known", Permanent}
+}
+
+func newOperationError(index int, opResult xdr.OperationResult, op Operation) *OperationError {
+	opErr := &OperationError{Index: index}
+	if op != nil {
+		describeOperation(opErr, op)
+	}
+
+	if opResult.Code != xdr.OperationResultCodeOpInner {
+		result, ok := operationResultCodes[opResult.Code]
+		if !ok {
+			result = ResultCode{fmt.Sprintf("op_unknown_%d", opResult.Code), "the result code is not known", Permanent}
+		}
+		opErr.Result = result
+		return opErr
+	}
+
+	tr := opResult.MustTr()
+	opErr.Type = operations.TypeNames[tr.Type]
+	code := innerResultCode(tr)
+	if code == 0 {
+		return nil
+	}
+	result, ok := innerResultCodes[tr.Type][code]
+	if !ok {
+		result = ResultCode{fmt.Sprintf("op_unknown_%d", code), "the result code is not known", Permanent}
+	}
+	opErr.Result = result
+	return opErr
+}
+
+// describeOperation sets the fields of an OperationError which describe the
+// operation.
+func describeOperation(opErr *OperationError, op Operation) {
+	if source := op.GetSourceAccount(); source != nil {
+		opErr.SourceAccount = source.GetAccountID()
+	}
+	if xdrOp, err := op.BuildXDR(); err == nil {
+		opErr.Type = operations.TypeNames[xdrOp.Body.Type]
+	}
+
+	switch o := op.(type) {
+	case *CreateAccount:
+		opErr.Destination = o.Destination
+		opErr.Amount = o.Amount
+		opErr.Asset = assetString(NativeAsset{})
+	case *Payment:
+		opErr.Destination = o.Destination
+		opErr.Amount = o.Amount
+		opErr.Asset = assetString(o.Asset)
+	case *PathPaymentStrictReceive:
+		opErr.Destination = o.Destination
+		opErr.Amount = o.DestAmount
+		opErr.Asset = assetString(o.DestAsset)
+	case *PathPaymentStrictSend:
+		opErr.Destination = o.Destination
+		opErr.Amount = o.SendAmount
+		opErr.Asset = assetString(o.SendAsset)
+	case *ManageSellOffer:
+		opErr.Amount = o.Amount
+		opErr.Asset = assetString(o.Selling)
+	case *CreatePassiveSellOffer:
+		opErr.Amount = o.Amount
+		opErr.Asset = assetString(o.Selling)
+	case *ManageBuyOffer:
+		opErr.Amount = o.Amount
+		opErr.Asset = assetString(o.Buying)
+	case *ChangeTrust:
+		opErr.Amount = o.Limit
+		opErr.Asset = assetString(o.Line)
+	case *AllowTrust:
+		opErr.Destination = o.Trustor
+		opErr.Asset = assetString(o.Type)
+	case *AccountMerge:
+		opErr.Destination = o.Destination
+	case *CreateClaimableBalance:
+		opErr.Amount = o.Amount
+		opErr.Asset = assetString(o.Asset)
+	case *BeginSponsoringFutureReserves:
+		opErr.Destination = o.SponsoredID
+	}
+}
+
+// assetString returns the canonical form of an asset, or an empty string if
+// the asset is not valid.
+func assetString(asset Asset) string {
+	if asset == nil {
+		return ""
+	}
+	xdrAsset, err := asset.ToXDR()
+	if err != nil {
+		return ""
+	}
+	return xdrAsset.StringCanonical()
+}
+
+// innerResultCode returns the result code of an operation, which is specific
+// to its type.
+func innerResultCode(tr xdr.OperationResultTr) int32 {
+	switch tr.Type {
+	case xdr.OperationTypeCreateAccount:
+		return int32(tr.MustCreateAccountResult().Code)
+	case xdr.OperationTypePayment:
+		return int32(tr.MustPaymentResult().Code)
+	case xdr.OperationTypePathPaymentStrictReceive:
+		return int32(tr.MustPathPaymentStrictReceiveResult().Code)
+	case xdr.OperationTypeManageSellOffer:
+		return int32(tr.MustManageSellOfferResult().Code)
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		return int32(tr.MustCreatePassiveSellOfferResult().Code)
+	case xdr.OperationTypeSetOptions:
+		return int32(tr.MustSetOptionsResult().Code)
+	case xdr.OperationTypeChangeTrust:
+		return int32(tr.MustChangeTrustResult().Code)
+	case xdr.OperationTypeAllowTrust:
+		return int32(tr.MustAllowTrustResult().Code)
+	case xdr.OperationTypeAccountMerge:
+		return int32(tr.MustAccountMergeResult().Code)
+	case xdr.OperationTypeInflation:
+		return int32(tr.MustInflationResult().Code)
+	case xdr.OperationTypeManageData:
+		return int32(tr.MustManageDataResult().Code)
+	case xdr.OperationTypeBumpSequence:
+		return int32(tr.MustBumpSeqResult().Code)
+	case xdr.OperationTypeManageBuyOffer:
+		return int32(tr.MustManageBuyOfferResult().Code)
+	case xdr.OperationTypePathPaymentStrictSend:
+		return int32(tr.MustPathPaymentStrictSendResult().Code)
+	case xdr.OperationTypeCreateClaimableBalance:
+		return int32(tr.MustCreateClaimableBalanceResult().Code)
+	case xdr.OperationTypeClaimClaimableBalance:
+		return int32(tr.MustClaimClaimableBalanceResult().Code)
+	case xdr.OperationTypeBeginSponsoringFutureReserves:
+		return int32(tr.MustBeginSponsoringFutureReservesResult().Code)
+	case xdr.OperationTypeEndSponsoringFutureReserves:
+		return int32(tr.MustEndSponsoringFutureReservesResult().Code)
+	case xdr.OperationTypeRevokeSponsorship:
+		return int32(tr.MustRevokeSponsorshipResult().Code)
+	}
+	return 0
+}
+
+var transactionResultCodes = map[xdr.TransactionResultCode]ResultCode{
+	xdr.TransactionResultCodeTxFeeBumpInnerSuccess: {"tx_fee_bump_inner_success", "the fee bump transaction and its inner transaction succeeded", 0},
+	xdr.TransactionResultCodeTxSuccess:             {"tx_success", "the transaction succeeded", 0},
+	xdr.TransactionResultCodeTxFailed:              {"tx_failed", "one of the operations failed", UserFixable},
+	xdr.TransactionResultCodeTxTooEarly:            {"tx_too_early", "the ledger close time is before the minimum time of the transaction", Retryable},
+	xdr.TransactionResultCodeTxTooLate:             {"tx_too_late", "the ledger close time is after the maximum time of the transaction", Retryable},
+	xdr.TransactionResultCodeTxMissingOperation:    {"tx_missing_operation", "the transaction has no operations", Permanent},
+	xdr.TransactionResultCodeTxBadSeq:              {"tx_bad_seq", "the sequence number does not match the source account", Retryable},
+	xdr.TransactionResultCodeTxBadAuth:             {"tx_bad_auth", "too few valid signatures or the wrong network", UserFixable},
+	xdr.TransactionResultCodeTxInsufficientBalance: {"tx_insufficient_balance", "the fee would bring the source account below its reserve", UserFixable},
+	xdr.TransactionResultCodeTxNoAccount:           {"tx_no_source_account", "the source account does not exist", UserFixable},
+	xdr.TransactionResultCodeTxInsufficientFee:     {"tx_insufficient_fee", "the fee is too small", Retryable},
+	xdr.TransactionResultCodeTxBadAuthExtra:        {"tx_bad_auth_extra", "the transaction has unused signatures", Permanent},
+	xdr.TransactionResultCodeTxInternalError:       {"tx_internal_error", "an unknown error occurred", Retryable},
+	xdr.TransactionResultCodeTxNotSupported:        {"tx_not_supported", "the transaction type is not supported", Permanent},
+	xdr.TransactionResultCodeTxFeeBumpInnerFailed:  {"tx_fee_bump_inner_failed", "the inner transaction of the fee bump transaction failed", UserFixable},
+	xdr.TransactionResultCodeTxBadSponsorship:      {"tx_bad_sponsorship", "a sponsorship is not ended in the transaction", Permanent},
+}
+
+var operationResultCodes = map[xdr.OperationResultCode]ResultCode{
+	xdr.OperationResultCodeOpBadAuth:           {"op_bad_auth", "too few valid signatures for the operation", UserFixable},
+	xdr.OperationResultCodeOpNoAccount:         {"op_no_source_account", "the source account of the operation does not exist", UserFixable},
+	xdr.OperationResultCodeOpNotSupported:      {"op_not_supported", "the operation is not supported", Permanent},
+	xdr.OperationResultCodeOpTooManySubentries: {"op_too_many_subentries", "the source account has too many subentries", UserFixable},
+	xdr.OperationResultCodeOpExceededWorkLimit: {"op_exceeded_work_limit", "the operation did too much work", Retryable},
+	xdr.OperationResultCodeOpTooManySponsoring: {"op_too_many_sponsoring", "the account is sponsoring too many entries", UserFixable},
+}
+
+var (
+	opMalformed        = ResultCode{"op_malformed", "the operation is invalid", Permanent}
+	opUnderfunded      = ResultCode{"op_underfunded", "the source account does not hold enough of the asset", UserFixable}
+	opLowReserve       = ResultCode{"op_low_reserve", "the account would go below its minimum balance", UserFixable}
+	opSrcNoTrust       = ResultCode{"op_src_no_trust", "the source account does not trust the asset", UserFixable}
+	opSrcNotAuthorized = ResultCode{"op_src_not_authorized", "the source account is not authorized to send the asset", UserFixable}
+	opNoDestination    = ResultCode{"op_no_destination", "the destination account does not exist", UserFixable}
+	opNoTrust          = ResultCode{"op_no_trust", "the destination account does not trust the asset", UserFixable}
+	opNotAuthorized    = ResultCode{"op_not_authorized", "the destination account is not authorized to hold the asset", UserFixable}
+	opLineFull         = ResultCode{"op_line_full", "the destination trustline would exceed its limit", UserFixable}
+	opNoIssuer         = ResultCode{"op_no_issuer", "the issuer of the asset does not exist", UserFixable}
+	opTooFewOffers     = ResultCode{"op_too_few_offers", "there is not enough liquidity along the path", Retryable}
+	opCrossSelf        = ResultCode{"op_cross_self", "the offer would cross an offer of the same account", UserFixable}
+)
+
+// offerResultCodes are the result codes of the manage sell offer, create
+// passive sell offer and manage buy offer operations, which share their
+// values.
+var offerResultCodes = map[int32]ResultCode{
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferMalformed):         opMalformed,
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferSellNoTrust):       {"op_sell_no_trust", "the account does not trust the selling asset", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferBuyNoTrust):        {"op_buy_no_trust", "the account does not trust the buying asset", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferSellNotAuthorized): {"sell_not_authorized", "the account is not authorized to sell the asset", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferBuyNotAuthorized):  {"buy_not_authorized", "the account is not authorized to buy the asset", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferLineFull):          {"op_line_full", "the buying trustline would exceed its limit", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferUnderfunded):       {"op_underfunded", "the account does not hold enough of the selling asset", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferCrossSelf):         opCrossSelf,
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferSellNoIssuer):      {"op_sell_no_issuer", "the issuer of the selling asset does not exist", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferBuyNoIssuer):       {"buy_no_issuer", "the issuer of the buying asset does not exist", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferNotFound):          {"op_offer_not_found", "the offer does not exist", UserFixable},
+	int32(xdr.ManageSellOfferResultCodeManageSellOfferLowReserve):        opLowReserve,
+}
+
+var innerResultCodes = map[xdr.OperationType]map[int32]ResultCode{
+	xdr.OperationTypeCreateAccount: {
+		int32(xdr.CreateAccountResultCodeCreateAccountMalformed):    opMalformed,
+		int32(xdr.CreateAccountResultCodeCreateAccountUnderfunded):  opUnderfunded,
+		int32(xdr.CreateAccountResultCodeCreateAccountLowReserve):   {"op_low_reserve", "the starting balance is below the minimum balance", UserFixable},
+		int32(xdr.CreateAccountResultCodeCreateAccountAlreadyExist): {"op_already_exists", "the destination account already exists", Permanent},
+	},
+	xdr.OperationTypePayment: {
+		int32(xdr.PaymentResultCodePaymentMalformed):        opMalformed,
+		int32(xdr.PaymentResultCodePaymentUnderfunded):      opUnderfunded,
+		int32(xdr.PaymentResultCodePaymentSrcNoTrust):       opSrcNoTrust,
+		int32(xdr.PaymentResultCodePaymentSrcNotAuthorized): opSrcNotAuthorized,
+		int32(xdr.PaymentResultCodePaymentNoDestination):    opNoDestination,
+		int32(xdr.PaymentResultCodePaymentNoTrust):          opNoTrust,
+		int32(xdr.PaymentResultCodePaymentNotAuthorized):    opNotAuthorized,
+		int32(xdr.PaymentResultCodePaymentLineFull):         opLineFull,
+		int32(xdr.PaymentResultCodePaymentNoIssuer):         opNoIssuer,
+	},
+	xdr.OperationTypePathPaymentStrictReceive: {
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveMalformed):        opMalformed,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveUnderfunded):      opUnderfunded,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveSrcNoTrust):       opSrcNoTrust,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveSrcNotAuthorized): opSrcNotAuthorized,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveNoDestination):    opNoDestination,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveNoTrust):          opNoTrust,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveNotAuthorized):    opNotAuthorized,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveLineFull):         opLineFull,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveNoIssuer):         opNoIssuer,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveTooFewOffers):     opTooFewOffers,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveOfferCrossSelf):   opCrossSelf,
+		int32(xdr.PathPaymentStrictReceiveResultCodePathPaymentStrictReceiveOverSendmax):      {"op_over_source_max", "the payment would send more than the send maximum", Retryable},
+	},
+	xdr.OperationTypeManageSellOffer:        offerResultCodes,
+	xdr.OperationTypeCreatePassiveSellOffer: offerResultCodes,
+	xdr.OperationTypeSetOptions: {
+		int32(xdr.SetOptionsResultCodeSetOptionsLowReserve):          opLowReserve,
+		int32(xdr.SetOptionsResultCodeSetOptionsTooManySigners):      {"op_too_many_signers", "the account would have too many signers", UserFixable},
+		int32(xdr.SetOptionsResultCodeSetOptionsBadFlags):            {"op_bad_flags", "the flags set and cleared are inconsistent", Permanent},
+		int32(xdr.SetOptionsResultCodeSetOptionsInvalidInflation):    {"op_invalid_inflation", "the inflation destination does not exist", UserFixable},
+		int32(xdr.SetOptionsResultCodeSetOptionsCantChange):          {"op_cant_change", "the flags can no longer be changed", Permanent},
+		int32(xdr.SetOptionsResultCodeSetOptionsUnknownFlag):         {"op_unknown_flag", "a flag is not known", Permanent},
+		int32(xdr.SetOptionsResultCodeSetOptionsThresholdOutOfRange): {"op_threshold_out_of_range", "a weight or threshold is out of range", Permanent},
+		int32(xdr.SetOptionsResultCodeSetOptionsBadSigner):           {"op_bad_signer", "the signer is the master key of the account", Permanent},
+		int32(xdr.SetOptionsResultCodeSetOptionsInvalidHomeDomain):   {"op_invalid_home_domain", "the home domain is invalid", Permanent},
+	},
+	xdr.OperationTypeChangeTrust: {
+		int32(xdr.ChangeTrustResultCodeChangeTrustMalformed):      opMalformed,
+		int32(xdr.ChangeTrustResultCodeChangeTrustNoIssuer):       opNoIssuer,
+		int32(xdr.ChangeTrustResultCodeChangeTrustInvalidLimit):   {"op_invalid_limit", "the limit is below the balance or liabilities of the trustline", UserFixable},
+		int32(xdr.ChangeTrustResultCodeChangeTrustLowReserve):     opLowReserve,
+		int32(xdr.ChangeTrustResultCodeChangeTrustSelfNotAllowed): {"op_self_not_allowed", "the issuer cannot trust its own asset", Permanent},
+	},
+	xdr.OperationTypeAllowTrust: {
+		int32(xdr.AllowTrustResultCodeAllowTrustMalformed):        opMalformed,
+		int32(xdr.AllowTrustResultCodeAllowTrustNoTrustLine):      {"op_no_trust", "the trustor does not trust the asset", UserFixable},
+		int32(xdr.AllowTrustResultCodeAllowTrustTrustNotRequired): {"op_not_required", "the issuer does not require authorization", UserFixable},
+		int32(xdr.AllowTrustResultCodeAllowTrustCantRevoke):       {"op_cant_revoke", "the issuer cannot revoke authorization", UserFixable},
+		int32(xdr.AllowTrustResultCodeAllowTrustSelfNotAllowed):   {"op_self_not_allowed", "the issuer cannot authorize itself", Permanent},
+	},
+	xdr.OperationTypeAccountMerge: {
+		int32(xdr.AccountMergeResultCodeAccountMergeMalformed):     {"op_malformed", "the account cannot merge into itself", Permanent},
+		int32(xdr.AccountMergeResultCodeAccountMergeNoAccount):     {"op_no_account", "the destination account does not exist", UserFixable},
+		int32(xdr.AccountMergeResultCodeAccountMergeImmutableSet):  {"op_immutable_set", "the account has the immutable flag set", Permanent},
+		int32(xdr.AccountMergeResultCodeAccountMergeHasSubEntries): {"op_has_sub_entries", "the account has trustlines, offers or data entries", UserFixable},
+		int32(xdr.AccountMergeResultCodeAccountMergeSeqnumTooFar):  {"op_seq_num_too_far", "the sequence number of the account is too high", Permanent},
+		int32(xdr.AccountMergeResultCodeAccountMergeDestFull):      {"op_dest_full", "the destination balance would overflow", UserFixable},
+		int32(xdr.AccountMergeResultCodeAccountMergeIsSponsor):     {"op_is_sponsor", "the account is sponsoring entries", UserFixable},
+	},
+	xdr.OperationTypeInflation: {
+		int32(xdr.InflationResultCodeInflationNotTime): {"op_not_time", "inflation cannot run yet", Retryable},
+	},
+	xdr.OperationTypeManageData: {
+		int32(xdr.ManageDataResultCodeManageDataNotSupportedYet): {"op_not_supported_yet", "the network does not support data entries yet", Permanent},
+		int32(xdr.ManageDataResultCodeManageDataNameNotFound):    {"op_data_name_not_found", "the data entry to delete does not exist", UserFixable},
+		int32(xdr.ManageDataResultCodeManageDataLowReserve):      opLowReserve,
+		int32(xdr.ManageDataResultCodeManageDataInvalidName):     {"op_data_invalid_name", "the name of the data entry is invalid", Permanent},
+	},
+	xdr.OperationTypeBumpSequence: {
+		int32(xdr.BumpSequenceResultCodeBumpSequenceBadSeq): {"op_bad_seq", "the sequence number to bump to is invalid", Permanent},
+	},
+	xdr.OperationTypeManageBuyOffer: offerResultCodes,
+	xdr.OperationTypePathPaymentStrictSend: {
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendMalformed):        opMalformed,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendUnderfunded):      opUnderfunded,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendSrcNoTrust):       opSrcNoTrust,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendSrcNotAuthorized): opSrcNotAuthorized,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendNoDestination):    opNoDestination,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendNoTrust):          opNoTrust,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendNotAuthorized):    opNotAuthorized,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendLineFull):         opLineFull,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendNoIssuer):         opNoIssuer,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendTooFewOffers):     opTooFewOffers,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendOfferCrossSelf):   opCrossSelf,
+		int32(xdr.PathPaymentStrictSendResultCodePathPaymentStrictSendUnderDestmin):     {"op_under_dest_min", "the payment would deliver less than the destination minimum", Retryable},
+	},
+	xdr.OperationTypeCreateClaimableBalance: {
+		int32(xdr.CreateClaimableBalanceResultCodeCreateClaimableBalanceMalformed):     opMalformed,
+		int32(xdr.CreateClaimableBalanceResultCodeCreateClaimableBalanceLowReserve):    opLowReserve,
+		int32(xdr.CreateClaimableBalanceResultCodeCreateClaimableBalanceNoTrust):       {"op_no_trust", "the source account does not trust the asset", UserFixable},
+		int32(xdr.CreateClaimableBalanceResultCodeCreateClaimableBalanceNotAuthorized): {"op_not_authorized", "the source account is not authorized to send the asset", UserFixable},
+		int32(xdr.CreateClaimableBalanceResultCodeCreateClaimableBalanceUnderfunded):   opUnderfunded,
+	},
+	xdr.OperationTypeClaimClaimableBalance: {
+		int32(xdr.ClaimClaimableBalanceResultCodeClaimClaimableBalanceDoesNotExist):  {"op_does_not_exist", "the claimable balance does not exist", UserFixable},
+		int32(xdr.ClaimClaimableBalanceResultCodeClaimClaimableBalanceCannotClaim):   {"op_cannot_claim", "the account cannot claim the balance", UserFixable},
+		int32(xdr.ClaimClaimableBalanceResultCodeClaimClaimableBalanceLineFull):      {"op_line_full", "the trustline would exceed its limit", UserFixable},
+		int32(xdr.ClaimClaimableBalanceResultCodeClaimClaimableBalanceNoTrust):       {"op_no_trust", "the account does not trust the asset", UserFixable},
+		int32(xdr.ClaimClaimableBalanceResultCodeClaimClaimableBalanceNotAuthorized): {"op_not_authorized", "the account is not authorized to hold the asset", UserFixable},
+	},
+	xdr.OperationTypeBeginSponsoringFutureReserves: {
+		int32(xdr.BeginSponsoringFutureReservesResultCodeBeginSponsoringFutureReservesMalformed):        {"op_malformed", "the account cannot sponsor itself", Permanent},
+		int32(xdr.BeginSponsoringFutureReservesResultCodeBeginSponsoringFutureReservesAlreadySponsored): {"op_already_sponsored", "the account is already sponsored", Permanent},
+		int32(xdr.BeginSponsoringFutureReservesResultCodeBeginSponsoringFutureReservesRecursive):        {"op_recursive", "the sponsor is itself sponsored", Permanent},
+	},
+	xdr.OperationTypeEndSponsoringFutureReserves: {
+		int32(xdr.EndSponsoringFutureReservesResultCodeEndSponsoringFutureReservesNotSponsored): {"op_not_sponsored", "the account is not sponsored", Permanent},
+	},
+	xdr.OperationTypeRevokeSponsorship: {
+		int32(xdr.RevokeSponsorshipResultCodeRevokeSponsorshipDoesNotExist):     {"op_does_not_exist", "the ledger entry or signer does not exist", UserFixable},
+		int32(xdr.RevokeSponsorshipResultCodeRevokeSponsorshipNotSponsor):       {"op_not_sponsor", "the account is not the sponsor", UserFixable},
+		int32(xdr.RevokeSponsorshipResultCodeRevokeSponsorshipLowReserve):       opLowReserve,
+		int32(xdr.RevokeSponsorshipResultCodeRevokeSponsorshipOnlyTransferable): {"op_only_transferable", "the sponsorship can only be transferred", Permanent},
+	},
+}
@@ -0,0 +1,195 @@
+package txnbuild
+
+import (
+	"testing"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/protocols/horizon/operations"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func failedResult(opResults ...xdr.OperationResult) xdr.TransactionResult {
+	return xdr.TransactionResult{
+		FeeCharged: 200,
+		Result: xdr.TransactionResultResult{
+			Code:    xdr.TransactionResultCodeTxFailed,
+			Results: &opResults,
+		},
+	}
+}
+
+func paymentResult(code xdr.PaymentResultCode) xdr.OperationResult {
+	return xdr.OperationResult{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type:          xdr.OperationTypePayment,
+			PaymentResult: &xdr.PaymentResult{Code: code},
+		},
+	}
+}
+
+func TestNewTransactionError(t *testing.T) {
+	kp0, kp1 := newKeypair0(), newKeypair1()
+	ops := []Operation{
+		&Payment{Destination: kp1.Address(), Amount: "10", Asset: NativeAsset{}},
+		&Payment{
+			Destination:   kp1.Address(),
+			Amount:        "5.5",
+			Asset:         CreditAsset{Code: "USD", Issuer: kp0.Address()},
+			SourceAccount: &SimpleAccount{AccountID: kp0.Address()},
+		},
+	}
+	result := failedResult(
+		paymentResult(xdr.PaymentResultCodePaymentSuccess),
+		paymentResult(xdr.PaymentResultCodePaymentUnderfunded),
+	)
+
+	txErr := NewTransactionError(result, ops)
+	require.NotNil(t, txErr)
+	assert.Equal(t, "tx_failed", txErr.Result.Code)
+	assert.Equal(t, int64(200), txErr.FeeCharged)
+	require.Len(t, txErr.Operations, 1)
+	opErr := txErr.Operations[0]
+	assert.Equal(t, 1, opErr.Index)
+	assert.Equal(t, "payment", opErr.Type)
+	assert.Equal(t, "op_underfunded", opErr.Result.Code)
+	assert.Equal(t, UserFixable, opErr.Result.Class)
+	assert.Equal(t, kp0.Address(), opErr.SourceAccount)
+	assert.Equal(t, kp1.Address(), opErr.Destination)
+	assert.Equal(t, "5.5", opErr.Amount)
+	assert.Equal(t, "USD:"+kp0.Address(), opErr.Asset)
+	assert.Equal(t, UserFixable, txErr.Class())
+	assert.EqualError(t, txErr,
+		"transaction failed: tx_failed (one of the operations failed): "+
+			"operation 1 (payment 5.5 USD:"+kp0.Address()+" to "+kp1.Address()+"): "+
+			"op_underfunded (the source account does not hold enough of the asset)",
+	)
+
+	// Without the operations only the result is described.
+	txErr = NewTransactionError(result, nil)
+	require.Len(t, txErr.Operations, 1)
+	assert.Equal(t, "payment", txErr.Operations[0].Type)
+	assert.Equal(t, "", txErr.Operations[0].Destination)
+
+	// The most severe class of the operations wins.
+	result = failedResult(
+		xdr.OperationResult{Code: xdr.OperationResultCodeOpExceededWorkLimit},
+		xdr.OperationResult{Code: xdr.OperationResultCodeOpNotSupported},
+	)
+	txErr = NewTransactionError(result, nil)
+	require.Len(t, txErr.Operations, 2)
+	assert.Equal(t, "op_exceeded_work_limit", txErr.Operations[0].Result.Code)
+	assert.Equal(t, Permanent, txErr.Class())
+
+	result = xdr.TransactionResult{
+		Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxBadSeq},
+	}
+	txErr = NewTransactionError(result, ops)
+	assert.Equal(t, "tx_bad_seq", txErr.Result.Code)
+	assert.Equal(t, Retryable, txErr.Class())
+	assert.Empty(t, txErr.Operations)
+
+	results := []xdr.OperationResult{paymentResult(xdr.PaymentResultCodePaymentSuccess)}
+	result = xdr.TransactionResult{
+		Result: xdr.TransactionResultResult{Code: xdr.TransactionResultCodeTxSuccess, Results: &results},
+	}
+	assert.Nil(t, NewTransactionError(result, ops))
+}
+
+func TestNewTransactionErrorFeeBump(t *testing.T) {
+	opResults := []xdr.OperationResult{paymentResult(xdr.PaymentResultCodePaymentNoDestination)}
+	result := xdr.TransactionResult{
+		FeeCharged: 300,
+		Result: xdr.TransactionResultResult{
+			Code: xdr.TransactionResultCodeTxFeeBumpInnerFailed,
+			InnerResultPair: &xdr.InnerTransactionResultPair{
+				Result: xdr.InnerTransactionResult{
+					Result: xdr.InnerTransactionResultResult{
+						Code:    xdr.TransactionResultCodeTxFailed,
+						Results: &opResults,
+					},
+				},
+			},
+		},
+	}
+
+	txErr := NewTransactionError(result, nil)
+	require.NotNil(t, txErr)
+	assert.Equal(t, "tx_fee_bump_inner_failed", txErr.Result.Code)
+	require.NotNil(t, txErr.InnerResult)
+	assert.Equal(t, "tx_failed", txErr.InnerResult.Code)
+	require.Len(t, txErr.Operations, 1)
+	assert.Equal(t, "op_no_destination", txErr.Operations[0].Result.Code)
+	assert.Equal(t, UserFixable, txErr.Class())
+
+	result.Result.Code = xdr.TransactionResultCodeTxFeeBumpInnerSuccess
+	result.Result.InnerResultPair.Result.Result.Code = xdr.TransactionResultCodeTxSuccess
+	opResults[0] = paymentResult(xdr.PaymentResultCodePaymentSuccess)
+	assert.Nil(t, NewTransactionError(result, nil))
+}
+
+func TestTransactionErrorFromXDR(t *testing.T) {
+	kp0, kp1 := newKeypair0(), newKeypair1()
+	source := NewSimpleAccount(kp0.Address(), 1)
+	envelope, err := newSignedTransaction(
+		TransactionParams{
+			SourceAccount:        &source,
+			IncrementSequenceNum: true,
+			Operations: []Operation{
+				&CreateAccount{Destination: kp1.Address(), Amount: "10"},
+			},
+			BaseFee:    MinBaseFee,
+			Timebounds: NewInfiniteTimeout(),
+		},
+		network.TestNetworkPassphrase,
+		kp0,
+	)
+	require.NoError(t, err)
+
+	result, err := xdr.MarshalBase64(failedResult(xdr.OperationResult{
+		Code: xdr.OperationResultCodeOpInner,
+		Tr: &xdr.OperationResultTr{
+			Type: xdr.OperationTypeCreateAccount,
+			CreateAccountResult: &xdr.CreateAccountResult{
+				Code: xdr.CreateAccountResultCodeCreateAccountAlreadyExist,
+			},
+		},
+	}))
+	require.NoError(t, err)
+
+	txErr, err := TransactionErrorFromXDR(result, envelope)
+	require.NoError(t, err)
+	require.Len(t, txErr.Operations, 1)
+	opErr := txErr.Operations[0]
+	assert.Equal(t, "op_already_exists", opErr.Result.Code)
+	assert.Equal(t, Permanent, opErr.Result.Class)
+	assert.Equal(t, "create_account", opErr.Type)
+	assert.Equal(t, kp1.Address(), opErr.Destination)
+	assert.Equal(t, "10.0000000", opErr.Amount)
+	assert.Equal(t, "native", opErr.Asset)
+
+	txErr, err = TransactionErrorFromXDR(result, "")
+	require.NoError(t, err)
+	assert.Equal(t, "", txErr.Operations[0].Destination)
+
+	_, err = TransactionErrorFromXDR("invalid", "")
+	assert.Error(t, err)
+	_, err = TransactionErrorFromXDR(result, "invalid")
+	assert.Error(t, err)
+}
+
+func TestResultCodesComplete(t *testing.T) {
+	for _, code := range []xdr.TransactionResultCode{
+		xdr.TransactionResultCodeTxFeeBumpInnerSuccess,
+		xdr.TransactionResultCodeTxBadSponsorship,
+	} {
+		assert.Contains(t, transactionResultCodes, code)
+	}
+	assert.Len(t, transactionResultCodes, 16)
+	assert.Len(t, operationResultCodes, 6)
+	for opType := range operations.TypeNames {
+		assert.Contains(t, innerResultCodes, opType)
+	}
+}
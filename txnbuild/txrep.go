@@ -0,0 +1,1168 @@
+package txnbuild
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/stellar/go/amount"
+	"github.com/stellar/go/strkey"
+	"github.com/stellar/go/support/errors"
+	"github.com/stellar/go/xdr"
+)
+
+// Txrep is the human readable representation of transaction envelopes
+// defined in SEP-11:
+// https://github.com/stellar/stellar-protocol/blob/master/ecosystem/sep-0011.md
+//
+// Every line has the form `key: value`, where the key is the path of an XDR
+// field, for example `tx.operations[0].body.paymentOp.amount`. Accounts and
+// signer keys are strkeys, assets are `native` or `CODE:ISSUER`, strings are
+// double quoted, opaque values are hex encoded and enums use the names of the
+// XDR constants. Optional values have a `._present` line and arrays a `.len`
+// line. Anything following a value is a comment; amounts are followed by
+// their value in lumens or units of the asset.
+
+var txrepEnvelopeTypes = map[int32]string{
+	int32(xdr.EnvelopeTypeEnvelopeTypeTxV0):      "ENVELOPE_TYPE_TX_V0",
+	int32(xdr.EnvelopeTypeEnvelopeTypeTx):        "ENVELOPE_TYPE_TX",
+	int32(xdr.EnvelopeTypeEnvelopeTypeTxFeeBump): "ENVELOPE_TYPE_TX_FEE_BUMP",
+}
+
+var txrepMemoTypes = map[int32]string{
+	int32(xdr.MemoTypeMemoNone):   "MEMO_NONE",
+	int32(xdr.MemoTypeMemoText):   "MEMO_TEXT",
+	int32(xdr.MemoTypeMemoId):     "MEMO_ID",
+	int32(xdr.MemoTypeMemoHash):   "MEMO_HASH",
+	int32(xdr.MemoTypeMemoReturn): "MEMO_RETURN",
+}
+
+var txrepOperationTypes = map[int32]string{
+	int32(xdr.OperationTypeCreateAccount):                 "CREATE_ACCOUNT",
+	int32(xdr.OperationTypePayment):                       "PAYMENT",
+	int32(xdr.OperationTypePathPaymentStrictReceive):      "PATH_PAYMENT_STRICT_RECEIVE",
+	int32(xdr.OperationTypeManageSellOffer):               "MANAGE_SELL_OFFER",
+	int32(xdr.OperationTypeCreatePassiveSellOffer):        "CREATE_PASSIVE_SELL_OFFER",
+	int32(xdr.OperationTypeSetOptions):                    "SET_OPTIONS",
+	int32(xdr.OperationTypeChangeTrust):                   "CHANGE_TRUST",
+	int32(xdr.OperationTypeAllowTrust):                    "ALLOW_TRUST",
+	int32(xdr.OperationTypeAccountMerge):                  "ACCOUNT_MERGE",
+	int32(xdr.OperationTypeInflation):                     "INFLATION",
+	int32(xdr.OperationTypeManageData):                    "MANAGE_DATA",
+	int32(xdr.OperationTypeBumpSequence):                  "BUMP_SEQUENCE",
+	int32(xdr.OperationTypeManageBuyOffer):                "MANAGE_BUY_OFFER",
+	int32(xdr.OperationTypePathPaymentStrictSend):         "PATH_PAYMENT_STRICT_SEND",
+	int32(xdr.OperationTypeCreateClaimableBalance):        "CREATE_CLAIMABLE_BALANCE",
+	int32(xdr.OperationTypeClaimClaimableBalance):         "CLAIM_CLAIMABLE_BALANCE",
+	int32(xdr.OperationTypeBeginSponsoringFutureReserves): "BEGIN_SPONSORING_FUTURE_RESERVES",
+	int32(xdr.OperationTypeEndSponsoringFutureReserves):   "END_SPONSORING_FUTURE_RESERVES",
+	int32(xdr.OperationTypeRevokeSponsorship):             "REVOKE_SPONSORSHIP",
+}
+
+var txrepClaimPredicateTypes = map[int32]string{
+	int32(xdr.ClaimPredicateTypeClaimPredicateUnconditional):      "CLAIM_PREDICATE_UNCONDITIONAL",
+	int32(xdr.ClaimPredicateTypeClaimPredicateAnd):                "CLAIM_PREDICATE_AND",
+	int32(xdr.ClaimPredicateTypeClaimPredicateOr):                 "CLAIM_PREDICATE_OR",
+	int32(xdr.ClaimPredicateTypeClaimPredicateNot):                "CLAIM_PREDICATE_NOT",
+	int32(xdr.ClaimPredicateTypeClaimPredicateBeforeAbsoluteTime): "CLAIM_PREDICATE_BEFORE_ABSOLUTE_TIME",
+	int32(xdr.ClaimPredicateTypeClaimPredicateBeforeRelativeTime): "CLAIM_PREDICATE_BEFORE_RELATIVE_TIME",
+}
+
+var txrepClaimantTypes = map[int32]string{
+	int32(xdr.ClaimantTypeClaimantTypeV0): "CLAIMANT_TYPE_V0",
+}
+
+var txrepClaimableBalanceIDTypes = map[int32]string{
+	int32(xdr.ClaimableBalanceIdTypeClaimableBalanceIdTypeV0): "CLAIMABLE_BALANCE_ID_TYPE_V0",
+}
+
+var txrepRevokeSponsorshipTypes = map[int32]string{
+	int32(xdr.RevokeSponsorshipTypeRevokeSponsorshipLedgerEntry): "REVOKE_SPONSORSHIP_LEDGER_ENTRY",
+	int32(xdr.RevokeSponsorshipTypeRevokeSponsorshipSigner):      "REVOKE_SPONSORSHIP_SIGNER",
+}
+
+var txrepLedgerEntryTypes = map[int32]string{
+	int32(xdr.LedgerEntryTypeAccount):          "ACCOUNT",
+	int32(xdr.LedgerEntryTypeTrustline):        "TRUSTLINE",
+	int32(xdr.LedgerEntryTypeOffer):            "OFFER",
+	int32(xdr.LedgerEntryTypeData):             "DATA",
+	int32(xdr.LedgerEntryTypeClaimableBalance): "CLAIMABLE_BALANCE",
+}
+
+// TxRep returns the txrep (SEP-11) representation of the transaction
+// envelope.
+func (t *Transaction) TxRep() (string, error) {
+	return txrepFromEnvelope(t.ToXDR())
+}
+
+// TxRep returns the txrep (SEP-11) representation of the transaction
+// envelope.
+func (t *FeeBumpTransaction) TxRep() (string, error) {
+	return txrepFromEnvelope(t.ToXDR())
+}
+
+// TransactionFromTxRep parses the supplied transaction envelope in txrep
+// (SEP-11) format and returns a GenericTransaction instance.
+func TransactionFromTxRep(txrep string) (*GenericTransaction, error) {
+	envelope, err := envelopeFromTxRep(txrep)
+	if err != nil {
+		return nil, errors.Wrap(err, "unable to parse txrep")
+	}
+	return transactionFromParsedXDR(envelope)
+}
+
+// txrepWriter builds a txrep document. The first error is kept in err and
+// stops further writes.
+type txrepWriter struct {
+	b   strings.Builder
+	err error
+}
+
+func (w *txrepWriter) add(key string, value interface{}) {
+	if w.err == nil {
+		fmt.Fprintf(&w.b, "%s: %v\n", key, value)
+	}
+}
+
+func (w *txrepWriter) fail(key string, err error) {
+	if w.err == nil {
+		w.err = errors.Wrapf(err, "cannot write %s", key)
+	}
+}
+
+func (w *txrepWriter) enum(key string, names map[int32]string, value int32) {
+	name, ok := names[value]
+	if !ok {
+		w.fail(key, errors.Errorf("unknown value %d", value))
+		return
+	}
+	w.add(key, name)
+}
+
+func (w *txrepWriter) amount(key string, value xdr.Int64) {
+	w.add(key, fmt.Sprintf("%d (%s)", value, amount.String(value)))
+}
+
+func (w *txrepWriter) str(key string, value string) {
+	w.add(key, strconv.Quote(value))
+}
+
+func (w *txrepWriter) hex(key string, value []byte) {
+	w.add(key, hex.EncodeToString(value))
+}
+
+func (w *txrepWriter) present(key string, present bool) bool {
+	w.add(key+"._present", present)
+	return present
+}
+
+func (w *txrepWriter) accountID(key string, aid xdr.AccountId) {
+	address, err := aid.GetAddress()
+	if err != nil {
+		w.fail(key, err)
+		return
+	}
+	w.add(key, address)
+}
+
+func (w *txrepWriter) muxedAccount(key string, account xdr.MuxedAccount) {
+	if account.Type != xdr.CryptoKeyTypeKeyTypeEd25519 {
+		w.fail(key, errors.New("muxed accounts are not supported"))
+		return
+	}
+	w.accountID(key, account.ToAccountId())
+}
+
+func (w *txrepWriter) signerKey(key string, signerKey xdr.SignerKey) {
+	address, err := signerKey.GetAddress()
+	if err != nil {
+		w.fail(key, err)
+		return
+	}
+	w.add(key, address)
+}
+
+func (w *txrepWriter) asset(key string, asset xdr.Asset) {
+	var typ, code, issuer string
+	if err := asset.Extract(&typ, &code, &issuer); err != nil {
+		w.fail(key, err)
+		return
+	}
+	if asset.Type == xdr.AssetTypeAssetTypeNative {
+		w.add(key, "native")
+		return
+	}
+	w.add(key, code+":"+issuer)
+}
+
+func (w *txrepWriter) price(key string, price xdr.Price) {
+	w.add(key+".n", price.N)
+	w.add(key+".d", price.D)
+}
+
+func txrepFromEnvelope(envelope xdr.TransactionEnvelope) (string, error) {
+	w := &txrepWriter{}
+	w.enum("type", txrepEnvelopeTypes, int32(envelope.Type))
+
+	switch envelope.Type {
+	case xdr.EnvelopeTypeEnvelopeTypeTxV0:
+		tx := envelope.V0.Tx
+		source, err := strkey.Encode(strkey.VersionByteAccountID, tx.SourceAccountEd25519[:])
+		if err != nil {
+			w.fail("tx.sourceAccountEd25519", err)
+		}
+		w.add("tx.sourceAccountEd25519", source)
+		w.transactionBody("tx", tx.Fee, tx.SeqNum, tx.TimeBounds, tx.Memo, tx.Operations)
+		w.add("tx.ext.v", tx.Ext.V)
+		w.signatures("signatures", envelope.V0.Signatures)
+	case xdr.EnvelopeTypeEnvelopeTypeTx:
+		w.transaction("tx", envelope.V1.Tx)
+		w.signatures("signatures", envelope.V1.Signatures)
+	case xdr.EnvelopeTypeEnvelopeTypeTxFeeBump:
+		tx := envelope.FeeBump.Tx
+		w.muxedAccount("feeBump.tx.feeSource", tx.FeeSource)
+		w.add("feeBump.tx.fee", tx.Fee)
+		w.enum("feeBump.tx.innerTx.type", txrepEnvelopeTypes, int32(tx.InnerTx.Type))
+		w.transaction("feeBump.tx.innerTx.tx", tx.InnerTx.V1.Tx)
+		w.signatures("feeBump.tx.innerTx.signatures", tx.InnerTx.V1.Signatures)
+		w.add("feeBump.tx.ext.v", tx.Ext.V)
+		w.signatures("feeBump.signatures", envelope.FeeBump.Signatures)
+	}
+
+	if w.err != nil {
+		return "", w.err
+	}
+	return w.b.String(), nil
+}
+
+func (w *txrepWriter) transaction(prefix string, tx xdr.Transaction) {
+	w.muxedAccount(prefix+".sourceAccount", tx.SourceAccount)
+	w.transactionBody(prefix, tx.Fee, tx.SeqNum, tx.TimeBounds, tx.Memo, tx.Operations)
+	w.add(prefix+".ext.v", tx.Ext.V)
+}
+
+// transactionBody writes the fields shared by Transaction and
+// TransactionV0.
+func (w *txrepWriter) transactionBody(
+	prefix string,
+	fee xdr.Uint32,
+	seqNum xdr.SequenceNumber,
+	timeBounds *xdr.TimeBounds,
+	memo xdr.Memo,
+	operations []xdr.Operation,
+) {
+	w.add(prefix+".fee", fee)
+	w.add(prefix+".seqNum", seqNum)
+	if w.present(prefix+".timeBounds", timeBounds != nil) {
+		w.add(prefix+".timeBounds.minTime", timeBounds.MinTime)
+		w.add(prefix+".timeBounds.maxTime", timeBounds.MaxTime)
+	}
+
+	w.enum(prefix+".memo.type", txrepMemoTypes, int32(memo.Type))
+	switch memo.Type {
+	case xdr.MemoTypeMemoText:
+		w.str(prefix+".memo.text", memo.MustText())
+	case xdr.MemoTypeMemoId:
+		w.add(prefix+".memo.id", memo.MustId())
+	case xdr.MemoTypeMemoHash:
+		hash := memo.MustHash()
+		w.hex(prefix+".memo.hash", hash[:])
+	case xdr.MemoTypeMemoReturn:
+		hash := memo.MustRetHash()
+		w.hex(prefix+".memo.retHash", hash[:])
+	}
+
+	w.add(prefix+".operations.len", len(operations))
+	for i, op := range operations {
+		opPrefix := fmt.Sprintf("%s.operations[%d]", prefix, i)
+		if w.present(opPrefix+".sourceAccount", op.SourceAccount != nil) {
+			w.muxedAccount(opPrefix+".sourceAccount", *op.SourceAccount)
+		}
+		w.operationBody(opPrefix+".body", op.Body)
+	}
+}
+
+func (w *txrepWriter) signatures(prefix string, signatures []xdr.DecoratedSignature) {
+	w.add(prefix+".len", len(signatures))
+	for i, signature := range signatures {
+		w.hex(fmt.Sprintf("%s[%d].hint", prefix, i), signature.Hint[:])
+		w.hex(fmt.Sprintf("%s[%d].signature", prefix, i), signature.Signature)
+	}
+}
+
+func (w *txrepWriter) operationBody(prefix string, body xdr.OperationBody) {
+	w.enum(prefix+".type", txrepOperationTypes, int32(body.Type))
+
+	switch body.Type {
+	case xdr.OperationTypeCreateAccount:
+		op := body.MustCreateAccountOp()
+		prefix += ".createAccountOp"
+		w.accountID(prefix+".destination", op.Destination)
+		w.amount(prefix+".startingBalance", op.StartingBalance)
+	case xdr.OperationTypePayment:
+		op := body.MustPaymentOp()
+		prefix += ".paymentOp"
+		w.muxedAccount(prefix+".destination", op.Destination)
+		w.asset(prefix+".asset", op.Asset)
+		w.amount(prefix+".amount", op.Amount)
+	case xdr.OperationTypePathPaymentStrictReceive:
+		op := body.MustPathPaymentStrictReceiveOp()
+		prefix += ".pathPaymentStrictReceiveOp"
+		w.asset(prefix+".sendAsset", op.SendAsset)
+		w.amount(prefix+".sendMax", op.SendMax)
+		w.muxedAccount(prefix+".destination", op.Destination)
+		w.asset(prefix+".destAsset", op.DestAsset)
+		w.amount(prefix+".destAmount", op.DestAmount)
+		w.path(prefix+".path", op.Path)
+	case xdr.OperationTypeManageSellOffer:
+		op := body.MustManageSellOfferOp()
+		prefix += ".manageSellOfferOp"
+		w.asset(prefix+".selling", op.Selling)
+		w.asset(prefix+".buying", op.Buying)
+		w.amount(prefix+".amount", op.Amount)
+		w.price(prefix+".price", op.Price)
+		w.add(prefix+".offerID", op.OfferId)
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		op := body.MustCreatePassiveSellOfferOp()
+		prefix += ".createPassiveSellOfferOp"
+		w.asset(prefix+".selling", op.Selling)
+		w.asset(prefix+".buying", op.Buying)
+		w.amount(prefix+".amount", op.Amount)
+		w.price(prefix+".price", op.Price)
+	case xdr.OperationTypeSetOptions:
+		w.setOptions(prefix+".setOptionsOp", body.MustSetOptionsOp())
+	case xdr.OperationTypeChangeTrust:
+		op := body.MustChangeTrustOp()
+		prefix += ".changeTrustOp"
+		w.asset(prefix+".line", op.Line)
+		w.amount(prefix+".limit", op.Limit)
+	case xdr.OperationTypeAllowTrust:
+		op := body.MustAllowTrustOp()
+		prefix += ".allowTrustOp"
+		w.accountID(prefix+".trustor", op.Trustor)
+		var typ, code, issuer string
+		if err := op.Asset.ToAsset(op.Trustor).Extract(&typ, &code, &issuer); err != nil {
+			w.fail(prefix+".asset", err)
+		}
+		w.add(prefix+".asset", code)
+		w.add(prefix+".authorize", op.Authorize)
+	case xdr.OperationTypeAccountMerge:
+		w.muxedAccount(prefix+".destination", body.MustDestination())
+	case xdr.OperationTypeManageData:
+		op := body.MustManageDataOp()
+		prefix += ".manageDataOp"
+		w.str(prefix+".dataName", string(op.DataName))
+		if w.present(prefix+".dataValue", op.DataValue != nil) {
+			w.hex(prefix+".dataValue", *op.DataValue)
+		}
+	case xdr.OperationTypeBumpSequence:
+		w.add(prefix+".bumpSequenceOp.bumpTo", body.MustBumpSequenceOp().BumpTo)
+	case xdr.OperationTypeManageBuyOffer:
+		op := body.MustManageBuyOfferOp()
+		prefix += ".manageBuyOfferOp"
+		w.asset(prefix+".selling", op.Selling)
+		w.asset(prefix+".buying", op.Buying)
+		w.amount(prefix+".buyAmount", op.BuyAmount)
+		w.price(prefix+".price", op.Price)
+		w.add(prefix+".offerID", op.OfferId)
+	case xdr.OperationTypePathPaymentStrictSend:
+		op := body.MustPathPaymentStrictSendOp()
+		prefix += ".pathPaymentStrictSendOp"
+		w.asset(prefix+".sendAsset", op.SendAsset)
+		w.amount(prefix+".sendAmount", op.SendAmount)
+		w.muxedAccount(prefix+".destination", op.Destination)
+		w.asset(prefix+".destAsset", op.DestAsset)
+		w.amount(prefix+".destMin", op.DestMin)
+		w.path(prefix+".path", op.Path)
+	case xdr.OperationTypeCreateClaimableBalance:
+		op := body.MustCreateClaimableBalanceOp()
+		prefix += ".createClaimableBalanceOp"
+		w.asset(prefix+".asset", op.Asset)
+		w.amount(prefix+".amount", op.Amount)
+		w.add(prefix+".claimants.len", len(op.Claimants))
+		for i, claimant := range op.Claimants {
+			claimantPrefix := fmt.Sprintf("%s.claimants[%d]", prefix, i)
+			w.enum(claimantPrefix+".type", txrepClaimantTypes, int32(claimant.Type))
+			if claimant.Type == xdr.ClaimantTypeClaimantTypeV0 {
+				v0 := claimant.MustV0()
+				w.accountID(claimantPrefix+".v0.destination", v0.Destination)
+				w.claimPredicate(claimantPrefix+".v0.predicate", v0.Predicate)
+			}
+		}
+	case xdr.OperationTypeClaimClaimableBalance:
+		w.claimableBalanceID(prefix+".claimClaimableBalanceOp.balanceID", body.MustClaimClaimableBalanceOp().BalanceId)
+	case xdr.OperationTypeBeginSponsoringFutureReserves:
+		w.accountID(prefix+".beginSponsoringFutureReservesOp.sponsoredID", body.MustBeginSponsoringFutureReservesOp().SponsoredId)
+	case xdr.OperationTypeRevokeSponsorship:
+		w.revokeSponsorship(prefix+".revokeSponsorshipOp", body.MustRevokeSponsorshipOp())
+	}
+}
+
+func (w *txrepWriter) path(prefix string, path []xdr.Asset) {
+	w.add(prefix+".len", len(path))
+	for i, asset := range path {
+		w.asset(fmt.Sprintf("%s[%d]", prefix, i), asset)
+	}
+}
+
+func (w *txrepWriter) setOptions(prefix string, op xdr.SetOptionsOp) {
+	if w.present(prefix+".inflationDest", op.InflationDest != nil) {
+		w.accountID(prefix+".inflationDest", *op.InflationDest)
+	}
+	for _, field := range []struct {
+		name  string
+		value *xdr.Uint32
+	}{
+		{"clearFlags", op.ClearFlags},
+		{"setFlags", op.SetFlags},
+		{"masterWeight", op.MasterWeight},
+		{"lowThreshold", op.LowThreshold},
+		{"medThreshold", op.MedThreshold},
+		{"highThreshold", op.HighThreshold},
+	} {
+		if w.present(prefix+"."+field.name, field.value != nil) {
+			w.add(prefix+"."+field.name, *field.value)
+		}
+	}
+	if w.present(prefix+".homeDomain", op.HomeDomain != nil) {
+		w.str(prefix+".homeDomain", string(*op.HomeDomain))
+	}
+	if w.present(prefix+".signer", op.Signer != nil) {
+		w.signerKey(prefix+".signer.key", op.Signer.Key)
+		w.add(prefix+".signer.weight", op.Signer.Weight)
+	}
+}
+
+func (w *txrepWriter) claimPredicate(prefix string, predicate xdr.ClaimPredicate) {
+	w.enum(prefix+".type", txrepClaimPredicateTypes, int32(predicate.Type))
+
+	switch predicate.Type {
+	case xdr.ClaimPredicateTypeClaimPredicateAnd:
+		w.claimPredicates(prefix+".andPredicates", predicate.MustAndPredicates())
+	case xdr.ClaimPredicateTypeClaimPredicateOr:
+		w.claimPredicates(prefix+".orPredicates", predicate.MustOrPredicates())
+	case xdr.ClaimPredicateTypeClaimPredicateNot:
+		inner := predicate.MustNotPredicate()
+		if w.present(prefix+".notPredicate", inner != nil) {
+			w.claimPredicate(prefix+".notPredicate", *inner)
+		}
+	case xdr.ClaimPredicateTypeClaimPredicateBeforeAbsoluteTime:
+		w.add(prefix+".absBefore", predicate.MustAbsBefore())
+	case xdr.ClaimPredicateTypeClaimPredicateBeforeRelativeTime:
+		w.add(prefix+".relBefore", predicate.MustRelBefore())
+	}
+}
+
+func (w *txrepWriter) claimPredicates(prefix string, predicates []xdr.ClaimPredicate) {
+	w.add(prefix+".len", len(predicates))
+	for i, predicate := range predicates {
+		w.claimPredicate(fmt.Sprintf("%s[%d]", prefix, i), predicate)
+	}
+}
+
+func (w *txrepWriter) claimableBalanceID(prefix string, id xdr.ClaimableBalanceId) {
+	w.enum(prefix+".type", txrepClaimableBalanceIDTypes, int32(id.Type))
+	if id.Type == xdr.ClaimableBalanceIdTypeClaimableBalanceIdTypeV0 {
+		hash := id.MustV0()
+		w.hex(prefix+".v0", hash[:])
+	}
+}
+
+func (w *txrepWriter) revokeSponsorship(prefix string, op xdr.RevokeSponsorshipOp) {
+	w.enum(prefix+".type", txrepRevokeSponsorshipTypes, int32(op.Type))
+
+	switch op.Type {
+	case xdr.RevokeSponsorshipTypeRevokeSponsorshipLedgerEntry:
+		key := op.MustLedgerKey()
+		prefix += ".ledgerKey"
+		w.enum(prefix+".type", txrepLedgerEntryTypes, int32(key.Type))
+		switch key.Type {
+		case xdr.LedgerEntryTypeAccount:
+			w.accountID(prefix+".account.accountID", key.MustAccount().AccountId)
+		case xdr.LedgerEntryTypeTrustline:
+			trustLine := key.MustTrustLine()
+			w.accountID(prefix+".trustLine.accountID", trustLine.AccountId)
+			w.asset(prefix+".trustLine.asset", trustLine.Asset)
+		case xdr.LedgerEntryTypeOffer:
+			offer := key.MustOffer()
+			w.accountID(prefix+".offer.sellerID", offer.SellerId)
+			w.add(prefix+".offer.offerID", offer.OfferId)
+		case xdr.LedgerEntryTypeData:
+			data := key.MustData()
+			w.accountID(prefix+".data.accountID", data.AccountId)
+			w.str(prefix+".data.dataName", string(data.DataName))
+		case xdr.LedgerEntryTypeClaimableBalance:
+			w.claimableBalanceID(prefix+".claimableBalance.balanceID", key.MustClaimableBalance().BalanceId)
+		}
+	case xdr.RevokeSponsorshipTypeRevokeSponsorshipSigner:
+		signer := op.MustSigner()
+		w.accountID(prefix+".signer.accountID", signer.AccountId)
+		w.signerKey(prefix+".signer.signerKey", signer.SignerKey)
+	}
+}
+
+type txrepLine struct {
+	number int
+	value  string
+}
+
+// txrepReader reads the values of a txrep document. The first error is kept
+// in err, after which all reads return zero values.
+type txrepReader struct {
+	lines map[string]txrepLine
+	used  map[string]bool
+	err   error
+}
+
+func newTxrepReader(txrep string) (*txrepReader, error) {
+	r := &txrepReader{
+		lines: map[string]txrepLine{},
+		used:  map[string]bool{},
+	}
+	for i, line := range strings.Split(txrep, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		separator := strings.Index(line, ":")
+		if separator <= 0 {
+			return nil, errors.Errorf("line %d: expected `key: value`", i+1)
+		}
+		key := strings.TrimSpace(line[:separator])
+		if previous, ok := r.lines[key]; ok {
+			return nil, errors.Errorf("line %d: %s is already set on line %d", i+1, key, previous.number)
+		}
+		r.lines[key] = txrepLine{number: i + 1, value: strings.TrimSpace(line[separator+1:])}
+	}
+	return r, nil
+}
+
+// unused returns an error for the first key which was not read.
+func (r *txrepReader) unused() error {
+	first := ""
+	for key, line := range r.lines {
+		if !r.used[key] && (first == "" || line.number < r.lines[first].number) {
+			first = key
+		}
+	}
+	if first == "" {
+		return nil
+	}
+	return errors.Errorf("line %d: unexpected key %s", r.lines[first].number, first)
+}
+
+func (r *txrepReader) fail(key string, line txrepLine, err error) {
+	if r.err == nil {
+		r.err = errors.Errorf("line %d: invalid %s %q: %s", line.number, key, line.value, err)
+	}
+}
+
+// raw returns the line of key.
+func (r *txrepReader) raw(key string) (txrepLine, bool) {
+	if r.err != nil {
+		return txrepLine{}, false
+	}
+	line, ok := r.lines[key]
+	if !ok {
+		r.err = errors.Errorf("missing %s", key)
+		return txrepLine{}, false
+	}
+	r.used[key] = true
+	return line, true
+}
+
+// value returns the value of key without its comment.
+func (r *txrepReader) value(key string) (string, txrepLine, bool) {
+	line, ok := r.raw(key)
+	if !ok {
+		return "", line, false
+	}
+	value := line.value
+	if end := strings.IndexAny(value, " \t"); end >= 0 {
+		value = value[:end]
+	}
+	return value, line, true
+}
+
+func (r *txrepReader) int64(key string) int64 {
+	value, line, ok := r.value(key)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		r.fail(key, line, errors.New("expected an integer"))
+	}
+	return n
+}
+
+func (r *txrepReader) uint64(key string) uint64 {
+	value, line, ok := r.value(key)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		r.fail(key, line, errors.New("expected an unsigned integer"))
+	}
+	return n
+}
+
+func (r *txrepReader) int32(key string) int32 {
+	value, line, ok := r.value(key)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseInt(value, 10, 32)
+	if err != nil {
+		r.fail(key, line, errors.New("expected a 32-bit integer"))
+	}
+	return int32(n)
+}
+
+func (r *txrepReader) uint32(key string) uint32 {
+	value, line, ok := r.value(key)
+	if !ok {
+		return 0
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		r.fail(key, line, errors.New("expected a 32-bit unsigned integer"))
+	}
+	return uint32(n)
+}
+
+// len returns the length of the array at prefix.
+func (r *txrepReader) len(prefix string) int {
+	return int(r.uint32(prefix + ".len"))
+}
+
+// present returns true if the optional value at prefix is set.
+func (r *txrepReader) present(prefix string) bool {
+	key := prefix + "._present"
+	value, line, ok := r.value(key)
+	if !ok {
+		return false
+	}
+	present, err := strconv.ParseBool(value)
+	if err != nil {
+		r.fail(key, line, errors.New("expected true or false"))
+	}
+	return present
+}
+
+func (r *txrepReader) str(key string) string {
+	line, ok := r.raw(key)
+	if !ok {
+		return ""
+	}
+	value := line.value
+	// Find the closing quote, skipping escaped characters.
+	end := -1
+	if strings.HasPrefix(value, `"`) {
+		for i := 1; i < len(value); i++ {
+			if value[i] == '\\' {
+				i++
+			} else if value[i] == '"' {
+				end = i
+				break
+			}
+		}
+	}
+	if end < 0 {
+		r.fail(key, line, errors.New("expected a double quoted string"))
+		return ""
+	}
+	s, err := strconv.Unquote(value[:end+1])
+	if err != nil {
+		r.fail(key, line, err)
+	}
+	return s
+}
+
+func (r *txrepReader) hex(key string) []byte {
+	value, line, ok := r.value(key)
+	if !ok {
+		return nil
+	}
+	b, err := hex.DecodeString(value)
+	if err != nil {
+		r.fail(key, line, errors.New("expected a hex value"))
+	}
+	return b
+}
+
+func (r *txrepReader) hash(key string) xdr.Hash {
+	var hash xdr.Hash
+	b := r.hex(key)
+	if r.err == nil && len(b) != len(hash) {
+		line := r.lines[key]
+		r.fail(key, line, errors.Errorf("expected %d bytes", len(hash)))
+	}
+	copy(hash[:], b)
+	return hash
+}
+
+func (r *txrepReader) enum(key string, names map[int32]string) int32 {
+	value, line, ok := r.value(key)
+	if !ok {
+		return 0
+	}
+	for n, name := range names {
+		if name == value {
+			return n
+		}
+	}
+	r.fail(key, line, errors.New("unknown value"))
+	return 0
+}
+
+func (r *txrepReader) accountID(key string) xdr.AccountId {
+	value, line, ok := r.value(key)
+	if !ok {
+		return xdr.AccountId{}
+	}
+	aid, err := xdr.AddressToAccountId(value)
+	if err != nil {
+		r.fail(key, line, errors.New("expected an account address"))
+	}
+	return aid
+}
+
+func (r *txrepReader) muxedAccount(key string) xdr.MuxedAccount {
+	aid := r.accountID(key)
+	return aid.ToMuxedAccount()
+}
+
+func (r *txrepReader) signerKey(key string) xdr.SignerKey {
+	value, line, ok := r.value(key)
+	if !ok {
+		return xdr.SignerKey{}
+	}
+	var signerKey xdr.SignerKey
+	if err := signerKey.SetAddress(value); err != nil {
+		r.fail(key, line, errors.New("expected a signer key"))
+	}
+	return signerKey
+}
+
+func (r *txrepReader) asset(key string) xdr.Asset {
+	value, line, ok := r.value(key)
+	if !ok {
+		return xdr.Asset{}
+	}
+	if value == "native" {
+		return xdr.MustNewNativeAsset()
+	}
+	parts := strings.SplitN(value, ":", 2)
+	if len(parts) != 2 {
+		r.fail(key, line, errors.New("expected native or CODE:ISSUER"))
+		return xdr.Asset{}
+	}
+	asset, err := xdr.NewCreditAsset(parts[0], parts[1])
+	if err != nil {
+		r.fail(key, line, err)
+	}
+	return asset
+}
+
+func (r *txrepReader) price(prefix string) xdr.Price {
+	return xdr.Price{
+		N: xdr.Int32(r.int32(prefix + ".n")),
+		D: xdr.Int32(r.int32(prefix + ".d")),
+	}
+}
+
+func envelopeFromTxRep(txrep string) (xdr.TransactionEnvelope, error) {
+	r, err := newTxrepReader(txrep)
+	if err != nil {
+		return xdr.TransactionEnvelope{}, err
+	}
+
+	envelope := xdr.TransactionEnvelope{
+		Type: xdr.EnvelopeType(r.enum("type", txrepEnvelopeTypes)),
+	}
+	switch envelope.Type {
+	case xdr.EnvelopeTypeEnvelopeTypeTxV0:
+		source := r.accountID("tx.sourceAccountEd25519")
+		tx := xdr.TransactionV0{}
+		if source.Ed25519 != nil {
+			tx.SourceAccountEd25519 = *source.Ed25519
+		}
+		tx.Fee, tx.SeqNum, tx.TimeBounds, tx.Memo, tx.Operations = r.transactionBody("tx")
+		tx.Ext.V = r.int32("tx.ext.v")
+		envelope.V0 = &xdr.TransactionV0Envelope{
+			Tx:         tx,
+			Signatures: r.signatures("signatures"),
+		}
+	case xdr.EnvelopeTypeEnvelopeTypeTx:
+		envelope.V1 = &xdr.TransactionV1Envelope{
+			Tx:         r.transaction("tx"),
+			Signatures: r.signatures("signatures"),
+		}
+	case xdr.EnvelopeTypeEnvelopeTypeTxFeeBump:
+		tx := xdr.FeeBumpTransaction{
+			FeeSource: r.muxedAccount("feeBump.tx.feeSource"),
+			Fee:       xdr.Int64(r.int64("feeBump.tx.fee")),
+		}
+		tx.InnerTx.Type = xdr.EnvelopeType(r.enum("feeBump.tx.innerTx.type", txrepEnvelopeTypes))
+		if r.err == nil && tx.InnerTx.Type != xdr.EnvelopeTypeEnvelopeTypeTx {
+			r.fail("feeBump.tx.innerTx.type", r.lines["feeBump.tx.innerTx.type"], errors.New("expected ENVELOPE_TYPE_TX"))
+		}
+		tx.InnerTx.V1 = &xdr.TransactionV1Envelope{
+			Tx:         r.transaction("feeBump.tx.innerTx.tx"),
+			Signatures: r.signatures("feeBump.tx.innerTx.signatures"),
+		}
+		tx.Ext.V = r.int32("feeBump.tx.ext.v")
+		envelope.FeeBump = &xdr.FeeBumpTransactionEnvelope{
+			Tx:         tx,
+			Signatures: r.signatures("feeBump.signatures"),
+		}
+	}
+
+	if r.err != nil {
+		return xdr.TransactionEnvelope{}, r.err
+	}
+	if err := r.unused(); err != nil {
+		return xdr.TransactionEnvelope{}, err
+	}
+	return envelope, nil
+}
+
+func (r *txrepReader) transaction(prefix string) xdr.Transaction {
+	tx := xdr.Transaction{SourceAccount: r.muxedAccount(prefix + ".sourceAccount")}
+	tx.Fee, tx.SeqNum, tx.TimeBounds, tx.Memo, tx.Operations = r.transactionBody(prefix)
+	tx.Ext.V = r.int32(prefix + ".ext.v")
+	return tx
+}
+
+func (r *txrepReader) transactionBody(prefix string) (
+	xdr.Uint32, xdr.SequenceNumber, *xdr.TimeBounds, xdr.Memo, []xdr.Operation,
+) {
+	fee := xdr.Uint32(r.uint32(prefix + ".fee"))
+	seqNum := xdr.SequenceNumber(r.int64(prefix + ".seqNum"))
+
+	var timeBounds *xdr.TimeBounds
+	if r.present(prefix + ".timeBounds") {
+		timeBounds = &xdr.TimeBounds{
+			MinTime: xdr.TimePoint(r.uint64(prefix + ".timeBounds.minTime")),
+			MaxTime: xdr.TimePoint(r.uint64(prefix + ".timeBounds.maxTime")),
+		}
+	}
+
+	memo := xdr.Memo{Type: xdr.MemoType(r.enum(prefix+".memo.type", txrepMemoTypes))}
+	switch memo.Type {
+	case xdr.MemoTypeMemoText:
+		text := r.str(prefix + ".memo.text")
+		memo.Text = &text
+	case xdr.MemoTypeMemoId:
+		id := xdr.Uint64(r.uint64(prefix + ".memo.id"))
+		memo.Id = &id
+	case xdr.MemoTypeMemoHash:
+		hash := r.hash(prefix + ".memo.hash")
+		memo.Hash = &hash
+	case xdr.MemoTypeMemoReturn:
+		hash := r.hash(prefix + ".memo.retHash")
+		memo.RetHash = &hash
+	}
+
+	var operations []xdr.Operation
+	n := r.len(prefix + ".operations")
+	for i := 0; i < n && r.err == nil; i++ {
+		opPrefix := fmt.Sprintf("%s.operations[%d]", prefix, i)
+		var op xdr.Operation
+		if r.present(opPrefix + ".sourceAccount") {
+			source := r.muxedAccount(opPrefix + ".sourceAccount")
+			op.SourceAccount = &source
+		}
+		op.Body = r.operationBody(opPrefix + ".body")
+		operations = append(operations, op)
+	}
+	return fee, seqNum, timeBounds, memo, operations
+}
+
+func (r *txrepReader) signatures(prefix string) []xdr.DecoratedSignature {
+	var signatures []xdr.DecoratedSignature
+	n := r.len(prefix)
+	for i := 0; i < n && r.err == nil; i++ {
+		key := fmt.Sprintf("%s[%d].hint", prefix, i)
+		var signature xdr.DecoratedSignature
+		hint := r.hex(key)
+		if r.err == nil && len(hint) != len(signature.Hint) {
+			r.fail(key, r.lines[key], errors.Errorf("expected %d bytes", len(signature.Hint)))
+		}
+		copy(signature.Hint[:], hint)
+		signature.Signature = r.hex(fmt.Sprintf("%s[%d].signature", prefix, i))
+		signatures = append(signatures, signature)
+	}
+	return signatures
+}
+
+func (r *txrepReader) operationBody(prefix string) xdr.OperationBody {
+	body := xdr.OperationBody{Type: xdr.OperationType(r.enum(prefix+".type", txrepOperationTypes))}
+
+	switch body.Type {
+	case xdr.OperationTypeCreateAccount:
+		prefix += ".createAccountOp"
+		body.CreateAccountOp = &xdr.CreateAccountOp{
+			Destination:     r.accountID(prefix + ".destination"),
+			StartingBalance: xdr.Int64(r.int64(prefix + ".startingBalance")),
+		}
+	case xdr.OperationTypePayment:
+		prefix += ".paymentOp"
+		body.PaymentOp = &xdr.PaymentOp{
+			Destination: r.muxedAccount(prefix + ".destination"),
+			Asset:       r.asset(prefix + ".asset"),
+			Amount:      xdr.Int64(r.int64(prefix + ".amount")),
+		}
+	case xdr.OperationTypePathPaymentStrictReceive:
+		prefix += ".pathPaymentStrictReceiveOp"
+		body.PathPaymentStrictReceiveOp = &xdr.PathPaymentStrictReceiveOp{
+			SendAsset:   r.asset(prefix + ".sendAsset"),
+			SendMax:     xdr.Int64(r.int64(prefix + ".sendMax")),
+			Destination: r.muxedAccount(prefix + ".destination"),
+			DestAsset:   r.asset(prefix + ".destAsset"),
+			DestAmount:  xdr.Int64(r.int64(prefix + ".destAmount")),
+			Path:        r.path(prefix + ".path"),
+		}
+	case xdr.OperationTypeManageSellOffer:
+		prefix += ".manageSellOfferOp"
+		body.ManageSellOfferOp = &xdr.ManageSellOfferOp{
+			Selling: r.asset(prefix + ".selling"),
+			Buying:  r.asset(prefix + ".buying"),
+			Amount:  xdr.Int64(r.int64(prefix + ".amount")),
+			Price:   r.price(prefix + ".price"),
+			OfferId: xdr.Int64(r.int64(prefix + ".offerID")),
+		}
+	case xdr.OperationTypeCreatePassiveSellOffer:
+		prefix += ".createPassiveSellOfferOp"
+		body.CreatePassiveSellOfferOp = &xdr.CreatePassiveSellOfferOp{
+			Selling: r.asset(prefix + ".selling"),
+			Buying:  r.asset(prefix + ".buying"),
+			Amount:  xdr.Int64(r.int64(prefix + ".amount")),
+			Price:   r.price(prefix + ".price"),
+		}
+	case xdr.OperationTypeSetOptions:
+		op := r.setOptions(prefix + ".setOptionsOp")
+		body.SetOptionsOp = &op
+	case xdr.OperationTypeChangeTrust:
+		prefix += ".changeTrustOp"
+		body.ChangeTrustOp = &xdr.ChangeTrustOp{
+			Line:  r.asset(prefix + ".line"),
+			Limit: xdr.Int64(r.int64(prefix + ".limit")),
+		}
+	case xdr.OperationTypeAllowTrust:
+		prefix += ".allowTrustOp"
+		op := xdr.AllowTrustOp{Trustor: r.accountID(prefix + ".trustor")}
+		code, line, ok := r.value(prefix + ".asset")
+		if ok {
+			asset, err := xdr.NewAllowTrustAsset(code)
+			if err != nil {
+				r.fail(prefix+".asset", line, err)
+			}
+			op.Asset = asset
+		}
+		op.Authorize = xdr.Uint32(r.uint32(prefix + ".authorize"))
+		body.AllowTrustOp = &op
+	case xdr.OperationTypeAccountMerge:
+		destination := r.muxedAccount(prefix + ".destination")
+		body.Destination = &destination
+	case xdr.OperationTypeManageData:
+		prefix += ".manageDataOp"
+		op := xdr.ManageDataOp{DataName: xdr.String64(r.str(prefix + ".dataName"))}
+		if r.present(prefix + ".dataValue") {
+			value := xdr.DataValue(r.hex(prefix + ".dataValue"))
+			op.DataValue = &value
+		}
+		body.ManageDataOp = &op
+	case xdr.OperationTypeBumpSequence:
+		body.BumpSequenceOp = &xdr.BumpSequenceOp{
+			BumpTo: xdr.SequenceNumber(r.int64(prefix + ".bumpSequenceOp.bumpTo")),
+		}
+	case xdr.OperationTypeManageBuyOffer:
+		prefix += ".manageBuyOfferOp"
+		body.ManageBuyOfferOp = &xdr.ManageBuyOfferOp{
+			Selling:   r.asset(prefix + ".selling"),
+			Buying:    r.asset(prefix + ".buying"),
+			BuyAmount: xdr.Int64(r.int64(prefix + ".buyAmount")),
+			Price:     r.price(prefix + ".price"),
+			OfferId:   xdr.Int64(r.int64(prefix + ".offerID")),
+		}
+	case xdr.OperationTypePathPaymentStrictSend:
+		prefix += ".pathPaymentStrictSendOp"
+		body.PathPaymentStrictSendOp = &xdr.PathPaymentStrictSendOp{
+			SendAsset:   r.asset(prefix + ".sendAsset"),
+			SendAmount:  xdr.Int64(r.int64(prefix + ".sendAmount")),
+			Destination: r.muxedAccount(prefix + ".destination"),
+			DestAsset:   r.asset(prefix + ".destAsset"),
+			DestMin:     xdr.Int64(r.int64(prefix + ".destMin")),
+			Path:        r.path(prefix + ".path"),
+		}
+	case xdr.OperationTypeCreateClaimableBalance:
+		prefix += ".createClaimableBalanceOp"
+		op := xdr.CreateClaimableBalanceOp{
+			Asset:  r.asset(prefix + ".asset"),
+			Amount: xdr.Int64(r.int64(prefix + ".amount")),
+		}
+		n := r.len(prefix + ".claimants")
+		for i := 0; i < n && r.err == nil; i++ {
+			claimantPrefix := fmt.Sprintf("%s.claimants[%d]", prefix, i)
+			claimant := xdr.Claimant{Type: xdr.ClaimantType(r.enum(claimantPrefix+".type", txrepClaimantTypes))}
+			if claimant.Type == xdr.ClaimantTypeClaimantTypeV0 {
+				claimant.V0 = &xdr.ClaimantV0{
+					Destination: r.accountID(claimantPrefix + ".v0.destination"),
+					Predicate:   r.claimPredicate(claimantPrefix + ".v0.predicate"),
+				}
+			}
+			op.Claimants = append(op.Claimants, claimant)
+		}
+		body.CreateClaimableBalanceOp = &op
+	case xdr.OperationTypeClaimClaimableBalance:
+		body.ClaimClaimableBalanceOp = &xdr.ClaimClaimableBalanceOp{
+			BalanceId: r.claimableBalanceID(prefix + ".claimClaimableBalanceOp.balanceID"),
+		}
+	case xdr.OperationTypeBeginSponsoringFutureReserves:
+		body.BeginSponsoringFutureReservesOp = &xdr.BeginSponsoringFutureReservesOp{
+			SponsoredId: r.accountID(prefix + ".beginSponsoringFutureReservesOp.sponsoredID"),
+		}
+	case xdr.OperationTypeRevokeSponsorship:
+		op := r.revokeSponsorship(prefix + ".revokeSponsorshipOp")
+		body.RevokeSponsorshipOp = &op
+	}
+	return body
+}
+
+func (r *txrepReader) path(prefix string) []xdr.Asset {
+	var path []xdr.Asset
+	n := r.len(prefix)
+	for i := 0; i < n && r.err == nil; i++ {
+		path = append(path, r.asset(fmt.Sprintf("%s[%d]", prefix, i)))
+	}
+	return path
+}
+
+func (r *txrepReader) setOptions(prefix string) xdr.SetOptionsOp {
+	var op xdr.SetOptionsOp
+	if r.present(prefix + ".inflationDest") {
+		dest := r.accountID(prefix + ".inflationDest")
+		op.InflationDest = &dest
+	}
+	for _, field := range []struct {
+		name  string
+		value **xdr.Uint32
+	}{
+		{"clearFlags", &op.ClearFlags},
+		{"setFlags", &op.SetFlags},
+		{"masterWeight", &op.MasterWeight},
+		{"lowThreshold", &op.LowThreshold},
+		{"medThreshold", &op.MedThreshold},
+		{"highThreshold", &op.HighThreshold},
+	} {
+		if r.present(prefix + "." + field.name) {
+			value := xdr.Uint32(r.uint32(prefix + "." + field.name))
+			*field.value = &value
+		}
+	}
+	if r.present(prefix + ".homeDomain") {
+		homeDomain := xdr.String32(r.str(prefix + ".homeDomain"))
+		op.HomeDomain = &homeDomain
+	}
+	if r.present(prefix + ".signer") {
+		op.Signer = &xdr.Signer{
+			Key:    r.signerKey(prefix + ".signer.key"),
+			Weight: xdr.Uint32(r.uint32(prefix + ".signer.weight")),
+		}
+	}
+	return op
+}
+
+func (r *txrepReader) claimPredicate(prefix string) xdr.ClaimPredicate {
+	predicate := xdr.ClaimPredicate{
+		Type: xdr.ClaimPredicateType(r.enum(prefix+".type", txrepClaimPredicateTypes)),
+	}
+
+	switch predicate.Type {
+	case xdr.ClaimPredicateTypeClaimPredicateAnd:
+		predicates := r.claimPredicates(prefix + ".andPredicates")
+		predicate.AndPredicates = &predicates
+	case xdr.ClaimPredicateTypeClaimPredicateOr:
+		predicates := r.claimPredicates(prefix + ".orPredicates")
+		predicate.OrPredicates = &predicates
+	case xdr.ClaimPredicateTypeClaimPredicateNot:
+		predicate.NotPredicate = new(*xdr.ClaimPredicate)
+		if r.present(prefix + ".notPredicate") {
+			inner := r.claimPredicate(prefix + ".notPredicate")
+			*predicate.NotPredicate = &inner
+		}
+	case xdr.ClaimPredicateTypeClaimPredicateBeforeAbsoluteTime:
+		absBefore := xdr.Int64(r.int64(prefix + ".absBefore"))
+		predicate.AbsBefore = &absBefore
+	case xdr.ClaimPredicateTypeClaimPredicateBeforeRelativeTime:
+		relBefore := xdr.Int64(r.int64(prefix + ".relBefore"))
+		predicate.RelBefore = &relBefore
+	}
+	return predicate
+}
+
+func (r *txrepReader) claimPredicates(prefix string) []xdr.ClaimPredicate {
+	var predicates []xdr.ClaimPredicate
+	n := r.len(prefix)
+	for i := 0; i < n && r.err == nil; i++ {
+		predicates = append(predicates, r.claimPredicate(fmt.Sprintf("%s[%d]", prefix, i)))
+	}
+	return predicates
+}
+
+func (r *txrepReader) claimableBalanceID(prefix string) xdr.ClaimableBalanceId {
+	id := xdr.ClaimableBalanceId{
+		Type: xdr.ClaimableBalanceIdType(r.enum(prefix+".type", txrepClaimableBalanceIDTypes)),
+	}
+	if id.Type == xdr.ClaimableBalanceIdTypeClaimableBalanceIdTypeV0 {
+		hash := r.hash(prefix + ".v0")
+		id.V0 = &hash
+	}
+	return id
+}
+
+func (r *txrepReader) revokeSponsorship(prefix string) xdr.RevokeSponsorshipOp {
+	op := xdr.RevokeSponsorshipOp{
+		Type: xdr.RevokeSponsorshipType(r.enum(prefix+".type", txrepRevokeSponsorshipTypes)),
+	}
+
+	switch op.Type {
+	case xdr.RevokeSponsorshipTypeRevokeSponsorshipLedgerEntry:
+		prefix += ".ledgerKey"
+		key := xdr.LedgerKey{Type: xdr.LedgerEntryType(r.enum(prefix+".type", txrepLedgerEntryTypes))}
+		switch key.Type {
+		case xdr.LedgerEntryTypeAccount:
+			key.Account = &xdr.LedgerKeyAccount{AccountId: r.accountID(prefix + ".account.accountID")}
+		case xdr.LedgerEntryTypeTrustline:
+			key.TrustLine = &xdr.LedgerKeyTrustLine{
+				AccountId: r.accountID(prefix + ".trustLine.accountID"),
+				Asset:     r.asset(prefix + ".trustLine.asset"),
+			}
+		case xdr.LedgerEntryTypeOffer:
+			key.Offer = &xdr.LedgerKeyOffer{
+				SellerId: r.accountID(prefix + ".offer.sellerID"),
+				OfferId:  xdr.Int64(r.int64(prefix + ".offer.offerID")),
+			}
+		case xdr.LedgerEntryTypeData:
+			key.Data = &xdr.LedgerKeyData{
+				AccountId: r.accountID(prefix + ".data.accountID"),
+				DataName:  xdr.String64(r.str(prefix + ".data.dataName")),
+			}
+		case xdr.LedgerEntryTypeClaimableBalance:
+			key.ClaimableBalance = &xdr.LedgerKeyClaimableBalance{
+				BalanceId: r.claimableBalanceID(prefix + ".claimableBalance.balanceID"),
+			}
+		}
+		op.LedgerKey = &key
+	case xdr.RevokeSponsorshipTypeRevokeSponsorshipSigner:
+		op.Signer = &xdr.RevokeSponsorshipOpSigner{
+			AccountId: r.accountID(prefix + ".signer.accountID"),
+			SignerKey: r.signerKey(prefix + ".signer.signerKey"),
+		}
+	}
+	return op
+}
@@ -0,0 +1,384 @@
+package txnbuild
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stellar/go/network"
+	"github.com/stellar/go/xdr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxRepPayment(t *testing.T) {
+	kp0 := newKeypair0()
+	kp1 := newKeypair1()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 9605939170639897)
+
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount:        &sourceAccount,
+			IncrementSequenceNum: true,
+			Operations: []Operation{&Payment{
+				Destination: kp1.Address(),
+				Amount:      "40.0004",
+				Asset:       CreditAsset{"USD", kp1.Address()},
+			}},
+			BaseFee:    MinBaseFee,
+			Memo:       MemoText("hello \"world\""),
+			Timebounds: NewTimebounds(0, 1600000000),
+		},
+	)
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, kp0)
+	require.NoError(t, err)
+
+	txrep, err := tx.TxRep()
+	require.NoError(t, err)
+	signature := tx.Signatures()[0]
+	expected := `type: ENVELOPE_TYPE_TX
+tx.sourceAccount: GDQNY3PBOJOKYZSRMK2S7LHHGWZIUISD4QORETLMXEWXBI7KFZZMKTL3
+tx.fee: 100
+tx.seqNum: 9605939170639898
+tx.timeBounds._present: true
+tx.timeBounds.minTime: 0
+tx.timeBounds.maxTime: 1600000000
+tx.memo.type: MEMO_TEXT
+tx.memo.text: "hello \"world\""
+tx.operations.len: 1
+tx.operations[0].sourceAccount._present: false
+tx.operations[0].body.type: PAYMENT
+tx.operations[0].body.paymentOp.destination: GAS4V4O2B7DW5T7IQRPEEVCRXMDZESKISR7DVIGKZQYYV3OSQ5SH5LVP
+tx.operations[0].body.paymentOp.asset: USD:GAS4V4O2B7DW5T7IQRPEEVCRXMDZESKISR7DVIGKZQYYV3OSQ5SH5LVP
+tx.operations[0].body.paymentOp.amount: 400004000 (40.0004000)
+tx.ext.v: 0
+signatures.len: 1
+signatures[0].hint: ` + hexString(signature.Hint[:]) + `
+signatures[0].signature: ` + hexString(signature.Signature) + `
+`
+	assert.Equal(t, expected, txrep)
+
+	parsed, err := TransactionFromTxRep(txrep)
+	require.NoError(t, err)
+	parsedTx, ok := parsed.Transaction()
+	require.True(t, ok)
+	assertSameEnvelope(t, tx, parsedTx)
+
+	// Comments, indentation and blank lines are ignored.
+	commented := strings.Replace(txrep, "tx.fee: 100\n", "\n  tx.fee: 100 stroops\n\n", 1)
+	commented = strings.Replace(commented, `"hello \"world\""`, `"hello \"world\"" a comment`, 1)
+	parsed, err = TransactionFromTxRep(commented)
+	require.NoError(t, err)
+	parsedTx, ok = parsed.Transaction()
+	require.True(t, ok)
+	assertSameEnvelope(t, tx, parsedTx)
+}
+
+func TestTxRepAllOperations(t *testing.T) {
+	kp0 := newKeypair0()
+	kp1 := newKeypair1()
+	kp2 := newKeypair2()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 1)
+	usd := CreditAsset{"USD", kp1.Address()}
+	eur := CreditAsset{"EURO12345678", kp2.Address()}
+	homeDomain := "example.com"
+	inflationDest := kp2.Address()
+	weight := Threshold(2)
+	accountID := kp1.Address()
+
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount:        &sourceAccount,
+			IncrementSequenceNum: true,
+			Operations: []Operation{
+				&CreateAccount{Destination: kp1.Address(), Amount: "10", SourceAccount: &SimpleAccount{AccountID: kp2.Address()}},
+				&Payment{Destination: kp1.Address(), Amount: "1", Asset: NativeAsset{}},
+				&PathPaymentStrictReceive{
+					SendAsset: NativeAsset{}, SendMax: "10", Destination: kp1.Address(),
+					DestAsset: usd, DestAmount: "1", Path: []Asset{eur, NativeAsset{}},
+				},
+				&ManageSellOffer{Selling: usd, Buying: eur, Amount: "3", Price: "0.5", OfferID: 7},
+				&CreatePassiveSellOffer{Selling: eur, Buying: NativeAsset{}, Amount: "3", Price: "2"},
+				&SetOptions{},
+				&SetOptions{
+					InflationDestination: &inflationDest,
+					SetFlags:             []AccountFlag{AuthRequired},
+					ClearFlags:           []AccountFlag{AuthRevocable},
+					MasterWeight:         &weight,
+					LowThreshold:         &weight,
+					MediumThreshold:      &weight,
+					HighThreshold:        &weight,
+					HomeDomain:           &homeDomain,
+					Signer:               &Signer{Address: kp1.Address(), Weight: 1},
+				},
+				&ChangeTrust{Line: eur, Limit: "100"},
+				&AllowTrust{Trustor: kp1.Address(), Type: CreditAsset{Code: "USD"}, Authorize: true},
+				&AccountMerge{Destination: kp1.Address()},
+				&Inflation{},
+				&ManageData{Name: "deleted"},
+				&ManageData{Name: "name", Value: []byte{0, 1, 2}},
+				&BumpSequence{BumpTo: 100},
+				&ManageBuyOffer{Selling: NativeAsset{}, Buying: usd, Amount: "4", Price: "1.5", OfferID: 0},
+				&PathPaymentStrictSend{
+					SendAsset: usd, SendAmount: "1", Destination: kp2.Address(),
+					DestAsset: NativeAsset{}, DestMin: "0.1",
+				},
+				&CreateClaimableBalance{
+					Amount: "5",
+					Asset:  usd,
+					Destinations: []Claimant{
+						NewClaimant(kp1.Address(), nil),
+						NewClaimant(kp2.Address(), &[]xdr.ClaimPredicate{
+							AndPredicate(
+								NotPredicate(BeforeAbsoluteTimePredicate(1600000000)),
+								OrPredicate(BeforeRelativeTimePredicate(60), UnconditionalPredicate),
+							),
+						}[0]),
+					},
+				},
+				&ClaimClaimableBalance{BalanceID: "00000000929b20b72e5890ab51c24f1cc46fa01c4f318d8d33367d24dd614cfdf5491072"},
+				&BeginSponsoringFutureReserves{SponsoredID: kp1.Address()},
+				&EndSponsoringFutureReserves{SourceAccount: &SimpleAccount{AccountID: kp1.Address()}},
+				&RevokeSponsorship{SponsorshipType: RevokeSponsorshipTypeAccount, Account: &accountID},
+				&RevokeSponsorship{
+					SponsorshipType: RevokeSponsorshipTypeTrustLine,
+					TrustLine:       &TrustLineID{Account: kp1.Address(), Asset: usd},
+				},
+				&RevokeSponsorship{
+					SponsorshipType: RevokeSponsorshipTypeOffer,
+					Offer:           &OfferID{SellerAccountAddress: kp1.Address(), OfferID: 3},
+				},
+				&RevokeSponsorship{
+					SponsorshipType: RevokeSponsorshipTypeData,
+					Data:            &DataID{Account: kp1.Address(), DataName: "name"},
+				},
+				&RevokeSponsorship{
+					SponsorshipType:  RevokeSponsorshipTypeClaimableBalance,
+					ClaimableBalance: &[]string{"00000000929b20b72e5890ab51c24f1cc46fa01c4f318d8d33367d24dd614cfdf5491072"}[0],
+				},
+				&RevokeSponsorship{
+					SponsorshipType: RevokeSponsorshipTypeSigner,
+					Signer:          &SignerID{AccountID: kp1.Address(), SignerAddress: kp2.Address()},
+				},
+			},
+			BaseFee:    MinBaseFee,
+			Memo:       MemoHash{1, 2, 3},
+			Timebounds: NewInfiniteTimeout(),
+		},
+	)
+	require.NoError(t, err)
+	tx, err = tx.Sign(network.TestNetworkPassphrase, kp0, kp1)
+	require.NoError(t, err)
+
+	txrep, err := tx.TxRep()
+	require.NoError(t, err)
+	assert.Contains(t, txrep, "tx.operations[0].sourceAccount: "+kp2.Address()+"\n")
+	assert.Contains(t, txrep, "tx.operations[8].body.allowTrustOp.asset: USD\n")
+	assert.Contains(t, txrep, "tx.operations[9].body.destination: "+kp1.Address()+"\n")
+	assert.Contains(t, txrep, "tx.operations[16].body.createClaimableBalanceOp.claimants[1].v0.predicate.andPredicates[0].notPredicate.absBefore: 1600000000\n")
+
+	parsed, err := TransactionFromTxRep(txrep)
+	require.NoError(t, err)
+	parsedTx, ok := parsed.Transaction()
+	require.True(t, ok)
+	assertSameEnvelope(t, tx, parsedTx)
+}
+
+func TestTxRepMemos(t *testing.T) {
+	kp0 := newKeypair0()
+	for _, memo := range []Memo{nil, MemoID(42), MemoReturn{4, 5, 6}} {
+		sourceAccount := NewSimpleAccount(kp0.Address(), 1)
+		tx, err := NewTransaction(
+			TransactionParams{
+				SourceAccount: &sourceAccount,
+				Operations:    []Operation{&BumpSequence{BumpTo: 2}},
+				BaseFee:       MinBaseFee,
+				Memo:          memo,
+				Timebounds:    NewInfiniteTimeout(),
+			},
+		)
+		require.NoError(t, err)
+
+		txrep, err := tx.TxRep()
+		require.NoError(t, err)
+		parsed, err := TransactionFromTxRep(txrep)
+		require.NoError(t, err)
+		parsedTx, ok := parsed.Transaction()
+		require.True(t, ok)
+		assertSameEnvelope(t, tx, parsedTx)
+	}
+}
+
+func TestTxRepV0(t *testing.T) {
+	kp0 := newKeypair0()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 1)
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations:    []Operation{&Inflation{}},
+			BaseFee:       MinBaseFee,
+			Timebounds:    NewInfiniteTimeout(),
+		},
+	)
+	require.NoError(t, err)
+	convertToV0(tx)
+
+	txrep, err := tx.TxRep()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(txrep, "type: ENVELOPE_TYPE_TX_V0\ntx.sourceAccountEd25519: "+kp0.Address()+"\n"))
+
+	parsed, err := TransactionFromTxRep(txrep)
+	require.NoError(t, err)
+	parsedTx, ok := parsed.Transaction()
+	require.True(t, ok)
+	assertSameEnvelope(t, tx, parsedTx)
+}
+
+func TestTxRepFeeBump(t *testing.T) {
+	kp0 := newKeypair0()
+	kp1 := newKeypair1()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 1)
+	inner, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations:    []Operation{&Inflation{}},
+			BaseFee:       MinBaseFee,
+			Timebounds:    NewInfiniteTimeout(),
+		},
+	)
+	require.NoError(t, err)
+	inner, err = inner.Sign(network.TestNetworkPassphrase, kp0)
+	require.NoError(t, err)
+
+	feeBump, err := NewFeeBumpTransaction(
+		FeeBumpTransactionParams{
+			Inner:      inner,
+			FeeAccount: kp1.Address(),
+			BaseFee:    2 * MinBaseFee,
+		},
+	)
+	require.NoError(t, err)
+	feeBump, err = feeBump.Sign(network.TestNetworkPassphrase, kp1)
+	require.NoError(t, err)
+
+	txrep, err := feeBump.TxRep()
+	require.NoError(t, err)
+	assert.Contains(t, txrep, "feeBump.tx.feeSource: "+kp1.Address()+"\n")
+	assert.Contains(t, txrep, "feeBump.tx.innerTx.tx.sourceAccount: "+kp0.Address()+"\n")
+	assert.Contains(t, txrep, "feeBump.signatures.len: 1\n")
+
+	parsed, err := TransactionFromTxRep(txrep)
+	require.NoError(t, err)
+	parsedFeeBump, ok := parsed.FeeBump()
+	require.True(t, ok)
+
+	expected, err := feeBump.Base64()
+	require.NoError(t, err)
+	actual, err := parsedFeeBump.Base64()
+	require.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestTxRepParseErrors(t *testing.T) {
+	kp0 := newKeypair0()
+	sourceAccount := NewSimpleAccount(kp0.Address(), 1)
+	tx, err := NewTransaction(
+		TransactionParams{
+			SourceAccount: &sourceAccount,
+			Operations:    []Operation{&Payment{Destination: kp0.Address(), Amount: "1", Asset: NativeAsset{}}},
+			BaseFee:       MinBaseFee,
+			Memo:          MemoText("memo"),
+			Timebounds:    NewInfiniteTimeout(),
+		},
+	)
+	require.NoError(t, err)
+	txrep, err := tx.TxRep()
+	require.NoError(t, err)
+
+	for _, testCase := range []struct {
+		name    string
+		old     string
+		new     string
+		message string
+	}{
+		{
+			"not a key value pair",
+			"tx.fee: 100\n",
+			"tx.fee 100\n",
+			"unable to parse txrep: line 3: expected `key: value`",
+		},
+		{
+			"duplicate key",
+			"tx.fee: 100\n",
+			"tx.fee: 100\ntx.fee: 200\n",
+			"unable to parse txrep: line 4: tx.fee is already set on line 3",
+		},
+		{
+			"missing key",
+			"tx.seqNum: 1\n",
+			"",
+			"unable to parse txrep: missing tx.seqNum",
+		},
+		{
+			"unexpected key",
+			"tx.ext.v: 0\n",
+			"tx.ext.v: 0\ntx.foo: 1\n",
+			"unable to parse txrep: line 17: unexpected key tx.foo",
+		},
+		{
+			"invalid integer",
+			"tx.fee: 100\n",
+			"tx.fee: -100\n",
+			`unable to parse txrep: line 3: invalid tx.fee "-100": expected a 32-bit unsigned integer`,
+		},
+		{
+			"invalid enum",
+			"body.type: PAYMENT\n",
+			"body.type: PAYMNT\n",
+			`unable to parse txrep: line 12: invalid tx.operations[0].body.type "PAYMNT": unknown value`,
+		},
+		{
+			"invalid account",
+			"tx.sourceAccount: " + kp0.Address(),
+			"tx.sourceAccount: GABC",
+			`unable to parse txrep: line 2: invalid tx.sourceAccount "GABC": expected an account address`,
+		},
+		{
+			"invalid asset",
+			"paymentOp.asset: native",
+			"paymentOp.asset: USD",
+			`unable to parse txrep: line 14: invalid tx.operations[0].body.paymentOp.asset "USD": expected native or CODE:ISSUER`,
+		},
+		{
+			"unquoted string",
+			`tx.memo.text: "memo"`,
+			`tx.memo.text: memo`,
+			`unable to parse txrep: line 9: invalid tx.memo.text "memo": expected a double quoted string`,
+		},
+		{
+			"invalid bool",
+			"tx.timeBounds._present: true",
+			"tx.timeBounds._present: yes",
+			`unable to parse txrep: line 5: invalid tx.timeBounds._present "yes": expected true or false`,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			require.Contains(t, txrep, testCase.old)
+			_, err := TransactionFromTxRep(strings.Replace(txrep, testCase.old, testCase.new, 1))
+			assert.EqualError(t, err, testCase.message)
+		})
+	}
+}
+
+func assertSameEnvelope(t *testing.T, expected, actual *Transaction) {
+	expectedB64, err := expected.Base64()
+	require.NoError(t, err)
+	actualB64, err := actual.Base64()
+	require.NoError(t, err)
+	assert.Equal(t, expectedB64, actualB64)
+}
+
+func hexString(b []byte) string {
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,708 @@
+package xdr
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"sync"
+
+	xdr "github.com/stellar/go-xdr/xdr3"
+)
+
+// Decoder decodes XDR values from byte slices. It produces the same values as
+// Unmarshal but does not allocate for primitive values and caches the
+// reflection work done for each type, which makes decoding large streams of
+// values (like bucket entries or ledger close meta) much cheaper.
+//
+// Decoder decodes into the memory already referenced by the destination:
+// optional values, union arms and the backing arrays of slices are reused
+// when they are present. Decoding into a zero value always allocates new
+// memory, so callers retaining decoded values must use a new destination for
+// each call. Decoded values never reference the input slice.
+//
+// The zero value is ready to use. A Decoder is not safe for concurrent use.
+type Decoder struct {
+	buf []byte
+	pos int
+}
+
+var decoderPool = sync.Pool{
+	New: func() interface{} {
+		return &Decoder{}
+	},
+}
+
+// Unmarshal decodes a single value from the beginning of data into v, which
+// must be a pointer. It returns the number of bytes read.
+func (d *Decoder) Unmarshal(data []byte, v interface{}) (int, error) {
+	if v == nil {
+		return 0, decodeError("Unmarshal", "can't unmarshal to nil interface")
+	}
+	vv := reflect.ValueOf(v)
+	if vv.Kind() != reflect.Ptr {
+		return 0, decodeError("Unmarshal", "can't unmarshal to non-pointer '%v' - use & operator", vv.Type())
+	}
+	if vv.IsNil() {
+		return 0, decodeError("Unmarshal", "can't unmarshal to nil pointer '%v'", vv.Type())
+	}
+
+	d.buf = data
+	d.pos = 0
+	elem := vv.Elem()
+	err := decoderForType(elem.Type())(d, elem, 0)
+	n := d.pos
+	// Do not keep a reference to the input.
+	d.buf = nil
+	d.pos = 0
+	return n, err
+}
+
+// FramedDecoder decodes framed XDR values, as found in history archive files
+// and stellar-core meta streams, from a reader. The buffer holding frames is
+// reused, so decoding a stream only allocates memory for the decoded values.
+type FramedDecoder struct {
+	r       io.Reader
+	frame   []byte
+	decoder Decoder
+}
+
+// NewFramedDecoder returns a FramedDecoder reading from r. FramedDecoder does
+// not read ahead so r can be used between calls to read other data.
+func NewFramedDecoder(r io.Reader) *FramedDecoder {
+	return &FramedDecoder{r: r}
+}
+
+// ReadFrameLength reads the header of the next frame and returns its length,
+// see ReadFrameLength.
+func (f *FramedDecoder) ReadFrameLength() (uint32, error) {
+	return ReadFrameLength(f.r)
+}
+
+// DecodeFrame reads a frame body of the given length, as returned by
+// ReadFrameLength, and decodes it into v. The value must use the whole frame.
+func (f *FramedDecoder) DecodeFrame(length uint32, v interface{}) error {
+	if cap(f.frame) < int(length) {
+		f.frame = make([]byte, length)
+	}
+	f.frame = f.frame[:length]
+	if _, err := io.ReadFull(f.r, f.frame); err != nil {
+		return err
+	}
+
+	n, err := f.decoder.Unmarshal(f.frame, v)
+	if err != nil {
+		return err
+	}
+	if n != int(length) {
+		return decodeError("DecodeFrame", "frame length is %d but value used %d bytes", length, n)
+	}
+	return nil
+}
+
+// Decode reads the next frame and decodes it into v. It returns the number
+// of bytes read including the frame header.
+func (f *FramedDecoder) Decode(v interface{}) (int, error) {
+	length, err := f.ReadFrameLength()
+	if err != nil {
+		return 0, err
+	}
+	if err := f.DecodeFrame(length, v); err != nil {
+		return 0, err
+	}
+	return int(length) + 4, nil
+}
+
+func decodeError(fn, format string, args ...interface{}) error {
+	return fmt.Errorf("xdr:"+fn+": "+format, args...)
+}
+
+// decodeFunc decodes the next value of the input into v. maxSize is the
+// maximum length of variable length values given by the enclosing struct
+// tags, 0 when unlimited.
+type decodeFunc func(d *Decoder, v reflect.Value, maxSize int) error
+
+var (
+	decoderCache sync.Map // map[reflect.Type]decodeFunc
+
+	enumType   = reflect.TypeOf((*xdr.Enum)(nil)).Elem()
+	sizedType  = reflect.TypeOf((*xdr.Sized)(nil)).Elem()
+	unionType  = reflect.TypeOf((*xdr.Union)(nil)).Elem()
+	timeTypeID = "time.Time"
+)
+
+// decoderForType returns the cached decodeFunc of t, creating it if needed.
+func decoderForType(t reflect.Type) decodeFunc {
+	if f, ok := decoderCache.Load(t); ok {
+		return f.(decodeFunc)
+	}
+
+	// Types can be recursive (for example ClaimPredicate) so store a function
+	// waiting for the real one before creating it, like encoding/json does.
+	// Decoders of other types are created eagerly and can refer to it.
+	var (
+		wg sync.WaitGroup
+		f  decodeFunc
+	)
+	wg.Add(1)
+	fi, loaded := decoderCache.LoadOrStore(t, decodeFunc(func(d *Decoder, v reflect.Value, maxSize int) error {
+		wg.Wait()
+		return f(d, v, maxSize)
+	}))
+	if loaded {
+		return fi.(decodeFunc)
+	}
+
+	f = newTypeDecoder(t)
+	wg.Done()
+	decoderCache.Store(t, f)
+	return f
+}
+
+func newTypeDecoder(t reflect.Type) decodeFunc {
+	if t.String() == timeTypeID {
+		return unsupportedDecoder(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		return newOptionalDecoder(t)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int:
+		return newIntDecoder(t)
+	case reflect.Int64:
+		return decodeHyper
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint:
+		return decodeUint
+	case reflect.Uint64:
+		return decodeUhyper
+	case reflect.Bool:
+		return decodeBool
+	case reflect.Float32:
+		return decodeFloat
+	case reflect.Float64:
+		return decodeDouble
+	case reflect.String:
+		return newStringDecoder(t)
+	case reflect.Array:
+		return newArrayDecoder(t, false)
+	case reflect.Slice:
+		return newSliceDecoder(t, false)
+	case reflect.Struct:
+		if t.Implements(unionType) {
+			return newUnionDecoder(t)
+		}
+		return newStructDecoder(t)
+	case reflect.Map:
+		return newMapDecoder(t)
+	case reflect.Interface:
+		return decodeInterface
+	}
+	return unsupportedDecoder(t)
+}
+
+func unsupportedDecoder(t reflect.Type) decodeFunc {
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		return decodeError("decode", "unsupported Go type '%s'", t)
+	}
+}
+
+// next returns the next n bytes of the input.
+func (d *Decoder) next(n int) ([]byte, error) {
+	if n < 0 || len(d.buf)-d.pos < n {
+		return nil, decodeError("decode", "unexpected end of input reading %d bytes at offset %d", n, d.pos)
+	}
+	b := d.buf[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+func (d *Decoder) readUint32() (uint32, error) {
+	b, err := d.next(4)
+	if err != nil {
+		return 0, err
+	}
+	return uint32(b[3]) | uint32(b[2])<<8 | uint32(b[1])<<16 | uint32(b[0])<<24, nil
+}
+
+func (d *Decoder) readUint64() (uint64, error) {
+	b, err := d.next(8)
+	if err != nil {
+		return 0, err
+	}
+	return uint64(b[7]) | uint64(b[6])<<8 | uint64(b[5])<<16 | uint64(b[4])<<24 |
+		uint64(b[3])<<32 | uint64(b[2])<<40 | uint64(b[1])<<48 | uint64(b[0])<<56, nil
+}
+
+// readLength reads the length of a variable length value and checks it
+// against maxSize.
+func (d *Decoder) readLength(maxSize int) (int, error) {
+	l, err := d.readUint32()
+	if err != nil {
+		return 0, err
+	}
+	if maxSize == 0 {
+		maxSize = math.MaxInt32
+	}
+	if uint(l) > uint(maxSize) {
+		return 0, decodeError("decode", "data length %d exceeds max length %d", l, maxSize)
+	}
+	return int(l), nil
+}
+
+// readOpaque returns the next n bytes of opaque data and checks its padding.
+func (d *Decoder) readOpaque(n int) ([]byte, error) {
+	padded := n + (4-n%4)%4
+	b, err := d.next(padded)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range b[n:] {
+		if p != 0 {
+			return nil, decodeError("decode", "non-zero padding")
+		}
+	}
+	return b[:n], nil
+}
+
+func newIntDecoder(t reflect.Type) decodeFunc {
+	var validEnum func(int32) bool
+	if t.Implements(enumType) {
+		// ValidEnum does not depend on the receiver.
+		validEnum = reflect.Zero(t).Interface().(xdr.Enum).ValidEnum
+	}
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		u, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+		i := int32(u)
+		if v.OverflowInt(int64(i)) {
+			return decodeError("decode", "signed integer too large to fit '%s'", v.Kind())
+		}
+		v.SetInt(int64(i))
+		if validEnum != nil && !validEnum(i) {
+			return decodeError("decode", "invalid enum")
+		}
+		return nil
+	}
+}
+
+func decodeHyper(d *Decoder, v reflect.Value, maxSize int) error {
+	u, err := d.readUint64()
+	if err != nil {
+		return err
+	}
+	v.SetInt(int64(u))
+	return nil
+}
+
+func decodeUint(d *Decoder, v reflect.Value, maxSize int) error {
+	u, err := d.readUint32()
+	if err != nil {
+		return err
+	}
+	if v.OverflowUint(uint64(u)) {
+		return decodeError("decode", "unsigned integer too large to fit '%s'", v.Kind())
+	}
+	v.SetUint(uint64(u))
+	return nil
+}
+
+func decodeUhyper(d *Decoder, v reflect.Value, maxSize int) error {
+	u, err := d.readUint64()
+	if err != nil {
+		return err
+	}
+	v.SetUint(u)
+	return nil
+}
+
+func decodeBool(d *Decoder, v reflect.Value, maxSize int) error {
+	u, err := d.readUint32()
+	if err != nil {
+		return err
+	}
+	switch u {
+	case 0:
+		v.SetBool(false)
+	case 1:
+		v.SetBool(true)
+	default:
+		return decodeError("DecodeBool", "bool not 0 or 1")
+	}
+	return nil
+}
+
+func decodeFloat(d *Decoder, v reflect.Value, maxSize int) error {
+	u, err := d.readUint32()
+	if err != nil {
+		return err
+	}
+	v.SetFloat(float64(math.Float32frombits(u)))
+	return nil
+}
+
+func decodeDouble(d *Decoder, v reflect.Value, maxSize int) error {
+	u, err := d.readUint64()
+	if err != nil {
+		return err
+	}
+	v.SetFloat(math.Float64frombits(u))
+	return nil
+}
+
+// sizedMaxSize returns the maximum size of t if it implements xdr.Sized.
+func sizedMaxSize(t reflect.Type) (int, bool) {
+	if !t.Implements(sizedType) {
+		return 0, false
+	}
+	return reflect.Zero(t).Interface().(xdr.Sized).XDRMaxSize(), true
+}
+
+func newStringDecoder(t reflect.Type) decodeFunc {
+	typeMaxSize, sized := sizedMaxSize(t)
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		if sized {
+			maxSize = typeMaxSize
+		}
+		l, err := d.readLength(maxSize)
+		if err != nil {
+			return err
+		}
+		b, err := d.readOpaque(l)
+		if err != nil {
+			return err
+		}
+		v.SetString(string(b))
+		return nil
+	}
+}
+
+func newOptionalDecoder(t reflect.Type) decodeFunc {
+	elemType := t.Elem()
+	elemDecoder := decoderForType(elemType)
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		u, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+		switch u {
+		case 0:
+			if !v.IsNil() {
+				v.Set(reflect.Zero(t))
+			}
+			return nil
+		case 1:
+		default:
+			return decodeError("DecodeBool", "bool not 0 or 1")
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(elemType))
+		}
+		return elemDecoder(d, v.Elem(), 0)
+	}
+}
+
+func newArrayDecoder(t reflect.Type, ignoreOpaque bool) decodeFunc {
+	elemType := t.Elem()
+	if !ignoreOpaque && elemType.Kind() == reflect.Uint8 {
+		return func(d *Decoder, v reflect.Value, maxSize int) error {
+			b, err := d.readOpaque(v.Len())
+			if err != nil {
+				return err
+			}
+			if v.CanAddr() {
+				copy(v.Bytes(), b)
+				return nil
+			}
+			for i := range b {
+				v.Index(i).SetUint(uint64(b[i]))
+			}
+			return nil
+		}
+	}
+
+	elemDecoder := decoderForType(elemType)
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		for i := 0; i < v.Len(); i++ {
+			if err := elemDecoder(d, v.Index(i), 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+func newSliceDecoder(t reflect.Type, ignoreOpaque bool) decodeFunc {
+	typeMaxSize, sized := sizedMaxSize(t)
+	elemType := t.Elem()
+
+	if !ignoreOpaque && elemType.Kind() == reflect.Uint8 {
+		return func(d *Decoder, v reflect.Value, maxSize int) error {
+			if sized {
+				maxSize = typeMaxSize
+			}
+			l, err := d.readLength(maxSize)
+			if err != nil {
+				return err
+			}
+			b, err := d.readOpaque(l)
+			if err != nil {
+				return err
+			}
+			if l == 0 {
+				// Unmarshal decodes empty opaque values to nil.
+				v.SetBytes(nil)
+				return nil
+			}
+			if v.Cap() >= l {
+				v.SetLen(l)
+			} else {
+				v.Set(reflect.MakeSlice(t, l, l))
+			}
+			copy(v.Bytes(), b)
+			return nil
+		}
+	}
+
+	elemMinSize := minEncodedSize(elemType)
+	elemDecoder := decoderForType(elemType)
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		if sized {
+			maxSize = typeMaxSize
+		}
+		l, err := d.readLength(maxSize)
+		if err != nil {
+			return err
+		}
+		// Check the length before allocating so corrupted input cannot
+		// trigger huge allocations.
+		if elemMinSize > 0 && uint64(l)*uint64(elemMinSize) > uint64(len(d.buf)-d.pos) {
+			return decodeError("decode", "unexpected end of input reading %d elements at offset %d", l, d.pos)
+		}
+		if v.Cap() < l {
+			v.Set(reflect.MakeSlice(t, l, l))
+		}
+		v.SetLen(l)
+
+		for i := 0; i < l; i++ {
+			if err := elemDecoder(d, v.Index(i), 0); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// minEncodedSize returns the minimum size of the XDR encoding of values of
+// type t.
+func minEncodedSize(t reflect.Type) int {
+	switch t.Kind() {
+	case reflect.Int64, reflect.Uint64, reflect.Float64:
+		return 8
+	case reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return t.Len() + (4-t.Len()%4)%4
+		}
+		return t.Len() * minEncodedSize(t.Elem())
+	case reflect.Struct:
+		if t.Implements(unionType) {
+			return 4
+		}
+		size := 0
+		for i := 0; i < t.NumField(); i++ {
+			if t.Field(i).PkgPath == "" {
+				size += minEncodedSize(t.Field(i).Type)
+			}
+		}
+		return size
+	case reflect.Interface:
+		return 0
+	}
+	// Other types are encoded using at least 4 bytes (integers, booleans,
+	// lengths of variable length values and optional value flags).
+	return 4
+}
+
+type structField struct {
+	index   int
+	maxSize int
+	decoder decodeFunc
+}
+
+// fieldMaxSize returns the maximum size set by the xdrmaxsize tag of field.
+func fieldMaxSize(field reflect.StructField) int {
+	tag := field.Tag.Get("xdrmaxsize")
+	if tag == "" {
+		return 0
+	}
+	size, err := strconv.ParseInt(tag, 10, 32)
+	if err != nil {
+		panic(fmt.Sprintf("invalid xdrmaxsize tag of %s: %s", field.Name, tag))
+	}
+	return int(size)
+}
+
+func newStructDecoder(t reflect.Type) decodeFunc {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		sf := structField{index: i, maxSize: fieldMaxSize(field)}
+		opaque := field.Tag.Get("xdropaque") != "false"
+		switch {
+		case !opaque && field.Type.Kind() == reflect.Slice:
+			sf.decoder = newSliceDecoder(field.Type, true)
+		case !opaque && field.Type.Kind() == reflect.Array:
+			sf.decoder = newArrayDecoder(field.Type, true)
+		default:
+			sf.decoder = decoderForType(field.Type)
+		}
+		fields = append(fields, sf)
+	}
+
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		for _, field := range fields {
+			if err := field.decoder(d, v.Field(field.index), field.maxSize); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+type unionArm struct {
+	index    int
+	maxSize  int
+	elemType reflect.Type
+	decoder  decodeFunc
+}
+
+func newUnionDecoder(t reflect.Type) decodeFunc {
+	// ArmForSwitch and SwitchFieldName do not depend on the receiver.
+	union := reflect.Zero(t).Interface().(xdr.Union)
+	switchField, ok := t.FieldByName(union.SwitchFieldName())
+	if !ok {
+		return unsupportedDecoder(t)
+	}
+	switchIndex := switchField.Index[0]
+	switchUnsigned := false
+	switch switchField.Type.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switchUnsigned = true
+	}
+	var validEnum func(int32) bool
+	if switchField.Type.Implements(enumType) {
+		validEnum = reflect.Zero(switchField.Type).Interface().(xdr.Enum).ValidEnum
+	}
+
+	arms := map[string]unionArm{}
+	var armIndexes []int
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" || field.Type.Kind() != reflect.Ptr {
+			continue
+		}
+		arms[field.Name] = unionArm{
+			index:    i,
+			maxSize:  fieldMaxSize(field),
+			elemType: field.Type.Elem(),
+			decoder:  decoderForType(field.Type.Elem()),
+		}
+		armIndexes = append(armIndexes, i)
+	}
+
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		u, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+		sw := int32(u)
+		if validEnum != nil && !validEnum(sw) {
+			return decodeError("decode", "switch '%d' is not valid enum value for union", sw)
+		}
+
+		armName, ok := union.ArmForSwitch(sw)
+		if !ok {
+			return decodeError("decode", "switch '%d' is not valid for union", sw)
+		}
+		arm, hasArm := arms[armName]
+		if armName != "" && !hasArm {
+			return decodeError("decode", "switch '%s' is not valid for union", armName)
+		}
+
+		// Clear the other arms, keeping the selected one to decode into.
+		for _, i := range armIndexes {
+			if (!hasArm || i != arm.index) && !v.Field(i).IsNil() {
+				v.Field(i).Set(reflect.Zero(v.Field(i).Type()))
+			}
+		}
+		if switchUnsigned {
+			v.Field(switchIndex).SetUint(uint64(u))
+		} else {
+			v.Field(switchIndex).SetInt(int64(sw))
+		}
+		if !hasArm {
+			return nil
+		}
+
+		armValue := v.Field(arm.index)
+		if armValue.IsNil() {
+			armValue.Set(reflect.New(arm.elemType))
+		}
+		return arm.decoder(d, armValue.Elem(), arm.maxSize)
+	}
+}
+
+func newMapDecoder(t reflect.Type) decodeFunc {
+	keyDecoder := decoderForType(t.Key())
+	elemDecoder := decoderForType(t.Elem())
+	return func(d *Decoder, v reflect.Value, maxSize int) error {
+		l, err := d.readUint32()
+		if err != nil {
+			return err
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(t))
+		} else {
+			for _, key := range v.MapKeys() {
+				v.SetMapIndex(key, reflect.Value{})
+			}
+		}
+		for i := uint32(0); i < l; i++ {
+			key := reflect.New(t.Key()).Elem()
+			if err := keyDecoder(d, key, 0); err != nil {
+				return err
+			}
+			elem := reflect.New(t.Elem()).Elem()
+			if err := elemDecoder(d, elem, 0); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, elem)
+		}
+		return nil
+	}
+}
+
+// decodeInterface decodes into the value held by an interface, which must be
+// a pointer.
+func decodeInterface(d *Decoder, v reflect.Value, maxSize int) error {
+	if v.IsNil() || !v.CanInterface() {
+		return decodeError("decodeInterface", "can't decode to nil interface")
+	}
+	ve := reflect.ValueOf(v.Interface())
+	if ve.Kind() == reflect.Ptr {
+		if ve.IsNil() {
+			return decodeError("decodePtr", "unable to allocate pointer for '%v'", ve.Type())
+		}
+		ve = ve.Elem()
+	}
+	if !ve.CanSet() {
+		return decodeError("decodeInterface", "can't decode to unsettable '%v'", ve.Type())
+	}
+	return decoderForType(ve.Type())(d, ve, 0)
+}
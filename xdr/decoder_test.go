@@ -0,0 +1,319 @@
+package xdr
+
+import (
+	"bytes"
+	"encoding/base64"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const decoderTestEnvelope = "AAAAAgAAAABi/B0L0JGythwN1lY0aypo19NHxvLCyO5tBEcCVvwF9wAAAAoAAAAAAAAAAQAAAAAAAAAAAAAAAQAAAAAAAAAAAAAAAK6jei3jmoI8TGlD/egc37PXtHKKzWV8wViZBaCu5L5MAAAAADuaygAAAAAAAAAAAVb8BfcAAABACmeyD4/+Oj7llOmTrcjKLHLTQJF0TV/VggCOUZ30ZPgMsQy6A2T//Zdzb7MULVo/Y7kDrqAZRS51rvIp7YMUAA=="
+
+func decoderTestValues(t *testing.T) []interface{} {
+	var envelope TransactionEnvelope
+	require.NoError(t, SafeUnmarshalBase64(decoderTestEnvelope, &envelope))
+
+	sponsor := MustAddress("GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ")
+	homeDomain := String32("example.com")
+	account := LedgerEntry{
+		LastModifiedLedgerSeq: 123,
+		Data: LedgerEntryData{
+			Type: LedgerEntryTypeAccount,
+			Account: &AccountEntry{
+				AccountId:     MustAddress("GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB"),
+				Balance:       100,
+				SeqNum:        1,
+				NumSubEntries: 1,
+				HomeDomain:    homeDomain,
+				Thresholds:    Thresholds{1, 2, 3, 4},
+				Signers: []Signer{{
+					Key:    MustSigner("GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ"),
+					Weight: 1,
+				}},
+				Ext: AccountEntryExt{
+					V: 1,
+					V1: &AccountEntryExtensionV1{
+						Liabilities: Liabilities{Buying: 1, Selling: 2},
+						Ext: AccountEntryExtensionV1Ext{
+							V: 2,
+							V2: &AccountEntryExtensionV2{
+								NumSponsored:        1,
+								SignerSponsoringIDs: []SponsorshipDescriptor{&sponsor, nil},
+							},
+						},
+					},
+				},
+			},
+		},
+		Ext: LedgerEntryExt{
+			V:  1,
+			V1: &LedgerEntryExtensionV1{SponsoringId: &sponsor},
+		},
+	}
+
+	relBefore := Int64(100)
+	notPredicate := &ClaimPredicate{
+		Type:      ClaimPredicateTypeClaimPredicateBeforeRelativeTime,
+		RelBefore: &relBefore,
+	}
+	balance := LedgerEntry{
+		Data: LedgerEntryData{
+			Type: LedgerEntryTypeClaimableBalance,
+			ClaimableBalance: &ClaimableBalanceEntry{
+				BalanceId: ClaimableBalanceId{Type: ClaimableBalanceIdTypeClaimableBalanceIdTypeV0, V0: &Hash{1, 2, 3}},
+				Claimants: []Claimant{{
+					Type: ClaimantTypeClaimantTypeV0,
+					V0: &ClaimantV0{
+						Destination: sponsor,
+						Predicate: ClaimPredicate{
+							Type: ClaimPredicateTypeClaimPredicateAnd,
+							AndPredicates: &[]ClaimPredicate{
+								{Type: ClaimPredicateTypeClaimPredicateUnconditional},
+								{
+									Type:         ClaimPredicateTypeClaimPredicateNot,
+									NotPredicate: &notPredicate,
+								},
+							},
+						},
+					},
+				}},
+				Asset:  MustNewCreditAsset("USD", "GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ"),
+				Amount: 10,
+			},
+		},
+	}
+
+	quorumSet := ScpQuorumSet{
+		Threshold: 2,
+		Validators: []PublicKey{
+			PublicKey(MustAddress("GAOQJGUAB7NI7K7I62ORBXMN3J4SSWQUQ7FOEPSDJ322W2HMCNWPHXFB")),
+		},
+		InnerSets: []ScpQuorumSet{{
+			Threshold:  1,
+			Validators: []PublicKey{PublicKey(sponsor)},
+		}},
+	}
+
+	meta := LedgerCloseMeta{
+		V: 0,
+		V0: &LedgerCloseMetaV0{
+			LedgerHeader: LedgerHeaderHistoryEntry{
+				Header: LedgerHeader{LedgerVersion: 15, LedgerSeq: 10},
+			},
+			TxSet: TransactionSet{Txs: []TransactionEnvelope{envelope}},
+			TxProcessing: []TransactionResultMeta{{
+				Result: TransactionResultPair{
+					Result: TransactionResult{
+						FeeCharged: 100,
+						Result: TransactionResultResult{
+							Code:    TransactionResultCodeTxSuccess,
+							Results: new([]OperationResult),
+						},
+					},
+				},
+				TxApplyProcessing: TransactionMeta{V: 2, V2: &TransactionMetaV2{}},
+			}},
+		},
+	}
+
+	dataValue := DataValue("value")
+	data := LedgerEntry{
+		Data: LedgerEntryData{
+			Type: LedgerEntryTypeData,
+			Data: &DataEntry{AccountId: sponsor, DataName: "name", DataValue: dataValue},
+		},
+	}
+
+	return []interface{}{&envelope, &account, &balance, &quorumSet, &meta, &data}
+}
+
+func TestDecoderMatchesUnmarshal(t *testing.T) {
+	var d Decoder
+	for _, value := range decoderTestValues(t) {
+		var raw bytes.Buffer
+		_, err := Marshal(&raw, value)
+		require.NoError(t, err)
+
+		expected := newValueOf(value)
+		expectedN, err := Unmarshal(bytes.NewReader(raw.Bytes()), expected)
+		require.NoError(t, err)
+
+		actual := newValueOf(value)
+		n, err := d.Unmarshal(raw.Bytes(), actual)
+		require.NoError(t, err)
+		assert.Equal(t, expectedN, n)
+		assert.Equal(t, expected, actual)
+		assert.Equal(t, value, actual)
+	}
+}
+
+func TestDecoderReuse(t *testing.T) {
+	values := decoderTestValues(t)
+	account := values[1].(*LedgerEntry)
+	balance := values[2].(*LedgerEntry)
+	data := values[5].(*LedgerEntry)
+
+	var d Decoder
+	var entry LedgerEntry
+	for _, value := range []*LedgerEntry{account, balance, account, data, account} {
+		raw, err := value.MarshalBinary()
+		require.NoError(t, err)
+		_, err = d.Unmarshal(raw, &entry)
+		require.NoError(t, err)
+		assert.Equal(t, *value, entry)
+	}
+
+	raw, err := account.MarshalBinary()
+	require.NoError(t, err)
+	accountEntry := entry.Data.Account
+	_, err = d.Unmarshal(raw, &entry)
+	require.NoError(t, err)
+	assert.True(t, accountEntry == entry.Data.Account, "union arm should be reused")
+}
+
+func TestSafeUnmarshalDoesNotReuse(t *testing.T) {
+	values := decoderTestValues(t)
+	account := values[1].(*LedgerEntry)
+	raw, err := account.MarshalBinary()
+	require.NoError(t, err)
+
+	var entry LedgerEntry
+	require.NoError(t, SafeUnmarshal(raw, &entry))
+	previous := entry.Data.Account
+	previousCopy := *previous
+
+	require.NoError(t, SafeUnmarshal(raw, &entry))
+	assert.Equal(t, *account, entry)
+	assert.False(t, previous == entry.Data.Account, "union arm should not be reused")
+	assert.Equal(t, previousCopy, *previous)
+}
+
+func TestDecoderMapReuse(t *testing.T) {
+	type withMap struct {
+		M map[Uint32]Uint32
+	}
+	raw := []byte{0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 2}
+
+	var d Decoder
+	dest := withMap{M: map[Uint32]Uint32{3: 4, 5: 6}}
+	_, err := d.Unmarshal(raw, &dest)
+	require.NoError(t, err)
+	assert.Equal(t, map[Uint32]Uint32{1: 2}, dest.M)
+}
+
+func TestDecoderErrors(t *testing.T) {
+	var d Decoder
+	var envelope TransactionEnvelope
+	raw, err := base64.StdEncoding.DecodeString(decoderTestEnvelope)
+	require.NoError(t, err)
+
+	_, err = d.Unmarshal(raw[:len(raw)-1], &envelope)
+	assert.Error(t, err)
+
+	invalid := append([]byte{}, raw...)
+	invalid[3] = 100
+	_, err = d.Unmarshal(invalid, &envelope)
+	assert.EqualError(t, err, "xdr:decode: switch '100' is not valid enum value for union")
+
+	_, err = d.Unmarshal([]byte{0, 0, 0, 2, 'a', 'b', 0, 1}, new(String32))
+	assert.EqualError(t, err, "xdr:decode: non-zero padding")
+
+	_, err = d.Unmarshal([]byte{0, 0, 0, 33}, new(String32))
+	assert.EqualError(t, err, "xdr:decode: data length 33 exceeds max length 32")
+
+	// The length is checked against the input before allocating.
+	_, err = d.Unmarshal([]byte{0x7f, 0xff, 0xff, 0xff}, new([]LedgerEntry))
+	assert.EqualError(t, err, "xdr:decode: unexpected end of input reading 2147483647 elements at offset 4")
+
+	_, err = d.Unmarshal([]byte{0, 0, 0, 2}, new(bool))
+	assert.EqualError(t, err, "xdr:DecodeBool: bool not 0 or 1")
+
+	_, err = d.Unmarshal(raw, envelope)
+	assert.Error(t, err)
+
+	err = SafeUnmarshal(append(raw, 0, 0, 0, 0), &envelope)
+	assert.EqualError(t, err, "input not fully consumed. expected to read: 200, actual: 196")
+}
+
+// TestDecoderMutations checks that Decoder and Unmarshal agree on corrupted
+// inputs.
+func TestDecoderMutations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var d Decoder
+	for _, value := range decoderTestValues(t) {
+		var raw bytes.Buffer
+		_, err := Marshal(&raw, value)
+		require.NoError(t, err)
+
+		for i := 0; i < 500; i++ {
+			mutated := append([]byte{}, raw.Bytes()...)
+			for j := 0; j < 1+r.Intn(3); j++ {
+				mutated[r.Intn(len(mutated))] = byte(r.Intn(256))
+			}
+			if r.Intn(10) == 0 {
+				mutated = mutated[:r.Intn(len(mutated))]
+			}
+
+			actual := newValueOf(value)
+			n, err := d.Unmarshal(mutated, actual)
+			if err != nil && strings.Contains(err.Error(), "elements at offset") {
+				// Unmarshal would try to allocate the whole slice.
+				continue
+			}
+			expected := newValueOf(value)
+			expectedN, expectedErr := Unmarshal(bytes.NewReader(mutated), expected)
+			require.Equal(t, expectedErr == nil, err == nil, "input: %x, errors: %v, %v", mutated, expectedErr, err)
+			if err == nil {
+				require.Equal(t, expectedN, n)
+				require.Equal(t, expected, actual)
+			}
+		}
+	}
+}
+
+func TestFramedDecoder(t *testing.T) {
+	values := decoderTestValues(t)
+	var buf bytes.Buffer
+	for _, value := range values {
+		require.NoError(t, MarshalFramed(&buf, value))
+	}
+	total := buf.Len()
+
+	f := NewFramedDecoder(&buf)
+	read := 0
+	for _, value := range values {
+		actual := newValueOf(value)
+		n, err := f.Decode(actual)
+		require.NoError(t, err)
+		assert.Equal(t, value, actual)
+		read += n
+	}
+	assert.Equal(t, total, read)
+
+	_, err := f.Decode(new(LedgerEntry))
+	assert.Error(t, err)
+
+	// Frames must be used entirely.
+	buf.Reset()
+	require.NoError(t, MarshalFramed(&buf, values[1]))
+	_, err = f.Decode(new(Uint32))
+	assert.EqualError(t, err, "xdr:DecodeFrame: frame length is 268 but value used 4 bytes")
+}
+
+func newValueOf(value interface{}) interface{} {
+	switch value.(type) {
+	case *TransactionEnvelope:
+		return &TransactionEnvelope{}
+	case *LedgerEntry:
+		return &LedgerEntry{}
+	case *ScpQuorumSet:
+		return &ScpQuorumSet{}
+	case *LedgerCloseMeta:
+		return &LedgerCloseMeta{}
+	}
+	panic("unknown type")
+}
@@ -0,0 +1,89 @@
+// +build gofuzz
+
+package decoder
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/stellar/go/xdr"
+)
+
+// newValue returns a new value of the type selected by the first byte of the
+// input.
+var newValue = []func() interface{}{
+	func() interface{} { return &xdr.TransactionEnvelope{} },
+	func() interface{} { return &xdr.TransactionResult{} },
+	func() interface{} { return &xdr.TransactionMeta{} },
+	func() interface{} { return &xdr.LedgerEntry{} },
+	func() interface{} { return &xdr.LedgerHeader{} },
+	func() interface{} { return &xdr.LedgerCloseMeta{} },
+	func() interface{} { return &xdr.BucketEntry{} },
+	func() interface{} { return &xdr.ScpEnvelope{} },
+	func() interface{} { return &xdr.Claimant{} },
+}
+
+// Fuzz is go-fuzz function for fuzzing xdr.Decoder. The first byte of data
+// selects the type of the value, the rest is decoded with xdr.Decoder and
+// with the reflection based decoder of go-xdr, which must agree on whether
+// the input is valid (including oversized lengths and invalid union
+// discriminants) and on the decoded value. xdr.SafeUnmarshal must accept
+// exactly the inputs which are decoded in full.
+func Fuzz(data []byte) int {
+	if len(data) == 0 {
+		return -1
+	}
+	input := data[1:]
+	kind := int(data[0]) % len(newValue)
+
+	var d xdr.Decoder
+	value := newValue[kind]()
+	n, err := d.Unmarshal(input, value)
+
+	safeValue := newValue[kind]()
+	safeErr := xdr.SafeUnmarshal(input, safeValue)
+	if (safeErr == nil) != (err == nil && n == len(input)) {
+		panic("SafeUnmarshal disagrees with Decoder")
+	}
+
+	if err != nil && strings.Contains(err.Error(), "elements at offset") {
+		// The length of a slice is larger than the rest of the input, go-xdr
+		// would allocate the whole slice before failing.
+		return 0
+	}
+
+	expected := newValue[kind]()
+	expectedN, expectedErr := xdr.Unmarshal(bytes.NewReader(input), expected)
+	if (expectedErr == nil) != (err == nil) {
+		panic("go-xdr and Decoder disagree on input validity")
+	}
+	if err != nil {
+		return 0
+	}
+	if expectedN != n {
+		panic("go-xdr and Decoder read a different number of bytes")
+	}
+
+	x, err := xdr.MarshalBase64(value)
+	if err != nil {
+		panic(err)
+	}
+	expectedX, err := xdr.MarshalBase64(expected)
+	if err != nil {
+		panic(err)
+	}
+	if x != expectedX {
+		panic("not equal " + x + " " + expectedX)
+	}
+	if safeErr == nil {
+		safeX, err := xdr.MarshalBase64(safeValue)
+		if err != nil {
+			panic(err)
+		}
+		if safeX != x {
+			panic("not equal " + safeX + " " + x)
+		}
+	}
+
+	return 1
+}
@@ -0,0 +1,68 @@
+// +build gofuzz
+
+package jsonxdr
+
+import (
+	"bytes"
+
+	"github.com/stellar/go/xdr"
+)
+
+// newValue returns a new value of the type selected by the first byte of the
+// input.
+var newValue = []func() interface{}{
+	func() interface{} { return &xdr.TransactionEnvelope{} },
+	func() interface{} { return &xdr.TransactionResult{} },
+	func() interface{} { return &xdr.TransactionMeta{} },
+	func() interface{} { return &xdr.LedgerEntry{} },
+	func() interface{} { return &xdr.LedgerHeader{} },
+	func() interface{} { return &xdr.LedgerCloseMeta{} },
+	func() interface{} { return &xdr.BucketEntry{} },
+	func() interface{} { return &xdr.ScpEnvelope{} },
+	func() interface{} { return &xdr.Claimant{} },
+}
+
+// Fuzz is go-fuzz function for fuzzing the canonical JSON encoding of XDR
+// values. The first byte of data selects the type of the value, the rest is
+// its XDR encoding.
+func Fuzz(data []byte) int {
+	if len(data) == 0 {
+		return -1
+	}
+	value := newValue[int(data[0])%len(newValue)]()
+	if err := xdr.SafeUnmarshal(data[1:], value); err != nil {
+		return -1
+	}
+
+	j, err := xdr.MarshalCanonicalJSON(value)
+	if err != nil {
+		panic(err)
+	}
+
+	value2 := newValue[int(data[0])%len(newValue)]()
+	if err = xdr.UnmarshalCanonicalJSON(j, value2); err != nil {
+		panic(err)
+	}
+
+	x, err := xdr.MarshalBase64(value)
+	if err != nil {
+		panic(err)
+	}
+	x2, err := xdr.MarshalBase64(value2)
+	if err != nil {
+		panic(err)
+	}
+	if x != x2 {
+		panic("not equal " + x + " " + x2)
+	}
+
+	j2, err := xdr.MarshalCanonicalJSON(value2)
+	if err != nil {
+		panic(err)
+	}
+	if !bytes.Equal(j, j2) {
+		panic("not equal " + string(j) + " " + string(j2))
+	}
+
+	return 1
+}
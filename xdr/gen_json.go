@@ -0,0 +1,133 @@
+// +build ignore
+
+// gen_json.go generates xdr_json_generated.go, the names of the values of the
+// enums in xdr_generated.go used by the canonical JSON encoding. Run it using
+// go generate after updating xdr_generated.go.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+const (
+	input  = "xdr_generated.go"
+	output = "xdr_json_generated.go"
+)
+
+var tmpl = template.Must(template.New(output).Parse(`// Code generated by gen_json.go. DO NOT EDIT.
+
+package xdr
+
+import "reflect"
+
+// jsonEnumNames maps enum types to the names of their values.
+var jsonEnumNames = map[reflect.Type]map[int32]string{
+{{- range .Enums}}
+	reflect.TypeOf({{.Name}}(0)): {{.Map}},
+{{- end}}
+}
+`))
+
+type enum struct {
+	Name string
+	Map  string
+}
+
+func main() {
+	fset := token.NewFileSet()
+	generated, err := parser.ParseFile(fset, input, nil, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Methods declared in the package, to find enums.
+	methods := map[string]map[string]bool{}
+	files, err := filepath.Glob("*.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, name := range files {
+		if strings.HasSuffix(name, "_test.go") || name == output || name == "gen_json.go" {
+			continue
+		}
+		file, err := parser.ParseFile(fset, name, nil, 0)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			fn, ok := decl.(*ast.FuncDecl)
+			if !ok || fn.Recv == nil {
+				continue
+			}
+			recv := fn.Recv.List[0].Type
+			if star, ok := recv.(*ast.StarExpr); ok {
+				recv = star.X
+			}
+			ident, ok := recv.(*ast.Ident)
+			if !ok {
+				continue
+			}
+			if methods[ident.Name] == nil {
+				methods[ident.Name] = map[string]bool{}
+			}
+			methods[ident.Name][fn.Name.Name] = true
+		}
+	}
+
+	vars := map[string]bool{}
+	var names []string
+	for _, decl := range generated.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			switch spec := spec.(type) {
+			case *ast.TypeSpec:
+				names = append(names, spec.Name.Name)
+			case *ast.ValueSpec:
+				for _, name := range spec.Names {
+					vars[name.Name] = true
+				}
+			}
+		}
+	}
+
+	var data struct {
+		Enums []enum
+	}
+	for _, name := range names {
+		if !methods[name]["ValidEnum"] {
+			continue
+		}
+		m := strings.ToLower(name[:1]) + name[1:] + "Map"
+		if !vars[m] {
+			log.Fatalf("cannot find the names of the values of %s", name)
+		}
+		data.Enums = append(data.Enums, enum{Name: name, Map: m})
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		log.Fatal(err)
+	}
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile(output, src, 0644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d enums to %s\n", len(data.Enums), output)
+}
@@ -149,8 +149,13 @@ func (c ClaimPredicate) toJSON() (claimPredicateJSON, error) {
 	case ClaimPredicateTypeClaimPredicateUnconditional:
 		payload.Unconditional = true
 	case ClaimPredicateTypeClaimPredicateNot:
+		inner := c.MustNotPredicate()
+		if inner == nil {
+			err = errors.New("invalid predicate: not predicate is nil")
+			break
+		}
 		payload.Not = new(claimPredicateJSON)
-		*payload.Not, err = c.MustNotPredicate().toJSON()
+		*payload.Not, err = inner.toJSON()
 	case ClaimPredicateTypeClaimPredicateBeforeAbsoluteTime:
 		payload.AbsBefore = new(iso8601Time)
 		*payload.AbsBefore = iso8601Time{time.Unix(int64(c.MustAbsBefore()), 0).UTC()}
@@ -0,0 +1,533 @@
+package xdr
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	xdr "github.com/stellar/go-xdr/xdr3"
+)
+
+//go:generate go run gen_json.go
+
+// MarshalCanonicalJSON and UnmarshalCanonicalJSON implement the canonical
+// JSON encoding of the XDR types. It is separate from the encoding used by
+// encoding/json, which is left unchanged for existing callers. The encoding
+// is lossless: decoding the JSON of a value gives back a value with the same
+// XDR encoding. Values are mapped as follows:
+//
+//   - structs are objects with a key for every field, named after the Go
+//     field, in the order of the XDR definition.
+//   - unions are objects with a key for the discriminant and, unless the arm
+//     is void, a key for the arm. Keys are named after the Go fields, for
+//     example {"Type": "AssetTypeAssetTypeNative"} or
+//     {"V": 1, "V1": {...}}.
+//   - enums are strings, the names of the Go constants.
+//   - 32-bit integers are numbers, 64-bit integers are decimal strings and
+//     booleans are true or false.
+//   - opaque data is a lowercase hex string.
+//   - strings are JSON strings in which backslashes are escaped as `\\` and
+//     bytes which are not valid UTF-8 as `\xNN`.
+//   - optional values are null or the value.
+//   - arrays are arrays.
+//
+// Types with their own MarshalJSON method, like ClaimPredicate, are encoded
+// like any other type: a ClaimPredicate is always a union in the canonical
+// encoding, and always the object defined in json.go with encoding/json.
+//
+// Decoding is strict: unknown or missing keys, values out of range and
+// values which exceed the maximum size of the XDR type are errors.
+
+// jsonEnumValues maps enum types to the values of their names. It is the
+// inverse of jsonEnumNames, set in xdr_json_generated.go.
+var jsonEnumValues = map[reflect.Type]map[string]int32{}
+
+func init() {
+	for t, names := range jsonEnumNames {
+		values := make(map[string]int32, len(names))
+		for value, name := range names {
+			values[name] = value
+		}
+		jsonEnumValues[t] = values
+	}
+}
+
+// JSONError is returned when a value cannot be encoded to or decoded from
+// JSON.
+type JSONError struct {
+	// Path is the location of the value, for example
+	// `.Tx.Operations[0].Body`.
+	Path string
+	Msg  string
+}
+
+func (e *JSONError) Error() string {
+	if e.Path == "" {
+		return "xdr:json: " + e.Msg
+	}
+	return fmt.Sprintf("xdr:json: %s: %s", e.Path, e.Msg)
+}
+
+func jsonErrorf(format string, args ...interface{}) error {
+	return &JSONError{Msg: fmt.Sprintf(format, args...)}
+}
+
+// withJSONPath prefixes the path of err with element.
+func withJSONPath(err error, element string) error {
+	if jerr, ok := err.(*JSONError); ok {
+		jerr.Path = element + jerr.Path
+		return jerr
+	}
+	return err
+}
+
+// MarshalCanonicalJSON returns the canonical JSON encoding of the XDR value
+// v.
+func MarshalCanonicalJSON(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := encodeJSON(&buf, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func encodeJSON(buf *bytes.Buffer, v reflect.Value) error {
+	t := v.Type()
+	switch t.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			buf.WriteString("null")
+			return nil
+		}
+		return encodeJSON(buf, v.Elem())
+	case reflect.Bool:
+		buf.WriteString(strconv.FormatBool(v.Bool()))
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int:
+		if names, ok := jsonEnumNames[t]; ok {
+			name, ok := names[int32(v.Int())]
+			if !ok {
+				return jsonErrorf("invalid %s value %d", t.Name(), v.Int())
+			}
+			writeJSONString(buf, name)
+			return nil
+		}
+		buf.WriteString(strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint:
+		buf.WriteString(strconv.FormatUint(v.Uint(), 10))
+	case reflect.Int64:
+		writeJSONString(buf, strconv.FormatInt(v.Int(), 10))
+	case reflect.Uint64:
+		writeJSONString(buf, strconv.FormatUint(v.Uint(), 10))
+	case reflect.String:
+		writeJSONString(buf, escapeJSONString(v.String()))
+	case reflect.Array, reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(b), v)
+			writeJSONString(buf, hex.EncodeToString(b))
+			return nil
+		}
+		buf.WriteByte('[')
+		for i := 0; i < v.Len(); i++ {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := encodeJSON(buf, v.Index(i)); err != nil {
+				return withJSONPath(err, fmt.Sprintf("[%d]", i))
+			}
+		}
+		buf.WriteByte(']')
+	case reflect.Struct:
+		if t.Implements(unionType) {
+			return encodeUnionJSON(buf, v)
+		}
+		buf.WriteByte('{')
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, field.Name)
+			buf.WriteByte(':')
+			if err := encodeJSON(buf, v.Field(i)); err != nil {
+				return withJSONPath(err, "."+field.Name)
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return jsonErrorf("unsupported type %s", t)
+	}
+	return nil
+}
+
+func encodeUnionJSON(buf *bytes.Buffer, v reflect.Value) error {
+	union := v.Interface().(xdr.Union)
+	switchName := union.SwitchFieldName()
+	switchValue := v.FieldByName(switchName)
+
+	buf.WriteByte('{')
+	writeJSONString(buf, switchName)
+	buf.WriteByte(':')
+	if err := encodeJSON(buf, switchValue); err != nil {
+		return withJSONPath(err, "."+switchName)
+	}
+
+	armName, ok := union.ArmForSwitch(unionSwitchValue(switchValue))
+	if !ok {
+		return jsonErrorf("invalid union switch %v", switchValue.Interface())
+	}
+	if armName != "" {
+		arm := v.FieldByName(armName)
+		if arm.IsNil() {
+			return jsonErrorf("arm %s is nil", armName)
+		}
+		buf.WriteByte(',')
+		writeJSONString(buf, armName)
+		buf.WriteByte(':')
+		if err := encodeJSON(buf, arm.Elem()); err != nil {
+			return withJSONPath(err, "."+armName)
+		}
+	}
+	buf.WriteByte('}')
+	return nil
+}
+
+func unionSwitchValue(v reflect.Value) int32 {
+	switch v.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return int32(v.Uint())
+	}
+	return int32(v.Int())
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	// Marshaling a string never fails.
+	b, _ := json.Marshal(s)
+	buf.Write(b)
+}
+
+// escapeJSONString escapes backslashes and bytes which are not valid UTF-8
+// so that any XDR string can be represented as a JSON string.
+func escapeJSONString(s string) string {
+	if utf8.ValidString(s) && strings.IndexByte(s, '\\') < 0 {
+		return s
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case r == utf8.RuneError && size == 1:
+			fmt.Fprintf(&b, `\x%02x`, s[i])
+		case r == '\\':
+			b.WriteString(`\\`)
+		default:
+			b.WriteString(s[i : i+size])
+		}
+		i += size
+	}
+	return b.String()
+}
+
+// unescapeJSONString is the inverse of escapeJSONString.
+func unescapeJSONString(s string) (string, error) {
+	if strings.IndexByte(s, '\\') < 0 {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' {
+			b.WriteByte(s[i])
+			continue
+		}
+		switch {
+		case i+1 < len(s) && s[i+1] == '\\':
+			b.WriteByte('\\')
+			i++
+		case i+3 < len(s) && s[i+1] == 'x':
+			c, err := strconv.ParseUint(s[i+2:i+4], 16, 8)
+			if err != nil {
+				return "", jsonErrorf("invalid escape sequence %q", s[i:i+4])
+			}
+			b.WriteByte(byte(c))
+			i += 3
+		default:
+			return "", jsonErrorf("invalid escape sequence at offset %d", i)
+		}
+	}
+	return b.String(), nil
+}
+
+// UnmarshalCanonicalJSON decodes the canonical JSON encoding of an XDR value
+// from data into v, which must be a non-nil pointer.
+func UnmarshalCanonicalJSON(data []byte, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return jsonErrorf("cannot decode into %T", v)
+	}
+	if !json.Valid(data) {
+		// Let encoding/json describe the syntax error.
+		var raw interface{}
+		return json.Unmarshal(data, &raw)
+	}
+	return decodeJSON(rv.Elem(), data, 0)
+}
+
+var jsonNull = []byte("null")
+
+// decodeJSON decodes the JSON value data into v. maxSize is the maximum
+// size of variable length values set by the xdrmaxsize tag of the field.
+func decodeJSON(v reflect.Value, data json.RawMessage, maxSize int) error {
+	t := v.Type()
+	if t.Implements(sizedType) {
+		maxSize = reflect.Zero(t).Interface().(xdr.Sized).XDRMaxSize()
+	}
+
+	data = bytes.TrimSpace(data)
+	if t.Kind() == reflect.Ptr {
+		if bytes.Equal(data, jsonNull) {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		if v.IsNil() {
+			v.Set(reflect.New(t.Elem()))
+		}
+		return decodeJSON(v.Elem(), data, maxSize)
+	}
+	if bytes.Equal(data, jsonNull) {
+		return jsonErrorf("unexpected null for %s", t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		var b bool
+		if err := json.Unmarshal(data, &b); err != nil {
+			return jsonErrorf("expected a boolean for %s", t)
+		}
+		v.SetBool(b)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int:
+		if values, ok := jsonEnumValues[t]; ok {
+			var name string
+			if err := json.Unmarshal(data, &name); err != nil {
+				return jsonErrorf("expected a string for %s", t.Name())
+			}
+			value, ok := values[name]
+			if !ok {
+				return jsonErrorf("invalid %s value %q", t.Name(), name)
+			}
+			v.SetInt(int64(value))
+			return nil
+		}
+		n, err := strconv.ParseInt(string(jsonNumber(data)), 10, t.Bits())
+		if err != nil {
+			return jsonErrorf("invalid %s value %s", t, data)
+		}
+		v.SetInt(n)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint:
+		n, err := strconv.ParseUint(string(jsonNumber(data)), 10, t.Bits())
+		if err != nil {
+			return jsonErrorf("invalid %s value %s", t, data)
+		}
+		v.SetUint(n)
+	case reflect.Int64:
+		s, err := decodeJSONString(data, t)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return jsonErrorf("invalid %s value %q", t, s)
+		}
+		v.SetInt(n)
+	case reflect.Uint64:
+		s, err := decodeJSONString(data, t)
+		if err != nil {
+			return err
+		}
+		n, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return jsonErrorf("invalid %s value %q", t, s)
+		}
+		v.SetUint(n)
+	case reflect.String:
+		s, err := decodeJSONString(data, t)
+		if err != nil {
+			return err
+		}
+		if s, err = unescapeJSONString(s); err != nil {
+			return err
+		}
+		if maxSize > 0 && len(s) > maxSize {
+			return jsonErrorf("length %d exceeds max length %d", len(s), maxSize)
+		}
+		v.SetString(s)
+	case reflect.Array, reflect.Slice:
+		return decodeArrayJSON(v, data, maxSize)
+	case reflect.Struct:
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return jsonErrorf("expected an object for %s", t)
+		}
+		v.Set(reflect.Zero(t))
+		if t.Implements(unionType) {
+			return decodeUnionJSON(v, fields)
+		}
+		return decodeStructJSON(v, fields)
+	default:
+		return jsonErrorf("unsupported type %s", t)
+	}
+	return nil
+}
+
+// jsonNumber returns data if it is a JSON number, so that strings are not
+// accepted for 32-bit integers.
+func jsonNumber(data []byte) []byte {
+	if len(data) > 0 && (data[0] == '-' || (data[0] >= '0' && data[0] <= '9')) {
+		return data
+	}
+	return nil
+}
+
+func decodeJSONString(data []byte, t reflect.Type) (string, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", jsonErrorf("expected a string for %s", t)
+	}
+	return s, nil
+}
+
+func decodeArrayJSON(v reflect.Value, data json.RawMessage, maxSize int) error {
+	t := v.Type()
+	checkLength := func(l int) error {
+		switch {
+		case t.Kind() == reflect.Array && l != t.Len():
+			return jsonErrorf("expected %d elements for %s but got %d", t.Len(), t, l)
+		case t.Kind() == reflect.Slice && maxSize > 0 && l > maxSize:
+			return jsonErrorf("length %d exceeds max length %d", l, maxSize)
+		}
+		return nil
+	}
+
+	if t.Elem().Kind() == reflect.Uint8 {
+		s, err := decodeJSONString(data, t)
+		if err != nil {
+			return err
+		}
+		b, err := hex.DecodeString(s)
+		if err != nil {
+			return jsonErrorf("invalid hex value %q", s)
+		}
+		if err := checkLength(len(b)); err != nil {
+			return err
+		}
+		if t.Kind() == reflect.Slice {
+			if len(b) == 0 {
+				// Empty opaque values are decoded from XDR to nil.
+				b = nil
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		reflect.Copy(v, reflect.ValueOf(b))
+		return nil
+	}
+
+	var elems []json.RawMessage
+	if err := json.Unmarshal(data, &elems); err != nil {
+		return jsonErrorf("expected an array for %s", t)
+	}
+	if err := checkLength(len(elems)); err != nil {
+		return err
+	}
+	if t.Kind() == reflect.Slice {
+		if len(elems) == 0 {
+			v.Set(reflect.Zero(t))
+			return nil
+		}
+		v.Set(reflect.MakeSlice(t, len(elems), len(elems)))
+	}
+	for i, elem := range elems {
+		if err := decodeJSON(v.Index(i), elem, 0); err != nil {
+			return withJSONPath(err, fmt.Sprintf("[%d]", i))
+		}
+	}
+	return nil
+}
+
+func decodeStructJSON(v reflect.Value, fields map[string]json.RawMessage) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		data, ok := fields[field.Name]
+		if !ok {
+			return jsonErrorf("missing field %s of %s", field.Name, t)
+		}
+		if err := decodeJSON(v.Field(i), data, fieldMaxSize(field)); err != nil {
+			return withJSONPath(err, "."+field.Name)
+		}
+	}
+	if len(fields) > t.NumField() {
+		return unknownJSONField(t, fields)
+	}
+	return nil
+}
+
+func decodeUnionJSON(v reflect.Value, fields map[string]json.RawMessage) error {
+	t := v.Type()
+	union := v.Interface().(xdr.Union)
+	switchName := union.SwitchFieldName()
+	switchField, _ := t.FieldByName(switchName)
+	data, ok := fields[switchName]
+	if !ok {
+		return jsonErrorf("missing field %s of %s", switchName, t)
+	}
+	switchValue := v.FieldByIndex(switchField.Index)
+	if err := decodeJSON(switchValue, data, 0); err != nil {
+		return withJSONPath(err, "."+switchName)
+	}
+
+	armName, ok := union.ArmForSwitch(unionSwitchValue(switchValue))
+	if !ok {
+		return jsonErrorf("invalid union switch %v for %s", switchValue.Interface(), t)
+	}
+	expected := 1
+	if armName != "" {
+		expected++
+		armField, _ := t.FieldByName(armName)
+		data, ok := fields[armName]
+		if !ok {
+			return jsonErrorf("missing field %s of %s", armName, t)
+		}
+		arm := v.FieldByIndex(armField.Index)
+		arm.Set(reflect.New(armField.Type.Elem()))
+		if err := decodeJSON(arm.Elem(), data, fieldMaxSize(armField)); err != nil {
+			return withJSONPath(err, "."+armName)
+		}
+	}
+	if len(fields) > expected {
+		return unknownJSONField(t, fields)
+	}
+	return nil
+}
+
+func unknownJSONField(t reflect.Type, fields map[string]json.RawMessage) error {
+	var unknown []string
+	for name := range fields {
+		if _, ok := t.FieldByName(name); !ok {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	if len(unknown) == 0 {
+		// The key is a field of another arm of the union.
+		return jsonErrorf("unexpected fields for the arm of %s", t)
+	}
+	return jsonErrorf("unknown field %s of %s", strings.Join(unknown, ", "), t)
+}
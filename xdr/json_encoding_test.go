@@ -0,0 +1,310 @@
+package xdr
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	for _, value := range decoderTestValues(t) {
+		raw, err := MarshalCanonicalJSON(value)
+		require.NoError(t, err)
+
+		actual := newValueOf(value)
+		require.NoError(t, UnmarshalCanonicalJSON(raw, actual))
+		assert.Equal(t, value, actual)
+
+		again, err := MarshalCanonicalJSON(actual)
+		require.NoError(t, err)
+		assert.Equal(t, string(raw), string(again))
+	}
+}
+
+func TestJSONEncoding(t *testing.T) {
+	entry := LedgerEntry{
+		LastModifiedLedgerSeq: 10,
+		Data: LedgerEntryData{
+			Type: LedgerEntryTypeData,
+			Data: &DataEntry{
+				AccountId: MustAddress("GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ"),
+				DataName:  "a\\b\xff",
+				DataValue: DataValue{0xca, 0xfe},
+			},
+		},
+	}
+	raw, err := MarshalCanonicalJSON(entry)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"LastModifiedLedgerSeq": 10,
+		"Data": {
+			"Type": "LedgerEntryTypeData",
+			"Data": {
+				"AccountId": {
+					"Type": "PublicKeyTypePublicKeyTypeEd25519",
+					"Ed25519": "9daf66411fb73562fce9f42f8f56716cea48dd0b26d16c26e419766de0c23d63"
+				},
+				"DataName": "a\\\\b\\xff",
+				"DataValue": "cafe",
+				"Ext": {"V": 0}
+			}
+		},
+		"Ext": {"V": 0}
+	}`, string(raw))
+
+	var decoded LedgerEntry
+	require.NoError(t, UnmarshalCanonicalJSON(raw, &decoded))
+	assert.Equal(t, entry, decoded)
+
+	offer := OfferEntry{
+		SellerId: MustAddress("GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ"),
+		OfferId:  math.MaxInt64,
+		Selling:  MustNewNativeAsset(),
+		Buying:   MustNewCreditAsset("USD", "GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ"),
+		Price:    Price{N: 1, D: 2},
+	}
+	raw, err = MarshalCanonicalJSON(offer)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"OfferId":"9223372036854775807"`)
+	assert.Contains(t, string(raw), `"Price":{"N":1,"D":2}`)
+}
+
+// TestJSONDefaultEncoding checks that the canonical encoding does not change
+// the encoding/json encoding of XDR values, which Horizon relies on.
+func TestJSONDefaultEncoding(t *testing.T) {
+	raw, err := json.Marshal(map[string]interface{}{
+		"offer_id": Int64(5),
+		"new_seq":  SequenceNumber(7),
+		"type":     AssetTypeAssetTypeCreditAlphanum4,
+		"name":     String64("name"),
+	})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"offer_id": 5, "new_seq": 7, "type": 1, "name": "name"}`, string(raw))
+
+	raw, err = json.Marshal(Asset{Type: AssetTypeAssetTypeNative})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"Type": 0, "AlphaNum4": null, "AlphaNum12": null}`, string(raw))
+}
+
+func TestJSONScalarTypes(t *testing.T) {
+	for _, testCase := range []struct {
+		value    interface{}
+		expected string
+	}{
+		{AssetTypeAssetTypeNative, `"AssetTypeAssetTypeNative"`},
+		{OperationTypeManageBuyOffer, `"OperationTypeManageBuyOffer"`},
+		{Int32(-5), `-5`},
+		{Uint32(5), `5`},
+		{Int64(5), `"5"`},
+		{Int64(math.MinInt64), `"-9223372036854775808"`},
+		{Uint64(math.MaxUint64), `"18446744073709551615"`},
+		{SequenceNumber(7), `"7"`},
+		{TimePoint(8), `"8"`},
+		{Hash{0xab, 0xcd}, `"abcd000000000000000000000000000000000000000000000000000000000000"`},
+		{Thresholds{1, 2, 3, 4}, `"01020304"`},
+		{AssetCode4{'U', 'S', 'D'}, `"55534400"`},
+		{DataValue{0xca, 0xfe}, `"cafe"`},
+		{Value(nil), `""`},
+		{String32("a\\b"), `"a\\\\b"`},
+		{String64("\xff"), `"\\xff"`},
+	} {
+		raw, err := MarshalCanonicalJSON(testCase.value)
+		require.NoError(t, err)
+		assert.Equal(t, testCase.expected, string(raw))
+
+		actual := reflect.New(reflect.TypeOf(testCase.value))
+		require.NoError(t, UnmarshalCanonicalJSON(raw, actual.Interface()))
+		assert.Equal(t, testCase.value, actual.Elem().Interface())
+	}
+
+	var assetType AssetType
+	assert.EqualError(t, UnmarshalCanonicalJSON([]byte(`0`), &assetType), "xdr:json: expected a string for AssetType")
+	_, err := MarshalCanonicalJSON(AssetType(100))
+	assert.EqualError(t, err, "xdr:json: invalid AssetType value 100")
+	var amount Int64
+	assert.EqualError(t, UnmarshalCanonicalJSON([]byte(`5`), &amount), "xdr:json: expected a string for xdr.Int64")
+	var name String32
+	assert.EqualError(t, UnmarshalCanonicalJSON([]byte(`"`+strings.Repeat("a", 33)+`"`), &name), "xdr:json: length 33 exceeds max length 32")
+}
+
+func TestJSONClaimPredicate(t *testing.T) {
+	unconditional := ClaimPredicate{Type: ClaimPredicateTypeClaimPredicateUnconditional}
+	claimant := Claimant{
+		Type: ClaimantTypeClaimantTypeV0,
+		V0: &ClaimantV0{
+			Destination: MustAddress("GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ"),
+			Predicate:   unconditional,
+		},
+	}
+
+	// The canonical encoding of ClaimPredicate is a union, on its own and
+	// within other values.
+	raw, err := MarshalCanonicalJSON(unconditional)
+	require.NoError(t, err)
+	assert.Equal(t, `{"Type":"ClaimPredicateTypeClaimPredicateUnconditional"}`, string(raw))
+	raw, err = MarshalCanonicalJSON(claimant)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"Predicate":{"Type":"ClaimPredicateTypeClaimPredicateUnconditional"}`)
+
+	// encoding/json keeps the encoding of Horizon, on its own and within
+	// other values.
+	raw, err = json.Marshal(unconditional)
+	require.NoError(t, err)
+	assert.Equal(t, `{"unconditional":true}`, string(raw))
+	raw, err = json.Marshal(claimant)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"Predicate":{"unconditional":true}`)
+
+	// A not predicate without an inner predicate cannot be encoded in the
+	// Horizon encoding of ClaimPredicate, but it is a valid XDR value.
+	var inner *ClaimPredicate
+	claimant.V0.Predicate = ClaimPredicate{
+		Type:         ClaimPredicateTypeClaimPredicateNot,
+		NotPredicate: &inner,
+	}
+	_, err = json.Marshal(claimant)
+	assert.Error(t, err)
+
+	raw, err = MarshalCanonicalJSON(claimant)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"Predicate":{"Type":"ClaimPredicateTypeClaimPredicateNot","NotPredicate":null}`)
+
+	var decoded Claimant
+	require.NoError(t, UnmarshalCanonicalJSON(raw, &decoded))
+	assert.Equal(t, claimant, decoded)
+}
+
+func TestJSONErrors(t *testing.T) {
+	for _, testCase := range []struct {
+		name  string
+		input string
+		err   string
+	}{
+		{
+			"syntax error",
+			`{"V":`,
+			"unexpected end of JSON input",
+		},
+		{
+			"missing field",
+			`{"V": 0, "V0": {}}`,
+			"xdr:json: .V0: missing field LedgerHeader of xdr.LedgerCloseMetaV0",
+		},
+		{
+			"null value",
+			`{"V": 0, "V0": null}`,
+			"xdr:json: .V0: unexpected null for xdr.LedgerCloseMetaV0",
+		},
+		{
+			"invalid switch",
+			`{"V": 1}`,
+			"xdr:json: invalid union switch 1 for xdr.LedgerCloseMeta",
+		},
+		{
+			"wrong length",
+			`{"V": 0, "V0": {"LedgerHeader": {"Hash": "00"}}}`,
+			"xdr:json: .V0.LedgerHeader.Hash: expected 32 elements for xdr.Hash but got 1",
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			var meta LedgerCloseMeta
+			err := UnmarshalCanonicalJSON([]byte(testCase.input), &meta)
+			assert.EqualError(t, err, testCase.err)
+		})
+	}
+
+	var asset Asset
+	assert.EqualError(
+		t,
+		UnmarshalCanonicalJSON([]byte(`{"Type": "AssetTypeAssetTypeNative", "AlphaNum4": null}`), &asset),
+		"xdr:json: unexpected fields for the arm of xdr.Asset",
+	)
+	assert.EqualError(
+		t,
+		UnmarshalCanonicalJSON([]byte(`{"Type": "Native"}`), &asset),
+		`xdr:json: .Type: invalid AssetType value "Native"`,
+	)
+	assert.EqualError(
+		t,
+		UnmarshalCanonicalJSON([]byte(`{"Type": "AssetTypeAssetTypeNative", "Foo": 1}`), &asset),
+		"xdr:json: unknown field Foo of xdr.Asset",
+	)
+
+	var price Price
+	assert.EqualError(
+		t,
+		UnmarshalCanonicalJSON([]byte(`{"N": 2147483648, "D": 1}`), &price),
+		"xdr:json: .N: invalid xdr.Int32 value 2147483648",
+	)
+	assert.EqualError(
+		t,
+		UnmarshalCanonicalJSON([]byte(`{"N": "1", "D": 1}`), &price),
+		`xdr:json: .N: invalid xdr.Int32 value "1"`,
+	)
+
+	var header LedgerHeaderHistoryEntry
+	raw := strings.Replace(mustJSON(t, header), `"IdPool":"0"`, `"IdPool":0`, 1)
+	assert.EqualError(
+		t,
+		UnmarshalCanonicalJSON([]byte(raw), &header),
+		"xdr:json: .Header.IdPool: expected a string for xdr.Uint64",
+	)
+
+	account := MustAddress("GCO26ZSBD63TKYX45H2C7D2WOFWOUSG5BMTNC3BG4QMXM3PAYI6WHKVZ")
+	data := DataEntry{AccountId: account, DataName: "name"}
+	var decoded DataEntry
+	raw = strings.Replace(mustJSON(t, data), `"name"`, `"`+strings.Repeat("a", 65)+`"`, 1)
+	assert.EqualError(
+		t,
+		UnmarshalCanonicalJSON([]byte(raw), &decoded),
+		"xdr:json: .DataName: length 65 exceeds max length 64",
+	)
+	raw = strings.Replace(mustJSON(t, data), `"name"`, `"\\q"`, 1)
+	assert.EqualError(
+		t,
+		UnmarshalCanonicalJSON([]byte(raw), &decoded),
+		"xdr:json: .DataName: invalid escape sequence at offset 0",
+	)
+}
+
+// TestJSONMutations checks that values decoded from corrupted XDR inputs
+// survive a JSON round trip.
+func TestJSONMutations(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, value := range decoderTestValues(t) {
+		var buf bytes.Buffer
+		_, err := Marshal(&buf, value)
+		require.NoError(t, err)
+
+		for i := 0; i < 500; i++ {
+			mutated := append([]byte{}, buf.Bytes()...)
+			for j := 0; j < 1+r.Intn(3); j++ {
+				mutated[r.Intn(len(mutated))] = byte(r.Intn(256))
+			}
+
+			decoded := newValueOf(value)
+			if SafeUnmarshal(mutated, decoded) != nil {
+				continue
+			}
+
+			encoded, err := MarshalCanonicalJSON(decoded)
+			require.NoError(t, err)
+			actual := newValueOf(value)
+			require.NoError(t, UnmarshalCanonicalJSON(encoded, actual), string(encoded))
+			require.Equal(t, decoded, actual, string(encoded))
+		}
+	}
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	raw, err := MarshalCanonicalJSON(v)
+	require.NoError(t, err)
+	return string(raw)
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestClaimPredicateJSON(t *testing.T) {
@@ -59,6 +60,16 @@ func TestClaimPredicateJSON(t *testing.T) {
 	assert.Equal(t, serializedBase64, parsedBase64)
 }
 
+func TestClaimPredicateJSONNilNot(t *testing.T) {
+	predicate := ClaimPredicate{
+		Type:         ClaimPredicateTypeClaimPredicateNot,
+		NotPredicate: new(*ClaimPredicate),
+	}
+	_, err := json.Marshal(predicate)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid predicate: not predicate is nil")
+}
+
 func TestAbsBeforeTimestamps(t *testing.T) {
 	const year = 365 * 24 * 60 * 60
 	for _, testCase := range []struct {
@@ -9,6 +9,7 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"reflect"
 	"strings"
 
 	xdr "github.com/stellar/go-xdr/xdr3"
@@ -65,10 +66,17 @@ func SafeUnmarshalHex(data string, dest interface{}) error {
 }
 
 // SafeUnmarshal decodes the provided reader into the destination and verifies
-// that provided bytes are all consumed by the unmarshalling process.
+// that provided bytes are all consumed by the unmarshalling process. dest is
+// reset to its zero value first, so, like Unmarshal, the result never shares
+// memory with what dest referenced before. Use a Decoder to reuse memory.
 func SafeUnmarshal(data []byte, dest interface{}) error {
-	r := bytes.NewReader(data)
-	n, err := Unmarshal(r, dest)
+	if v := reflect.ValueOf(dest); v.Kind() == reflect.Ptr && !v.IsNil() {
+		v.Elem().Set(reflect.Zero(v.Elem().Type()))
+	}
+
+	d := decoderPool.Get().(*Decoder)
+	n, err := d.Unmarshal(data, dest)
+	decoderPool.Put(d)
 
 	if err != nil {
 		return err
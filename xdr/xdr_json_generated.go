@@ -0,0 +1,55 @@
+// Code generated by gen_json.go. DO NOT EDIT.
+
+package xdr
+
+import "reflect"
+
+// jsonEnumNames maps enum types to the names of their values.
+var jsonEnumNames = map[reflect.Type]map[int32]string{
+	reflect.TypeOf(ScpStatementType(0)):                        scpStatementTypeMap,
+	reflect.TypeOf(AssetType(0)):                               assetTypeMap,
+	reflect.TypeOf(ThresholdIndexes(0)):                        thresholdIndexesMap,
+	reflect.TypeOf(LedgerEntryType(0)):                         ledgerEntryTypeMap,
+	reflect.TypeOf(AccountFlags(0)):                            accountFlagsMap,
+	reflect.TypeOf(TrustLineFlags(0)):                          trustLineFlagsMap,
+	reflect.TypeOf(OfferEntryFlags(0)):                         offerEntryFlagsMap,
+	reflect.TypeOf(ClaimPredicateType(0)):                      claimPredicateTypeMap,
+	reflect.TypeOf(ClaimantType(0)):                            claimantTypeMap,
+	reflect.TypeOf(ClaimableBalanceIdType(0)):                  claimableBalanceIdTypeMap,
+	reflect.TypeOf(EnvelopeType(0)):                            envelopeTypeMap,
+	reflect.TypeOf(StellarValueType(0)):                        stellarValueTypeMap,
+	reflect.TypeOf(LedgerUpgradeType(0)):                       ledgerUpgradeTypeMap,
+	reflect.TypeOf(BucketEntryType(0)):                         bucketEntryTypeMap,
+	reflect.TypeOf(LedgerEntryChangeType(0)):                   ledgerEntryChangeTypeMap,
+	reflect.TypeOf(ErrorCode(0)):                               errorCodeMap,
+	reflect.TypeOf(IpAddrType(0)):                              ipAddrTypeMap,
+	reflect.TypeOf(MessageType(0)):                             messageTypeMap,
+	reflect.TypeOf(SurveyMessageCommandType(0)):                surveyMessageCommandTypeMap,
+	reflect.TypeOf(OperationType(0)):                           operationTypeMap,
+	reflect.TypeOf(RevokeSponsorshipType(0)):                   revokeSponsorshipTypeMap,
+	reflect.TypeOf(MemoType(0)):                                memoTypeMap,
+	reflect.TypeOf(CreateAccountResultCode(0)):                 createAccountResultCodeMap,
+	reflect.TypeOf(PaymentResultCode(0)):                       paymentResultCodeMap,
+	reflect.TypeOf(PathPaymentStrictReceiveResultCode(0)):      pathPaymentStrictReceiveResultCodeMap,
+	reflect.TypeOf(PathPaymentStrictSendResultCode(0)):         pathPaymentStrictSendResultCodeMap,
+	reflect.TypeOf(ManageSellOfferResultCode(0)):               manageSellOfferResultCodeMap,
+	reflect.TypeOf(ManageOfferEffect(0)):                       manageOfferEffectMap,
+	reflect.TypeOf(ManageBuyOfferResultCode(0)):                manageBuyOfferResultCodeMap,
+	reflect.TypeOf(SetOptionsResultCode(0)):                    setOptionsResultCodeMap,
+	reflect.TypeOf(ChangeTrustResultCode(0)):                   changeTrustResultCodeMap,
+	reflect.TypeOf(AllowTrustResultCode(0)):                    allowTrustResultCodeMap,
+	reflect.TypeOf(AccountMergeResultCode(0)):                  accountMergeResultCodeMap,
+	reflect.TypeOf(InflationResultCode(0)):                     inflationResultCodeMap,
+	reflect.TypeOf(ManageDataResultCode(0)):                    manageDataResultCodeMap,
+	reflect.TypeOf(BumpSequenceResultCode(0)):                  bumpSequenceResultCodeMap,
+	reflect.TypeOf(CreateClaimableBalanceResultCode(0)):        createClaimableBalanceResultCodeMap,
+	reflect.TypeOf(ClaimClaimableBalanceResultCode(0)):         claimClaimableBalanceResultCodeMap,
+	reflect.TypeOf(BeginSponsoringFutureReservesResultCode(0)): beginSponsoringFutureReservesResultCodeMap,
+	reflect.TypeOf(EndSponsoringFutureReservesResultCode(0)):   endSponsoringFutureReservesResultCodeMap,
+	reflect.TypeOf(RevokeSponsorshipResultCode(0)):             revokeSponsorshipResultCodeMap,
+	reflect.TypeOf(OperationResultCode(0)):                     operationResultCodeMap,
+	reflect.TypeOf(TransactionResultCode(0)):                   transactionResultCodeMap,
+	reflect.TypeOf(CryptoKeyType(0)):                           cryptoKeyTypeMap,
+	reflect.TypeOf(PublicKeyType(0)):                           publicKeyTypeMap,
+	reflect.TypeOf(SignerKeyType(0)):                           signerKeyTypeMap,
+}